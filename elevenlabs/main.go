@@ -42,6 +42,38 @@ type ElevenLabsClient struct {
 	APIKey string
 	Client *http.Client
 }
+
+// APIError is a non-200 answer from the API. Callers rotating keys use StatusCode and
+// RetryAfter to tell a bad or exhausted key from a bad request.
+type APIError struct {
+	StatusCode int
+	RetryAfter string // Retry-After header, if any
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error (%d): %s", e.StatusCode, e.Body)
+}
+
+// DetailStatus is the machine-readable reason in the error body, like quota_exceeded or
+// invalid_api_key, or "" when the body has none
+func (e *APIError) DetailStatus() string {
+	var body struct {
+		Detail struct {
+			Status string `json:"status"`
+		} `json:"detail"`
+	}
+	if json.Unmarshal([]byte(e.Body), &body) != nil {
+		return ""
+	}
+	return body.Detail.Status
+}
+
+func newAPIError(resp *http.Response) *APIError {
+	body, _ := io.ReadAll(resp.Body)
+	return &APIError{StatusCode: resp.StatusCode, RetryAfter: resp.Header.Get("Retry-After"), Body: string(body)}
+}
+
 type SubscriptionInfo struct {
 	CharacterCount              int   `json:"character_count"`
 	CharacterLimit              int   `json:"character_limit"`
@@ -77,6 +109,11 @@ func NewElevenLabsClient(apiKey string, proxy *Proxy) *ElevenLabsClient {
 	}
 }
 
+// WithAPIKey returns a client using apiKey that shares this client's connections and proxy
+func (c *ElevenLabsClient) WithAPIKey(apiKey string) *ElevenLabsClient {
+	return &ElevenLabsClient{APIKey: apiKey, Client: c.Client}
+}
+
 func (c *ElevenLabsClient) TextToSpeech(text, voiceID string) ([]byte, error) {
 	// Create request payload
 	requestBody := TTSRequest{
@@ -105,8 +142,6 @@ func (c *ElevenLabsClient) TextToSpeech(text, voiceID string) ([]byte, error) {
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("xi-api-key", c.APIKey)
 
-	fmt.Println("========APIKey", c.APIKey)
-
 	// Make request
 	resp, err := c.Client.Do(req)
 	if err != nil {
@@ -115,8 +150,7 @@ func (c *ElevenLabsClient) TextToSpeech(text, voiceID string) ([]byte, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, string(body))
+		return nil, newAPIError(resp)
 	}
 
 	// Read response body
@@ -144,8 +178,7 @@ func (c *ElevenLabsClient) GetSubscriptionInfo() (*SubscriptionInfo, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, string(body))
+		return nil, newAPIError(resp)
 	}
 
 	var subInfo SubscriptionInfo
@@ -171,8 +204,7 @@ func (c *ElevenLabsClient) GetVoices() ([]map[string]interface{}, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error (%d): %s", resp.StatusCode, string(body))
+		return nil, newAPIError(resp)
 	}
 
 	var result map[string]interface{}
@@ -1,10 +1,15 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
+	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
+	"youtube_automation/tracing"
 )
 
 func buildFilterComplexWithCounts(req *VideoRequest, videoInputCount int, audioInputs []string) string {
@@ -126,20 +131,36 @@ func buildFilterComplexWithCounts(req *VideoRequest, videoInputCount int, audioI
 
 	return strings.Join(filters, ";")
 }
-func generateVideo(jobID string, req *VideoRequest) {
+func generateVideo(ctx context.Context, jobID string, req *VideoRequest) {
 	job := jobs[jobID]
 	job.Status = "processing"
 	job.Progress = 10
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, "render", tracing.KindInternal)
+	span.SetAttribute("job.id", jobID)
 
 	defer func() {
 		if r := recover(); r != nil {
 			job.Status = "failed"
 			job.Error = fmt.Sprintf("Panic: %v", r)
 		}
+		renderDuration.ObserveSince(start, job.Status)
+		if job.Status == "failed" {
+			span.SetError(fmt.Errorf("%s", job.Error))
+			slog.ErrorContext(ctx, "Render failed", logKeyJobID, jobID, "error", job.Error)
+		} else {
+			slog.InfoContext(ctx, "Render finished", logKeyJobID, jobID, "status", job.Status, "duration", time.Since(start))
+		}
+		span.SetAttribute("job.status", job.Status)
+		span.End()
 	}()
 
 	// Process assets
-	if err := processAssets(jobID, req); err != nil {
+	_, assetsSpan := tracing.Start(ctx, "process_assets", tracing.KindInternal)
+	err := processAssets(jobID, req)
+	assetsSpan.SetError(err)
+	assetsSpan.End()
+	if err != nil {
 		job.Status = "failed"
 		job.Error = err.Error()
 		return
@@ -155,14 +176,31 @@ func generateVideo(jobID string, req *VideoRequest) {
 	}
 	job.Progress = 50
 
+	// Last safe point: a render not started yet is saved for the next process instead
+	if renders.draining.Load() {
+		job.Status = "interrupted"
+		job.Error = "Server shutting down"
+		return
+	}
+
 	// Execute FFmpeg
 	outputPath := filepath.Join("output", fmt.Sprintf("%s_%s.mp4",
 		sanitizeFilename(req.Title), jobID[:8]))
 
-	if err := executeFFmpegCommand(ffmpegArgs, outputPath); err != nil {
+	_, ffmpegSpan := tracing.Start(ctx, "ffmpeg", tracing.KindInternal)
+	err = executeFFmpegCommand(renders.ctx, ffmpegArgs, outputPath)
+	ffmpegSpan.SetError(err)
+	ffmpegSpan.End()
+	if err != nil && renders.ctx.Err() != nil {
+		// Killed by the shutdown; the partial output is dropped and the render starts over
+		os.Remove(outputPath)
+		job.Status = "interrupted"
+		job.Error = "Server shutting down"
+		return
+	}
+	if err != nil {
 		job.Status = "failed"
 		job.Error = err.Error()
-		fmt.Println("❌ Job failed:", job.Error)
 		return
 	}
 	job.Progress = 90
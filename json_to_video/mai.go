@@ -1,17 +1,24 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
+	"youtube_automation/logging"
+	"youtube_automation/metrics"
+	"youtube_automation/readiness"
+	"youtube_automation/tracing"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
@@ -19,7 +26,13 @@ import (
 
 var jobs = make(map[string]*JobStatus)
 
+// logKeyJobID is the attribute naming the render job a log line is about
+const logKeyJobID = "job_id"
+
 func main() {
+	logging.Setup()
+	tracing.Init("json_to_video")
+
 	// Create directories
 	directories := []string{"./output", "./temp", "./assets/images", "./assets/audio", "./assets/subtitles"}
 	for _, dir := range directories {
@@ -27,6 +40,7 @@ func main() {
 	}
 
 	r := mux.NewRouter()
+	r.Use(tracingMiddleware, metricsMiddleware)
 
 	// API routes
 	r.HandleFunc("/api/generate", generateVideoHandler).Methods("POST")
@@ -45,25 +59,36 @@ func main() {
 
 	// Health check
 	r.HandleFunc("/health", healthCheckHandler).Methods("GET")
-
-	fmt.Println("🎬 Enhanced JSON to Video API Server starting...")
-	fmt.Println("📡 Server running on http://localhost:8088")
-	fmt.Println("📚 API Endpoints:")
-	fmt.Println("   POST /api/generate - Generate video from JSON")
-	fmt.Println("   GET  /api/status/{jobId} - Check job status")
-	fmt.Println("   GET  /api/jobs - List all jobs")
-	fmt.Println("   DELETE /api/cancel/{jobId} - Cancel job")
-	fmt.Println("   POST /api/upload/image - Upload image file")
-	fmt.Println("   POST /api/upload/audio - Upload audio file")
-	fmt.Println("   POST /api/upload/subtitle - Upload SRT file")
-	fmt.Println("   GET  /videos/{filename} - Download generated videos")
-	fmt.Println("   GET  /assets/{type}/{filename} - Download assets")
-	fmt.Println("   GET  /health - Health check")
-
-	log.Fatal(http.ListenAndServe(":8088", r))
+	r.Handle("/ready", readiness.Handler(readiness.DefaultTimeout, readinessChecks)).Methods("GET")
+	r.Handle("/metrics", metrics.Handler()).Methods("GET")
+
+	server := &http.Server{Addr: ":8088", Handler: r}
+	// The readme lists the endpoints
+	slog.Info("JSON to Video API server starting", "addr", server.Addr)
+	resumeInterruptedJobs()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	serverErr := make(chan error, 1)
+	go func() { serverErr <- server.ListenAndServe() }()
+
+	select {
+	case err := <-serverErr:
+		slog.Error("Server stopped", "error", err)
+		os.Exit(1)
+	case sig := <-stop:
+		slog.Info("Shutting down, waiting for running renders", "signal", sig.String())
+	}
+	shutdown(server)
+	tracing.Shutdown(context.Background())
 }
 
 func generateVideoHandler(w http.ResponseWriter, r *http.Request) {
+	if renders.draining.Load() {
+		http.Error(w, "Server shutting down", http.StatusServiceUnavailable)
+		return
+	}
+
 	var req VideoRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid JSON format: "+err.Error(), http.StatusBadRequest)
@@ -85,10 +110,15 @@ func generateVideoHandler(w http.ResponseWriter, r *http.Request) {
 		CreatedAt: time.Now(),
 		Request:   &req,
 	}
+	jobsMu.Lock()
 	jobs[jobID] = job
+	jobsMu.Unlock()
 
-	// Start video generation in background
-	go generateVideo(jobID, &req)
+	// Start video generation in background; a shutdown that began meanwhile saves the job
+	// for the next process
+	if !startRender(tracing.Detach(r.Context()), jobID, &req) {
+		job.Status = "interrupted"
+	}
 
 	response := VideoResponse{
 		JobID:    jobID,
@@ -245,6 +275,21 @@ func healthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// readinessChecks covers what a render needs: ffmpeg with the encoders and filters of
+// buildFFmpegCommand (libx264 being the fallback when no hardware encoder works) and
+// writable working directories
+func readinessChecks() []readiness.Check {
+	return []readiness.Check{
+		readiness.FFmpeg(
+			[]string{"libx264", "aac"},
+			[]string{"scale", "crop", "zoompan", "overlay", "drawtext", "subtitles", "amix", "color"},
+		),
+		readiness.Writable("./output"),
+		readiness.Writable("./temp"),
+		readiness.Writable("./assets"),
+	}
+}
+
 func validateVideoRequest(req *VideoRequest) error {
 	if req.Title == "" {
 		return fmt.Errorf("title is required")
@@ -301,12 +346,12 @@ func saveBase64Asset(base64Data, filepath string) error {
 	return os.WriteFile(filepath, []byte(base64Data), 0644)
 }
 
-func executeFFmpegCommand(args []string, outputPath string) error {
+func executeFFmpegCommand(ctx context.Context, args []string, outputPath string) error {
 	args = append(args, outputPath)
 
-	fmt.Printf("Executing FFmpeg command: ffmpeg %s\n", strings.Join(args, " "))
+	slog.DebugContext(ctx, "Running ffmpeg", "args", strings.Join(args, " "))
 
-	cmd := exec.Command("ffmpeg", args...)
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
 	output, err := cmd.CombinedOutput()
 
 	if err != nil {
@@ -368,6 +413,8 @@ func getStatusMessage(status string) string {
 		return "Video generation failed"
 	case "cancelled":
 		return "Video generation was cancelled"
+	case "interrupted":
+		return "Video generation was interrupted by a restart and will start again"
 	default:
 		return "Unknown status"
 	}
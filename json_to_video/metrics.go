@@ -0,0 +1,77 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"youtube_automation/metrics"
+	"youtube_automation/tracing"
+
+	"github.com/gorilla/mux"
+)
+
+// jobsMu guards inserts into jobs against the scrape-time walk in jobsByStatus
+var jobsMu sync.RWMutex
+
+var (
+	httpRequests = metrics.NewCounterVec("json_to_video_http_requests_total",
+		"HTTP requests by route and status code.", "route", "code")
+	renderDuration = metrics.NewHistogramVec("json_to_video_render_duration_seconds",
+		"Time from picking up a job to its final status.", nil, "status")
+	_ = metrics.NewGaugeFunc("json_to_video_jobs",
+		"Jobs held in memory by status; pending and processing make up the render queue.",
+		jobsByStatus, "status")
+)
+
+var jobStatuses = []string{"pending", "processing", "completed", "failed", "cancelled", "interrupted"}
+
+func jobsByStatus() []metrics.Sample {
+	counts := map[string]int{}
+	jobsMu.RLock()
+	for _, job := range jobs {
+		counts[job.Status]++
+	}
+	jobsMu.RUnlock()
+
+	samples := make([]metrics.Sample, 0, len(counts))
+	for _, status := range jobStatuses {
+		samples = append(samples, metrics.Sample{Labels: []string{status}, Value: float64(counts[status])})
+	}
+	return samples
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// routeTemplate is the matched route's path template, so job IDs don't become labels or span names
+func routeTemplate(r *http.Request) string {
+	if current := mux.CurrentRoute(r); current != nil {
+		if template, err := current.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return "unmatched"
+}
+
+func tracingMiddleware(next http.Handler) http.Handler {
+	return tracing.Middleware(func(r *http.Request) string {
+		return r.Method + " " + routeTemplate(r)
+	}, next)
+}
+
+// metricsMiddleware counts requests by route template, so job IDs don't become labels
+func metricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+
+		httpRequests.Inc(routeTemplate(r), strconv.Itoa(rec.status))
+	})
+}
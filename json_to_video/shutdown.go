@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultShutdownTimeout = 5 * time.Minute // Renders are long, so they get longer than requests; SHUTDOWN_TIMEOUT overrides
+	shutdownCancelGrace    = 10 * time.Second
+	interruptedJobsFile    = "temp/interrupted_jobs.json"
+)
+
+// renders tracks running generateVideo calls so a shutdown can wait for them
+var renders = struct {
+	draining atomic.Bool
+	wg       sync.WaitGroup
+	ctx      context.Context // Cancelled, killing ffmpeg, when draining runs out of time
+	cancel   context.CancelFunc
+}{}
+
+func init() {
+	renders.ctx, renders.cancel = context.WithCancel(context.Background())
+}
+
+// startRender runs a render in the background unless the server is draining
+func startRender(ctx context.Context, jobID string, req *VideoRequest) bool {
+	if renders.draining.Load() {
+		return false
+	}
+	renders.wg.Add(1)
+	go func() {
+		defer renders.wg.Done()
+		generateVideo(ctx, jobID, req)
+	}()
+	return true
+}
+
+// shutdown stops accepting requests, waits for running renders until SHUTDOWN_TIMEOUT,
+// then kills the ffmpeg processes left. Renders that did not finish are saved as
+// interrupted and start again with the next process.
+func shutdown(server *http.Server) {
+	deadline := time.Now().Add(getEnvDuration("SHUTDOWN_TIMEOUT", defaultShutdownTimeout))
+	renders.draining.Store(true)
+
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Warn("Requests still open at shutdown", "error", err)
+	}
+	cancel()
+
+	if !waitRenders(time.Until(deadline)) {
+		slog.Warn("Renders still running, stopping ffmpeg")
+		renders.cancel()
+		if !waitRenders(shutdownCancelGrace) {
+			slog.Warn("Renders did not stop in time")
+		}
+	}
+
+	if err := saveInterruptedJobs(); err != nil {
+		slog.Warn("Failed to save interrupted jobs", "error", err)
+	}
+	slog.Info("Shutdown complete")
+}
+
+func waitRenders(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		renders.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// saveInterruptedJobs writes the jobs a shutdown stopped, with their requests, so the
+// next process can run them again under the same IDs
+func saveInterruptedJobs() error {
+	var interrupted []*JobStatus
+	jobsMu.RLock()
+	for _, job := range jobs {
+		if job.Status == "interrupted" || job.Status == "pending" {
+			interrupted = append(interrupted, job)
+		}
+	}
+	jobsMu.RUnlock()
+	if len(interrupted) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(interrupted)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(interruptedJobsFile), 0755); err != nil {
+		return err
+	}
+	slog.Info("Saved interrupted jobs", "count", len(interrupted))
+	return os.WriteFile(interruptedJobsFile, data, 0644)
+}
+
+// resumeInterruptedJobs starts again the renders saved by the last shutdown
+func resumeInterruptedJobs() {
+	data, err := os.ReadFile(interruptedJobsFile)
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		slog.Warn("Failed to read interrupted jobs", "error", err)
+		return
+	}
+	os.Remove(interruptedJobsFile)
+
+	var saved []*JobStatus
+	if err := json.Unmarshal(data, &saved); err != nil {
+		slog.Warn("Failed to parse interrupted jobs", "error", err)
+		return
+	}
+	for _, job := range saved {
+		if job.Request == nil {
+			continue
+		}
+		job.Status = "pending"
+		job.Progress = 0
+		job.Error = ""
+		jobsMu.Lock()
+		jobs[job.ID] = job
+		jobsMu.Unlock()
+		startRender(context.Background(), job.ID, job.Request)
+	}
+	slog.Info("Resumed interrupted jobs", "count", len(saved))
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
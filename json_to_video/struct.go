@@ -74,7 +74,7 @@ type VideoResponse struct {
 // JobStatus tracks video generation jobs
 type JobStatus struct {
 	ID        string        `json:"id"`
-	Status    string        `json:"status"` // "pending", "processing", "completed", "failed"
+	Status    string        `json:"status"` // "pending", "processing", "completed", "failed", "interrupted"
 	Progress  int           `json:"progress"`
 	CreatedAt time.Time     `json:"created_at"`
 	VideoPath string        `json:"video_path"`
@@ -0,0 +1,50 @@
+// Package logging sets up the JSON logs of the services without their own logging
+// config: one slog line per record on stdout, at the level LOG_LEVEL names, carrying the
+// trace ID of the request being served.
+package logging
+
+import (
+	"context"
+	"encoding/hex"
+	"log/slog"
+	"os"
+	"strings"
+	"youtube_automation/tracing"
+)
+
+// ParseLevel accepts debug, info, warn or error, defaulting to info
+func ParseLevel(value string) slog.Level {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(value))); err != nil {
+		return slog.LevelInfo
+	}
+	return level
+}
+
+// Setup makes slog, and the log package through it, write JSON lines to stdout at LOG_LEVEL
+func Setup() {
+	handler := traceHandler{slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		Level: ParseLevel(os.Getenv("LOG_LEVEL")),
+	})}
+	slog.SetDefault(slog.New(handler))
+}
+
+// traceHandler adds the trace ID of ctx's span to each record
+type traceHandler struct {
+	slog.Handler
+}
+
+func (h traceHandler) Handle(ctx context.Context, record slog.Record) error {
+	if sc := tracing.SpanFromContext(ctx).SpanContext(); sc.IsValid() {
+		record.AddAttrs(slog.String("trace_id", hex.EncodeToString(sc.TraceID[:])))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h traceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return traceHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h traceHandler) WithGroup(name string) slog.Handler {
+	return traceHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,309 @@
+// Package metrics is a small Prometheus registry shared by the services. It only
+// supports what the services need — labelled counters, gauges, histograms and
+// gauges read at scrape time — and writes the text exposition format, so no client
+// library is required.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets suit pipeline stages, which take from a fraction of a second (a
+// single API call) to tens of minutes (a full render)
+var DefaultBuckets = []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600, 1800}
+
+type metric interface {
+	write(w io.Writer)
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = map[string]metric{}
+)
+
+func register(name string, m metric) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, exists := registry[name]; exists {
+		panic("metrics: duplicate metric " + name)
+	}
+	registry[name] = m
+}
+
+// series holds one value per label combination
+type series struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	values map[string][]string // key -> label values
+}
+
+func newSeries(name, help string, labels []string) series {
+	return series{name: name, help: help, labels: labels, values: map[string][]string{}}
+}
+
+func (s *series) key(values []string) string {
+	if len(values) != len(s.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", s.name, len(s.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	if _, ok := s.values[key]; !ok {
+		s.values[key] = append([]string(nil), values...)
+	}
+	return key
+}
+
+func (s *series) sortedKeys() []string {
+	keys := make([]string, 0, len(s.values))
+	for key := range s.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *series) header(w io.Writer, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", s.name, escapeHelp(s.help), s.name, kind)
+}
+
+func formatLabels(names, values []string, extra ...string) string {
+	if len(names) == 0 && len(extra) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(names)+len(extra)/2)
+	for i, name := range names {
+		pairs = append(pairs, name+`="`+escapeLabel(values[i])+`"`)
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, extra[i]+`="`+escapeLabel(extra[i+1])+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// CounterVec counts events per label combination
+type CounterVec struct {
+	series
+	counts map[string]float64
+}
+
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{series: newSeries(name, help, labels), counts: map[string]float64{}}
+	register(name, c)
+	return c
+}
+
+func (c *CounterVec) Inc(labels ...string) {
+	c.Add(1, labels...)
+}
+
+func (c *CounterVec) Add(v float64, labels ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[c.key(labels)] += v
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.header(w, "counter")
+	for _, key := range c.sortedKeys() {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, formatLabels(c.labels, c.values[key]), formatFloat(c.counts[key]))
+	}
+}
+
+// GaugeVec holds a value per label combination that can go up and down
+type GaugeVec struct {
+	series
+	gauges map[string]float64
+}
+
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{series: newSeries(name, help, labels), gauges: map[string]float64{}}
+	register(name, g)
+	return g
+}
+
+func (g *GaugeVec) Set(v float64, labels ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.gauges[g.key(labels)] = v
+}
+
+func (g *GaugeVec) Add(v float64, labels ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.gauges[g.key(labels)] += v
+}
+
+func (g *GaugeVec) write(w io.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.header(w, "gauge")
+	for _, key := range g.sortedKeys() {
+		fmt.Fprintf(w, "%s%s %s\n", g.name, formatLabels(g.labels, g.values[key]), formatFloat(g.gauges[key]))
+	}
+}
+
+// HistogramVec records observations into cumulative buckets per label combination
+type HistogramVec struct {
+	series
+	buckets []float64
+	counts  map[string][]uint64 // per bucket, plus +Inf as the last entry
+	sums    map[string]float64
+}
+
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	h := &HistogramVec{
+		series:  newSeries(name, help, labels),
+		buckets: sorted,
+		counts:  map[string][]uint64{},
+		sums:    map[string]float64{},
+	}
+	register(name, h)
+	return h
+}
+
+func (h *HistogramVec) Observe(v float64, labels ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	key := h.key(labels)
+	counts, ok := h.counts[key]
+	if !ok {
+		counts = make([]uint64, len(h.buckets)+1)
+		h.counts[key] = counts
+	}
+	i := sort.SearchFloat64s(h.buckets, v)
+	counts[i]++
+	h.sums[key] += v
+}
+
+// ObserveSince records the seconds elapsed since start
+func (h *HistogramVec) ObserveSince(start time.Time, labels ...string) {
+	h.Observe(time.Since(start).Seconds(), labels...)
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.header(w, "histogram")
+	for _, key := range h.sortedKeys() {
+		values := h.values[key]
+		counts := h.counts[key]
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, values, "le", formatFloat(upper)), cumulative)
+		}
+		cumulative += counts[len(h.buckets)]
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, values, "le", "+Inf"), cumulative)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels(h.labels, values), formatFloat(h.sums[key]))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels(h.labels, values), cumulative)
+	}
+}
+
+// Sample is one value reported by a GaugeFunc, with label values in declaration order
+type Sample struct {
+	Labels []string
+	Value  float64
+}
+
+// GaugeFunc is a gauge whose samples are read when /metrics is scraped, for values
+// owned elsewhere such as queue lengths or rate limiter usage
+type GaugeFunc struct {
+	series
+	collect func() []Sample
+}
+
+func NewGaugeFunc(name, help string, collect func() []Sample, labels ...string) *GaugeFunc {
+	g := &GaugeFunc{series: newSeries(name, help, labels), collect: collect}
+	register(name, g)
+	return g
+}
+
+func (g *GaugeFunc) write(w io.Writer) {
+	samples := g.collect()
+	g.header(w, "gauge")
+	for _, sample := range samples {
+		if len(sample.Labels) != len(g.labels) {
+			continue
+		}
+		fmt.Fprintf(w, "%s%s %s\n", g.name, formatLabels(g.labels, sample.Labels), formatFloat(sample.Value))
+	}
+}
+
+// WriteTo writes every registered metric in the Prometheus text format
+func WriteTo(w io.Writer) {
+	registryMu.Lock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	metrics := make([]metric, len(names))
+	for i, name := range names {
+		metrics[i] = registry[name]
+	}
+	registryMu.Unlock()
+
+	for _, m := range metrics {
+		m.write(w)
+	}
+}
+
+// Handler serves the registry for Prometheus to scrape
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		WriteTo(w)
+	})
+}
+
+// StatusClass buckets an HTTP status code for provider error metrics
+func StatusClass(code int) string {
+	switch {
+	case code == http.StatusTooManyRequests:
+		return "rate_limited"
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return "auth"
+	case code >= 500:
+		return "server_error"
+	case code >= 400:
+		return "client_error"
+	}
+	return "ok"
+}
@@ -0,0 +1,231 @@
+package metrics
+
+import (
+	"bytes"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// parse reads the exposition with Prometheus' own parser, so anything it accepts is what
+// a scrape would see
+func parse(t *testing.T, m metric) map[string]*dto.MetricFamily {
+	t.Helper()
+	var buf bytes.Buffer
+	m.write(&buf)
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(&buf)
+	if err != nil {
+		t.Fatalf("Prometheus cannot parse the output: %v\n%s", err, buf.String())
+	}
+	return families
+}
+
+func labelMap(m *dto.Metric) map[string]string {
+	labels := map[string]string{}
+	for _, pair := range m.GetLabel() {
+		labels[pair.GetName()] = pair.GetValue()
+	}
+	return labels
+}
+
+func TestCounterLabelEscaping(t *testing.T) {
+	counter := NewCounterVec("test_escaping_total", "Counts with \\ backslashes\nand newlines.", "path", "note")
+	awkward := []string{
+		`C:\assets\video.mp4`,
+		`say "hi"`,
+		"two\nlines",
+		`trailing\`,
+		"unicode ✓",
+		"",
+	}
+	for i, value := range awkward {
+		counter.Add(float64(i+1), value, "x")
+	}
+
+	family := parse(t, counter)["test_escaping_total"]
+	if family == nil {
+		t.Fatal("test_escaping_total missing")
+	}
+	if family.GetType() != dto.MetricType_COUNTER {
+		t.Errorf("type = %v, want counter", family.GetType())
+	}
+	if family.GetHelp() != "Counts with \\ backslashes\nand newlines." {
+		t.Errorf("help = %q", family.GetHelp())
+	}
+	got := map[string]float64{}
+	for _, m := range family.GetMetric() {
+		got[labelMap(m)["path"]] = m.GetCounter().GetValue()
+	}
+	for i, value := range awkward {
+		if got[value] != float64(i+1) {
+			t.Errorf("path %q = %v, want %d", value, got[value], i+1)
+		}
+	}
+}
+
+func TestGaugeSpecialValues(t *testing.T) {
+	gauge := NewGaugeVec("test_special_values", "Gauges at the edges.", "case")
+	gauge.Set(math.Inf(1), "pos_inf")
+	gauge.Set(math.Inf(-1), "neg_inf")
+	gauge.Set(math.NaN(), "nan")
+	gauge.Set(1e-9, "tiny")
+	gauge.Set(5, "added")
+	gauge.Add(-7.5, "added")
+
+	got := map[string]float64{}
+	for _, m := range parse(t, gauge)["test_special_values"].GetMetric() {
+		got[labelMap(m)["case"]] = m.GetGauge().GetValue()
+	}
+	if !math.IsInf(got["pos_inf"], 1) || !math.IsInf(got["neg_inf"], -1) || !math.IsNaN(got["nan"]) {
+		t.Errorf("special values = %v", got)
+	}
+	if got["tiny"] != 1e-9 || got["added"] != -2.5 {
+		t.Errorf("tiny = %v, added = %v", got["tiny"], got["added"])
+	}
+}
+
+func TestHistogramBuckets(t *testing.T) {
+	// Unsorted on purpose; buckets are sorted and an observation on a bound falls in it
+	histogram := NewHistogramVec("test_duration_seconds", "Durations.", []float64{5, 1, 2.5}, "stage")
+	for _, v := range []float64{0.2, 1, 1.5, 2.5, 4, 7, 100} {
+		histogram.Observe(v, "render")
+	}
+	histogram.Observe(3, "tts")
+
+	family := parse(t, histogram)["test_duration_seconds"]
+	if family.GetType() != dto.MetricType_HISTOGRAM {
+		t.Fatalf("type = %v, want histogram", family.GetType())
+	}
+	var render *dto.Histogram
+	for _, m := range family.GetMetric() {
+		if labelMap(m)["stage"] == "render" {
+			render = m.GetHistogram()
+		}
+	}
+	if render == nil {
+		t.Fatal("no render series")
+	}
+
+	want := map[float64]uint64{1: 2, 2.5: 4, 5: 5, math.Inf(1): 7}
+	buckets := render.GetBucket()
+	if len(buckets) != len(want) {
+		t.Fatalf("%d buckets, want %d", len(buckets), len(want))
+	}
+	previous := 0.0
+	for _, bucket := range buckets {
+		if bucket.GetUpperBound() <= previous {
+			t.Errorf("bucket bounds not increasing at %v", bucket.GetUpperBound())
+		}
+		previous = bucket.GetUpperBound()
+		if bucket.GetCumulativeCount() != want[bucket.GetUpperBound()] {
+			t.Errorf("le=%v count = %d, want %d", bucket.GetUpperBound(), bucket.GetCumulativeCount(), want[bucket.GetUpperBound()])
+		}
+	}
+	if render.GetSampleCount() != 7 {
+		t.Errorf("count = %d, want 7", render.GetSampleCount())
+	}
+	if render.GetSampleSum() != 116.2 {
+		t.Errorf("sum = %v, want 116.2", render.GetSampleSum())
+	}
+}
+
+func TestHistogramInfBucketLine(t *testing.T) {
+	histogram := NewHistogramVec("test_inf_seconds", "Durations.", []float64{1}, "stage")
+	histogram.Observe(2, "a")
+	var buf bytes.Buffer
+	histogram.write(&buf)
+	for _, line := range []string{
+		`test_inf_seconds_bucket{stage="a",le="1"} 0`,
+		`test_inf_seconds_bucket{stage="a",le="+Inf"} 1`,
+		`test_inf_seconds_sum{stage="a"} 2`,
+		`test_inf_seconds_count{stage="a"} 1`,
+	} {
+		if !strings.Contains(buf.String(), line+"\n") {
+			t.Errorf("output lacks %q:\n%s", line, buf.String())
+		}
+	}
+}
+
+func TestGaugeFuncSkipsMislabelledSamples(t *testing.T) {
+	gauge := NewGaugeFunc("test_queue_length", "Queued items.", func() []Sample {
+		return []Sample{
+			{Labels: []string{"images"}, Value: 3},
+			{Labels: []string{"audio", "extra"}, Value: 9},
+		}
+	}, "queue")
+
+	metrics := parse(t, gauge)["test_queue_length"].GetMetric()
+	if len(metrics) != 1 || labelMap(metrics[0])["queue"] != "images" || metrics[0].GetGauge().GetValue() != 3 {
+		t.Errorf("samples = %v, want only the images queue", metrics)
+	}
+}
+
+func TestUnlabelledMetric(t *testing.T) {
+	gauge := NewGaugeVec("test_in_flight", "Running.")
+	gauge.Add(2)
+	var buf bytes.Buffer
+	gauge.write(&buf)
+	if !strings.Contains(buf.String(), "\ntest_in_flight 2\n") {
+		t.Errorf("output = %q, want a sample without braces", buf.String())
+	}
+}
+
+func TestLabelCountMismatchPanics(t *testing.T) {
+	counter := NewCounterVec("test_mismatch_total", "Mismatch.", "a", "b")
+	defer func() {
+		if recover() == nil {
+			t.Error("Inc with too few label values did not panic")
+		}
+	}()
+	counter.Inc("only-one")
+}
+
+func TestDuplicateRegistrationPanics(t *testing.T) {
+	NewCounterVec("test_duplicate_total", "First.")
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a name twice did not panic")
+		}
+	}()
+	NewGaugeVec("test_duplicate_total", "Second.")
+}
+
+func TestHandler(t *testing.T) {
+	NewCounterVec("test_handler_total", "Handler.", "code").Inc("200")
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q, want the text format", got)
+	}
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(rec.Body)
+	if err != nil {
+		t.Fatalf("Prometheus cannot parse the scrape: %v", err)
+	}
+	if families["test_handler_total"] == nil {
+		t.Error("scrape lacks test_handler_total")
+	}
+}
+
+func TestStatusClass(t *testing.T) {
+	for code, want := range map[int]string{
+		http.StatusOK:                  "ok",
+		http.StatusTooManyRequests:     "rate_limited",
+		http.StatusUnauthorized:        "auth",
+		http.StatusForbidden:           "auth",
+		http.StatusNotFound:            "client_error",
+		http.StatusInternalServerError: "server_error",
+		http.StatusBadGateway:          "server_error",
+	} {
+		if got := StatusClass(code); got != want {
+			t.Errorf("StatusClass(%d) = %q, want %q", code, got, want)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+// Package readiness runs the dependency checks behind the services' /ready endpoints.
+// Unlike /health, which only says the process is up, /ready answers 503 until every
+// external dependency a job needs — other services, binaries, files, database rows —
+// is usable, and reports the status and latency of each check.
+package readiness
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	StatusReady    = "ready"
+	StatusNotReady = "not_ready"
+
+	StatusOK     = "ok"
+	StatusFailed = "failed"
+)
+
+// DefaultTimeout bounds a whole /ready request; checks run concurrently
+const DefaultTimeout = 5 * time.Second
+
+// Check is one dependency; Run returns nil when the dependency is usable
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// CheckResult is the outcome of one check
+type CheckResult struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"` // ok or failed
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// ReadyResponse is the /ready response body
+type ReadyResponse struct {
+	Status    string        `json:"status"` // ready or not_ready
+	Timestamp string        `json:"timestamp"`
+	Checks    []CheckResult `json:"checks"`
+}
+
+// Run runs the checks concurrently, each bounded by ctx
+func Run(ctx context.Context, checks []Check) ReadyResponse {
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			err := check.Run(ctx)
+			results[i] = CheckResult{
+				Name:      check.Name,
+				Status:    StatusOK,
+				LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				results[i].Status = StatusFailed
+				results[i].Error = err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+
+	report := ReadyResponse{Status: StatusReady, Timestamp: time.Now().Format(time.RFC3339), Checks: results}
+	for _, result := range results {
+		if result.Status != StatusOK {
+			report.Status = StatusNotReady
+		}
+	}
+	return report
+}
+
+// Handler serves the report of checks() as JSON, with 503 when any check fails. checks
+// is called per request so the list can depend on the current configuration.
+func Handler(timeout time.Duration, checks func() []Check) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		report := Run(ctx, checks())
+		code := http.StatusOK
+		if report.Status != StatusReady {
+			code = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(report)
+	})
+}
+
+// HTTP checks that url answers with a status below 500. An empty url fails, so an unset
+// environment variable shows up as a failed check.
+func HTTP(name, url string) Check {
+	return Check{Name: name, Run: func(ctx context.Context) error {
+		if url == "" {
+			return fmt.Errorf("URL not configured")
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return fmt.Errorf("%s answered %d", url, resp.StatusCode)
+		}
+		return nil
+	}}
+}
+
+// Binary checks that the executable is on PATH
+func Binary(name string) Check {
+	return Check{Name: "binary:" + name, Run: func(ctx context.Context) error {
+		_, err := exec.LookPath(name)
+		return err
+	}}
+}
+
+// FFmpeg checks that ffmpeg is on PATH and was built with the encoders and filters
+// the service uses
+func FFmpeg(encoders, filters []string) Check {
+	return Check{Name: "ffmpeg", Run: func(ctx context.Context) error {
+		var missing []string
+		for _, list := range []struct {
+			flag  string
+			names []string
+		}{{"-encoders", encoders}, {"-filters", filters}} {
+			if len(list.names) == 0 {
+				continue
+			}
+			output, err := exec.CommandContext(ctx, "ffmpeg", "-hide_banner", list.flag).Output()
+			if err != nil {
+				return fmt.Errorf("ffmpeg %s: %w", list.flag, err)
+			}
+			available := listedNames(output)
+			for _, name := range list.names {
+				if !available[name] {
+					missing = append(missing, name)
+				}
+			}
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("ffmpeg lacks %s", strings.Join(missing, ", "))
+		}
+		return nil
+	}}
+}
+
+// listedNames picks the second column of ffmpeg's -encoders/-filters output, which
+// holds the names after a column of capability flags
+func listedNames(output []byte) map[string]bool {
+	names := map[string]bool{}
+	for _, line := range bytes.Split(output, []byte("\n")) {
+		fields := strings.Fields(string(line))
+		if len(fields) >= 2 {
+			names[fields[1]] = true
+		}
+	}
+	return names
+}
+
+// File checks that path exists and is a regular, non-empty file
+func File(name, path string) Check {
+	return Check{Name: name, Run: func(ctx context.Context) error {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() || info.Size() == 0 {
+			return fmt.Errorf("%s is not a usable file", path)
+		}
+		return nil
+	}}
+}
+
+// Writable checks that a file can be created in dir, creating dir if needed
+func Writable(dir string) Check {
+	return Check{Name: "writable:" + dir, Run: func(ctx context.Context) error {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		file, err := os.CreateTemp(dir, ".ready-*")
+		if err != nil {
+			return err
+		}
+		file.Close()
+		return os.Remove(file.Name())
+	}}
+}
@@ -5,17 +5,22 @@ import (
 	"fmt"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
-	"log"
-	"os"
+	"log/slog"
+	"math/rand"
 	"sync"
 	"time"
 )
 
+// APIKeyManager hands out provider keys from api_keys and records how providers answer
+// them, see api_key_health.go
 type APIKeyManager struct {
-	currentKey *APIKey
-	mu         sync.RWMutex
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+func NewAPIKeyManager() *APIKeyManager {
+	return &APIKeyManager{rng: rand.New(rand.NewSource(time.Now().UnixNano()))}
 }
 
 func seedAPIKeys() error {
@@ -28,44 +33,27 @@ func seedAPIKeys() error {
 	}
 
 	if count > 0 {
-		fmt.Printf("API keys already exist (%d found), skipping seed\n", count)
+		slog.Debug("API keys already exist, skipping seed", "count", count)
 		return nil
 	}
 
-	// Get API keys from environment variables
-	whiskKey := os.Getenv("API_AUTH_TOKEN")
-	elevenLabsKey := os.Getenv("ELEVENLABS_API_KEY")
+	// Get API keys from the configuration
+	whiskKey := appConfig().Images.AuthToken
+	elevenLabsKey := appConfig().Voice.ElevenLabsAPIKey
 
 	var keysToInsert []interface{}
 
-	// Add Whisk API key if available
-	if whiskKey != "" {
-		whiskAPIKey := APIKey{
-			ID:         primitive.NewObjectID(),
-			KeyValue:   whiskKey,
-			Provider:   "whisk",
-			IsActive:   true,
-			LastUsed:   time.Now(),
-			ErrorCount: 0,
-			CreatedAt:  time.Now(),
+	// Add Whisk and ElevenLabs API keys if available
+	for provider, value := range map[string]string{"whisk": whiskKey, "elevenlabs": elevenLabsKey} {
+		if value == "" {
+			continue
 		}
-		keysToInsert = append(keysToInsert, whiskAPIKey)
-		fmt.Println("✓ Added Whisk API key")
-	}
-
-	// Add ElevenLabs API key if available
-	if elevenLabsKey != "" {
-		elevenLabsAPIKey := APIKey{
-			ID:         primitive.NewObjectID(),
-			KeyValue:   elevenLabsKey,
-			Provider:   "elevenlabs",
-			IsActive:   true,
-			LastUsed:   time.Now(),
-			ErrorCount: 0,
-			CreatedAt:  time.Now(),
+		apiKey, err := newAPIKey(provider, value)
+		if err != nil {
+			return err
 		}
-		keysToInsert = append(keysToInsert, elevenLabsAPIKey)
-		fmt.Println("✓ Added ElevenLabs API key")
+		keysToInsert = append(keysToInsert, apiKey)
+		slog.Info("Seeding API key", logKeyProvider, provider, "fingerprint", apiKey.Fingerprint)
 	}
 
 	if len(keysToInsert) == 0 {
@@ -78,32 +66,36 @@ func seedAPIKeys() error {
 		return fmt.Errorf("failed to insert API keys: %w", err)
 	}
 
-	fmt.Printf("✓ Successfully seeded %d API keys\n", len(keysToInsert))
+	slog.Info("Seeded API keys", "count", len(keysToInsert))
 	return nil
 }
 
-// 2. Add this function to manually add API keys
-func addAPIKey(provider, keyValue string) error {
+// addAPIKey seals a new key into the provider's pool, refusing one already in it
+func addAPIKey(provider, keyValue string) (*APIKey, error) {
 	ctx := context.Background()
 
-	apiKey := APIKey{
-		ID:         primitive.NewObjectID(),
-		KeyValue:   keyValue,
-		Provider:   provider,
-		IsActive:   true,
-		LastUsed:   time.Now(),
-		ErrorCount: 0,
-		CreatedAt:  time.Now(),
+	apiKey, err := newAPIKey(provider, keyValue)
+	if err != nil {
+		return nil, err
 	}
 
-	_, err := apiKeysCollection.InsertOne(ctx, apiKey)
+	count, err := apiKeysCollection.CountDocuments(ctx, bson.M{"provider": provider, "fingerprint": apiKey.Fingerprint})
 	if err != nil {
-		return fmt.Errorf("failed to add API key: %w", err)
+		return nil, fmt.Errorf("failed to check for duplicate API key: %w", err)
+	}
+	if count > 0 {
+		return nil, fmt.Errorf("%w: %s", errDuplicateAPIKey, apiKey.Fingerprint)
 	}
 
-	fmt.Printf("✓ Added API key for provider: %s\n", provider)
-	return nil
+	_, err = apiKeysCollection.InsertOne(ctx, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add API key: %w", err)
+	}
+
+	slog.Info("Added API key", logKeyProvider, provider, "fingerprint", apiKey.Fingerprint)
+	return apiKey, nil
 }
+
 func (yt *YtAutomation) getAPIKeyStats() error {
 	ctx := context.Background()
 
@@ -118,62 +110,88 @@ func (yt *YtAutomation) getAPIKeyStats() error {
 		return fmt.Errorf("failed to decode API keys: %w", err)
 	}
 
-	fmt.Printf("\n=== API Key Usage Statistics ===\n")
 	for _, key := range keys {
-		status := "INACTIVE"
-		if key.IsActive {
-			status = "ACTIVE"
-		}
-
-		timeSinceLastUsed := time.Since(key.LastUsed)
-		fmt.Printf("ID: %s | Provider: %s | Status: %s | Errors: %d | Last Used: %s ago\n",
-			key.ID.Hex()[:8]+"...", key.Provider, status, key.ErrorCount,
-			timeSinceLastUsed.Round(time.Minute))
+		slog.Info("API key usage", "fingerprint", key.Fingerprint, logKeyProvider, key.Provider,
+			"active", key.IsActive, "state", key.State, "uses", key.UsageCount, "errors", key.ErrorCount,
+			"since_last_used", time.Since(key.LastUsed).Round(time.Minute))
 	}
-	fmt.Printf("=================================\n\n")
 
 	return nil
 }
 
-// 3. Improved APIKeyManager with better error handling
-func (akm *APIKeyManager) GetActiveKey(provider string) (*APIKey, error) {
-	akm.mu.RLock()
-	if akm.currentKey != nil && akm.currentKey.IsActive && akm.currentKey.Provider == provider {
-		akm.mu.RUnlock()
-		return akm.currentKey, nil
+// GetActiveKey picks one of the provider's enabled, healthy keys, weighted towards the
+// least recently used so the load spreads over the pool. Keys in exclude are skipped,
+// for callers failing over from keys they already tried.
+func (akm *APIKeyManager) GetActiveKey(provider string, exclude ...primitive.ObjectID) (*APIKey, error) {
+	ctx := context.Background()
+	filter := bson.M{"is_active": true, "provider": provider, "state": KeyStateHealthy}
+	if len(exclude) > 0 {
+		filter["_id"] = bson.M{"$nin": exclude}
 	}
-	akm.mu.RUnlock()
-
-	akm.mu.Lock()
-	defer akm.mu.Unlock()
-
-	// Find active key for provider
-	var apiKey APIKey
-	err := apiKeysCollection.FindOne(
-		context.Background(),
-		bson.M{"is_active": true, "provider": provider},
-		options.FindOne().SetSort(bson.M{"last_used": 1}),
-	).Decode(&apiKey)
-
+	cursor, err := apiKeysCollection.Find(ctx, filter)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return nil, fmt.Errorf("no active API keys found for provider '%s'", provider)
-		}
 		return nil, fmt.Errorf("database error: %w", err)
 	}
+	var keys []APIKey
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	if len(keys) == 0 {
+		return nil, noHealthyKeyError(ctx, provider)
+	}
+
+	apiKey := &keys[akm.pickLeastRecentlyUsed(keys)]
+	if err := apiKey.decrypt(); err != nil {
+		return nil, err
+	}
 
 	// Update last used timestamp
-	_, updateErr := apiKeysCollection.UpdateOne(
-		context.Background(),
+	_, updateErr := apiKeysCollection.UpdateOne(ctx,
 		bson.M{"_id": apiKey.ID},
-		bson.M{"$set": bson.M{"last_used": time.Now()}},
+		bson.M{"$set": bson.M{"last_used": time.Now()}, "$inc": bson.M{"usage_count": 1}},
 	)
 	if updateErr != nil {
-		log.Printf("Warning: failed to update last_used timestamp: %v", updateErr)
+		slog.Warn("Failed to update API key last_used", logKeyProvider, provider, "error", updateErr)
 	}
+	return apiKey, nil
+}
+
+// pickLeastRecentlyUsed draws a key with odds growing with its idle time, capped so a key
+// unused for days does not take every request
+func (akm *APIKeyManager) pickLeastRecentlyUsed(keys []APIKey) int {
+	now := time.Now()
+	weights := make([]float64, len(keys))
+	total := 0.0
+	for i, key := range keys {
+		idle := min(max(now.Sub(key.LastUsed), 0), maxKeyIdleWeight)
+		weights[i] = idle.Seconds() + 1
+		total += weights[i]
+	}
+
+	akm.mu.Lock()
+	r := akm.rng.Float64() * total
+	akm.mu.Unlock()
+	for i, weight := range weights {
+		if r < weight {
+			return i
+		}
+		r -= weight
+	}
+	return len(keys) - 1
+}
 
-	akm.currentKey = &apiKey
-	return &apiKey, nil
+// noHealthyKeyError says when the provider's next key comes back, if one is recovering
+func noHealthyKeyError(ctx context.Context, provider string) error {
+	var next APIKey
+	err := apiKeysCollection.FindOne(ctx,
+		bson.M{"is_active": true, "provider": provider, "cooldown_until": bson.M{"$ne": nil}},
+		options.FindOne().SetSort(bson.M{"cooldown_until": 1}),
+	).Decode(&next)
+	if err != nil || next.CooldownUntil == nil {
+		return fmt.Errorf("%w for provider '%s'", errNoHealthyAPIKey, provider)
+	}
+	return fmt.Errorf("%w for provider '%s', next recovers at %s", errNoHealthyAPIKey, provider,
+		next.CooldownUntil.Format(time.RFC3339))
 }
 
 // 4. Function to list all API keys (for debugging)
@@ -191,37 +209,58 @@ func listAPIKeys() error {
 		return fmt.Errorf("failed to decode API keys: %w", err)
 	}
 
-	fmt.Printf("\n=== API Keys in Database ===\n")
 	for _, key := range keys {
-		status := "INACTIVE"
-		if key.IsActive {
-			status = "ACTIVE"
-		}
-		fmt.Printf("Provider: %s | Status: %s | Errors: %d | Last Used: %s\n",
-			key.Provider, status, key.ErrorCount, key.LastUsed.Format("2006-01-02 15:04:05"))
+		slog.Info("API key", logKeyProvider, key.Provider, "fingerprint", key.Fingerprint, "active", key.IsActive, "state", key.State,
+			"errors", key.ErrorCount, "last_used", key.LastUsed)
 	}
-	fmt.Printf("===========================\n\n")
 
 	return nil
 }
 
-func (akm *APIKeyManager) FlagKeyAsProblematic(keyID primitive.ObjectID, errorMsg string) error {
-	akm.mu.Lock()
-	defer akm.mu.Unlock()
-
-	_, err := apiKeysCollection.UpdateOne(
-		context.Background(),
-		bson.M{"_id": keyID},
-		bson.M{
-			"$set": bson.M{"is_active": false},
-			"$inc": bson.M{"error_count": 1},
-		},
-	)
+// ReportFailure moves a key into the state the failure calls for, see nextKeyState
+func (akm *APIKeyManager) ReportFailure(key *APIKey, failure keyFailure) error {
+	now := time.Now()
+	state, until := nextKeyState(key, failure, now)
+	set := bson.M{"state": state, "last_error": failure.Message, "last_error_at": now}
+	update := bson.M{"$set": set, "$inc": bson.M{"error_count": 1, "consecutive_errors": 1}}
+	if until != nil {
+		set["cooldown_until"] = *until
+	} else {
+		update["$unset"] = bson.M{"cooldown_until": ""}
+	}
+
+	_, err := apiKeysCollection.UpdateByID(context.Background(), key.ID, update)
+	if err != nil {
+		return err
+	}
+	attrs := []any{logKeyProvider, key.Provider, "key", key.Fingerprint, "status", failure.Status, "state", state}
+	if until != nil {
+		attrs = append(attrs, "until", until.Format(time.RFC3339))
+	}
+	slog.Warn("API key failed", attrs...)
+	return nil
+}
 
-	if akm.currentKey != nil && akm.currentKey.ID == keyID {
-		akm.currentKey = nil // Force getting new key
+// ReportSuccess clears the failure streak that lengthens cooldowns
+func (akm *APIKeyManager) ReportSuccess(key *APIKey) {
+	if key.ConsecutiveErrors == 0 {
+		return
+	}
+	_, err := apiKeysCollection.UpdateByID(context.Background(), key.ID,
+		bson.M{"$set": bson.M{"consecutive_errors": 0}})
+	if err != nil {
+		slog.Warn("Failed to reset API key error streak", logKeyProvider, key.Provider, "key", key.Fingerprint, "error", err)
 	}
+}
 
-	log.Printf("API Key flagged as problematic: %s", errorMsg)
-	return err
+// RecordCharacters adds characters spent with a metered key and keeps what the provider
+// last said was left
+func (akm *APIKeyManager) RecordCharacters(key *APIKey, used, remaining int) {
+	_, err := apiKeysCollection.UpdateByID(context.Background(), key.ID, bson.M{
+		"$inc": bson.M{"characters_used": used},
+		"$set": bson.M{"characters_remaining": remaining},
+	})
+	if err != nil {
+		slog.Warn("Failed to record API key characters", logKeyProvider, key.Provider, "key", key.Fingerprint, "error", err)
+	}
 }
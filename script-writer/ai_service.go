@@ -3,16 +3,18 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"time"
 )
 
 // AIService interface for different AI providers
 type AIService interface {
-	GenerateContentWithSystem(systemPrompt, userPrompt string) (string, error)
+	GenerateContentWithSystem(ctx context.Context, systemPrompt, userPrompt string) (string, error)
 }
 
 // AIProvider enum
@@ -63,7 +65,7 @@ type OpenRouterError struct {
 func NewOpenRouterService(apiKey string) *OpenRouterService {
 	return &OpenRouterService{
 		apiKey: apiKey,
-		client: &http.Client{Timeout: 300 * time.Second}, // 5 minutes for R1 models
+		client: providerClient(string(ProviderOpenRouter), 300*time.Second), // 5 minutes for R1 models
 		//model:  "meta-llama/llama-3.1-70b-instruct",
 		model: "deepseek/deepseek-chat-v3-0324:free",
 		//model: "deepseek/deepseek-r1-distill-llama-70b", // Free DeepSeek R1 model
@@ -72,14 +74,14 @@ func NewOpenRouterService(apiKey string) *OpenRouterService {
 }
 
 // GenerateContent implements AIService interface
-func (o *OpenRouterService) GenerateContentWithSystem(systemPrompt, userPrompt string) (string, error) {
-	return o.retryWithBackoffSystem(systemPrompt, userPrompt, 3)
+func (o *OpenRouterService) GenerateContentWithSystem(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	return o.retryWithBackoffSystem(ctx, systemPrompt, userPrompt, 3)
 }
-func (o *OpenRouterService) callAPIWithSystem(systemPrompt, userPrompt string) (string, error) {
+func (o *OpenRouterService) callAPIWithSystem(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
 
 	if debugMode {
-		fmt.Println(systemPrompt)
-		fmt.Println(userPrompt)
+		slog.DebugContext(ctx, "OpenRouter prompt", logKeyProvider, ProviderOpenRouter,
+			"system_prompt", systemPrompt, "user_prompt", userPrompt)
 	}
 	requestBody := OpenRouterRequest{
 		Model: o.model,
@@ -104,7 +106,7 @@ func (o *OpenRouterService) callAPIWithSystem(systemPrompt, userPrompt string) (
 		return "", fmt.Errorf("marshalling JSON: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", "https://openrouter.ai/api/v1/chat/completions", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://openrouter.ai/api/v1/chat/completions", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", fmt.Errorf("creating request: %w", err)
 	}
@@ -144,11 +146,12 @@ func (o *OpenRouterService) callAPIWithSystem(systemPrompt, userPrompt string) (
 
 	return openRouterResp.Choices[0].Message.Content, nil
 }
-func (o *OpenRouterService) retryWithBackoffSystem(systemPrompt, userPrompt string, maxRetries int) (string, error) {
+func (o *OpenRouterService) retryWithBackoffSystem(ctx context.Context, systemPrompt, userPrompt string, maxRetries int) (string, error) {
 	var lastErr error
 
 	for attempt := 0; attempt < maxRetries; attempt++ {
-		result, err := o.callAPIWithSystem(systemPrompt, userPrompt)
+		result, err := o.callAPIWithSystem(ctx, systemPrompt, userPrompt)
+		recordProviderCall(string(ProviderOpenRouter), err)
 		if err == nil {
 			return result, nil
 		}
@@ -158,15 +161,17 @@ func (o *OpenRouterService) retryWithBackoffSystem(systemPrompt, userPrompt stri
 		backoffDuration := time.Duration(1<<attempt) * time.Second
 		if isTimeoutError(err) {
 			backoffDuration = time.Duration(10*(attempt+1)) * time.Second
-			fmt.Printf("OpenRouter timeout (attempt %d/%d), retrying in %v: %v\n",
-				attempt+1, maxRetries, backoffDuration, err)
+			slog.WarnContext(ctx, "OpenRouter timeout, retrying", logKeyProvider, ProviderOpenRouter,
+				"attempt", attempt+1, "max_attempts", maxRetries, "backoff", backoffDuration, "error", err)
 		} else {
-			fmt.Printf("OpenRouter API call failed (attempt %d/%d), retrying in %v: %v\n",
-				attempt+1, maxRetries, backoffDuration, err)
+			slog.WarnContext(ctx, "OpenRouter API call failed, retrying", logKeyProvider, ProviderOpenRouter,
+				"attempt", attempt+1, "max_attempts", maxRetries, "backoff", backoffDuration, "error", err)
 		}
 
 		if attempt < maxRetries-1 {
-			time.Sleep(backoffDuration)
+			if err := sleepContext(ctx, backoffDuration); err != nil {
+				return "", err
+			}
 		}
 	}
 
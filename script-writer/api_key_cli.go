@@ -0,0 +1,98 @@
+// File: api_key_cli.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const keysUsage = `Usage:
+  script-writer keys new-master-key
+  script-writer keys list
+  script-writer keys rotate`
+
+// runKeysCommand manages the provider key pool from the command line and returns the exit code
+func runKeysCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, keysUsage)
+		return 2
+	}
+
+	var err error
+	switch args[0] {
+	case "new-master-key":
+		err = newMasterKeyCommand()
+	case "list":
+		err = listKeysCommand()
+	case "rotate":
+		err = rotateKeysCommand()
+	default:
+		fmt.Fprintln(os.Stderr, keysUsage)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func newMasterKeyCommand() error {
+	key, err := newMasterKey()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n\nSet it as security.master_key (API_KEY_MASTER_KEY) or put it in security.master_key_file.\n", key)
+	fmt.Println("When rotating, move the current key to security.previous_master_keys, then run: script-writer keys rotate")
+	return nil
+}
+
+func listKeysCommand() error {
+	cursor, err := apiKeysCollection.Find(context.Background(), bson.M{},
+		options.Find().SetSort(bson.D{{"provider", 1}, {"created_at", 1}}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(context.Background())
+
+	var keys []APIKey
+	if err := cursor.All(context.Background(), &keys); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tPROVIDER\tFINGERPRINT\tMASTER KEY\tSTATUS\tSTATE\tERRORS\tLAST USED")
+	for _, key := range keys {
+		status := "active"
+		if !key.IsActive {
+			status = "inactive"
+		}
+		masterKey := "plaintext"
+		if key.Secret != nil {
+			masterKey = key.Secret.MasterKeyID
+		}
+		state := key.State
+		if key.CooldownUntil != nil {
+			state += " until " + key.CooldownUntil.Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%d\t%s\n", key.ID.Hex(), key.Provider, key.Fingerprint,
+			masterKey, status, state, key.ErrorCount, key.LastUsed.Format(time.RFC3339))
+	}
+	return tw.Flush()
+}
+
+func rotateKeysCommand() error {
+	encrypted, rewrapped, err := encryptStoredAPIKeys(context.Background())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Encrypted %d plaintext keys, re-sealed %d keys with master key %s\n",
+		encrypted, rewrapped, apiKeyRing.current)
+	return nil
+}
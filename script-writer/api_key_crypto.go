@@ -0,0 +1,290 @@
+// File: api_key_crypto.go
+package main
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Provider API keys are stored with envelope encryption: each key is sealed with its own
+// random data key (AES-256-GCM, bound to the document ID), and the data key is sealed
+// with a master key from the configuration. Rotating the master key only re-seals the
+// data keys.
+
+// EncryptedSecret is a sealed value as stored in Mongo. Both byte fields start with
+// their GCM nonce.
+type EncryptedSecret struct {
+	MasterKeyID string `bson:"master_key_id" json:"master_key_id"`
+	WrappedKey  []byte `bson:"wrapped_key" json:"-"` // Data key sealed with the master key
+	Ciphertext  []byte `bson:"ciphertext" json:"-"`  // Value sealed with the data key
+}
+
+// keyRing holds the master keys: the current one seals, older ones only open
+type keyRing struct {
+	current string
+	keys    map[string][]byte
+}
+
+// apiKeyRing is built from the security section at startup
+var apiKeyRing *keyRing
+
+// newKeyRing decodes the master keys of the configuration
+func newKeyRing(security SecurityConfig) (*keyRing, error) {
+	encoded := security.MasterKey
+	if security.MasterKeyFile != "" {
+		data, err := os.ReadFile(security.MasterKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading master key file: %w", err)
+		}
+		encoded = strings.TrimSpace(string(data))
+	}
+	if encoded == "" {
+		return nil, errors.New("a master key is required to encrypt api_keys; generate one with: script-writer keys new-master-key")
+	}
+
+	ring := &keyRing{keys: map[string][]byte{}}
+	current, err := decodeMasterKey(encoded)
+	if err != nil {
+		return nil, err
+	}
+	ring.current = masterKeyID(current)
+	ring.keys[ring.current] = current
+	for i, previous := range security.PreviousMasterKeys {
+		key, err := decodeMasterKey(previous)
+		if err != nil {
+			return nil, fmt.Errorf("previous master key %d: %w", i+1, err)
+		}
+		ring.keys[masterKeyID(key)] = key
+	}
+	return ring, nil
+}
+
+func decodeMasterKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("master key is not base64: %w", err)
+	}
+	if len(key) != masterKeySize {
+		return nil, fmt.Errorf("master key must be %d bytes, got %d", masterKeySize, len(key))
+	}
+	return key, nil
+}
+
+// masterKeyID names a master key without revealing it
+func masterKeyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:4])
+}
+
+// newMasterKey returns a random master key, base64 encoded for the configuration
+func newMasterKey() (string, error) {
+	key := make([]byte, masterKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// seal encrypts value under a new data key; aad binds it to its document
+func (r *keyRing) seal(value string, aad []byte) (*EncryptedSecret, error) {
+	dataKey := make([]byte, masterKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+	ciphertext, err := gcmSeal(dataKey, []byte(value), aad)
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := gcmSeal(r.keys[r.current], dataKey, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptedSecret{MasterKeyID: r.current, WrappedKey: wrapped, Ciphertext: ciphertext}, nil
+}
+
+func (r *keyRing) open(secret *EncryptedSecret, aad []byte) (string, error) {
+	dataKey, err := r.unwrap(secret)
+	if err != nil {
+		return "", err
+	}
+	value, err := gcmOpen(dataKey, secret.Ciphertext, aad)
+	if err != nil {
+		return "", fmt.Errorf("decrypting secret: %w", err)
+	}
+	return string(value), nil
+}
+
+func (r *keyRing) unwrap(secret *EncryptedSecret) ([]byte, error) {
+	masterKey, ok := r.keys[secret.MasterKeyID]
+	if !ok {
+		return nil, fmt.Errorf("master key %s is not configured", secret.MasterKeyID)
+	}
+	dataKey, err := gcmOpen(masterKey, secret.WrappedKey, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unwrapping data key with master key %s: %w", secret.MasterKeyID, err)
+	}
+	return dataKey, nil
+}
+
+// rewrap seals the data key with the current master key, leaving the ciphertext as is
+func (r *keyRing) rewrap(secret *EncryptedSecret) (*EncryptedSecret, error) {
+	dataKey, err := r.unwrap(secret)
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := gcmSeal(r.keys[r.current], dataKey, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptedSecret{MasterKeyID: r.current, WrappedKey: wrapped, Ciphertext: secret.Ciphertext}, nil
+}
+
+func gcmSeal(key, plaintext, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func gcmOpen(key, sealed, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("sealed value too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, aad)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// apiKeyFingerprint identifies a provider key in logs and responses without revealing it
+func apiKeyFingerprint(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return "sha256:" + hex.EncodeToString(sum[:4])
+}
+
+// newAPIKey builds an active key document with its value sealed
+func newAPIKey(provider, value string) (*APIKey, error) {
+	id := primitive.NewObjectID()
+	secret, err := apiKeyRing.seal(value, id[:])
+	if err != nil {
+		return nil, fmt.Errorf("encrypting API key: %w", err)
+	}
+	now := time.Now()
+	return &APIKey{
+		ID:          id,
+		Secret:      secret,
+		Fingerprint: apiKeyFingerprint(value),
+		Provider:    provider,
+		IsActive:    true,
+		State:       KeyStateHealthy,
+		LastUsed:    now,
+		CreatedAt:   now,
+		KeyValue:    value,
+	}, nil
+}
+
+// decrypt fills KeyValue from the sealed secret
+func (k *APIKey) decrypt() error {
+	if k.Secret == nil {
+		return fmt.Errorf("API key %s is not encrypted yet", k.Fingerprint)
+	}
+	value, err := apiKeyRing.open(k.Secret, k.ID[:])
+	if err != nil {
+		return fmt.Errorf("API key %s: %w", k.Fingerprint, err)
+	}
+	k.KeyValue = value
+	return nil
+}
+
+// securedKeyUpdate is the update that seals a key still stored in plaintext, reported as
+// sealed, or re-seals the data key of one sealed with an older master key. It is nil when
+// the key is already sealed with the current master key.
+func (r *keyRing) securedKeyUpdate(key *APIKey) (update bson.M, sealed bool, err error) {
+	switch {
+	case key.LegacyKeyValue != "":
+		secret, err := r.seal(key.LegacyKeyValue, key.ID[:])
+		if err != nil {
+			return nil, false, err
+		}
+		return bson.M{
+			"$set":   bson.M{"secret": secret, "fingerprint": apiKeyFingerprint(key.LegacyKeyValue)},
+			"$unset": bson.M{"key_value": ""},
+		}, true, nil
+	case key.Secret != nil && key.Secret.MasterKeyID != r.current:
+		secret, err := r.rewrap(key.Secret)
+		if err != nil {
+			return nil, false, err
+		}
+		return bson.M{"$set": bson.M{"secret": secret}}, false, nil
+	}
+	return nil, false, nil
+}
+
+// encryptStoredAPIKeys seals keys still stored in plaintext and re-seals the data keys of
+// those sealed with an older master key. It runs at startup and from `keys rotate`.
+func encryptStoredAPIKeys(ctx context.Context) (encrypted, rewrapped int, err error) {
+	cursor, err := apiKeysCollection.Find(ctx, bson.M{"$or": []bson.M{
+		{"key_value": bson.M{"$exists": true}},
+		{"secret.master_key_id": bson.M{"$ne": apiKeyRing.current}},
+	}})
+	if err != nil {
+		return 0, 0, err
+	}
+	var keys []APIKey
+	if err := cursor.All(ctx, &keys); err != nil {
+		return 0, 0, err
+	}
+
+	for i := range keys {
+		update, sealed, err := apiKeyRing.securedKeyUpdate(&keys[i])
+		if err != nil {
+			return encrypted, rewrapped, err
+		}
+		if update == nil {
+			continue
+		}
+		if _, err := apiKeysCollection.UpdateByID(ctx, keys[i].ID, update); err != nil {
+			return encrypted, rewrapped, err
+		}
+		if sealed {
+			encrypted++
+		} else {
+			rewrapped++
+		}
+	}
+
+	if encrypted > 0 || rewrapped > 0 {
+		slog.Info("Secured stored API keys", "encrypted", encrypted, "rewrapped", rewrapped,
+			"master_key", apiKeyRing.current)
+	}
+	return encrypted, rewrapped, nil
+}
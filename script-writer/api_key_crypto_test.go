@@ -0,0 +1,199 @@
+package main
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func testKeyRing(t *testing.T, security SecurityConfig) *keyRing {
+	t.Helper()
+	ring, err := newKeyRing(security)
+	if err != nil {
+		t.Fatalf("newKeyRing: %v", err)
+	}
+	return ring
+}
+
+func testMasterKey(t *testing.T) string {
+	t.Helper()
+	key, err := newMasterKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestKeyRingSealOpen(t *testing.T) {
+	ring := testKeyRing(t, SecurityConfig{MasterKey: testMasterKey(t)})
+	id := primitive.NewObjectID()
+
+	secret, err := ring.seal("sk-live-123", id[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.MasterKeyID != ring.current {
+		t.Errorf("MasterKeyID = %s, want %s", secret.MasterKeyID, ring.current)
+	}
+	if value, err := ring.open(secret, id[:]); err != nil || value != "sk-live-123" {
+		t.Errorf("open = %q, %v; want the sealed value", value, err)
+	}
+
+	// Sealing twice uses a new data key and nonces
+	again, err := ring.seal("sk-live-123", id[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again.Ciphertext) == string(secret.Ciphertext) || string(again.WrappedKey) == string(secret.WrappedKey) {
+		t.Error("sealing the same value twice gave the same ciphertext")
+	}
+}
+
+func TestKeyRingOpenWrongAAD(t *testing.T) {
+	ring := testKeyRing(t, SecurityConfig{MasterKey: testMasterKey(t)})
+	id, other := primitive.NewObjectID(), primitive.NewObjectID()
+
+	secret, err := ring.seal("sk-live-123", id[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A secret copied onto another document must not open
+	if value, err := ring.open(secret, other[:]); err == nil {
+		t.Errorf("open with another document's ID = %q, want an error", value)
+	}
+}
+
+func TestKeyRingOpenTampered(t *testing.T) {
+	ring := testKeyRing(t, SecurityConfig{MasterKey: testMasterKey(t)})
+	id := primitive.NewObjectID()
+
+	secret, err := ring.seal("sk-live-123", id[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret.Ciphertext[len(secret.Ciphertext)-1] ^= 1
+	if _, err := ring.open(secret, id[:]); err == nil {
+		t.Error("open of a tampered ciphertext succeeded")
+	}
+}
+
+func TestKeyRingRotation(t *testing.T) {
+	oldKey, newKey := testMasterKey(t), testMasterKey(t)
+	oldRing := testKeyRing(t, SecurityConfig{MasterKey: oldKey})
+	id := primitive.NewObjectID()
+	secret, err := oldRing.seal("sk-live-123", id[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The new ring opens old secrets through the previous key and rewraps them
+	rotated := testKeyRing(t, SecurityConfig{MasterKey: newKey, PreviousMasterKeys: []string{oldKey}})
+	if value, err := rotated.open(secret, id[:]); err != nil || value != "sk-live-123" {
+		t.Fatalf("open with the previous key = %q, %v", value, err)
+	}
+	rewrapped, err := rotated.rewrap(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rewrapped.MasterKeyID != rotated.current {
+		t.Errorf("MasterKeyID = %s, want %s", rewrapped.MasterKeyID, rotated.current)
+	}
+	if string(rewrapped.Ciphertext) != string(secret.Ciphertext) {
+		t.Error("rewrap changed the ciphertext; it should only re-seal the data key")
+	}
+
+	// Once the previous key is dropped, only rewrapped secrets open
+	current := testKeyRing(t, SecurityConfig{MasterKey: newKey})
+	if value, err := current.open(rewrapped, id[:]); err != nil || value != "sk-live-123" {
+		t.Errorf("open after rewrap = %q, %v", value, err)
+	}
+	if _, err := current.open(secret, id[:]); err == nil {
+		t.Error("open of a secret sealed with a removed master key succeeded")
+	}
+}
+
+func TestNewKeyRingErrors(t *testing.T) {
+	for name, security := range map[string]SecurityConfig{
+		"missing":      {},
+		"not base64":   {MasterKey: "not base64!"},
+		"too short":    {MasterKey: "c2hvcnQ="},
+		"bad previous": {MasterKey: testMasterKey(t), PreviousMasterKeys: []string{"c2hvcnQ="}},
+	} {
+		if _, err := newKeyRing(security); err == nil {
+			t.Errorf("%s: newKeyRing succeeded, want an error", name)
+		}
+	}
+}
+
+func TestSecuredKeyUpdate(t *testing.T) {
+	oldKey, newKey := testMasterKey(t), testMasterKey(t)
+	oldRing := testKeyRing(t, SecurityConfig{MasterKey: oldKey})
+	ring := testKeyRing(t, SecurityConfig{MasterKey: newKey, PreviousMasterKeys: []string{oldKey}})
+
+	t.Run("legacy plaintext is sealed", func(t *testing.T) {
+		key := &APIKey{ID: primitive.NewObjectID(), LegacyKeyValue: "sk-legacy"}
+		update, sealed, err := ring.securedKeyUpdate(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !sealed {
+			t.Error("sealed = false, want true")
+		}
+		set := update["$set"].(bson.M)
+		if set["fingerprint"] != apiKeyFingerprint("sk-legacy") {
+			t.Errorf("fingerprint = %v, want %s", set["fingerprint"], apiKeyFingerprint("sk-legacy"))
+		}
+		if _, ok := update["$unset"].(bson.M)["key_value"]; !ok {
+			t.Error("update does not remove the plaintext key_value")
+		}
+		secret := set["secret"].(*EncryptedSecret)
+		if value, err := ring.open(secret, key.ID[:]); err != nil || value != "sk-legacy" {
+			t.Errorf("open = %q, %v; want the legacy value", value, err)
+		}
+	})
+
+	t.Run("old master key is rewrapped", func(t *testing.T) {
+		key := &APIKey{ID: primitive.NewObjectID()}
+		secret, err := oldRing.seal("sk-old", key.ID[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		key.Secret = secret
+		update, sealed, err := ring.securedKeyUpdate(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sealed || update == nil {
+			t.Fatalf("update = %v, sealed = %v; want a rewrap", update, sealed)
+		}
+		rewrapped := update["$set"].(bson.M)["secret"].(*EncryptedSecret)
+		if rewrapped.MasterKeyID != ring.current {
+			t.Errorf("MasterKeyID = %s, want %s", rewrapped.MasterKeyID, ring.current)
+		}
+	})
+
+	t.Run("current key is left alone", func(t *testing.T) {
+		key := &APIKey{ID: primitive.NewObjectID()}
+		secret, err := ring.seal("sk-current", key.ID[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		key.Secret = secret
+		if update, _, err := ring.securedKeyUpdate(key); err != nil || update != nil {
+			t.Errorf("update = %v, %v; want none", update, err)
+		}
+	})
+
+	t.Run("unknown master key fails", func(t *testing.T) {
+		key := &APIKey{ID: primitive.NewObjectID()}
+		secret, err := testKeyRing(t, SecurityConfig{MasterKey: testMasterKey(t)}).seal("sk-lost", key.ID[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		key.Secret = secret
+		if _, _, err := ring.securedKeyUpdate(key); err == nil {
+			t.Error("securedKeyUpdate succeeded for a secret no configured key opens")
+		}
+	})
+}
@@ -0,0 +1,269 @@
+// File: api_key_handlers.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// apiKeyProviderNames are the providers whose keys live in api_keys
+var apiKeyProviderNames = []string{"whisk", "imagefx", "elevenlabs"}
+
+var errDuplicateAPIKey = errors.New("API key is already in the pool")
+
+// APIKeyCreateRequest adds a key to a provider's pool
+type APIKeyCreateRequest struct {
+	Provider string `json:"provider"`
+	Key      string `json:"key"`
+}
+
+// APIKeyUpdateRequest replaces a key's value, e.g. after regenerating it at the provider.
+// The key keeps its ID and usage counts and goes back to healthy.
+type APIKeyUpdateRequest struct {
+	Key string `json:"key"`
+}
+
+// APIKeyResponse shows a key by its fingerprint; the value is never returned
+type APIKeyResponse struct {
+	Success bool    `json:"success"`
+	Message string  `json:"message,omitempty"`
+	Key     *APIKey `json:"key,omitempty"`
+}
+
+type APIKeyListResponse struct {
+	Success   bool                `json:"success"`
+	Count     int                 `json:"count"`
+	Keys      []APIKey            `json:"keys"`
+	Providers []APIKeyPoolSummary `json:"providers"`
+}
+
+// APIKeyPoolSummary counts a provider's keys; Available is what GetActiveKey can pick from
+type APIKeyPoolSummary struct {
+	Provider  string         `json:"provider"`
+	Total     int            `json:"total"`
+	Available int            `json:"available"`
+	Disabled  int            `json:"disabled"`
+	States    map[string]int `json:"states"`
+	Uses      int            `json:"uses"`
+	Errors    int            `json:"errors"`
+}
+
+// apiKeysHandler serves /api-keys (GET, POST)
+func (yt *YtAutomation) apiKeysHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case "OPTIONS":
+		w.WriteHeader(http.StatusOK)
+	case "POST":
+		createAPIKey(w, r)
+	case "GET":
+		listAPIKeysHandler(w, r)
+	default:
+		respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func createAPIKey(w http.ResponseWriter, r *http.Request) {
+	var req APIKeyCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid JSON request body")
+		return
+	}
+
+	req.Provider = strings.ToLower(strings.TrimSpace(req.Provider))
+	req.Key = strings.TrimSpace(req.Key)
+	if !slices.Contains(apiKeyProviderNames, req.Provider) {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("provider must be one of %s", strings.Join(apiKeyProviderNames, ", ")))
+		return
+	}
+	if req.Key == "" {
+		respondWithError(w, http.StatusBadRequest, "Key cannot be empty")
+		return
+	}
+
+	apiKey, err := addAPIKey(req.Provider, req.Key)
+	if err != nil {
+		if errors.Is(err, errDuplicateAPIKey) {
+			respondWithError(w, http.StatusConflict, err.Error())
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, APIKeyResponse{Success: true, Key: apiKey})
+}
+
+func listAPIKeysHandler(w http.ResponseWriter, r *http.Request) {
+	filter := bson.M{}
+	if provider := r.URL.Query().Get("provider"); provider != "" {
+		filter["provider"] = provider
+	}
+
+	cursor, err := apiKeysCollection.Find(context.Background(), filter,
+		options.Find().SetSort(bson.D{{"provider", 1}, {"created_at", 1}}))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Database error: %v", err))
+		return
+	}
+	keys := []APIKey{}
+	if err := cursor.All(context.Background(), &keys); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Database error: %v", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, APIKeyListResponse{
+		Success:   true,
+		Count:     len(keys),
+		Keys:      keys,
+		Providers: summarizeAPIKeyPools(keys),
+	})
+}
+
+// summarizeAPIKeyPools groups keys sorted by provider into one summary per provider
+func summarizeAPIKeyPools(keys []APIKey) []APIKeyPoolSummary {
+	summaries := []APIKeyPoolSummary{}
+	for _, key := range keys {
+		if len(summaries) == 0 || summaries[len(summaries)-1].Provider != key.Provider {
+			summaries = append(summaries, APIKeyPoolSummary{Provider: key.Provider, States: map[string]int{}})
+		}
+		summary := &summaries[len(summaries)-1]
+		summary.Total++
+		summary.Uses += key.UsageCount
+		summary.Errors += key.ErrorCount
+		summary.States[key.State]++
+		switch {
+		case !key.IsActive:
+			summary.Disabled++
+		case key.State == KeyStateHealthy:
+			summary.Available++
+		}
+	}
+	return summaries
+}
+
+// apiKeyHandler serves /api-keys/{id} (GET, PATCH, DELETE) and /api-keys/{id}/enable or
+// /disable (POST)
+func (yt *YtAutomation) apiKeyHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	id, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api-keys/"), "/")
+	keyID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid API key ID format")
+		return
+	}
+
+	var apiKey APIKey
+	if err := apiKeysCollection.FindOne(context.Background(), bson.M{"_id": keyID}).Decode(&apiKey); err != nil {
+		if err == mongo.ErrNoDocuments {
+			respondWithError(w, http.StatusNotFound, "API key not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Database error: %v", err))
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == "GET":
+		respondWithJSON(w, http.StatusOK, APIKeyResponse{Success: true, Key: &apiKey})
+	case action == "" && r.Method == "PATCH":
+		updateAPIKey(w, r, &apiKey)
+	case action == "" && r.Method == "DELETE":
+		if _, err := apiKeysCollection.DeleteOne(context.Background(), bson.M{"_id": apiKey.ID}); err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete API key: %v", err))
+			return
+		}
+		slog.Info("Deleted API key", logKeyProvider, apiKey.Provider, "key", apiKey.Fingerprint)
+		respondWithJSON(w, http.StatusOK, APIKeyResponse{Success: true, Message: "API key deleted"})
+	case action == "enable" && r.Method == "POST":
+		// Enabling vouches for the key, so it skips any cooldown or revocation
+		setAPIKeyFields(w, &apiKey, bson.M{
+			"$set":   bson.M{"is_active": true, "state": KeyStateHealthy, "consecutive_errors": 0},
+			"$unset": bson.M{"cooldown_until": ""},
+		}, "Enabled API key")
+	case action == "disable" && r.Method == "POST":
+		setAPIKeyFields(w, &apiKey, bson.M{"$set": bson.M{"is_active": false}}, "Disabled API key")
+	default:
+		respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func updateAPIKey(w http.ResponseWriter, r *http.Request, apiKey *APIKey) {
+	var req APIKeyUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid JSON request body")
+		return
+	}
+	req.Key = strings.TrimSpace(req.Key)
+	if req.Key == "" {
+		respondWithError(w, http.StatusBadRequest, "Key cannot be empty")
+		return
+	}
+
+	fingerprint := apiKeyFingerprint(req.Key)
+	count, err := apiKeysCollection.CountDocuments(context.Background(), bson.M{
+		"provider": apiKey.Provider, "fingerprint": fingerprint, "_id": bson.M{"$ne": apiKey.ID},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Database error: %v", err))
+		return
+	}
+	if count > 0 {
+		respondWithError(w, http.StatusConflict, fmt.Sprintf("%v: %s", errDuplicateAPIKey, fingerprint))
+		return
+	}
+
+	secret, err := apiKeyRing.seal(req.Key, apiKey.ID[:])
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to encrypt API key: %v", err))
+		return
+	}
+	setAPIKeyFields(w, apiKey, bson.M{
+		"$set": bson.M{
+			"secret": secret, "fingerprint": fingerprint,
+			"state": KeyStateHealthy, "consecutive_errors": 0, "last_used": time.Now(),
+		},
+		"$unset": bson.M{"cooldown_until": "", "key_value": ""},
+	}, "Replaced API key")
+}
+
+// setAPIKeyFields applies update to the key and responds with the updated key
+func setAPIKeyFields(w http.ResponseWriter, apiKey *APIKey, update bson.M, logMessage string) {
+	var updated APIKey
+	err := apiKeysCollection.FindOneAndUpdate(
+		context.Background(),
+		bson.M{"_id": apiKey.ID},
+		update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to update API key: %v", err))
+		return
+	}
+
+	attrs := []any{logKeyProvider, updated.Provider, "key", updated.Fingerprint}
+	if updated.Fingerprint != apiKey.Fingerprint {
+		attrs = append(attrs, "replaced", apiKey.Fingerprint)
+	}
+	slog.Info(logMessage, attrs...)
+	respondWithJSON(w, http.StatusOK, APIKeyResponse{Success: true, Key: &updated})
+}
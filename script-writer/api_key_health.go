@@ -0,0 +1,181 @@
+// File: api_key_health.go
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Health states of a provider key. is_active stays the operator's switch; the state is
+// what the provider last said about the key.
+const (
+	KeyStateHealthy     = "healthy"
+	KeyStateCoolingDown = "cooling_down" // Rate limited or provider errors, usable again at cooldown_until
+	KeyStateExhausted   = "exhausted"    // Quota used up until its reset at cooldown_until
+	KeyStateRevoked     = "revoked"      // Rejected as invalid; only a probe or an operator brings it back
+)
+
+// errNoHealthyAPIKey is returned when every key of a provider is disabled or recovering
+var errNoHealthyAPIKey = errors.New("no healthy API keys")
+
+// keyFailure is why a provider refused a request made with a key
+type keyFailure struct {
+	Status     int
+	RetryAfter time.Duration // From the Retry-After header, 0 when absent
+	Quota      bool          // The key's quota is used up, not just its request rate
+	Message    string
+}
+
+// keyFailureFromResponse classifies an error response of an API key provider
+func keyFailureFromResponse(resp *http.Response, body []byte) keyFailure {
+	return newKeyFailure(resp.StatusCode, resp.Header.Get("Retry-After"), body)
+}
+
+// newKeyFailure classifies an error answer by its status. A 429 is a rate limit the key
+// cools down from; only a provider's own quota signal marks it exhausted, so callers that
+// can recognize one set Quota themselves.
+func newKeyFailure(status int, retryAfter string, body []byte) keyFailure {
+	return keyFailure{
+		Status:     status,
+		RetryAfter: parseRetryAfter(retryAfter, time.Now()),
+		Message:    fmt.Sprintf("HTTP %d: %s", status, truncateString(string(body), maxKeyErrorLength)),
+	}
+}
+
+// parseRetryAfter reads Retry-After as seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// nextKeyState decides where a failure leaves a key. Cooldowns double with each failure
+// in a row, so a key that keeps failing is tried less and less often.
+func nextKeyState(key *APIKey, failure keyFailure, now time.Time) (string, *time.Time) {
+	backoff := func(base time.Duration) time.Duration {
+		d := base << min(key.ConsecutiveErrors, 10)
+		return min(max(d, failure.RetryAfter), maxKeyCooldown)
+	}
+	until := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+
+	switch {
+	case failure.Quota:
+		if failure.RetryAfter > 0 {
+			return KeyStateExhausted, until(failure.RetryAfter)
+		}
+		// Without a hint, assume the daily quota resets at midnight UTC
+		reset := now.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+		return KeyStateExhausted, &reset
+	case failure.Status == http.StatusUnauthorized || failure.Status == http.StatusForbidden:
+		return KeyStateRevoked, nil
+	case failure.Status == http.StatusTooManyRequests:
+		return KeyStateCoolingDown, until(backoff(keyRateLimitCooldown))
+	case failure.Status >= http.StatusInternalServerError:
+		return KeyStateCoolingDown, until(backoff(keyServerErrorCooldown))
+	}
+	// Other errors say nothing about the key
+	return key.State, key.CooldownUntil
+}
+
+// keyProbes check a recovering key against its provider. Providers without a probe get
+// their keys back once the cooldown ends, and a further failure cools them down longer.
+var keyProbes = map[string]func(ctx context.Context, value string) *keyFailure{}
+
+// StartProber returns recovering keys to the pool once their cooldown is over
+func (akm *APIKeyManager) StartProber() {
+	go func() {
+		ticker := time.NewTicker(keyProbeInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if shuttingDown() {
+				return
+			}
+			akm.probeKeys(context.Background())
+		}
+	}()
+}
+
+func (akm *APIKeyManager) probeKeys(ctx context.Context) {
+	now := time.Now()
+	cursor, err := apiKeysCollection.Find(ctx, bson.M{"is_active": true, "$or": []bson.M{
+		{"state": bson.M{"$in": []string{KeyStateCoolingDown, KeyStateExhausted}}, "cooldown_until": bson.M{"$lte": now}},
+		{"state": KeyStateRevoked, "last_probed_at": bson.M{"$not": bson.M{"$gt": now.Add(-revokedKeyProbeInterval)}}},
+	}})
+	if err != nil {
+		slog.Warn("Failed to load recovering API keys", "error", err)
+		return
+	}
+	var keys []APIKey
+	if err := cursor.All(ctx, &keys); err != nil {
+		slog.Warn("Failed to decode recovering API keys", "error", err)
+		return
+	}
+
+	for i := range keys {
+		key := &keys[i]
+		probe := keyProbes[key.Provider]
+		if probe == nil {
+			if key.State != KeyStateRevoked {
+				akm.setKeyHealthy(ctx, key)
+			}
+			continue
+		}
+
+		apiKeysCollection.UpdateByID(ctx, key.ID, bson.M{"$set": bson.M{"last_probed_at": now}})
+		if err := key.decrypt(); err != nil {
+			slog.Warn("Cannot probe API key", logKeyProvider, key.Provider, "key", key.Fingerprint, "error", err)
+			continue
+		}
+		probeCtx, cancel := context.WithTimeout(ctx, keyProbeTimeout)
+		failure := probe(probeCtx, key.KeyValue)
+		cancel()
+		if failure == nil {
+			akm.setKeyHealthy(ctx, key)
+		} else {
+			akm.ReportFailure(key, *failure)
+		}
+	}
+}
+
+func (akm *APIKeyManager) setKeyHealthy(ctx context.Context, key *APIKey) {
+	_, err := apiKeysCollection.UpdateOne(ctx, bson.M{"_id": key.ID, "state": key.State}, bson.M{
+		"$set":   bson.M{"state": KeyStateHealthy},
+		"$unset": bson.M{"cooldown_until": ""},
+	})
+	if err != nil {
+		slog.Warn("Failed to re-enable API key", logKeyProvider, key.Provider, "key", key.Fingerprint, "error", err)
+		return
+	}
+	slog.Info("API key back in the pool", logKeyProvider, key.Provider, "key", key.Fingerprint, "was", key.State)
+}
+
+// migrateAPIKeyStates gives keys from before health states one. Those deactivated by
+// the old error handling get another chance; the prober sorts out any still failing.
+func migrateAPIKeyStates(ctx context.Context) error {
+	result, err := apiKeysCollection.UpdateMany(ctx, bson.M{"state": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"state": KeyStateHealthy, "is_active": true}})
+	if err != nil {
+		return err
+	}
+	if result.ModifiedCount > 0 {
+		slog.Info("Gave API keys a health state", "count", result.ModifiedCount)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNewKeyFailure(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		retryAfter string
+		body       string
+		wantRetry  time.Duration
+	}{
+		{"google per-minute limit", http.StatusTooManyRequests, "",
+			`{"error":{"code":429,"message":"Quota exceeded for quota metric 'Requests' ... check quota","status":"RESOURCE_EXHAUSTED"}}`, 0},
+		{"rate limit with Retry-After", http.StatusTooManyRequests, "30", `{"error":"slow down"}`, 30 * time.Second},
+		{"quota mentioned on a bad request", http.StatusBadRequest, "", `{"error":"quota field is invalid"}`, 0},
+		{"unauthorized", http.StatusUnauthorized, "", `{"error":"invalid key"}`, 0},
+		{"server error", http.StatusBadGateway, "", "upstream quota service down", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failure := newKeyFailure(tt.status, tt.retryAfter, []byte(tt.body))
+			if failure.Quota {
+				t.Errorf("Quota = true, want false: only provider-specific signals mark a key exhausted")
+			}
+			if failure.Status != tt.status {
+				t.Errorf("Status = %d, want %d", failure.Status, tt.status)
+			}
+			if failure.RetryAfter != tt.wantRetry {
+				t.Errorf("RetryAfter = %v, want %v", failure.RetryAfter, tt.wantRetry)
+			}
+		})
+	}
+}
+
+func TestNewKeyFailureTruncatesMessage(t *testing.T) {
+	body := make([]byte, 4*maxKeyErrorLength)
+	for i := range body {
+		body[i] = 'x'
+	}
+	failure := newKeyFailure(http.StatusInternalServerError, "", body)
+	if len(failure.Message) > maxKeyErrorLength+len("HTTP 500: ")+len("...") {
+		t.Errorf("Message has %d bytes, want it truncated near %d", len(failure.Message), maxKeyErrorLength)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"120", 2 * time.Minute},
+		{"0", 0},
+		{"-5", 0},
+		{"soon", 0},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestNextKeyState(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	midnight := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	previous := now.Add(-time.Hour)
+
+	tests := []struct {
+		name       string
+		errors     int
+		failure    keyFailure
+		wantState  string
+		wantUntil  *time.Time
+		keepsState bool // The key keeps its state and cooldown
+	}{
+		{"429 cools down", 0, keyFailure{Status: http.StatusTooManyRequests},
+			KeyStateCoolingDown, ptr(now.Add(keyRateLimitCooldown)), false},
+		{"429 backs off with failures in a row", 3, keyFailure{Status: http.StatusTooManyRequests},
+			KeyStateCoolingDown, ptr(now.Add(8 * keyRateLimitCooldown)), false},
+		{"429 honors a longer Retry-After", 0, keyFailure{Status: http.StatusTooManyRequests, RetryAfter: 10 * time.Minute},
+			KeyStateCoolingDown, ptr(now.Add(10 * time.Minute)), false},
+		{"backoff is capped", 20, keyFailure{Status: http.StatusTooManyRequests},
+			KeyStateCoolingDown, ptr(now.Add(maxKeyCooldown)), false},
+		{"server error cools down briefly", 0, keyFailure{Status: http.StatusServiceUnavailable},
+			KeyStateCoolingDown, ptr(now.Add(keyServerErrorCooldown)), false},
+		{"quota with a reset time", 0, keyFailure{Status: http.StatusUnauthorized, Quota: true, RetryAfter: 5 * time.Hour},
+			KeyStateExhausted, ptr(now.Add(5 * time.Hour)), false},
+		{"quota without a reset time waits for midnight UTC", 0, keyFailure{Status: http.StatusTooManyRequests, Quota: true},
+			KeyStateExhausted, &midnight, false},
+		{"unauthorized revokes", 0, keyFailure{Status: http.StatusUnauthorized}, KeyStateRevoked, nil, false},
+		{"forbidden revokes", 0, keyFailure{Status: http.StatusForbidden}, KeyStateRevoked, nil, false},
+		{"bad request leaves the key alone", 2, keyFailure{Status: http.StatusBadRequest}, "", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := &APIKey{State: KeyStateCoolingDown, CooldownUntil: &previous, ConsecutiveErrors: tt.errors}
+			state, until := nextKeyState(key, tt.failure, now)
+			if tt.keepsState {
+				tt.wantState, tt.wantUntil = key.State, key.CooldownUntil
+			}
+			if state != tt.wantState {
+				t.Errorf("state = %q, want %q", state, tt.wantState)
+			}
+			switch {
+			case (until == nil) != (tt.wantUntil == nil):
+				t.Errorf("cooldown_until = %v, want %v", until, tt.wantUntil)
+			case until != nil && !until.Equal(*tt.wantUntil):
+				t.Errorf("cooldown_until = %v, want %v", *until, *tt.wantUntil)
+			}
+		})
+	}
+}
@@ -0,0 +1,410 @@
+// File: auth.go
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Scopes are ordered: generate includes read, admin includes both
+const (
+	ScopeRead     = "read"
+	ScopeGenerate = "generate"
+	ScopeAdmin    = "admin"
+)
+
+var scopeRank = map[string]int{
+	ScopeRead:     1,
+	ScopeGenerate: 2,
+	ScopeAdmin:    3,
+}
+
+// publicPaths skip authentication: health and readiness checks, metrics, the API description
+// and the OAuth redirect Google sends the browser to
+var publicPaths = map[string]bool{
+	"/health":                 true,
+	"/ready":                  true,
+	"/metrics":                true,
+	"/openapi.json":           true,
+	"/oauth/youtube/callback": true,
+}
+
+// sharedPaths hold data that is not tied to a channel, so channel-restricted tokens may read them
+var sharedPaths = map[string]bool{
+	"/prompt-templates/list": true,
+	"/visual-styles/list":    true,
+	"/tts/providers":         true,
+	"/tts/voices":            true,
+}
+
+type authContextKey struct{}
+
+// authTokenFromContext returns the token that authenticated the request, or nil when auth is disabled
+func authTokenFromContext(ctx context.Context) *AuthToken {
+	token, _ := ctx.Value(authContextKey{}).(*AuthToken)
+	return token
+}
+
+func hashAuthToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (t *AuthToken) hasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if scopeRank[s] >= scopeRank[scope] {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *AuthToken) allowsChannel(channelName string) bool {
+	return len(t.Channels) == 0 || containsString(t.Channels, channelName)
+}
+
+func validateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("at least one scope is required")
+	}
+	for _, scope := range scopes {
+		if _, ok := scopeRank[scope]; !ok {
+			return fmt.Errorf("unknown scope %q, use read, generate or admin", scope)
+		}
+	}
+	return nil
+}
+
+// CreateAuthToken stores a new token and returns it with the plaintext value, which is
+// shown once and never stored
+func CreateAuthToken(name string, scopes, channels []string, ttl time.Duration) (*AuthToken, string, error) {
+	if err := validateScopes(scopes); err != nil {
+		return nil, "", err
+	}
+
+	secret := make([]byte, authTokenBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", fmt.Errorf("generating token: %w", err)
+	}
+	plaintext := authTokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	token := &AuthToken{
+		ID:        primitive.NewObjectID(),
+		Name:      name,
+		TokenHash: hashAuthToken(plaintext),
+		Prefix:    plaintext[:len(authTokenPrefix)+6],
+		Scopes:    scopes,
+		Channels:  channels,
+		CreatedAt: time.Now(),
+	}
+	if ttl > 0 {
+		expiresAt := token.CreatedAt.Add(ttl)
+		token.ExpiresAt = &expiresAt
+	}
+
+	if _, err := authTokensCollection.InsertOne(context.Background(), token); err != nil {
+		return nil, "", fmt.Errorf("saving token: %w", err)
+	}
+	return token, plaintext, nil
+}
+
+// lookupAuthToken returns the active token matching plaintext
+func lookupAuthToken(ctx context.Context, plaintext string) (*AuthToken, error) {
+	var token AuthToken
+	err := authTokensCollection.FindOne(ctx, bson.M{"token_hash": hashAuthToken(plaintext)}).Decode(&token)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("invalid token")
+		}
+		return nil, err
+	}
+	if token.Revoked {
+		return nil, fmt.Errorf("token revoked")
+	}
+	if token.ExpiresAt != nil && time.Now().After(*token.ExpiresAt) {
+		return nil, fmt.Errorf("token expired")
+	}
+	return &token, nil
+}
+
+func touchAuthToken(token *AuthToken) {
+	if token.LastUsedAt != nil && time.Since(*token.LastUsedAt) < authLastUsedInterval {
+		return
+	}
+	_, err := authTokensCollection.UpdateOne(context.Background(),
+		bson.M{"_id": token.ID},
+		bson.M{"$set": bson.M{"last_used_at": time.Now()}},
+	)
+	if err != nil {
+		slog.Warn("Failed to update token last_used_at", "token", token.Name, "error", err)
+	}
+}
+
+// requiredScope maps a request to the scope it needs: reads need read, anything that
+// spends credits or changes data needs generate, and configuration and provider keys need admin
+func requiredScope(r *http.Request) string {
+	path := r.URL.Path
+	switch {
+	case path == "/prompt-templates", path == "/visual-styles", path == "/config":
+		return ScopeAdmin
+	case path == "/api-keys", strings.HasPrefix(path, "/api-keys/"):
+		return ScopeAdmin
+	case strings.HasSuffix(path, "/youtube/auth"):
+		return ScopeAdmin
+	case strings.HasSuffix(path, "/thumbnail-layout") && r.Method != "GET":
+		return ScopeAdmin
+	case strings.HasSuffix(path, "/voice") && r.Method != "GET":
+		return ScopeAdmin
+	case r.Method == "GET" || r.Method == "HEAD":
+		return ScopeRead
+	}
+	return ScopeGenerate
+}
+
+// channelForObjectID finds the channel owning a script, topic, batch or video status ID
+func channelForObjectID(ctx context.Context, id primitive.ObjectID) (string, error) {
+	var doc struct {
+		ChannelName string             `bson:"channel_name"`
+		ScriptID    primitive.ObjectID `bson:"script_id"`
+	}
+	for _, collection := range []*mongo.Collection{scriptsCollection, topicBacklogCollection, batchesCollection} {
+		err := collection.FindOne(ctx, bson.M{"_id": id},
+			options.FindOne().SetProjection(bson.M{"channel_name": 1})).Decode(&doc)
+		if err == nil {
+			return doc.ChannelName, nil
+		}
+		if err != mongo.ErrNoDocuments {
+			return "", err
+		}
+	}
+
+	err := videoStatusCollection.FindOne(ctx, bson.M{"_id": id},
+		options.FindOne().SetProjection(bson.M{"script_id": 1})).Decode(&doc)
+	if err == nil {
+		return channelForObjectID(ctx, doc.ScriptID)
+	}
+	if err != mongo.ErrNoDocuments {
+		return "", err
+	}
+	return "", nil
+}
+
+// resolveRequestChannels lists the channels a request targets, from the path, query
+// parameters, IDs in the path and the channel_name/script_id of a JSON body. IDs that are
+// malformed or belong to no channel are returned as unresolved.
+func resolveRequestChannels(r *http.Request) (channels []string, unresolved []string, err error) {
+	var ids []primitive.ObjectID
+	addID := func(value string) {
+		if value == "" {
+			return
+		}
+		if id, err := primitive.ObjectIDFromHex(value); err == nil {
+			ids = append(ids, id)
+		} else {
+			unresolved = append(unresolved, value)
+		}
+	}
+
+	path := r.URL.Path
+	if strings.HasPrefix(path, "/channels/") {
+		channels = append(channels, strings.SplitN(strings.TrimPrefix(path, "/channels/"), "/", 2)[0])
+	} else {
+		for _, segment := range strings.Split(path, "/") {
+			if id, err := primitive.ObjectIDFromHex(segment); err == nil {
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	query := r.URL.Query()
+	channels = append(channels, splitList(query.Get("channel"))...)
+	channels = append(channels, splitList(query.Get("channel_name"))...)
+	addID(query.Get("script_id"))
+
+	if r.Body != nil && r.Method != "GET" && r.Method != "HEAD" {
+		data, err := io.ReadAll(io.LimitReader(r.Body, authBodyPeekLimit))
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading body: %w", err)
+		}
+		// Hand the handler the full body again
+		r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(data), r.Body))
+
+		var body struct {
+			ChannelName string `json:"channel_name"`
+			ScriptID    string `json:"script_id"`
+		}
+		if json.Unmarshal(data, &body) == nil {
+			if body.ChannelName != "" {
+				channels = append(channels, body.ChannelName)
+			}
+			addID(body.ScriptID)
+		}
+	}
+
+	for _, id := range ids {
+		channel, err := channelForObjectID(r.Context(), id)
+		if err != nil {
+			return nil, nil, err
+		}
+		if channel == "" {
+			unresolved = append(unresolved, id.Hex())
+			continue
+		}
+		channels = append(channels, channel)
+	}
+	return channels, unresolved, nil
+}
+
+// restrictListing narrows a cross-channel listing to the token's channels. It returns false
+// when the request is not a listing it knows how to narrow.
+func restrictListing(r *http.Request, token *AuthToken) bool {
+	query := r.URL.Query()
+	switch r.URL.Path {
+	case "/scripts", "/search":
+		query.Set("channel", strings.Join(token.Channels, ","))
+	case "/batches":
+		if len(token.Channels) != 1 {
+			return false
+		}
+		query.Set("channel_name", token.Channels[0])
+	default:
+		return false
+	}
+	r.URL.RawQuery = query.Encode()
+	return true
+}
+
+// Authenticator checks bearer tokens and applies the CORS policy for every request
+type Authenticator struct {
+	enabled bool
+}
+
+func NewAuthenticator() *Authenticator {
+	return &Authenticator{enabled: appConfig().Server.AuthEnabled}
+}
+
+// applyCORS sets the CORS headers when the request's origin is allowed and reports
+// whether it is. Requests without an Origin are not cross-origin and always pass.
+func (a *Authenticator) applyCORS(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	allowedOrigins := appConfig().Server.CORSAllowedOrigins
+	header := w.Header()
+	switch {
+	case containsString(allowedOrigins, "*"):
+		header.Set("Access-Control-Allow-Origin", "*")
+	case containsString(allowedOrigins, origin):
+		header.Set("Access-Control-Allow-Origin", origin)
+		header.Add("Vary", "Origin")
+	default:
+		return false
+	}
+	header.Set("Access-Control-Allow-Methods", corsAllowedMethods)
+	header.Set("Access-Control-Allow-Headers", corsAllowedHeaders)
+	header.Set("Access-Control-Expose-Headers", corsExposedHeaders)
+	header.Set("Access-Control-Max-Age", "600")
+	return true
+}
+
+// Middleware wraps the API mux with CORS handling, authentication, scope checks and
+// channel restrictions
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowed := a.applyCORS(w, r)
+		if r.Method == "OPTIONS" {
+			// Preflights from other origins get no allow headers, so the browser refuses
+			if !allowed {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if !a.enabled || publicPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		plaintext, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || strings.TrimSpace(plaintext) == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="script-writer"`)
+			respondWithError(w, http.StatusUnauthorized, "Missing bearer token")
+			return
+		}
+		token, err := lookupAuthToken(r.Context(), strings.TrimSpace(plaintext))
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="script-writer", error="invalid_token"`)
+			respondWithError(w, http.StatusUnauthorized, fmt.Sprintf("Unauthorized: %v", err))
+			return
+		}
+
+		if scope := requiredScope(r); !token.hasScope(scope) {
+			respondWithError(w, http.StatusForbidden, fmt.Sprintf("Token %s lacks the %s scope", token.Prefix, scope))
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), authContextKey{}, token)
+		if len(token.Channels) > 0 && !sharedPaths[r.URL.Path] {
+			channels, unresolved, err := resolveRequestChannels(r)
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to resolve channel: %v", err))
+				return
+			}
+			// An ID outside the token's channels must not slip through next to an allowed one
+			if len(unresolved) > 0 {
+				respondWithError(w, http.StatusForbidden, fmt.Sprintf("Token %s cannot access %s", token.Prefix, strings.Join(unresolved, ", ")))
+				return
+			}
+			for _, channel := range channels {
+				if !token.allowsChannel(channel) {
+					respondWithError(w, http.StatusForbidden, fmt.Sprintf("Token %s is not allowed to access channel %s", token.Prefix, channel))
+					return
+				}
+			}
+			if len(channels) == 0 && !restrictListing(r, token) {
+				respondWithError(w, http.StatusForbidden, fmt.Sprintf("Token %s is restricted to channels %s; the request must name one", token.Prefix, strings.Join(token.Channels, ", ")))
+				return
+			}
+			// Saves the rate limiter resolving them again
+			ctx = context.WithValue(ctx, channelsContextKey{}, channels)
+		}
+
+		go touchAuthToken(token)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// warnIfNoAuthTokens points at the CLI when auth is on but nobody could call the API yet
+func (a *Authenticator) warnIfNoAuthTokens() {
+	if !a.enabled {
+		slog.Warn("API authentication is disabled (server.auth_enabled: false)")
+		return
+	}
+	count, err := authTokensCollection.CountDocuments(context.Background(), bson.M{"revoked": false})
+	if err != nil {
+		slog.Warn("Failed to count API tokens", "error", err)
+		return
+	}
+	if count == 0 {
+		slog.Warn("No API tokens exist; create one with: script-writer tokens create -name admin -scopes admin")
+	}
+}
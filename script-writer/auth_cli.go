@@ -0,0 +1,153 @@
+// File: auth_cli.go
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const tokensUsage = `Usage:
+  script-writer tokens create -name NAME -scopes read,generate,admin [-channels a,b] [-expires 720h] [-rate N] [-jobs N]
+  script-writer tokens list
+  script-writer tokens revoke ID|PREFIX`
+
+// runTokensCommand manages API tokens from the command line and returns the exit code
+func runTokensCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, tokensUsage)
+		return 2
+	}
+
+	var err error
+	switch args[0] {
+	case "create":
+		err = createTokenCommand(args[1:])
+	case "list":
+		err = listTokensCommand()
+	case "revoke":
+		err = revokeTokenCommand(args[1:])
+	default:
+		fmt.Fprintln(os.Stderr, tokensUsage)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func createTokenCommand(args []string) error {
+	fs := flag.NewFlagSet("tokens create", flag.ContinueOnError)
+	name := fs.String("name", "", "token name, e.g. the client using it")
+	scopes := fs.String("scopes", ScopeRead, "comma-separated scopes: read, generate, admin")
+	channels := fs.String("channels", "", "comma-separated channels the token may access (default all)")
+	expires := fs.Duration("expires", 0, "lifetime such as 720h (default never)")
+	rate := fs.Int("rate", 0, "requests per minute (default RATE_LIMIT_TOKEN_PER_MINUTE)")
+	jobs := fs.Int("jobs", 0, "concurrent generation jobs (default JOB_LIMIT_PER_TOKEN)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *name == "" {
+		return fmt.Errorf("-name is required")
+	}
+	if *rate < 0 || *jobs < 0 {
+		return fmt.Errorf("-rate and -jobs cannot be negative")
+	}
+
+	token, plaintext, err := CreateAuthToken(*name, splitList(*scopes), splitList(*channels), *expires)
+	if err != nil {
+		return err
+	}
+	if *rate > 0 || *jobs > 0 {
+		token.RateLimit, token.MaxJobs = *rate, *jobs
+		_, err = authTokensCollection.UpdateOne(context.Background(), bson.M{"_id": token.ID},
+			bson.M{"$set": bson.M{"rate_limit": token.RateLimit, "max_jobs": token.MaxJobs}})
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Created token %s (%s)\n", token.ID.Hex(), token.Name)
+	fmt.Printf("Scopes:   %s\n", strings.Join(token.Scopes, ", "))
+	if len(token.Channels) > 0 {
+		fmt.Printf("Channels: %s\n", strings.Join(token.Channels, ", "))
+	}
+	if token.RateLimit > 0 {
+		fmt.Printf("Rate:     %d requests/minute\n", token.RateLimit)
+	}
+	if token.MaxJobs > 0 {
+		fmt.Printf("Jobs:     %d concurrent\n", token.MaxJobs)
+	}
+	if token.ExpiresAt != nil {
+		fmt.Printf("Expires:  %s\n", token.ExpiresAt.Format(time.RFC3339))
+	}
+	fmt.Printf("\n%s\n\nStore it now; it cannot be shown again.\n", plaintext)
+	return nil
+}
+
+func listTokensCommand() error {
+	cursor, err := authTokensCollection.Find(context.Background(), bson.M{},
+		options.Find().SetSort(bson.D{{"created_at", -1}}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(context.Background())
+
+	var tokens []AuthToken
+	if err := cursor.All(context.Background(), &tokens); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tPREFIX\tNAME\tSCOPES\tCHANNELS\tSTATUS\tLAST USED")
+	for _, token := range tokens {
+		status := "active"
+		if token.Revoked {
+			status = "revoked"
+		} else if token.ExpiresAt != nil && time.Now().After(*token.ExpiresAt) {
+			status = "expired"
+		}
+		channels := strings.Join(token.Channels, ",")
+		if channels == "" {
+			channels = "*"
+		}
+		lastUsed := "never"
+		if token.LastUsedAt != nil {
+			lastUsed = token.LastUsedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			token.ID.Hex(), token.Prefix, token.Name, strings.Join(token.Scopes, ","), channels, status, lastUsed)
+	}
+	return tw.Flush()
+}
+
+func revokeTokenCommand(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("revoke takes one token ID or prefix")
+	}
+
+	filter := bson.M{"prefix": args[0]}
+	if id, err := primitive.ObjectIDFromHex(args[0]); err == nil {
+		filter = bson.M{"_id": id}
+	}
+	result, err := authTokensCollection.UpdateMany(context.Background(), filter,
+		bson.M{"$set": bson.M{"revoked": true}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("no token matches %s", args[0])
+	}
+	fmt.Printf("Revoked %d token(s)\n", result.MatchedCount)
+	return nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// These requests name no ObjectIDs, so resolving them needs no database
+func TestResolveRequestChannels(t *testing.T) {
+	tests := []struct {
+		name           string
+		method, target string
+		body           string
+		wantChannels   []string
+		wantUnresolved []string
+	}{
+		{"channel path", "GET", "/channels/history/scripts", "", []string{"history"}, nil},
+		{"channel query list", "GET", "/scripts?channel=history,science", "", []string{"history", "science"}, nil},
+		{"malformed script_id next to a channel", "GET", "/video-status?channel=history&script_id=nope", "",
+			[]string{"history"}, []string{"nope"}},
+		{"body channel and malformed script_id", "POST", "/generate-voiceover",
+			`{"channel_name": "history", "script_id": "123"}`, []string{"history"}, []string{"123"}},
+		{"nothing named", "GET", "/batches", "", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			channels, unresolved, err := resolveRequestChannels(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(channels, ",") != strings.Join(tt.wantChannels, ",") {
+				t.Errorf("channels = %q, want %q", channels, tt.wantChannels)
+			}
+			if strings.Join(unresolved, ",") != strings.Join(tt.wantUnresolved, ",") {
+				t.Errorf("unresolved = %q, want %q", unresolved, tt.wantUnresolved)
+			}
+		})
+	}
+}
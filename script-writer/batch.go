@@ -0,0 +1,641 @@
+// File: batch.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	BatchStatusScheduled           = "scheduled"
+	BatchStatusRunning             = "running"
+	BatchStatusCompleted           = "completed"
+	BatchStatusCompletedWithErrors = "completed_with_errors"
+	BatchStatusCancelled           = "cancelled"
+
+	BatchItemScheduled = "scheduled"
+	BatchItemRunning   = "running"
+	BatchItemCompleted = "completed"
+	BatchItemFailed    = "failed"
+	BatchItemCancelled = "cancelled"
+)
+
+type BatchRequest struct {
+	Name            string        `json:"name,omitempty"`
+	ChannelName     string        `json:"channel_name"`
+	Topics          []string      `json:"topics,omitempty"`
+	TopicIDs        []string      `json:"topic_ids,omitempty"` // Backlog entries, queued after Topics
+	Schedule        BatchSchedule `json:"schedule"`
+	GenerateVisuals bool          `json:"generate_visuals"`
+	TargetMinutes   int           `json:"target_minutes,omitempty"`
+}
+
+type BatchResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message,omitempty"`
+	Batch   Batch  `json:"batch"`
+}
+
+// BatchListResponse leaves out each batch's items; fetch a batch for those
+type BatchListResponse struct {
+	Success bool    `json:"success"`
+	Count   int     `json:"count"`
+	Batches []Batch `json:"batches"`
+}
+
+// BatchScheduler starts batch items when their slot comes up, keeping each channel
+// under its concurrency limit, and tracks running items until their script finishes.
+// Items change status with conditional updates, so several instances can run it.
+type BatchScheduler struct {
+	yt       *YtAutomation
+	interval time.Duration
+	mu       sync.Mutex // Serializes this instance's scheduler passes
+}
+
+func NewBatchScheduler(yt *YtAutomation) *BatchScheduler {
+	return &BatchScheduler{
+		yt:       yt,
+		interval: appConfig().Batches.SchedulerInterval,
+	}
+}
+
+func (s *BatchScheduler) Start() {
+	slog.Info("Batch scheduler running", "interval", s.interval)
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			s.runOnce()
+			<-ticker.C
+		}
+	}()
+}
+
+func (s *BatchScheduler) runOnce() {
+	// Items started now would only stop at their first checkpoint
+	if shuttingDown() {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cursor, err := batchesCollection.Find(
+		context.Background(),
+		bson.M{"$or": []bson.M{
+			{"status": bson.M{"$in": []string{BatchStatusScheduled, BatchStatusRunning}}},
+			{"status": BatchStatusCancelled, "progress.running": bson.M{"$gt": 0}}, // Still tracking started items
+		}},
+		options.Find().SetSort(bson.D{{"created_at", 1}}),
+	)
+	if err != nil {
+		slog.Error("Batch scheduler failed to load batches", "error", err)
+		return
+	}
+	var batches []Batch
+	if err := cursor.All(context.Background(), &batches); err != nil {
+		slog.Error("Batch scheduler failed to decode batches", "error", err)
+		return
+	}
+
+	for i := range batches {
+		if err := s.processBatch(&batches[i]); err != nil {
+			slog.Error("Batch scheduler failed to process batch", "batch_id", batches[i].ID.Hex(), "error", err)
+		}
+	}
+}
+
+func (s *BatchScheduler) processBatch(batch *Batch) error {
+	now := time.Now()
+	changed := false
+	itemTimeout := appConfig().Batches.ItemTimeout
+
+	// Follow up on running items
+	for i := range batch.Items {
+		item := &batch.Items[i]
+		if item.Status != BatchItemRunning || item.ScriptID == nil {
+			continue
+		}
+
+		script, err := s.yt.getScriptByID(*item.ScriptID)
+		switch {
+		case err != nil:
+			item.Status = BatchItemFailed
+			item.Error = fmt.Sprintf("loading script: %v", err)
+		case script.Status == StatusCompleted:
+			item.Status = BatchItemCompleted
+		case script.Status == StatusFailed || script.Status == "error":
+			item.Status = BatchItemFailed
+			item.Error = script.ErrorMessage
+		case item.StartedAt != nil && now.Sub(*item.StartedAt) > itemTimeout:
+			item.Status = BatchItemFailed
+			item.Error = fmt.Sprintf("script still %q after %s", script.Status, itemTimeout)
+		default:
+			continue
+		}
+		item.CompletedAt = &now
+		// Another instance may have finished the item already
+		saved, err := saveBatchItem(batch.ID, *item, BatchItemRunning)
+		if err != nil {
+			return fmt.Errorf("saving item %d: %w", item.Index, err)
+		}
+		changed = changed || saved
+	}
+
+	// Start items whose slot has come up, within the channel's concurrency limit
+	var channel *Channel
+	for i := range batch.Items {
+		item := &batch.Items[i]
+		if item.Status != BatchItemScheduled || item.ScheduledAt.After(now) {
+			continue
+		}
+
+		if channel == nil {
+			var err error
+			if channel, err = s.yt.getChannelByID(batch.ChannelID); err != nil {
+				return fmt.Errorf("loading channel: %w", err)
+			}
+		}
+		active, err := countActiveScripts(channel.ID)
+		if err != nil {
+			return fmt.Errorf("counting active scripts: %w", err)
+		}
+		limit := channel.Settings.MaxConcurrentScripts
+		if limit <= 0 {
+			limit = defaultChannelConcurrency
+		}
+		if active >= limit {
+			break
+		}
+
+		claimed, err := claimBatchItem(batch.ID, item.Index, now)
+		if err != nil {
+			return fmt.Errorf("claiming item %d: %w", item.Index, err)
+		}
+		if !claimed {
+			// Started by another instance or cancelled since the batch was loaded
+			continue
+		}
+		item.Status = BatchItemRunning
+		item.StartedAt = &now
+
+		s.startItem(batch, item, *channel)
+		if _, err := saveBatchItem(batch.ID, *item, BatchItemRunning); err != nil {
+			return fmt.Errorf("saving item %d: %w", item.Index, err)
+		}
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+	return refreshBatchProgress(batch.ID)
+}
+
+// claimBatchItem moves a scheduled item to running. Only one instance gets true for an item.
+func claimBatchItem(batchID primitive.ObjectID, index int, now time.Time) (bool, error) {
+	field := fmt.Sprintf("items.%d.", index)
+	err := batchesCollection.FindOneAndUpdate(context.Background(),
+		bson.M{"_id": batchID, field + "status": BatchItemScheduled},
+		bson.M{"$set": bson.M{field + "status": BatchItemRunning, field + "started_at": now}},
+		options.FindOneAndUpdate().SetProjection(bson.M{"_id": 1}),
+	).Err()
+	if err == mongo.ErrNoDocuments {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// saveBatchItem writes an item back if it is still in the status it was read in
+func saveBatchItem(batchID primitive.ObjectID, item BatchItem, from string) (bool, error) {
+	field := fmt.Sprintf("items.%d", item.Index)
+	result, err := batchesCollection.UpdateOne(context.Background(),
+		bson.M{"_id": batchID, field + ".status": from},
+		bson.M{"$set": bson.M{field: item, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// startItem starts the script of a claimed item, leaving the item running or failed
+func (s *BatchScheduler) startItem(batch *Batch, item *BatchItem, channel Channel) {
+	now := time.Now()
+
+	req := ScriptRequest{
+		Topic:           item.Topic,
+		ChannelName:     channel.ChannelName,
+		GenerateVisuals: batch.GenerateVisuals,
+		TargetMinutes:   batch.TargetMinutes,
+	}
+	// The topic may have been produced or rejected since the batch was scheduled
+	var previousStatus string
+	if item.TopicID != nil {
+		var err error
+		if previousStatus, err = claimTopic(*item.TopicID); err != nil {
+			item.Status = BatchItemFailed
+			item.Error = err.Error()
+			item.CompletedAt = &now
+			slog.Warn("Batch item topic is no longer available", "batch_id", batch.ID.Hex(), "item", item.Index, "error", err)
+			return
+		}
+	}
+	script, _, err := s.yt.startScriptGeneration(context.Background(), channel, req)
+	if err != nil {
+		if item.TopicID != nil {
+			releaseTopic(*item.TopicID, previousStatus)
+		}
+		item.Status = BatchItemFailed
+		item.Error = err.Error()
+		item.CompletedAt = &now
+		slog.Error("Batch item failed to start", "batch_id", batch.ID.Hex(), "item", item.Index, "error", err)
+		return
+	}
+
+	item.Status = BatchItemRunning
+	item.ScriptID = &script.ID
+	if item.TopicID != nil {
+		if err := markTopicUsed(*item.TopicID, script.ID); err != nil {
+			slog.Warn("Failed to mark topic as used", "topic_id", item.TopicID.Hex(), logKeyScriptID, script.ID.Hex(), "error", err)
+		}
+	}
+	slog.Info("Batch item started", "batch_id", batch.ID.Hex(), "item", item.Index,
+		"topic", item.Topic, logKeyScriptID, script.ID.Hex())
+}
+
+// countActiveScripts counts the channel's scripts that are still generating. Scripts whose
+// batch item timed out keep counting, since they are still using the channel's slot.
+func countActiveScripts(channelID primitive.ObjectID) (int, error) {
+	count, err := scriptsCollection.CountDocuments(context.Background(), bson.M{
+		"channel_id": channelID,
+		"status":     bson.M{"$nin": []string{StatusCompleted, StatusFailed, "error"}},
+	})
+	return int(count), err
+}
+
+// updateBatchProgress recomputes the progress counters and the overall batch status
+func updateBatchProgress(batch *Batch) {
+	progress := BatchProgress{Total: len(batch.Items)}
+	for _, item := range batch.Items {
+		switch item.Status {
+		case BatchItemScheduled:
+			progress.Scheduled++
+		case BatchItemRunning:
+			progress.Running++
+		case BatchItemCompleted:
+			progress.Completed++
+		case BatchItemFailed:
+			progress.Failed++
+		case BatchItemCancelled:
+			progress.Cancelled++
+		}
+	}
+	batch.Progress = progress
+
+	if batch.Status == BatchStatusCancelled {
+		return
+	}
+	switch {
+	case progress.Scheduled+progress.Running > 0:
+		if progress.Running+progress.Completed+progress.Failed > 0 {
+			batch.Status = BatchStatusRunning
+		}
+	case progress.Failed > 0:
+		batch.Status = BatchStatusCompletedWithErrors
+	default:
+		batch.Status = BatchStatusCompleted
+	}
+	if batch.CompletedAt == nil && (batch.Status == BatchStatusCompleted || batch.Status == BatchStatusCompletedWithErrors) {
+		now := time.Now()
+		batch.CompletedAt = &now
+		slog.Info("Batch finished", "batch_id", batch.ID.Hex(), "completed", progress.Completed, "failed", progress.Failed)
+	}
+}
+
+// refreshBatchProgress recomputes the stored progress and status from the stored items,
+// which other instances may have changed since this one read the batch
+func refreshBatchProgress(batchID primitive.ObjectID) error {
+	var batch Batch
+	if err := batchesCollection.FindOne(context.Background(), bson.M{"_id": batchID}).Decode(&batch); err != nil {
+		return err
+	}
+	return saveBatchProgress(&batch)
+}
+
+// saveBatchProgress stores the batch's progress unless its status changed since it was read,
+// so a cancellation is not overwritten
+func saveBatchProgress(batch *Batch) error {
+	readStatus := batch.Status
+	updateBatchProgress(batch)
+	batch.UpdatedAt = time.Now()
+
+	update := bson.M{
+		"progress":   batch.Progress,
+		"status":     batch.Status,
+		"updated_at": batch.UpdatedAt,
+	}
+	if batch.CompletedAt != nil {
+		update["completed_at"] = batch.CompletedAt
+	}
+	_, err := batchesCollection.UpdateOne(context.Background(),
+		bson.M{"_id": batch.ID, "status": readStatus}, bson.M{"$set": update})
+	return err
+}
+
+// scheduleBatchSlots returns count start times for the schedule
+func scheduleBatchSlots(schedule BatchSchedule, count int) ([]time.Time, error) {
+	now := time.Now()
+	slots := make([]time.Time, 0, count)
+
+	switch {
+	case schedule.Cron != "" && len(schedule.Dates) > 0:
+		return nil, fmt.Errorf("schedule takes either cron or dates, not both")
+
+	case len(schedule.Dates) > 0:
+		if len(schedule.Dates) != count {
+			return nil, fmt.Errorf("schedule has %d dates for %d topics", len(schedule.Dates), count)
+		}
+		// Dates pair with topics by position; the scheduler starts whichever is due
+		slots = append(slots, schedule.Dates...)
+
+	case schedule.Cron != "":
+		cron, err := ParseCron(schedule.Cron)
+		if err != nil {
+			return nil, err
+		}
+		location := time.Local
+		if schedule.Timezone != "" {
+			if location, err = time.LoadLocation(schedule.Timezone); err != nil {
+				return nil, fmt.Errorf("invalid timezone %q", schedule.Timezone)
+			}
+		}
+		next := now
+		if schedule.StartAt != nil && schedule.StartAt.After(now) {
+			next = *schedule.StartAt
+		}
+		next = next.In(location)
+		for i := 0; i < count; i++ {
+			if next, err = cron.Next(next); err != nil {
+				return nil, err
+			}
+			slots = append(slots, next)
+		}
+
+	default:
+		start := now
+		if schedule.StartAt != nil {
+			start = *schedule.StartAt
+		}
+		for i := 0; i < count; i++ {
+			slots = append(slots, start)
+		}
+	}
+
+	return slots, nil
+}
+
+func (yt *YtAutomation) batchesHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	switch r.Method {
+	case "OPTIONS":
+		w.WriteHeader(http.StatusOK)
+	case "POST":
+		yt.createBatch(w, r)
+	case "GET":
+		listBatches(w, r)
+	default:
+		respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (yt *YtAutomation) createBatch(w http.ResponseWriter, r *http.Request) {
+	var req BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid JSON request body")
+		return
+	}
+
+	req.ChannelName = strings.TrimSpace(req.ChannelName)
+	if req.ChannelName == "" {
+		respondWithError(w, http.StatusBadRequest, "Channel name cannot be empty")
+		return
+	}
+	if req.TargetMinutes < 0 || req.TargetMinutes > maxLongFormMinutes {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("target_minutes must be between 1 and %d, or 0 for a regular script", maxLongFormMinutes))
+		return
+	}
+
+	channel, err := getOrCreateChannel(req.ChannelName)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var items []BatchItem
+	for _, topic := range req.Topics {
+		if topic = strings.TrimSpace(topic); topic != "" {
+			items = append(items, BatchItem{Topic: topic})
+		}
+	}
+	for _, hex := range req.TopicIDs {
+		topicID, err := primitive.ObjectIDFromHex(hex)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid topic ID: %s", hex))
+			return
+		}
+		idea, err := getTopicIdeaByID(topicID)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Topic %s not found", hex))
+			return
+		}
+		if idea.ChannelID != channel.ID {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Topic %s belongs to channel %s", hex, idea.ChannelName))
+			return
+		}
+		if idea.Status == TopicStatusUsed || idea.Status == TopicStatusRejected {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Topic %s is %s", hex, idea.Status))
+			return
+		}
+		items = append(items, BatchItem{Topic: idea.Topic, TopicID: &idea.ID})
+	}
+
+	if len(items) == 0 {
+		respondWithError(w, http.StatusBadRequest, "Batch needs at least one topic")
+		return
+	}
+	if len(items) > maxBatchItems {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Batch can have at most %d topics", maxBatchItems))
+		return
+	}
+
+	slots, err := scheduleBatchSlots(req.Schedule, len(items))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Invalid schedule: %v", err))
+		return
+	}
+	for i := range items {
+		items[i].Index = i
+		items[i].ScheduledAt = slots[i]
+		items[i].Status = BatchItemScheduled
+	}
+
+	now := time.Now()
+	batch := Batch{
+		Name:            strings.TrimSpace(req.Name),
+		ChannelID:       channel.ID,
+		ChannelName:     channel.ChannelName,
+		Status:          BatchStatusScheduled,
+		Schedule:        req.Schedule,
+		GenerateVisuals: req.GenerateVisuals,
+		TargetMinutes:   req.TargetMinutes,
+		Items:           items,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	updateBatchProgress(&batch)
+
+	result, err := batchesCollection.InsertOne(context.Background(), batch)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create batch: %v", err))
+		return
+	}
+	batch.ID = result.InsertedID.(primitive.ObjectID)
+
+	respondWithJSON(w, http.StatusCreated, BatchResponse{
+		Success: true,
+		Message: fmt.Sprintf("Batch scheduled with %d topics", len(items)),
+		Batch:   batch,
+	})
+
+	slog.Info("Batch scheduled", "batch_id", batch.ID.Hex(), "channel", channel.ChannelName,
+		"topics", len(items), "first_slot", slots[0])
+}
+
+func listBatches(w http.ResponseWriter, r *http.Request) {
+	filter := bson.M{}
+	if channelName := r.URL.Query().Get("channel_name"); channelName != "" {
+		filter["channel_name"] = channelName
+	}
+	if status := r.URL.Query().Get("status"); status != "" {
+		filter["status"] = status
+	}
+
+	cursor, err := batchesCollection.Find(
+		context.Background(),
+		filter,
+		options.Find().
+			SetSort(bson.D{{"created_at", -1}}).
+			SetProjection(bson.M{"items": 0}),
+	)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Database error: %v", err))
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	batches := []Batch{}
+	if err := cursor.All(context.Background(), &batches); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error decoding batches: %v", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, BatchListResponse{
+		Success: true,
+		Count:   len(batches),
+		Batches: batches,
+	})
+}
+
+// batchHandler serves GET /batches/{id} and POST /batches/{id}/cancel
+func (yt *YtAutomation) batchHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/batches/")
+	cancel := strings.HasSuffix(path, "/cancel")
+	batchID, err := primitive.ObjectIDFromHex(strings.TrimSuffix(path, "/cancel"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid batch ID format")
+		return
+	}
+
+	switch {
+	case !cancel && r.Method == "GET":
+		var batch Batch
+		if err := batchesCollection.FindOne(context.Background(), bson.M{"_id": batchID}).Decode(&batch); err != nil {
+			if err == mongo.ErrNoDocuments {
+				respondWithError(w, http.StatusNotFound, "Batch not found")
+				return
+			}
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Database error: %v", err))
+			return
+		}
+		respondWithJSON(w, http.StatusOK, BatchResponse{Success: true, Batch: batch})
+	case cancel && r.Method == "POST":
+		yt.cancelBatch(w, batchID)
+	default:
+		respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// cancelBatch stops items that have not started yet; running scripts are left to finish
+func (yt *YtAutomation) cancelBatch(w http.ResponseWriter, batchID primitive.ObjectID) {
+	now := time.Now()
+	// Items still scheduled are cancelled in the same update, so none can be claimed halfway
+	result, err := batchesCollection.UpdateOne(context.Background(),
+		bson.M{"_id": batchID, "status": bson.M{"$in": []string{BatchStatusScheduled, BatchStatusRunning}}},
+		bson.M{"$set": bson.M{
+			"status":                     BatchStatusCancelled,
+			"completed_at":               now,
+			"items.$[item].status":       BatchItemCancelled,
+			"items.$[item].completed_at": now,
+		}},
+		options.Update().SetArrayFilters(options.ArrayFilters{
+			Filters: []interface{}{bson.M{"item.status": BatchItemScheduled}},
+		}),
+	)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to cancel batch: %v", err))
+		return
+	}
+
+	var batch Batch
+	if err := batchesCollection.FindOne(context.Background(), bson.M{"_id": batchID}).Decode(&batch); err != nil {
+		if err == mongo.ErrNoDocuments {
+			respondWithError(w, http.StatusNotFound, "Batch not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Database error: %v", err))
+		return
+	}
+	if result.MatchedCount == 0 {
+		respondWithError(w, http.StatusConflict, fmt.Sprintf("Batch is already %s", batch.Status))
+		return
+	}
+	if err := saveBatchProgress(&batch); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to cancel batch: %v", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, BatchResponse{
+		Success: true,
+		Message: "Batch cancelled",
+		Batch:   batch,
+	})
+}
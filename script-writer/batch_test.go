@@ -0,0 +1,91 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleBatchSlotsKeepsDatePairing(t *testing.T) {
+	dates := []time.Time{
+		time.Date(2026, 3, 5, 9, 0, 0, 0, time.UTC),
+		time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC),
+		time.Date(2026, 3, 9, 9, 0, 0, 0, time.UTC),
+	}
+	slots, err := scheduleBatchSlots(BatchSchedule{Dates: dates}, len(dates))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range dates {
+		if !slots[i].Equal(dates[i]) {
+			t.Errorf("slot %d = %s, want the date given for topic %d, %s", i, slots[i], i, dates[i])
+		}
+	}
+}
+
+func TestScheduleBatchSlotsCron(t *testing.T) {
+	start := time.Date(2099, 3, 2, 10, 0, 0, 0, time.UTC) // A Monday
+	slots, err := scheduleBatchSlots(BatchSchedule{Cron: "0 9 * * 1-5", StartAt: &start, Timezone: "UTC"}, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []time.Time{
+		time.Date(2099, 3, 3, 9, 0, 0, 0, time.UTC),
+		time.Date(2099, 3, 4, 9, 0, 0, 0, time.UTC),
+		time.Date(2099, 3, 5, 9, 0, 0, 0, time.UTC),
+		time.Date(2099, 3, 6, 9, 0, 0, 0, time.UTC),
+		time.Date(2099, 3, 9, 9, 0, 0, 0, time.UTC),
+	}
+	for i := range want {
+		if !slots[i].Equal(want[i]) {
+			t.Errorf("slot %d = %s, want %s", i, slots[i], want[i])
+		}
+	}
+}
+
+func TestScheduleBatchSlotsErrors(t *testing.T) {
+	date := time.Date(2099, 1, 1, 0, 0, 0, 0, time.UTC)
+	for name, schedule := range map[string]BatchSchedule{
+		"cron and dates":   {Cron: "0 9 * * *", Dates: []time.Time{date, date}},
+		"too few dates":    {Dates: []time.Time{date}},
+		"invalid cron":     {Cron: "0 25 * * *"},
+		"invalid timezone": {Cron: "0 9 * * *", Timezone: "Mars/Olympus"},
+	} {
+		if _, err := scheduleBatchSlots(schedule, 2); err == nil {
+			t.Errorf("%s: scheduleBatchSlots succeeded, want an error", name)
+		}
+	}
+}
+
+func TestUpdateBatchProgress(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   string
+		items    []string
+		want     string
+		finished bool
+	}{
+		{"nothing started", BatchStatusScheduled, []string{BatchItemScheduled, BatchItemScheduled}, BatchStatusScheduled, false},
+		{"one running", BatchStatusScheduled, []string{BatchItemRunning, BatchItemScheduled}, BatchStatusRunning, false},
+		{"all completed", BatchStatusRunning, []string{BatchItemCompleted, BatchItemCompleted}, BatchStatusCompleted, true},
+		{"some failed", BatchStatusRunning, []string{BatchItemCompleted, BatchItemFailed}, BatchStatusCompletedWithErrors, true},
+		{"cancelled stays cancelled", BatchStatusCancelled, []string{BatchItemRunning, BatchItemCancelled}, BatchStatusCancelled, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			batch := &Batch{Status: tt.status}
+			for i, status := range tt.items {
+				batch.Items = append(batch.Items, BatchItem{Index: i, Status: status})
+			}
+			updateBatchProgress(batch)
+			if batch.Status != tt.want {
+				t.Errorf("status = %q, want %q", batch.Status, tt.want)
+			}
+			if (batch.CompletedAt != nil) != tt.finished {
+				t.Errorf("completed_at set = %v, want %v", batch.CompletedAt != nil, tt.finished)
+			}
+			if batch.Progress.Total != len(tt.items) {
+				t.Errorf("total = %d, want %d", batch.Progress.Total, len(tt.items))
+			}
+		})
+	}
+}
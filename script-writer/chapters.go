@@ -0,0 +1,300 @@
+// File: chapters.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// timedWord is a single spoken word with its estimated start time in seconds
+type timedWord struct {
+	word  string
+	start float64
+}
+
+func normalizeWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// srtWordTimeline spreads each subtitle entry's words evenly over its duration
+func srtWordTimeline(entries []SRTEntry) []timedWord {
+	var timeline []timedWord
+	for _, entry := range entries {
+		words := normalizeWords(entry.Text)
+		start := entry.StartTime.Seconds()
+		step := (entry.EndTime.Seconds() - start) / math.Max(float64(len(words)), 1)
+		for i, word := range words {
+			timeline = append(timeline, timedWord{word: word, start: start + float64(i)*step})
+		}
+	}
+	return timeline
+}
+
+// findOpening returns the timeline index where opening starts, searching from cursor.
+// Transcription drops and merges words, so a position matches when enough of the opening
+// words appear in a slightly wider window starting with one of its first two words.
+func findOpening(timeline []timedWord, opening []string, cursor int) (int, bool) {
+	if len(opening) == 0 {
+		return 0, false
+	}
+	needed := int(math.Ceil(float64(len(opening)) * chapterMatchThreshold))
+
+	for pos := cursor; pos < len(timeline); pos++ {
+		if timeline[pos].word != opening[0] && (len(opening) < 2 || timeline[pos].word != opening[1]) {
+			continue
+		}
+
+		end := pos + len(opening) + 2
+		if end > len(timeline) {
+			end = len(timeline)
+		}
+		window := make(map[string]int)
+		for _, tw := range timeline[pos:end] {
+			window[tw.word]++
+		}
+
+		matched := 0
+		for _, word := range opening {
+			if window[word] > 0 {
+				window[word]--
+				matched++
+			}
+		}
+		if matched >= needed {
+			return pos, true
+		}
+	}
+	return 0, false
+}
+
+// buildChapters aligns the opening sentence of the intro and every section to the SRT
+// timeline and applies YouTube's chapter rules.
+func buildChapters(script *Script) ([]Chapter, error) {
+	if strings.TrimSpace(script.SRT) == "" {
+		return nil, fmt.Errorf("script has no subtitles yet")
+	}
+	if len(script.OutlinePoints) == 0 {
+		return nil, fmt.Errorf("script has no outline points")
+	}
+
+	entries, err := parseSRT(script.SRT)
+	if err != nil {
+		return nil, fmt.Errorf("parsing SRT: %w", err)
+	}
+	timeline := srtWordTimeline(entries)
+	if len(timeline) == 0 {
+		return nil, fmt.Errorf("SRT has no words")
+	}
+	duration := entries[len(entries)-1].EndTime.Seconds()
+
+	var blocks []string
+	for _, block := range strings.Split(script.FullScript, sectionSeparator) {
+		if strings.TrimSpace(block) != "" {
+			blocks = append(blocks, block)
+		}
+	}
+
+	// Generated scripts start with the hook and introduction; imported ones may not
+	hasIntro := len(blocks) == len(script.OutlinePoints)+1
+	if !hasIntro && len(blocks) != len(script.OutlinePoints) {
+		return nil, fmt.Errorf("script has %d blocks for %d outline points", len(blocks), len(script.OutlinePoints))
+	}
+
+	totalWords := 0
+	for _, block := range blocks {
+		totalWords += len(normalizeWords(block))
+	}
+
+	var chapters []Chapter
+	cursor := 0
+	wordsBefore := 0
+	for i, block := range blocks {
+		title := "Introduction"
+		sectionNumber := 0
+		if hasIntro && i > 0 {
+			title = script.OutlinePoints[i-1].Title
+			sectionNumber = script.OutlinePoints[i-1].SectionNumber
+		} else if !hasIntro {
+			title = script.OutlinePoints[i].Title
+			sectionNumber = script.OutlinePoints[i].SectionNumber
+		}
+
+		words := normalizeWords(block)
+		opening := words
+		if len(opening) > chapterMatchWords {
+			opening = opening[:chapterMatchWords]
+		}
+
+		var seconds float64
+		if pos, ok := findOpening(timeline, opening, cursor); ok {
+			seconds = timeline[pos].start
+			cursor = pos + 1
+		} else {
+			// Fall back to the block's share of the script's words
+			seconds = duration * float64(wordsBefore) / float64(totalWords)
+			slog.Warn("Could not align chapter to subtitles, estimating", logKeyScriptID, script.ID.Hex(), "title", title, "seconds", seconds)
+		}
+		wordsBefore += len(words)
+
+		chapters = append(chapters, Chapter{
+			Seconds:       seconds,
+			Title:         strings.TrimSpace(title),
+			SectionNumber: sectionNumber,
+		})
+	}
+
+	return applyChapterRules(chapters, duration), nil
+}
+
+// applyChapterRules starts the first chapter at 0:00 and drops chapters that would run
+// shorter than minChapterSeconds
+func applyChapterRules(chapters []Chapter, duration float64) []Chapter {
+	if len(chapters) == 0 {
+		return chapters
+	}
+	chapters[0].Seconds = 0
+
+	kept := []Chapter{chapters[0]}
+	for _, chapter := range chapters[1:] {
+		if chapter.Seconds-kept[len(kept)-1].Seconds >= minChapterSeconds {
+			kept = append(kept, chapter)
+		}
+	}
+	if len(kept) > 1 && duration-kept[len(kept)-1].Seconds < minChapterSeconds {
+		kept = kept[:len(kept)-1]
+	}
+
+	withHours := duration >= 3600
+	for i := range kept {
+		kept[i].Seconds = math.Floor(kept[i].Seconds)
+		kept[i].Timestamp = formatChapterTimestamp(kept[i].Seconds, withHours)
+	}
+	return kept
+}
+
+func formatChapterTimestamp(seconds float64, withHours bool) string {
+	total := int(seconds)
+	hours, minutes, secs := total/3600, (total%3600)/60, total%60
+	if withHours {
+		return fmt.Sprintf("%d:%02d:%02d", hours, minutes, secs)
+	}
+	return fmt.Sprintf("%02d:%02d", minutes, secs)
+}
+
+// withChapters replaces any previous chapter block at the end of the description
+func withChapters(description string, chapters []Chapter) string {
+	if idx := strings.LastIndex(description, chaptersHeader); idx >= 0 {
+		description = description[:idx]
+	}
+	description = strings.TrimSpace(description)
+
+	var block strings.Builder
+	block.WriteString(chaptersHeader + "\n")
+	for _, chapter := range chapters {
+		block.WriteString(fmt.Sprintf("%s %s\n", chapter.Timestamp, chapter.Title))
+	}
+
+	if description == "" {
+		return strings.TrimSpace(block.String())
+	}
+	return description + "\n\n" + strings.TrimSpace(block.String())
+}
+
+// chapterDescription adds the chapter list to description, or reports false when YouTube
+// would ignore it for having fewer than minChapterCount chapters
+func chapterDescription(description string, chapters []Chapter) (string, bool) {
+	if len(chapters) < minChapterCount {
+		return description, false
+	}
+	return withChapters(description, chapters), true
+}
+
+// GenerateChapters stores chapter markers in meta.chapters and, when YouTube would accept
+// them, appends them to the description.
+func (yt *YtAutomation) GenerateChapters(script *Script) ([]Chapter, error) {
+	chapters, err := buildChapters(script)
+	if err != nil {
+		return nil, err
+	}
+
+	update := bson.M{"meta.chapters": chapters}
+	if description, ok := chapterDescription(script.Meta.Description, chapters); ok {
+		script.Meta.Description = description
+		update["meta.description"] = script.Meta.Description
+	} else {
+		slog.Warn("Too few chapters fit YouTube's rules, description left unchanged", logKeyScriptID, script.ID.Hex(), "chapters", len(chapters))
+	}
+	script.Meta.Chapters = chapters
+
+	if err := yt.updateScriptInDB(script.ID, update); err != nil {
+		return nil, fmt.Errorf("saving chapters: %w", err)
+	}
+
+	slog.Info("Generated chapters", logKeyScriptID, script.ID.Hex(), "chapters", len(chapters))
+	return chapters, nil
+}
+
+type ChaptersResponse struct {
+	Message string       `json:"message"`
+	Data    ChaptersData `json:"data"`
+}
+
+type ChaptersData struct {
+	ScriptID    string    `json:"script_id"`
+	Chapters    []Chapter `json:"chapters"`
+	Description string    `json:"description"` // Meta description with the chapter list added
+}
+
+func (yt *YtAutomation) generateChaptersHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Extract script ID from URL path (/generate-chapters/{scriptID})
+	path := strings.TrimPrefix(r.URL.Path, "/generate-chapters/")
+	if path == "" {
+		respondWithError(w, http.StatusBadRequest, "Script ID is required")
+		return
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(path)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid script ID format")
+		return
+	}
+
+	var script Script
+	err = scriptsCollection.FindOne(context.Background(), bson.M{"_id": objectID}).Decode(&script)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			respondWithError(w, http.StatusNotFound, "Script not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Database error: %v", err))
+		return
+	}
+
+	chapters, err := yt.GenerateChapters(&script)
+	if err != nil {
+		respondWithError(w, http.StatusUnprocessableEntity, fmt.Sprintf("Failed to generate chapters: %v", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, ChaptersResponse{
+		Message: "Chapter generation completed",
+		Data: ChaptersData{
+			ScriptID:    path,
+			Chapters:    chapters,
+			Description: script.Meta.Description,
+		},
+	})
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// cue is one subtitle entry, in seconds
+type cue struct {
+	start, end float64
+	text       string
+}
+
+func buildSRT(cues []cue) string {
+	stamp := func(seconds float64) string {
+		ms := int(seconds*1000 + 0.5)
+		return fmt.Sprintf("%02d:%02d:%02d,%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+	}
+	var srt strings.Builder
+	for i, c := range cues {
+		fmt.Fprintf(&srt, "%d\n%s --> %s\n%s\n\n", i+1, stamp(c.start), stamp(c.end), c.text)
+	}
+	return srt.String()
+}
+
+// chapterScript builds a script whose blocks are narrated by the cues; titles name the
+// sections after the optional intro
+func chapterScript(blocks []string, titles []string, cues []cue) *Script {
+	script := &Script{
+		FullScript: strings.Join(blocks, sectionSeparator) + sectionSeparator,
+		SRT:        buildSRT(cues),
+	}
+	for i, title := range titles {
+		script.OutlinePoints = append(script.OutlinePoints, OutlinePoint{SectionNumber: i + 1, Title: title})
+	}
+	return script
+}
+
+func TestBuildChapters(t *testing.T) {
+	intro := "Welcome back to the channel where we explore ancient history"
+	first := "The first empire rose along the banks of a great river"
+	second := "Trade routes carried silk spices and ideas across the desert"
+	third := "Eventually drought and war brought the golden age to an end"
+
+	tests := []struct {
+		name   string
+		blocks []string
+		titles []string
+		cues   []cue
+		want   []string // "timestamp title"
+	}{
+		{
+			name:   "intro and sections line up",
+			blocks: []string{intro, first, second, third},
+			titles: []string{"Rise", "Trade", "Fall"},
+			cues:   []cue{{0.5, 20, intro}, {20.7, 40, first}, {40, 60, second}, {60, 80, third}},
+			want:   []string{"00:00 Introduction", "00:20 Rise", "00:40 Trade", "01:00 Fall"},
+		},
+		{
+			name:   "chapter shorter than ten seconds is dropped",
+			blocks: []string{intro, first, second, third},
+			titles: []string{"Rise", "Trade", "Fall"},
+			cues:   []cue{{0, 20, intro}, {20, 25, first}, {25, 50, second}, {50, 80, third}},
+			want:   []string{"00:00 Introduction", "00:20 Rise", "00:50 Fall"},
+		},
+		{
+			name:   "last chapter too close to the end is dropped",
+			blocks: []string{intro, first, second, third},
+			titles: []string{"Rise", "Trade", "Fall"},
+			cues:   []cue{{0, 20, intro}, {20, 40, first}, {40, 75, second}, {75, 80, third}},
+			want:   []string{"00:00 Introduction", "00:20 Rise", "00:40 Trade"},
+		},
+		{
+			name:   "imported script without intro starts at zero",
+			blocks: []string{first, second, third},
+			titles: []string{"Rise", "Trade", "Fall"},
+			cues:   []cue{{3.5, 30, first}, {30, 60, second}, {60, 90, third}},
+			want:   []string{"00:00 Rise", "00:30 Trade", "01:00 Fall"},
+		},
+		{
+			name:   "transcription with dropped words still matches",
+			blocks: []string{intro, first, second, third},
+			titles: []string{"Rise", "Trade", "Fall"},
+			cues: []cue{{0, 20, intro}, {20, 40, "The first empire rose along banks of great river"},
+				{40, 60, "Trade routes carry silk and ideas across desert"}, {60, 80, third}},
+			want: []string{"00:00 Introduction", "00:20 Rise", "00:40 Trade", "01:00 Fall"},
+		},
+		{
+			name:   "unmatched section is estimated from its share of words",
+			blocks: []string{intro, first, second, third},
+			titles: []string{"Rise", "Trade", "Fall"},
+			cues: []cue{{0, 20, intro}, {20, 40, first},
+				{40, 60, "completely different narration nothing in common whatsoever here today"}, {60, 80, third}},
+			// The second section starts after 21 of the script's 42 words: half of 80s
+			want: []string{"00:00 Introduction", "00:20 Rise", "00:40 Trade", "01:00 Fall"},
+		},
+		{
+			name:   "hour long videos use hours",
+			blocks: []string{intro, first, second, third},
+			titles: []string{"Rise", "Trade", "Fall"},
+			cues:   []cue{{0, 1200, intro}, {1200, 2400, first}, {2400, 3000, second}, {3000, 3700, third}},
+			want:   []string{"0:00:00 Introduction", "0:20:00 Rise", "0:40:00 Trade", "0:50:00 Fall"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chapters, err := buildChapters(chapterScript(tt.blocks, tt.titles, tt.cues))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, chapter := range chapters {
+				got = append(got, chapter.Timestamp+" "+chapter.Title)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("chapters:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestBuildChaptersErrors(t *testing.T) {
+	cues := []cue{{0, 10, "one"}, {10, 20, "two"}}
+	tests := map[string]*Script{
+		"no subtitles":        {FullScript: "one", OutlinePoints: []OutlinePoint{{Title: "One"}}},
+		"no outline points":   {FullScript: "one", SRT: buildSRT(cues)},
+		"no words":            {FullScript: "one", SRT: "garbage", OutlinePoints: []OutlinePoint{{Title: "One"}}},
+		"block count differs": chapterScript([]string{"one", "two", "three"}, []string{"One"}, cues),
+	}
+	for name, script := range tests {
+		if _, err := buildChapters(script); err == nil {
+			t.Errorf("%s: buildChapters succeeded, want an error", name)
+		}
+	}
+}
+
+func TestApplyChapterRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		seconds  []float64
+		duration float64
+		want     []string
+	}{
+		{"first chapter moves to zero", []float64{4.2, 30, 60}, 90, []string{"00:00", "00:30", "01:00"}},
+		{"timestamps round down", []float64{0, 29.99, 61.5}, 90, []string{"00:00", "00:29", "01:01"}},
+		{"exactly ten seconds is kept", []float64{0, 10, 20}, 30, []string{"00:00", "00:10", "00:20"}},
+		{"a short chapter is merged into the previous one", []float64{0, 30, 35, 60}, 90, []string{"00:00", "00:30", "01:00"}},
+		{"spacing is measured from the last kept chapter", []float64{0, 6, 12, 18}, 60, []string{"00:00", "00:12"}},
+		{"a short last chapter is dropped", []float64{0, 30, 85}, 90, []string{"00:00", "00:30"}},
+		{"a lone chapter is kept", []float64{0}, 5, []string{"00:00"}},
+		{"no chapters", nil, 60, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var chapters []Chapter
+			for i, seconds := range tt.seconds {
+				chapters = append(chapters, Chapter{Seconds: seconds, Title: fmt.Sprint(i)})
+			}
+			var got []string
+			for _, chapter := range applyChapterRules(chapters, tt.duration) {
+				got = append(got, chapter.Timestamp)
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("timestamps = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChapterDescription(t *testing.T) {
+	two := []Chapter{{Timestamp: "00:00", Title: "Intro"}, {Timestamp: "00:30", Title: "Body"}}
+	if description, ok := chapterDescription("About the video.", two); ok || description != "About the video." {
+		t.Errorf("two chapters = %q, %v; want the description unchanged", description, ok)
+	}
+
+	three := append(two, Chapter{Timestamp: "01:00", Title: "End"})
+	description, ok := chapterDescription("About the video.", three)
+	want := "About the video.\n\nChapters:\n00:00 Intro\n00:30 Body\n01:00 End"
+	if !ok || description != want {
+		t.Errorf("three chapters = %q, %v; want %q", description, ok, want)
+	}
+
+	// Generating again replaces the previous list
+	three[1].Title = "Middle"
+	again, _ := chapterDescription(description, three)
+	if want := "About the video.\n\nChapters:\n00:00 Intro\n00:30 Middle\n01:00 End"; again != want {
+		t.Errorf("regenerated = %q, want %q", again, want)
+	}
+}
@@ -0,0 +1,1575 @@
+// Code generated by internal/gen from openapi.json. DO NOT EDIT.
+
+package client
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type APIKey struct {
+	CharactersRemaining *int       `json:"characters_remaining,omitempty"`
+	CharactersUsed      int        `json:"characters_used,omitempty"`
+	ConsecutiveErrors   int        `json:"consecutive_errors"`
+	CooldownUntil       *time.Time `json:"cooldown_until,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	ErrorCount          int        `json:"error_count"`
+	Fingerprint         string     `json:"fingerprint"`
+	ID                  string     `json:"id"`
+	IsActive            bool       `json:"is_active"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastErrorAt         *time.Time `json:"last_error_at,omitempty"`
+	LastProbedAt        *time.Time `json:"last_probed_at,omitempty"`
+	LastUsed            time.Time  `json:"last_used"`
+	Provider            string     `json:"provider"`
+	State               string     `json:"state"`
+	UsageCount          int        `json:"usage_count"`
+}
+
+type APIKeyCreateRequest struct {
+	Key      string `json:"key"`
+	Provider string `json:"provider"`
+}
+
+type APIKeyListResponse struct {
+	Count     int                 `json:"count"`
+	Keys      []APIKey            `json:"keys"`
+	Providers []APIKeyPoolSummary `json:"providers"`
+	Success   bool                `json:"success"`
+}
+
+type APIKeyPoolSummary struct {
+	Available int            `json:"available"`
+	Disabled  int            `json:"disabled"`
+	Errors    int            `json:"errors"`
+	Provider  string         `json:"provider"`
+	States    map[string]int `json:"states"`
+	Total     int            `json:"total"`
+	Uses      int            `json:"uses"`
+}
+
+type APIKeyResponse struct {
+	Key     *APIKey `json:"key,omitempty"`
+	Message string  `json:"message,omitempty"`
+	Success bool    `json:"success"`
+}
+
+type APIKeyUpdateRequest struct {
+	Key string `json:"key"`
+}
+
+type AudioConfig struct {
+	BackgroundMusic string  `json:"background_music"`
+	BackgroundURL   string  `json:"background_url"`
+	FadeIn          int     `json:"fade_in"`
+	FadeOut         int     `json:"fade_out"`
+	VoiceOver       string  `json:"voice_over"`
+	VoiceOverURL    string  `json:"voice_over_url"`
+	VoiceVolume     float64 `json:"voice_volume"`
+	Volume          float64 `json:"volume"`
+}
+
+type Batch struct {
+	ChannelID       string        `json:"channel_id"`
+	ChannelName     string        `json:"channel_name"`
+	CompletedAt     *time.Time    `json:"completed_at,omitempty"`
+	CreatedAt       time.Time     `json:"created_at"`
+	GenerateVisuals bool          `json:"generate_visuals"`
+	ID              string        `json:"id"`
+	Items           []BatchItem   `json:"items"`
+	Name            string        `json:"name,omitempty"`
+	Progress        BatchProgress `json:"progress"`
+	Schedule        BatchSchedule `json:"schedule"`
+	Status          string        `json:"status"`
+	TargetMinutes   int           `json:"target_minutes,omitempty"`
+	UpdatedAt       time.Time     `json:"updated_at"`
+}
+
+type BatchItem struct {
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	Index       int        `json:"index"`
+	ScheduledAt time.Time  `json:"scheduled_at"`
+	ScriptID    *string    `json:"script_id,omitempty"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	Status      string     `json:"status"`
+	Topic       string     `json:"topic"`
+	TopicID     *string    `json:"topic_id,omitempty"`
+}
+
+type BatchListResponse struct {
+	Batches []Batch `json:"batches"`
+	Count   int     `json:"count"`
+	Success bool    `json:"success"`
+}
+
+type BatchProgress struct {
+	Cancelled int `json:"cancelled"`
+	Completed int `json:"completed"`
+	Failed    int `json:"failed"`
+	Running   int `json:"running"`
+	Scheduled int `json:"scheduled"`
+	Total     int `json:"total"`
+}
+
+type BatchRequest struct {
+	ChannelName     string        `json:"channel_name"`
+	GenerateVisuals bool          `json:"generate_visuals"`
+	Name            string        `json:"name,omitempty"`
+	Schedule        BatchSchedule `json:"schedule"`
+	TargetMinutes   int           `json:"target_minutes,omitempty"`
+	TopicIds        []string      `json:"topic_ids,omitempty"`
+	Topics          []string      `json:"topics,omitempty"`
+}
+
+type BatchResponse struct {
+	Batch   Batch  `json:"batch"`
+	Message string `json:"message,omitempty"`
+	Success bool   `json:"success"`
+}
+
+type BatchSchedule struct {
+	Cron     string      `json:"cron,omitempty"`
+	Dates    []time.Time `json:"dates,omitempty"`
+	StartAt  *time.Time  `json:"start_at,omitempty"`
+	Timezone string      `json:"timezone,omitempty"`
+}
+
+type Channel struct {
+	ChannelName  string          `json:"channel_name"`
+	CreatedAt    time.Time       `json:"created_at"`
+	ID           string          `json:"id"`
+	Settings     ChannelSettings `json:"settings"`
+	TotalScripts int             `json:"total_scripts"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
+
+type ChannelSettings struct {
+	DefaultSectionCount     int              `json:"default_section_count"`
+	MaxConcurrentScripts    int              `json:"max_concurrent_scripts,omitempty"`
+	NicheDescription        string           `json:"niche_description,omitempty"`
+	PreferredVisualGuidance bool             `json:"preferred_visual_guidance"`
+	ThumbnailLayout         *ThumbnailLayout `json:"thumbnail_layout,omitempty"`
+	TtsProvider             string           `json:"tts_provider,omitempty"`
+	TtsVoice                string           `json:"tts_voice,omitempty"`
+	VisualImageMultiplier   int              `json:"visual_image_multiplier"`
+	WordLimitForHookIntro   int              `json:"word_limit_for_hook_intro"`
+	WordLimitPerSection     int              `json:"word_limit_per_section"`
+	WordsPerMinute          int              `json:"words_per_minute,omitempty"`
+}
+
+type ChannelVoice struct {
+	Provider string `json:"provider"`
+	Voice    string `json:"voice,omitempty"`
+}
+
+type Chapter struct {
+	Seconds       float64 `json:"seconds"`
+	SectionNumber int     `json:"section_number"`
+	Timestamp     string  `json:"timestamp"`
+	Title         string  `json:"title"`
+}
+
+type ChaptersData struct {
+	Chapters    []Chapter `json:"chapters"`
+	Description string    `json:"description"`
+	ScriptID    string    `json:"script_id"`
+}
+
+type ChaptersResponse struct {
+	Data    ChaptersData `json:"data"`
+	Message string       `json:"message"`
+}
+
+type CheckResult struct {
+	Error     string  `json:"error,omitempty"`
+	LatencyMs float64 `json:"latency_ms"`
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+}
+
+type ConfigResponse struct {
+	Config   map[string]interface{} `json:"config"`
+	File     string                 `json:"file,omitempty"`
+	LoadedAt time.Time              `json:"loaded_at"`
+}
+
+type HealthResponse struct {
+	MongoDB   string `json:"mongodb"`
+	Service   string `json:"service"`
+	Status    string `json:"status"`
+	Timestamp string `json:"timestamp"`
+}
+
+type ImageAsset struct {
+	Data      string         `json:"data,omitempty"`
+	Duration  float64        `json:"duration"`
+	Effect    string         `json:"effect,omitempty"`
+	Height    int            `json:"height"`
+	ID        string         `json:"id"`
+	Kenburns  KenBurnsConfig `json:"kenburns,omitempty"`
+	Opacity   float64        `json:"opacity"`
+	Starttime float64        `json:"starttime"`
+	URL       string         `json:"url,omitempty"`
+	Width     int            `json:"width"`
+	X         int            `json:"x"`
+	Y         int            `json:"y"`
+	Zindex    int            `json:"zindex"`
+}
+
+type ImportedSection struct {
+	Content string `json:"content"`
+	Summary string `json:"summary,omitempty"`
+	Title   string `json:"title"`
+}
+
+type KenBurnsConfig struct {
+	Direction  string  `json:"direction"`
+	Enabled    bool    `json:"enabled"`
+	PanX       string  `json:"pan_x"`
+	PanY       string  `json:"pan_y"`
+	ScaleWidth int     `json:"scale_width"`
+	ZoomEnd    float64 `json:"zoom_end"`
+	ZoomRate   float64 `json:"zoom_rate"`
+	ZoomStart  float64 `json:"zoom_start"`
+}
+
+type LongFormPlan struct {
+	Acts            []OutlineAct `json:"acts"`
+	SectionCount    int          `json:"section_count"`
+	TargetMinutes   int          `json:"target_minutes"`
+	TargetWords     int          `json:"target_words"`
+	WordsPerMinute  int          `json:"words_per_minute"`
+	WordsPerSection int          `json:"words_per_section"`
+}
+
+type MetaContent struct {
+	Chapters      []Chapter `json:"chapters,omitempty"`
+	Description   string    `json:"description"`
+	Tags          []string  `json:"tags"`
+	ThumbnailText string    `json:"thumbnail_text"`
+	Title         string    `json:"title"`
+}
+
+type OutlineAct struct {
+	ActNumber int              `json:"act_number"`
+	Chapters  []OutlineChapter `json:"chapters"`
+	Summary   string           `json:"summary"`
+	Title     string           `json:"title"`
+}
+
+type OutlineChapter struct {
+	ChapterNumber int    `json:"chapter_number"`
+	SectionCount  int    `json:"section_count"`
+	Summary       string `json:"summary"`
+	Title         string `json:"title"`
+}
+
+type OutlinePoint struct {
+	ActNumber     int    `json:"act_number,omitempty"`
+	ChapterNumber int    `json:"chapter_number,omitempty"`
+	SectionNumber int    `json:"section_number"`
+	Summary       string `json:"summary"`
+	Title         string `json:"title"`
+}
+
+type PromptTemplate struct {
+	ChannelID    string    `json:"channel_id"`
+	CreatedAt    time.Time `json:"created_at"`
+	ID           string    `json:"id"`
+	IsActive     bool      `json:"is_active"`
+	IsGlobal     bool      `json:"is_global"`
+	Name         string    `json:"name"`
+	StyleIds     []string  `json:"style_ids"`
+	SystemPrompt string    `json:"system_prompt"`
+	Type         string    `json:"type"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	UserPrompt   string    `json:"user_prompt"`
+	Variables    []string  `json:"variables"`
+	Version      int       `json:"version"`
+}
+
+type PromptTemplateListResponse struct {
+	Data    []PromptTemplate `json:"data"`
+	Success bool             `json:"success"`
+}
+
+type PromptTemplateRequest struct {
+	ChannelID    string   `json:"channel_id,omitempty"`
+	IsGlobal     bool     `json:"is_global"`
+	Name         string   `json:"name"`
+	StyleIds     []string `json:"style_ids,omitempty"`
+	SystemPrompt string   `json:"system_prompt"`
+	Type         string   `json:"type"`
+	UserPrompt   string   `json:"user_prompt"`
+	Variables    []string `json:"variables,omitempty"`
+}
+
+type PromptTemplateResponse struct {
+	Data    PromptTemplate `json:"data"`
+	Success bool           `json:"success"`
+}
+
+type PublishRequest struct {
+	CategoryID       string     `json:"category_id,omitempty"`
+	PrivacyStatus    string     `json:"privacy_status"`
+	PublishAt        *time.Time `json:"publish_at,omitempty"`
+	ThumbnailVariant int        `json:"thumbnail_variant,omitempty"`
+	VideoPath        string     `json:"video_path,omitempty"`
+}
+
+type ReadyResponse struct {
+	Checks    []CheckResult `json:"checks"`
+	Status    string        `json:"status"`
+	Timestamp string        `json:"timestamp"`
+}
+
+type SRTCoverageResponse struct {
+	Data    []VisualPromptResponse `json:"data"`
+	Success bool                   `json:"success"`
+}
+
+type Scene struct {
+	Duration  float64 `json:"duration"`
+	Fontcolor string  `json:"fontcolor"`
+	Fontsize  int     `json:"fontsize"`
+	ID        string  `json:"id"`
+	Position  string  `json:"position"`
+	Starttime float64 `json:"starttime"`
+	Text      string  `json:"text"`
+	X         int     `json:"x"`
+	Y         int     `json:"y"`
+}
+
+type Script struct {
+	ChannelID             string         `json:"channel_id"`
+	ChannelName           string         `json:"channel_name"`
+	CompletedAt           *time.Time     `json:"completed_at,omitempty"`
+	CreatedAt             time.Time      `json:"created_at"`
+	CurrentSection        int            `json:"current_section,omitempty"`
+	ErrorMessage          string         `json:"error_message,omitempty"`
+	FullAudioFile         string         `json:"full_audio_file,omitempty"`
+	FullScript            string         `json:"full_script"`
+	GenerateVisuals       bool           `json:"generate_visuals"`
+	ID                    string         `json:"id"`
+	LongForm              *LongFormPlan  `json:"long_form,omitempty"`
+	Meta                  MetaContent    `json:"meta"`
+	Outline               string         `json:"outline"`
+	OutlinePoints         []OutlinePoint `json:"outline_points"`
+	ProcessingTimeSeconds float64        `json:"processing_time_seconds,omitempty"`
+	RollingSummary        string         `json:"rolling_summary,omitempty"`
+	SectionsGenerated     int            `json:"sections_generated,omitempty"`
+	Source                string         `json:"source,omitempty"`
+	SRT                   string         `json:"srt"`
+	StartedAt             *time.Time     `json:"started_at,omitempty"`
+	Status                string         `json:"status"`
+	Thumbnails            []Thumbnail    `json:"thumbnails,omitempty"`
+	Topic                 string         `json:"topic"`
+	Upload                *UploadStatus  `json:"upload,omitempty"`
+}
+
+type ScriptAudio struct {
+	AudioFilePath    string    `json:"audio_file_path,omitempty"`
+	CharCount        int       `json:"char_count"`
+	ChunkIndex       int       `json:"chunk_index"`
+	Content          string    `json:"content"`
+	CreatedAt        time.Time `json:"created_at"`
+	GenerationStatus string    `json:"generation_status"`
+	HasVisual        bool      `json:"has_visual"`
+	ID               string    `json:"id"`
+	ScriptID         string    `json:"script_id"`
+	UpdatedAt        time.Time `json:"updated_at,omitempty"`
+}
+
+type ScriptChunksResponse struct {
+	Chunks      []ScriptAudio `json:"chunks"`
+	ScriptID    string        `json:"script_id"`
+	TotalChunks int           `json:"total_chunks"`
+}
+
+type ScriptImportRequest struct {
+	ChannelName  string            `json:"channel_name"`
+	Content      string            `json:"content,omitempty"`
+	Format       string            `json:"format"`
+	GenerateMeta bool              `json:"generate_meta"`
+	Intro        string            `json:"intro,omitempty"`
+	Meta         *MetaContent      `json:"meta,omitempty"`
+	Sections     []ImportedSection `json:"sections,omitempty"`
+	Topic        string            `json:"topic"`
+}
+
+type ScriptImportResponse struct {
+	ChannelName   string         `json:"channel_name"`
+	Message       string         `json:"message"`
+	OutlinePoints []OutlinePoint `json:"outline_points"`
+	ScriptID      string         `json:"script_id"`
+	Sections      int            `json:"sections"`
+	Status        string         `json:"status"`
+	Success       bool           `json:"success"`
+	Topic         string         `json:"topic"`
+}
+
+type ScriptListResponse struct {
+	Count      int      `json:"count"`
+	HasMore    bool     `json:"has_more"`
+	NextCursor string   `json:"next_cursor"`
+	Scripts    []Script `json:"scripts"`
+}
+
+type ScriptLog struct {
+	Attrs   map[string]interface{} `json:"attrs,omitempty"`
+	Level   string                 `json:"level"`
+	Msg     string                 `json:"msg"`
+	Stage   string                 `json:"stage,omitempty"`
+	Time    time.Time              `json:"time"`
+	TraceID string                 `json:"trace_id,omitempty"`
+}
+
+type ScriptLogsResponse struct {
+	Count    int         `json:"count"`
+	Logs     []ScriptLog `json:"logs"`
+	ScriptID string      `json:"script_id"`
+}
+
+type ScriptRequest struct {
+	ChannelName     string `json:"channel_name"`
+	GenerateVisuals bool   `json:"generate_visuals"`
+	TargetMinutes   int    `json:"target_minutes,omitempty"`
+	Topic           string `json:"topic"`
+}
+
+type ScriptResponse struct {
+	ChannelName     string `json:"channel_name,omitempty"`
+	Error           string `json:"error,omitempty"`
+	GeneratedAt     string `json:"generated_at,omitempty"`
+	Message         string `json:"message,omitempty"`
+	MetatagFilename string `json:"metatag_filename,omitempty"`
+	OutputFilename  string `json:"output_filename,omitempty"`
+	OutputFolder    string `json:"output_folder,omitempty"`
+	ScriptID        string `json:"script_id,omitempty"`
+	Status          string `json:"status,omitempty"`
+	Success         bool   `json:"success"`
+	Topic           string `json:"topic,omitempty"`
+}
+
+type SearchHighlight struct {
+	Field   string `json:"field"`
+	Snippet string `json:"snippet"`
+}
+
+type SearchHit struct {
+	ChannelName   string            `json:"channel_name"`
+	CreatedAt     time.Time         `json:"created_at"`
+	Highlights    []SearchHighlight `json:"highlights"`
+	Score         float64           `json:"score"`
+	ScriptID      string            `json:"script_id"`
+	SectionNumber *int              `json:"section_number,omitempty"`
+	SectionTitle  string            `json:"section_title,omitempty"`
+	Status        string            `json:"status"`
+	Topic         string            `json:"topic"`
+}
+
+type SearchResponse struct {
+	Count int         `json:"count"`
+	Hits  []SearchHit `json:"hits"`
+	Query string      `json:"query"`
+}
+
+type StepData struct {
+	ScriptID    string `json:"script_id"`
+	TotalChunks *int   `json:"total_chunks,omitempty"`
+}
+
+type StepResponse struct {
+	Data    StepData `json:"data"`
+	Message string   `json:"message"`
+}
+
+type SubtitleConfig struct {
+	Background string `json:"background"`
+	FontColor  string `json:"font_color"`
+	FontSize   int    `json:"font_size"`
+	Outline    bool   `json:"outline"`
+	Position   string `json:"position"`
+	SRTData    string `json:"srt_data"`
+	SRTURL     string `json:"srt_url"`
+}
+
+type TTSProviderInfo struct {
+	Default    bool      `json:"default"`
+	Fallback   bool      `json:"fallback"`
+	Formats    []string  `json:"formats"`
+	Name       string    `json:"name"`
+	Quota      *TTSQuota `json:"quota,omitempty"`
+	QuotaError string    `json:"quota_error,omitempty"`
+}
+
+type TTSProvidersResponse struct {
+	Providers []TTSProviderInfo `json:"providers"`
+	Success   bool              `json:"success"`
+}
+
+type TTSQuota struct {
+	Keys      int        `json:"keys,omitempty"`
+	Limit     int        `json:"limit"`
+	Remaining int        `json:"remaining"`
+	ResetsAt  *time.Time `json:"resets_at,omitempty"`
+	Unlimited bool       `json:"unlimited"`
+	Used      int        `json:"used"`
+}
+
+type TTSVoice struct {
+	ID       string `json:"id"`
+	Language string `json:"language,omitempty"`
+	Name     string `json:"name"`
+}
+
+type TTSVoicesResponse struct {
+	Provider string     `json:"provider"`
+	Success  bool       `json:"success"`
+	Voices   []TTSVoice `json:"voices"`
+}
+
+type Thumbnail struct {
+	CreatedAt   time.Time `json:"created_at"`
+	Path        string    `json:"path"`
+	Position    string    `json:"position"`
+	SourceImage string    `json:"source_image"`
+	Variant     int       `json:"variant"`
+}
+
+type ThumbnailLayout struct {
+	FontColor         string   `json:"font_color"`
+	FontFile          string   `json:"font_file,omitempty"`
+	FontSize          int      `json:"font_size"`
+	GradientColor     string   `json:"gradient_color"`
+	GradientDirection string   `json:"gradient_direction"`
+	GradientOpacity   *float64 `json:"gradient_opacity,omitempty"`
+	MaxCharsPerLine   int      `json:"max_chars_per_line"`
+	Position          string   `json:"position"`
+	StrokeColor       string   `json:"stroke_color"`
+	StrokeWidth       int      `json:"stroke_width"`
+	Uppercase         *bool    `json:"uppercase,omitempty"`
+}
+
+type ThumbnailListResponse struct {
+	ScriptID      string      `json:"script_id"`
+	ThumbnailText string      `json:"thumbnail_text"`
+	Thumbnails    []Thumbnail `json:"thumbnails"`
+}
+
+type ThumbnailRequest struct {
+	Dedicated bool             `json:"dedicated"`
+	Layout    *ThumbnailLayout `json:"layout,omitempty"`
+	Text      string           `json:"text,omitempty"`
+	Variants  int              `json:"variants"`
+}
+
+type ThumbnailsData struct {
+	ScriptID   string      `json:"script_id"`
+	Thumbnails []Thumbnail `json:"thumbnails"`
+}
+
+type ThumbnailsResponse struct {
+	Data    ThumbnailsData `json:"data"`
+	Message string         `json:"message"`
+}
+
+type TopicCreateRequest struct {
+	Angle    string `json:"angle,omitempty"`
+	Priority int    `json:"priority"`
+	Topic    string `json:"topic"`
+}
+
+type TopicIdea struct {
+	Angle       string    `json:"angle,omitempty"`
+	ChannelID   string    `json:"channel_id"`
+	ChannelName string    `json:"channel_name"`
+	CreatedAt   time.Time `json:"created_at"`
+	ID          string    `json:"id"`
+	Priority    int       `json:"priority"`
+	ScriptID    *string   `json:"script_id,omitempty"`
+	Source      string    `json:"source"`
+	Status      string    `json:"status"`
+	Topic       string    `json:"topic"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type TopicIdeationRequest struct {
+	Count            int    `json:"count"`
+	NicheDescription string `json:"niche_description,omitempty"`
+}
+
+type TopicIdeationResponse struct {
+	Message           string      `json:"message"`
+	SkippedDuplicates []string    `json:"skipped_duplicates"`
+	Success           bool        `json:"success"`
+	Topics            []TopicIdea `json:"topics"`
+}
+
+type TopicListResponse struct {
+	Count   int         `json:"count"`
+	Success bool        `json:"success"`
+	Topics  []TopicIdea `json:"topics"`
+}
+
+type TopicResponse struct {
+	Message string     `json:"message,omitempty"`
+	Success bool       `json:"success"`
+	Topic   *TopicIdea `json:"topic,omitempty"`
+}
+
+type TopicUpdateRequest struct {
+	Angle    *string `json:"angle,omitempty"`
+	Priority *int    `json:"priority,omitempty"`
+	Status   *string `json:"status,omitempty"`
+	Topic    *string `json:"topic,omitempty"`
+}
+
+type UploadData struct {
+	ScriptID string       `json:"script_id"`
+	Upload   UploadStatus `json:"upload"`
+}
+
+type UploadResponse struct {
+	Data    *UploadData   `json:"data,omitempty"`
+	Message string        `json:"message"`
+	Upload  *UploadStatus `json:"upload,omitempty"`
+}
+
+type UploadStatus struct {
+	CompletedAt   *time.Time `json:"completed_at,omitempty"`
+	ErrorMsg      string     `json:"error_msg,omitempty"`
+	PrivacyStatus string     `json:"privacy_status"`
+	Progress      int        `json:"progress"`
+	Provider      string     `json:"provider"`
+	PublishAt     *time.Time `json:"publish_at,omitempty"`
+	StartedAt     time.Time  `json:"started_at"`
+	Status        string     `json:"status"`
+	ThumbnailSet  bool       `json:"thumbnail_set"`
+	VideoID       string     `json:"video_id,omitempty"`
+	VideoURL      string     `json:"video_url,omitempty"`
+}
+
+type VideoGenerationStatus struct {
+	CompletedAt *time.Time   `json:"completed_at,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+	ErrorMsg    string       `json:"error_msg,omitempty"`
+	ID          string       `json:"id"`
+	ProcessID   string       `json:"process_id,omitempty"`
+	Progress    int          `json:"progress"`
+	RequestData VideoRequest `json:"request_data"`
+	ScriptID    string       `json:"script_id"`
+	Status      string       `json:"status"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+	VideoID     string       `json:"video_id,omitempty"`
+	VideoURL    string       `json:"video_url,omitempty"`
+}
+
+type VideoJobResponse struct {
+	CheckURL  string `json:"check_url,omitempty"`
+	Message   string `json:"message"`
+	ProcessID string `json:"process_id,omitempty"`
+	Progress  *int   `json:"progress,omitempty"`
+	ScriptID  string `json:"script_id,omitempty"`
+	Status    string `json:"status"`
+	StatusID  string `json:"status_id,omitempty"`
+}
+
+type VideoRequest struct {
+	Audio      AudioConfig    `json:"audio"`
+	Background string         `json:"background"`
+	Duration   float64        `json:"duration"`
+	Height     int            `json:"height"`
+	Images     []ImageAsset   `json:"images"`
+	Scenes     []Scene        `json:"scenes"`
+	Subtitles  SubtitleConfig `json:"subtitles"`
+	Title      string         `json:"title"`
+	Width      int            `json:"width"`
+}
+
+type VisualPromptResponse struct {
+	EndTime   string `json:"end_time"`
+	Prompt    string `json:"prompt"`
+	StartTime string `json:"start_time"`
+}
+
+type VisualPromptsRequest struct {
+	Force    bool   `json:"force,omitempty"`
+	ScriptID string `json:"script_id"`
+	StyleID  string `json:"style_id"`
+}
+
+type VisualStyle struct {
+	Category       string    `json:"category"`
+	CreatedAt      time.Time `json:"created_at"`
+	Description    string    `json:"description"`
+	ID             string    `json:"id"`
+	IsActive       bool      `json:"is_active"`
+	Name           string    `json:"name"`
+	PromptTemplate string    `json:"prompt_template"`
+	StyleRules     []string  `json:"style_rules"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type VisualStyleListResponse struct {
+	Data    []VisualStyle `json:"data"`
+	Success bool          `json:"success"`
+}
+
+type VisualStyleRequest struct {
+	Category       string   `json:"category"`
+	Description    string   `json:"description"`
+	Name           string   `json:"name"`
+	PromptTemplate string   `json:"prompt_template"`
+	StyleRules     []string `json:"style_rules"`
+}
+
+type VisualStyleResponse struct {
+	Data    VisualStyle `json:"data"`
+	Success bool        `json:"success"`
+}
+
+type YouTubeAuthResponse struct {
+	AuthURL string `json:"auth_url"`
+}
+
+type YouTubeAuthorizedData struct {
+	Channel string    `json:"channel"`
+	Expiry  time.Time `json:"expiry"`
+	Scope   string    `json:"scope"`
+}
+
+type YouTubeAuthorizedResponse struct {
+	Data    YouTubeAuthorizedData `json:"data"`
+	Message string                `json:"message"`
+}
+
+// ListAPIKeysParams holds the query parameters of ListAPIKeys
+type ListAPIKeysParams struct {
+	Provider string // Only this provider's keys
+}
+
+func (p *ListAPIKeysParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	if p.Provider != "" {
+		q.Set("provider", p.Provider)
+	}
+	return q
+}
+
+// ListAPIKeys calls GET /api-keys: List provider keys by fingerprint, with usage, errors and health. Requires the admin scope.
+func (c *Client) ListAPIKeys(ctx context.Context, params *ListAPIKeysParams) (*APIKeyListResponse, error) {
+	var out APIKeyListResponse
+	if err := c.do(ctx, "GET", "/api-keys", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateAPIKey calls POST /api-keys: Add a key to a provider's pool. Requires the admin scope.
+func (c *Client) CreateAPIKey(ctx context.Context, req *APIKeyCreateRequest) (*APIKeyResponse, error) {
+	var out APIKeyResponse
+	if err := c.do(ctx, "POST", "/api-keys", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetAPIKey calls GET /api-keys/{id}: Get a provider key. Requires the admin scope.
+func (c *Client) GetAPIKey(ctx context.Context, id string) (*APIKeyResponse, error) {
+	var out APIKeyResponse
+	if err := c.do(ctx, "GET", "/api-keys/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateAPIKey calls PATCH /api-keys/{id}: Replace a key's value, keeping its history. Requires the admin scope.
+func (c *Client) UpdateAPIKey(ctx context.Context, id string, req *APIKeyUpdateRequest) (*APIKeyResponse, error) {
+	var out APIKeyResponse
+	if err := c.do(ctx, "PATCH", "/api-keys/"+url.PathEscape(id), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteAPIKey calls DELETE /api-keys/{id}: Delete a provider key. Requires the admin scope.
+func (c *Client) DeleteAPIKey(ctx context.Context, id string) (*APIKeyResponse, error) {
+	var out APIKeyResponse
+	if err := c.do(ctx, "DELETE", "/api-keys/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DisableAPIKey calls POST /api-keys/{id}/disable: Take a key out of the pool. Requires the admin scope.
+func (c *Client) DisableAPIKey(ctx context.Context, id string) (*APIKeyResponse, error) {
+	var out APIKeyResponse
+	if err := c.do(ctx, "POST", "/api-keys/"+url.PathEscape(id)+"/disable", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// EnableAPIKey calls POST /api-keys/{id}/enable: Return a key to the pool, clearing any cooldown. Requires the admin scope.
+func (c *Client) EnableAPIKey(ctx context.Context, id string) (*APIKeyResponse, error) {
+	var out APIKeyResponse
+	if err := c.do(ctx, "POST", "/api-keys/"+url.PathEscape(id)+"/enable", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListBatchesParams holds the query parameters of ListBatches
+type ListBatchesParams struct {
+	ChannelName string
+	Status      string
+}
+
+func (p *ListBatchesParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	if p.ChannelName != "" {
+		q.Set("channel_name", p.ChannelName)
+	}
+	if p.Status != "" {
+		q.Set("status", p.Status)
+	}
+	return q
+}
+
+// ListBatches calls GET /batches: List batches without their items. Requires the read scope.
+func (c *Client) ListBatches(ctx context.Context, params *ListBatchesParams) (*BatchListResponse, error) {
+	var out BatchListResponse
+	if err := c.do(ctx, "GET", "/batches", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateBatch calls POST /batches: Schedule a batch of scripts. Requires the generate scope.
+func (c *Client) CreateBatch(ctx context.Context, req *BatchRequest) (*BatchResponse, error) {
+	var out BatchResponse
+	if err := c.do(ctx, "POST", "/batches", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetBatch calls GET /batches/{id}: Get a batch and its progress. Requires the read scope.
+func (c *Client) GetBatch(ctx context.Context, id string) (*BatchResponse, error) {
+	var out BatchResponse
+	if err := c.do(ctx, "GET", "/batches/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CancelBatch calls POST /batches/{id}/cancel: Cancel the items that have not started. Requires the generate scope.
+func (c *Client) CancelBatch(ctx context.Context, id string) (*BatchResponse, error) {
+	var out BatchResponse
+	if err := c.do(ctx, "POST", "/batches/"+url.PathEscape(id)+"/cancel", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetChannel calls GET /channels/{name}: Get a channel. Requires the read scope.
+func (c *Client) GetChannel(ctx context.Context, name string) (*Channel, error) {
+	var out Channel
+	if err := c.do(ctx, "GET", "/channels/"+url.PathEscape(name), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListChannelScriptsParams holds the query parameters of ListChannelScripts
+type ListChannelScriptsParams struct {
+	Status string // Comma-separated statuses
+	From   string // Created on or after, YYYY-MM-DD or RFC3339
+	To     string // Created on or before, YYYY-MM-DD or RFC3339
+	Q      string // Case-insensitive match on the topic
+	Fields string // Comma-separated fields to return
+	Sort   string
+	Order  string
+	Limit  int    // Page size, at most 100
+	Cursor string // next_cursor of the previous page
+}
+
+func (p *ListChannelScriptsParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	if p.Status != "" {
+		q.Set("status", p.Status)
+	}
+	if p.From != "" {
+		q.Set("from", p.From)
+	}
+	if p.To != "" {
+		q.Set("to", p.To)
+	}
+	if p.Q != "" {
+		q.Set("q", p.Q)
+	}
+	if p.Fields != "" {
+		q.Set("fields", p.Fields)
+	}
+	if p.Sort != "" {
+		q.Set("sort", p.Sort)
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.Itoa(p.Limit))
+	}
+	if p.Cursor != "" {
+		q.Set("cursor", p.Cursor)
+	}
+	return q
+}
+
+// ListChannelScripts calls GET /channels/{name}/scripts: List a channel's scripts. Requires the read scope.
+func (c *Client) ListChannelScripts(ctx context.Context, name string, params *ListChannelScriptsParams) (*ScriptListResponse, error) {
+	var out ScriptListResponse
+	if err := c.do(ctx, "GET", "/channels/"+url.PathEscape(name)+"/scripts", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetThumbnailLayout calls GET /channels/{name}/thumbnail-layout: Get a channel's thumbnail layout. Requires the read scope.
+func (c *Client) GetThumbnailLayout(ctx context.Context, name string) (*ThumbnailLayout, error) {
+	var out ThumbnailLayout
+	if err := c.do(ctx, "GET", "/channels/"+url.PathEscape(name)+"/thumbnail-layout", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateThumbnailLayout calls PUT /channels/{name}/thumbnail-layout: Replace a channel's thumbnail layout. Requires the admin scope.
+func (c *Client) UpdateThumbnailLayout(ctx context.Context, name string, req *ThumbnailLayout) (*ThumbnailLayout, error) {
+	var out ThumbnailLayout
+	if err := c.do(ctx, "PUT", "/channels/"+url.PathEscape(name)+"/thumbnail-layout", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListTopicsParams holds the query parameters of ListTopics
+type ListTopicsParams struct {
+	Status string
+}
+
+func (p *ListTopicsParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	if p.Status != "" {
+		q.Set("status", p.Status)
+	}
+	return q
+}
+
+// ListTopics calls GET /channels/{name}/topics: List the topic backlog. Requires the read scope.
+func (c *Client) ListTopics(ctx context.Context, name string, params *ListTopicsParams) (*TopicListResponse, error) {
+	var out TopicListResponse
+	if err := c.do(ctx, "GET", "/channels/"+url.PathEscape(name)+"/topics", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateTopic calls POST /channels/{name}/topics: Add a topic to the backlog. Requires the generate scope.
+func (c *Client) CreateTopic(ctx context.Context, name string, req *TopicCreateRequest) (*TopicResponse, error) {
+	var out TopicResponse
+	if err := c.do(ctx, "POST", "/channels/"+url.PathEscape(name)+"/topics", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// IdeateTopics calls POST /channels/{name}/topics/ideate: Generate topic ideas into the backlog. Requires the generate scope.
+func (c *Client) IdeateTopics(ctx context.Context, name string, req *TopicIdeationRequest) (*TopicIdeationResponse, error) {
+	var out TopicIdeationResponse
+	if err := c.do(ctx, "POST", "/channels/"+url.PathEscape(name)+"/topics/ideate", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetChannelVoice calls GET /channels/{name}/voice: Get a channel's text-to-speech provider and voice. Requires the read scope.
+func (c *Client) GetChannelVoice(ctx context.Context, name string) (*ChannelVoice, error) {
+	var out ChannelVoice
+	if err := c.do(ctx, "GET", "/channels/"+url.PathEscape(name)+"/voice", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateChannelVoice calls PUT /channels/{name}/voice: Set a channel's text-to-speech provider and voice. Requires the admin scope.
+func (c *Client) UpdateChannelVoice(ctx context.Context, name string, req *ChannelVoice) (*ChannelVoice, error) {
+	var out ChannelVoice
+	if err := c.do(ctx, "PUT", "/channels/"+url.PathEscape(name)+"/voice", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetYouTubeAuthURL calls GET /channels/{name}/youtube/auth: Get the URL that authorizes uploads for a channel. Requires the admin scope.
+func (c *Client) GetYouTubeAuthURL(ctx context.Context, name string) (*YouTubeAuthResponse, error) {
+	var out YouTubeAuthResponse
+	if err := c.do(ctx, "GET", "/channels/"+url.PathEscape(name)+"/youtube/auth", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CheckMissingSRTRangesParams holds the query parameters of CheckMissingSRTRanges
+type CheckMissingSRTRangesParams struct {
+	ScriptID string
+}
+
+func (p *CheckMissingSRTRangesParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	if p.ScriptID != "" {
+		q.Set("script_id", p.ScriptID)
+	}
+	return q
+}
+
+// CheckMissingSRTRanges calls GET /check-missing-srt-ranges: Check that visual prompts cover the whole subtitle track. Requires the read scope.
+func (c *Client) CheckMissingSRTRanges(ctx context.Context, params *CheckMissingSRTRangesParams) (*SRTCoverageResponse, error) {
+	var out SRTCoverageResponse
+	if err := c.do(ctx, "GET", "/check-missing-srt-ranges", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetConfig calls GET /config: Configuration in effect, secrets redacted. Requires the admin scope.
+func (c *Client) GetConfig(ctx context.Context) (*ConfigResponse, error) {
+	var out ConfigResponse
+	if err := c.do(ctx, "GET", "/config", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GenerateAudio calls POST /generate-audio/{id}: Generate the voiceover. Requires the generate scope.
+func (c *Client) GenerateAudio(ctx context.Context, id string) (*StepResponse, error) {
+	var out StepResponse
+	if err := c.do(ctx, "POST", "/generate-audio/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GenerateChapters calls POST /generate-chapters/{id}: Add chapter timestamps to the description. Requires the generate scope.
+func (c *Client) GenerateChapters(ctx context.Context, id string) (*ChaptersResponse, error) {
+	var out ChaptersResponse
+	if err := c.do(ctx, "POST", "/generate-chapters/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GenerateScript calls POST /generate-script: Start generating a script. Requires the generate scope.
+func (c *Client) GenerateScript(ctx context.Context, req *ScriptRequest) (*ScriptResponse, error) {
+	var out ScriptResponse
+	if err := c.do(ctx, "POST", "/generate-script", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GenerateSubtitles calls POST /generate-subtitle/{id}: Generate subtitles from the voiceover. Requires the generate scope.
+func (c *Client) GenerateSubtitles(ctx context.Context, id string) (*StepResponse, error) {
+	var out StepResponse
+	if err := c.do(ctx, "POST", "/generate-subtitle/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GenerateThumbnails calls POST /generate-thumbnails/{id}: Render thumbnail variants. Requires the generate scope.
+func (c *Client) GenerateThumbnails(ctx context.Context, id string, req *ThumbnailRequest) (*ThumbnailsResponse, error) {
+	var out ThumbnailsResponse
+	if err := c.do(ctx, "POST", "/generate-thumbnails/"+url.PathEscape(id), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GenerateVideo calls POST /generate-video/{id}: Start rendering the video. Requires the generate scope.
+func (c *Client) GenerateVideo(ctx context.Context, id string) (*VideoJobResponse, error) {
+	var out VideoJobResponse
+	if err := c.do(ctx, "POST", "/generate-video/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GenerateVisualImages calls POST /generate-visual-images/{id}: Start generating images for the visual prompts. Requires the generate scope.
+func (c *Client) GenerateVisualImages(ctx context.Context, id string) (*StepResponse, error) {
+	var out StepResponse
+	if err := c.do(ctx, "POST", "/generate-visual-images/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GenerateVisualPrompts calls POST /generate-visual-prompts-with-style: Start generating visual prompts in a style. Requires the generate scope.
+func (c *Client) GenerateVisualPrompts(ctx context.Context, req *VisualPromptsRequest) (*StepResponse, error) {
+	var out StepResponse
+	if err := c.do(ctx, "POST", "/generate-visual-prompts-with-style", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Health calls GET /health: Health check
+func (c *Client) Health(ctx context.Context) (*HealthResponse, error) {
+	var out HealthResponse
+	if err := c.do(ctx, "GET", "/health", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetMetrics calls GET /metrics: Prometheus metrics
+func (c *Client) GetMetrics(ctx context.Context) (io.ReadCloser, error) {
+	return c.stream(ctx, "GET", "/metrics", nil, nil)
+}
+
+// YoutubeOAuthCallbackParams holds the query parameters of YoutubeOAuthCallback
+type YoutubeOAuthCallbackParams struct {
+	Code  string
+	State string
+	Error string
+}
+
+func (p *YoutubeOAuthCallbackParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	if p.Code != "" {
+		q.Set("code", p.Code)
+	}
+	if p.State != "" {
+		q.Set("state", p.State)
+	}
+	if p.Error != "" {
+		q.Set("error", p.Error)
+	}
+	return q
+}
+
+// YoutubeOAuthCallback calls GET /oauth/youtube/callback: OAuth redirect target, opened by the browser
+func (c *Client) YoutubeOAuthCallback(ctx context.Context, params *YoutubeOAuthCallbackParams) (*YouTubeAuthorizedResponse, error) {
+	var out YouTubeAuthorizedResponse
+	if err := c.do(ctx, "GET", "/oauth/youtube/callback", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetOpenAPISpec calls GET /openapi.json: This document
+func (c *Client) GetOpenAPISpec(ctx context.Context) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	if err := c.do(ctx, "GET", "/openapi.json", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CreatePromptTemplate calls POST /prompt-templates: Create a prompt template. Requires the admin scope.
+func (c *Client) CreatePromptTemplate(ctx context.Context, req *PromptTemplateRequest) (*PromptTemplateResponse, error) {
+	var out PromptTemplateResponse
+	if err := c.do(ctx, "POST", "/prompt-templates", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListPromptTemplatesParams holds the query parameters of ListPromptTemplates
+type ListPromptTemplatesParams struct {
+	ChannelID string // Include this channel's templates
+	Type      string
+}
+
+func (p *ListPromptTemplatesParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	if p.ChannelID != "" {
+		q.Set("channel_id", p.ChannelID)
+	}
+	if p.Type != "" {
+		q.Set("type", p.Type)
+	}
+	return q
+}
+
+// ListPromptTemplates calls GET /prompt-templates/list: List active prompt templates. Requires the read scope.
+func (c *Client) ListPromptTemplates(ctx context.Context, params *ListPromptTemplatesParams) (*PromptTemplateListResponse, error) {
+	var out PromptTemplateListResponse
+	if err := c.do(ctx, "GET", "/prompt-templates/list", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Ready calls GET /ready: Check every external dependency; 503 with the same body when one fails
+func (c *Client) Ready(ctx context.Context) (*ReadyResponse, error) {
+	var out ReadyResponse
+	if err := c.do(ctx, "GET", "/ready", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListScriptsParams holds the query parameters of ListScripts
+type ListScriptsParams struct {
+	Channel string // Comma-separated channel names
+	Status  string // Comma-separated statuses
+	From    string // Created on or after, YYYY-MM-DD or RFC3339
+	To      string // Created on or before, YYYY-MM-DD or RFC3339
+	Q       string // Case-insensitive match on the topic
+	Fields  string // Comma-separated fields to return
+	Sort    string
+	Order   string
+	Limit   int    // Page size, at most 100
+	Cursor  string // next_cursor of the previous page
+}
+
+func (p *ListScriptsParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	if p.Channel != "" {
+		q.Set("channel", p.Channel)
+	}
+	if p.Status != "" {
+		q.Set("status", p.Status)
+	}
+	if p.From != "" {
+		q.Set("from", p.From)
+	}
+	if p.To != "" {
+		q.Set("to", p.To)
+	}
+	if p.Q != "" {
+		q.Set("q", p.Q)
+	}
+	if p.Fields != "" {
+		q.Set("fields", p.Fields)
+	}
+	if p.Sort != "" {
+		q.Set("sort", p.Sort)
+	}
+	if p.Order != "" {
+		q.Set("order", p.Order)
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.Itoa(p.Limit))
+	}
+	if p.Cursor != "" {
+		q.Set("cursor", p.Cursor)
+	}
+	return q
+}
+
+// ListScripts calls GET /scripts: List scripts across channels. Requires the read scope.
+func (c *Client) ListScripts(ctx context.Context, params *ListScriptsParams) (*ScriptListResponse, error) {
+	var out ScriptListResponse
+	if err := c.do(ctx, "GET", "/scripts", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListScriptChunks calls GET /scripts-chunks/{id}: List a script's narration chunks. Requires the read scope.
+func (c *Client) ListScriptChunks(ctx context.Context, id string) (*ScriptChunksResponse, error) {
+	var out ScriptChunksResponse
+	if err := c.do(ctx, "GET", "/scripts-chunks/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ImportScriptParams holds the query parameters of ImportScript
+type ImportScriptParams struct {
+	ChannelName  string // Raw bodies only
+	Topic        string // Raw bodies only
+	Format       string // Raw bodies only: text or markdown
+	GenerateMeta bool   // Raw bodies only
+}
+
+func (p *ImportScriptParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	if p.ChannelName != "" {
+		q.Set("channel_name", p.ChannelName)
+	}
+	if p.Topic != "" {
+		q.Set("topic", p.Topic)
+	}
+	if p.Format != "" {
+		q.Set("format", p.Format)
+	}
+	if p.GenerateMeta {
+		q.Set("generate_meta", "true")
+	}
+	return q
+}
+
+// ImportScript calls POST /scripts/import: Import an existing script. Requires the generate scope.
+func (c *Client) ImportScript(ctx context.Context, params *ImportScriptParams, req *ScriptImportRequest) (*ScriptImportResponse, error) {
+	var out ScriptImportResponse
+	if err := c.do(ctx, "POST", "/scripts/import", params.values(), req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetScript calls GET /scripts/{id}: Get a script and its status. Requires the read scope.
+func (c *Client) GetScript(ctx context.Context, id string) (*Script, error) {
+	var out Script
+	if err := c.do(ctx, "GET", "/scripts/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ExportScript calls GET /scripts/{id}/export: Download the project package. Requires the read scope.
+func (c *Client) ExportScript(ctx context.Context, id string) (io.ReadCloser, error) {
+	return c.stream(ctx, "GET", "/scripts/"+url.PathEscape(id)+"/export", nil, nil)
+}
+
+// ExportTimeline calls GET /scripts/{id}/export/{format}: Export the timeline for an editor. Requires the read scope.
+func (c *Client) ExportTimeline(ctx context.Context, id string, format string) (io.ReadCloser, error) {
+	return c.stream(ctx, "GET", "/scripts/"+url.PathEscape(id)+"/export/"+url.PathEscape(format), nil, nil)
+}
+
+// GetScriptLogsParams holds the query parameters of GetScriptLogs
+type GetScriptLogsParams struct {
+	Stage string // Only lines from this stage
+	Level string // Minimum level
+	Limit int    // Newest lines to return, at most 2000
+}
+
+func (p *GetScriptLogsParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	if p.Stage != "" {
+		q.Set("stage", p.Stage)
+	}
+	if p.Level != "" {
+		q.Set("level", p.Level)
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.Itoa(p.Limit))
+	}
+	return q
+}
+
+// GetScriptLogs calls GET /scripts/{id}/logs: Get the pipeline log lines stored for a script. Requires the read scope.
+func (c *Client) GetScriptLogs(ctx context.Context, id string, params *GetScriptLogsParams) (*ScriptLogsResponse, error) {
+	var out ScriptLogsResponse
+	if err := c.do(ctx, "GET", "/scripts/"+url.PathEscape(id)+"/logs", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SearchParams holds the query parameters of Search
+type SearchParams struct {
+	Q       string
+	Channel string // Comma-separated channel names
+	Limit   int    // At most 100
+}
+
+func (p *SearchParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	if p.Q != "" {
+		q.Set("q", p.Q)
+	}
+	if p.Channel != "" {
+		q.Set("channel", p.Channel)
+	}
+	if p.Limit != 0 {
+		q.Set("limit", strconv.Itoa(p.Limit))
+	}
+	return q
+}
+
+// Search calls GET /search: Full-text search across scripts. Requires the read scope.
+func (c *Client) Search(ctx context.Context, params *SearchParams) (*SearchResponse, error) {
+	var out SearchResponse
+	if err := c.do(ctx, "GET", "/search", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListThumbnails calls GET /thumbnails/{id}: List a script's thumbnails. Requires the read scope.
+func (c *Client) ListThumbnails(ctx context.Context, id string) (*ThumbnailListResponse, error) {
+	var out ThumbnailListResponse
+	if err := c.do(ctx, "GET", "/thumbnails/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetThumbnail calls GET /thumbnails/{id}/{variant}: Download a thumbnail. Requires the read scope.
+func (c *Client) GetThumbnail(ctx context.Context, id string, variant string) (io.ReadCloser, error) {
+	return c.stream(ctx, "GET", "/thumbnails/"+url.PathEscape(id)+"/"+url.PathEscape(variant), nil, nil)
+}
+
+// GetTopic calls GET /topics/{id}: Get a topic. Requires the read scope.
+func (c *Client) GetTopic(ctx context.Context, id string) (*TopicResponse, error) {
+	var out TopicResponse
+	if err := c.do(ctx, "GET", "/topics/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateTopic calls PATCH /topics/{id}: Edit, approve or reject a topic. Requires the generate scope.
+func (c *Client) UpdateTopic(ctx context.Context, id string, req *TopicUpdateRequest) (*TopicResponse, error) {
+	var out TopicResponse
+	if err := c.do(ctx, "PATCH", "/topics/"+url.PathEscape(id), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteTopic calls DELETE /topics/{id}: Delete a topic. Requires the generate scope.
+func (c *Client) DeleteTopic(ctx context.Context, id string) (*TopicResponse, error) {
+	var out TopicResponse
+	if err := c.do(ctx, "DELETE", "/topics/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GenerateScriptFromTopic calls POST /topics/{id}/generate: Start generating a script from a topic. Requires the generate scope.
+func (c *Client) GenerateScriptFromTopic(ctx context.Context, id string, req *ScriptRequest) (*ScriptResponse, error) {
+	var out ScriptResponse
+	if err := c.do(ctx, "POST", "/topics/"+url.PathEscape(id)+"/generate", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListTTSProviders calls GET /tts/providers: List text-to-speech providers with their formats and remaining quota. Requires the read scope.
+func (c *Client) ListTTSProviders(ctx context.Context) (*TTSProvidersResponse, error) {
+	var out TTSProvidersResponse
+	if err := c.do(ctx, "GET", "/tts/providers", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListTTSVoicesParams holds the query parameters of ListTTSVoices
+type ListTTSVoicesParams struct {
+	Provider string // Defaults to voice.provider
+}
+
+func (p *ListTTSVoicesParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	if p.Provider != "" {
+		q.Set("provider", p.Provider)
+	}
+	return q
+}
+
+// ListTTSVoices calls GET /tts/voices: List a text-to-speech provider's voices. Requires the read scope.
+func (c *Client) ListTTSVoices(ctx context.Context, params *ListTTSVoicesParams) (*TTSVoicesResponse, error) {
+	var out TTSVoicesResponse
+	if err := c.do(ctx, "GET", "/tts/voices", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UploadVideo calls POST /upload-video/{id}: Publish the video to YouTube. Requires the generate scope.
+func (c *Client) UploadVideo(ctx context.Context, id string, req *PublishRequest) (*UploadResponse, error) {
+	var out UploadResponse
+	if err := c.do(ctx, "POST", "/upload-video/"+url.PathEscape(id), nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetVideoStatus calls GET /video-status/{id}: Get video rendering progress. Requires the read scope.
+func (c *Client) GetVideoStatus(ctx context.Context, id string) (*VideoGenerationStatus, error) {
+	var out VideoGenerationStatus
+	if err := c.do(ctx, "GET", "/video-status/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateVisualStyle calls POST /visual-styles: Create a visual style. Requires the admin scope.
+func (c *Client) CreateVisualStyle(ctx context.Context, req *VisualStyleRequest) (*VisualStyleResponse, error) {
+	var out VisualStyleResponse
+	if err := c.do(ctx, "POST", "/visual-styles", nil, req, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListVisualStylesParams holds the query parameters of ListVisualStyles
+type ListVisualStylesParams struct {
+	Category string
+}
+
+func (p *ListVisualStylesParams) values() url.Values {
+	q := url.Values{}
+	if p == nil {
+		return q
+	}
+	if p.Category != "" {
+		q.Set("category", p.Category)
+	}
+	return q
+}
+
+// ListVisualStyles calls GET /visual-styles/list: List active visual styles. Requires the read scope.
+func (c *Client) ListVisualStyles(ctx context.Context, params *ListVisualStylesParams) (*VisualStyleListResponse, error) {
+	var out VisualStyleListResponse
+	if err := c.do(ctx, "GET", "/visual-styles/list", params.values(), nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
@@ -0,0 +1,331 @@
+// Command gen writes the client's types and endpoint methods from the OpenAPI document
+// served by script-writer.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Format               string             `json:"format"`
+	Nullable             bool               `json:"nullable"`
+	Items                *schema            `json:"items"`
+	Properties           map[string]*schema `json:"properties"`
+	AdditionalProperties *schema            `json:"additionalProperties"`
+	Required             []string           `json:"required"`
+	AllOf                []*schema          `json:"allOf"`
+	Enum                 []string           `json:"enum"`
+}
+
+type parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Required    bool    `json:"required"`
+	Description string  `json:"description"`
+	Schema      *schema `json:"schema"`
+}
+
+type mediaType struct {
+	Schema *schema `json:"schema"`
+}
+
+type operation struct {
+	OperationID string                                  `json:"operationId"`
+	Summary     string                                  `json:"summary"`
+	Description string                                  `json:"description"`
+	Parameters  []parameter                             `json:"parameters"`
+	RequestBody *struct{ Content map[string]mediaType } `json:"requestBody"`
+	Responses   map[string]struct {
+		Content map[string]mediaType `json:"content"`
+	} `json:"responses"`
+}
+
+type document struct {
+	Paths      map[string]map[string]operation `json:"paths"`
+	Components struct {
+		Schemas map[string]*schema `json:"schemas"`
+	} `json:"components"`
+}
+
+var initialisms = map[string]string{
+	"id": "ID", "url": "URL", "uri": "URI", "srt": "SRT", "api": "API", "json": "JSON", "mongodb": "MongoDB",
+}
+
+// exportedName turns snake_case and camelCase into a Go identifier
+func exportedName(name string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(name, "_") {
+		if part == "" {
+			continue
+		}
+		if initialism, ok := initialisms[part]; ok {
+			b.WriteString(initialism)
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+func unexportedName(name string) string {
+	exported := exportedName(name)
+	if initialism, ok := initialisms[name]; ok && initialism == exported {
+		return strings.ToLower(exported)
+	}
+	return strings.ToLower(exported[:1]) + exported[1:]
+}
+
+func refName(ref string) string {
+	return ref[strings.LastIndex(ref, "/")+1:]
+}
+
+func goType(s *schema) string {
+	if s == nil {
+		return "interface{}"
+	}
+	if s.Ref != "" {
+		return refName(s.Ref)
+	}
+	if len(s.AllOf) == 1 {
+		return goType(s.AllOf[0])
+	}
+	switch s.Type {
+	case "string":
+		switch s.Format {
+		case "date-time":
+			return "time.Time"
+		case "byte", "binary":
+			return "[]byte"
+		}
+		return "string"
+	case "integer":
+		return "int"
+	case "number":
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "array":
+		return "[]" + goType(s.Items)
+	case "object":
+		if s.AdditionalProperties != nil {
+			return "map[string]" + goType(s.AdditionalProperties)
+		}
+		return "map[string]interface{}"
+	}
+	return "interface{}"
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func writeTypes(b *bytes.Buffer, schemas map[string]*schema) {
+	for _, name := range sortedKeys(schemas) {
+		s := schemas[name]
+		fmt.Fprintf(b, "type %s struct {\n", name)
+		for _, prop := range sortedKeys(s.Properties) {
+			field := s.Properties[prop]
+			typ := goType(field)
+			tag := prop
+			if !contains(s.Required, prop) {
+				tag += ",omitempty"
+			}
+			if field.Nullable {
+				typ = "*" + typ
+			}
+			fmt.Fprintf(b, "\t%s %s `json:%q`\n", exportedName(prop), typ, tag)
+		}
+		b.WriteString("}\n\n")
+	}
+}
+
+var pathVarRegex = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// pathExpr builds the Go expression for a path template, escaping each parameter
+func pathExpr(path string) string {
+	var parts []string
+	last := 0
+	for _, match := range pathVarRegex.FindAllStringSubmatchIndex(path, -1) {
+		if match[0] > last {
+			parts = append(parts, fmt.Sprintf("%q", path[last:match[0]]))
+		}
+		parts = append(parts, fmt.Sprintf("url.PathEscape(%s)", unexportedName(path[match[2]:match[3]])))
+		last = match[1]
+	}
+	if last < len(path) {
+		parts = append(parts, fmt.Sprintf("%q", path[last:]))
+	}
+	return strings.Join(parts, " + ")
+}
+
+func successResponse(op operation) (jsonType string, binary bool) {
+	for _, status := range sortedKeys(op.Responses) {
+		if !strings.HasPrefix(status, "2") {
+			continue
+		}
+		// Only pure JSON responses are decoded; files come back as a stream
+		content := op.Responses[status].Content
+		if media, ok := content["application/json"]; ok && len(content) == 1 {
+			return goType(media.Schema), false
+		}
+		return "", true
+	}
+	return "", false
+}
+
+func writeOperation(b *bytes.Buffer, method, path string, op operation) {
+	name := exportedName(op.OperationID)
+
+	var pathParams, queryParams []parameter
+	for _, param := range op.Parameters {
+		if param.In == "path" {
+			pathParams = append(pathParams, param)
+		} else if param.In == "query" {
+			queryParams = append(queryParams, param)
+		}
+	}
+
+	paramsType := name + "Params"
+	if len(queryParams) > 0 {
+		fmt.Fprintf(b, "// %s holds the query parameters of %s\n", paramsType, name)
+		fmt.Fprintf(b, "type %s struct {\n", paramsType)
+		for _, param := range queryParams {
+			comment := ""
+			if param.Description != "" {
+				comment = " // " + param.Description
+			}
+			fmt.Fprintf(b, "\t%s %s%s\n", exportedName(param.Name), goType(param.Schema), comment)
+		}
+		b.WriteString("}\n\n")
+
+		fmt.Fprintf(b, "func (p *%s) values() url.Values {\n\tq := url.Values{}\n\tif p == nil {\n\t\treturn q\n\t}\n", paramsType)
+		for _, param := range queryParams {
+			field := "p." + exportedName(param.Name)
+			switch goType(param.Schema) {
+			case "int":
+				fmt.Fprintf(b, "\tif %s != 0 {\n\t\tq.Set(%q, strconv.Itoa(%s))\n\t}\n", field, param.Name, field)
+			case "bool":
+				fmt.Fprintf(b, "\tif %s {\n\t\tq.Set(%q, \"true\")\n\t}\n", field, param.Name)
+			default:
+				fmt.Fprintf(b, "\tif %s != \"\" {\n\t\tq.Set(%q, %s)\n\t}\n", field, param.Name, field)
+			}
+		}
+		b.WriteString("\treturn q\n}\n\n")
+	}
+
+	args := []string{"ctx context.Context"}
+	for _, param := range pathParams {
+		args = append(args, unexportedName(param.Name)+" string")
+	}
+	query := "nil"
+	if len(queryParams) > 0 {
+		args = append(args, "params *"+paramsType)
+		query = "params.values()"
+	}
+	body := "nil"
+	if op.RequestBody != nil {
+		if media, ok := op.RequestBody.Content["application/json"]; ok {
+			args = append(args, "req *"+goType(media.Schema))
+			body = "req"
+		}
+	}
+
+	summary := op.Summary
+	if op.Description != "" {
+		summary += ". " + op.Description
+	}
+	fmt.Fprintf(b, "// %s calls %s %s: %s\n", name, method, path, summary)
+
+	jsonType, binary := successResponse(op)
+	switch {
+	case binary:
+		fmt.Fprintf(b, "func (c *Client) %s(%s) (io.ReadCloser, error) {\n", name, strings.Join(args, ", "))
+		fmt.Fprintf(b, "\treturn c.stream(ctx, %q, %s, %s, %s)\n}\n\n", method, pathExpr(path), query, body)
+	default:
+		if jsonType == "" {
+			jsonType = "map[string]interface{}"
+		}
+		// Maps and slices are returned as they are, structs by pointer
+		result, ret := "*"+jsonType, "&out"
+		if strings.HasPrefix(jsonType, "map[") || strings.HasPrefix(jsonType, "[]") {
+			result, ret = jsonType, "out"
+		}
+		fmt.Fprintf(b, "func (c *Client) %s(%s) (%s, error) {\n", name, strings.Join(args, ", "), result)
+		fmt.Fprintf(b, "\tvar out %s\n", jsonType)
+		fmt.Fprintf(b, "\tif err := c.do(ctx, %q, %s, %s, %s, &out); err != nil {\n\t\treturn nil, err\n\t}\n", method, pathExpr(path), query, body)
+		fmt.Fprintf(b, "\treturn %s, nil\n}\n\n", ret)
+	}
+}
+
+func main() {
+	in := flag.String("in", "openapi.json", "OpenAPI document")
+	out := flag.String("out", "api.go", "generated Go file")
+	flag.Parse()
+
+	data, err := os.ReadFile(*in)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	var doc document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		fmt.Fprintf(os.Stderr, "parsing %s: %v\n", *in, err)
+		os.Exit(1)
+	}
+
+	var body bytes.Buffer
+	writeTypes(&body, doc.Components.Schemas)
+
+	for _, path := range sortedKeys(doc.Paths) {
+		for _, method := range []string{"get", "post", "put", "patch", "delete"} {
+			if op, ok := doc.Paths[path][method]; ok {
+				writeOperation(&body, strings.ToUpper(method), path, op)
+			}
+		}
+	}
+
+	var b bytes.Buffer
+	b.WriteString("// Code generated by internal/gen from openapi.json. DO NOT EDIT.\n\n")
+	b.WriteString("package client\n\nimport (\n")
+	for _, pkg := range []string{"context", "io", "net/url", "strconv", "time"} {
+		if strings.Contains(body.String(), pkg[strings.LastIndex(pkg, "/")+1:]+".") {
+			fmt.Fprintf(&b, "\t%q\n", pkg)
+		}
+	}
+	b.WriteString(")\n\n")
+	b.Write(body.Bytes())
+
+	source, err := format.Source(b.Bytes())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "formatting generated code: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(*out, source, 0644); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,526 @@
+// File: config.go
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+	"youtube_automation/logging"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the whole service configuration. Defaults come from defaultConfig, then the
+// YAML file at CONFIG_FILE (config.yaml when unset, optional then), then environment
+// variables, which may come from an optional .env file.
+//
+// Field tags: env names the variable overriding the field, secret:"true" hides the value
+// in /config (secret:"url" only hides a URL's password) and reload:"true" marks settings
+// that SIGHUP may change in a running process. Everything else needs a restart.
+type Config struct {
+	Server     ServerConfig    `yaml:"server"`
+	Mongo      MongoConfig     `yaml:"mongo"`
+	AI         AIConfig        `yaml:"ai"`
+	Voice      VoiceConfig     `yaml:"voice"`
+	Images     HttpConfig      `yaml:"images"`
+	Services   ServicesConfig  `yaml:"services"`
+	RateLimits RateLimitConfig `yaml:"rate_limits"`
+	Batches    BatchConfig     `yaml:"batches"`
+	Thumbnails ThumbnailConfig `yaml:"thumbnails"`
+	Uploads    UploadConfig    `yaml:"uploads"`
+	Security   SecurityConfig  `yaml:"security"`
+}
+
+type ServerConfig struct {
+	Port               string        `yaml:"port" env:"PORT"`
+	AuthEnabled        bool          `yaml:"auth_enabled" env:"AUTH_ENABLED"`
+	CORSAllowedOrigins []string      `yaml:"cors_allowed_origins" env:"CORS_ALLOWED_ORIGINS" reload:"true"`
+	LogLevel           string        `yaml:"log_level" env:"LOG_LEVEL" reload:"true"`
+	ShutdownTimeout    time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" reload:"true"`
+	UserAgent          string        `yaml:"user_agent" env:"USER_AGENT" reload:"true"` // For the image, transcript and video APIs; each has its own default
+}
+
+type MongoConfig struct {
+	URI      string `yaml:"uri" env:"MONGODB_URI" secret:"url"`
+	Database string `yaml:"database" env:"MONGODB_DATABASE"`
+}
+
+type AIConfig struct {
+	Provider         AIProvider `yaml:"provider" env:"AI_PROVIDER"`
+	GeminiAPIKey     string     `yaml:"gemini_api_key" env:"GEMINI_API_KEY" secret:"true"`
+	OpenRouterAPIKey string     `yaml:"openrouter_api_key" env:"OPENROUTER_API_KEY" secret:"true"`
+}
+
+type VoiceConfig struct {
+	ElevenLabsAPIKey string `yaml:"elevenlabs_api_key" env:"ELEVENLABS_API_KEY" secret:"true"`   // Seeds api_keys when it is empty
+	Provider         string `yaml:"provider" env:"TTS_PROVIDER" reload:"true"`                   // For channels without settings.tts_provider
+	FallbackProvider string `yaml:"fallback_provider" env:"TTS_FALLBACK_PROVIDER" reload:"true"` // Voices a chunk the channel's provider failed, empty for none
+	VoiceID          string `yaml:"voice_id" env:"VOICE_ID" reload:"true"`                       // Default ElevenLabs voice
+	OfflineEngine    string `yaml:"offline_engine" env:"OFFLINE_TTS_ENGINE" reload:"true"`
+	OfflineBinary    string `yaml:"offline_binary" env:"OFFLINE_TTS_BINARY" reload:"true"`       // Defaults to the engine's name on PATH
+	OfflineVoice     string `yaml:"offline_voice" env:"OFFLINE_TTS_VOICE" reload:"true"`         // Default Piper model or espeak-ng voice
+	PiperModelDir    string `yaml:"piper_model_dir" env:"PIPER_MODEL_DIR" reload:"true"`         // Piper models are listed from and resolved against it
+	SplitCharLimit   int    `yaml:"split_char_limit" env:"VOICE_SPLIT_CHAR_LIMIT" reload:"true"` // Largest chunk sent to TTS at once
+	ProxyServer      string `yaml:"proxy_server" env:"PROXY_SERVER"`
+	ProxyUsername    string `yaml:"proxy_username" env:"PROXY_USERNAME"`
+	ProxyPassword    string `yaml:"proxy_password" env:"PROXY_PASSWORD" secret:"true"`
+}
+
+type ServicesConfig struct {
+	TranscriptURL string `yaml:"transcript_url" env:"TRANSCRIPT_SERVER_API_URL" reload:"true"`
+	VideoURL      string `yaml:"video_url" env:"VIDEO_SERVER_API_URL" reload:"true"`
+	VideoAPIKey   string `yaml:"video_api_key" env:"VIDEO_API_KEY" secret:"true" reload:"true"`
+}
+
+// RateLimitConfig holds the inbound limits; 0 disables a limit
+type RateLimitConfig struct {
+	TokenPerMinute   int `yaml:"token_per_minute" env:"RATE_LIMIT_TOKEN_PER_MINUTE" reload:"true"`
+	ChannelPerMinute int `yaml:"channel_per_minute" env:"RATE_LIMIT_CHANNEL_PER_MINUTE" reload:"true"`
+	JobsPerToken     int `yaml:"jobs_per_token" env:"JOB_LIMIT_PER_TOKEN" reload:"true"`
+	JobsPerChannel   int `yaml:"jobs_per_channel" env:"JOB_LIMIT_PER_CHANNEL" reload:"true"`
+}
+
+type BatchConfig struct {
+	SchedulerInterval time.Duration `yaml:"scheduler_interval" env:"BATCH_SCHEDULER_INTERVAL"`
+	ItemTimeout       time.Duration `yaml:"item_timeout" env:"BATCH_ITEM_TIMEOUT" reload:"true"`
+}
+
+type ThumbnailConfig struct {
+	FontFile  string `yaml:"font_file" env:"THUMBNAIL_FONT_FILE" reload:"true"`
+	OutputDir string `yaml:"output_dir" env:"THUMBNAIL_OUTPUT_DIR" reload:"true"`
+}
+
+type UploadConfig struct {
+	Uploader            string `yaml:"uploader" env:"UPLOADER"` // youtube or fake
+	FakeDir             string `yaml:"fake_dir" env:"FAKE_UPLOAD_DIR"`
+	VideoDir            string `yaml:"video_dir" env:"UPLOAD_VIDEO_DIR"` // The only place a publish request's video_path may point into
+	YouTubeClientID     string `yaml:"youtube_client_id" env:"YOUTUBE_CLIENT_ID"`
+	YouTubeClientSecret string `yaml:"youtube_client_secret" env:"YOUTUBE_CLIENT_SECRET" secret:"true"`
+	YouTubeRedirectURL  string `yaml:"youtube_redirect_url" env:"YOUTUBE_REDIRECT_URL"`
+}
+
+// SecurityConfig holds the master keys sealing api_keys. To rotate, move the current key
+// to previous_master_keys, set a new one and restart or run `keys rotate`; the previous
+// key can go once every key is re-sealed.
+type SecurityConfig struct {
+	MasterKey          string   `yaml:"master_key" env:"API_KEY_MASTER_KEY" secret:"true"` // Base64 of 32 bytes
+	MasterKeyFile      string   `yaml:"master_key_file" env:"API_KEY_MASTER_KEY_FILE"`     // Holds the master key instead, wins over master_key
+	PreviousMasterKeys []string `yaml:"previous_master_keys" env:"API_KEY_PREVIOUS_MASTER_KEYS" secret:"true"`
+}
+
+func defaultConfig() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Port:            "8085",
+			AuthEnabled:     true,
+			LogLevel:        "info",
+			ShutdownTimeout: defaultShutdownTimeout,
+		},
+		Mongo: MongoConfig{
+			URI:      "mongodb://localhost:27017",
+			Database: "youtube_automation",
+		},
+		AI: AIConfig{Provider: defaultAIProvider},
+		Voice: VoiceConfig{
+			Provider:       TTSProviderElevenLabs,
+			OfflineEngine:  ttsEngineEspeak,
+			SplitCharLimit: defaultVoiceSplitCharLimit,
+		},
+		Images: HttpConfig{
+			URL:               "https://aisandbox-pa.googleapis.com/v1/whisk:generateImage",
+			Accept:            "application/json",
+			OutputDirectory:   "./assets/images/",
+			MaxConcurrency:    2,
+			Timeout:           60 * time.Second,
+			SeedMode:          "random",
+			StaticSeed:        12345,
+			RequestsPerMinute: 15,
+			RetryAttempts:     3,
+			InitialRetryDelay: 2 * time.Second,
+			BackoffMultiplier: 2.0,
+			MaxRetryDelay:     30 * time.Second,
+			Tool:              "whisk",
+			AspectRatio:       ASPECT_RATIO,
+		},
+		RateLimits: RateLimitConfig{
+			TokenPerMinute:   defaultTokenRequestsPerMinute,
+			ChannelPerMinute: defaultChannelRequestsPerMinute,
+			JobsPerToken:     defaultTokenConcurrentJobs,
+			JobsPerChannel:   defaultChannelConcurrentJobs,
+		},
+		Batches: BatchConfig{
+			SchedulerInterval: defaultBatchSchedulerInterval,
+			ItemTimeout:       defaultBatchItemTimeout,
+		},
+		Thumbnails: ThumbnailConfig{OutputDir: "./assets/thumbnails/"},
+		Uploads: UploadConfig{
+			Uploader: "youtube",
+			FakeDir:  "./assets/uploads/",
+			VideoDir: "./assets/videos/",
+		},
+	}
+}
+
+var (
+	currentConfig  atomic.Pointer[Config]
+	configFile     string // Empty when no file was read
+	configLoadedAt atomic.Pointer[time.Time]
+)
+
+// appConfig returns the configuration in effect. Callers must not modify it; read it
+// where the value is used rather than copying it, so SIGHUP reloads reach them.
+func appConfig() *Config {
+	return currentConfig.Load()
+}
+
+func setConfig(config *Config) {
+	now := time.Now()
+	currentConfig.Store(config)
+	configLoadedAt.Store(&now)
+}
+
+// initConfig loads .env and the configuration, reporting every problem at once
+func initConfig() error {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("loading .env: %w", err)
+	}
+	config, path, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	configFile = path
+	setConfig(config)
+	return nil
+}
+
+// loadConfig reads the file and environment over the defaults and validates the result.
+// It returns the file read, if any.
+func loadConfig() (*Config, string, error) {
+	config := defaultConfig()
+	path := os.Getenv("CONFIG_FILE")
+	explicit := path != ""
+	if !explicit {
+		path = defaultConfigFile
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist) && !explicit:
+		path = ""
+	case err != nil:
+		return nil, "", fmt.Errorf("reading config file: %w", err)
+	default:
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+			return nil, "", fmt.Errorf("parsing %s: %w", path, err)
+		}
+	}
+
+	errs := config.applyEnv()
+	errs = append(errs, config.validate()...)
+	if len(errs) > 0 {
+		return nil, "", errors.Join(errs...)
+	}
+	return config, path, nil
+}
+
+// configField is one setting of Config, found by walking its sections
+type configField struct {
+	path   string // e.g. images.max_concurrency
+	env    string
+	secret string
+	reload bool
+	value  reflect.Value
+}
+
+func (c *Config) fields() []configField {
+	var fields []configField
+	sections := reflect.ValueOf(c).Elem()
+	for i := 0; i < sections.NumField(); i++ {
+		section := sections.Field(i)
+		sectionName := yamlName(sections.Type().Field(i))
+		for j := 0; j < section.NumField(); j++ {
+			field := section.Type().Field(j)
+			name := yamlName(field)
+			if name == "-" {
+				continue
+			}
+			fields = append(fields, configField{
+				path:   sectionName + "." + name,
+				env:    field.Tag.Get("env"),
+				secret: field.Tag.Get("secret"),
+				reload: field.Tag.Get("reload") == "true",
+				value:  section.Field(j),
+			})
+		}
+	}
+	return fields
+}
+
+func yamlName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	if name == "" {
+		return strings.ToLower(field.Name)
+	}
+	return name
+}
+
+// applyEnv overrides fields from their non-empty environment variables
+func (c *Config) applyEnv() []error {
+	var errs []error
+	for _, field := range c.fields() {
+		if field.env == "" {
+			continue
+		}
+		value := os.Getenv(field.env)
+		if value == "" {
+			continue
+		}
+		if err := setConfigValue(field.value, value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", field.env, err))
+		}
+	}
+	return errs
+}
+
+func setConfigValue(v reflect.Value, value string) error {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", value)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		v.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+		v.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		v.Set(reflect.ValueOf(splitList(value)))
+	default:
+		return fmt.Errorf("cannot be set from the environment")
+	}
+	return nil
+}
+
+// validate returns every problem found, so one start shows them all
+func (c *Config) validate() []error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+	oneOf := func(path, value string, allowed ...string) {
+		check(containsString(allowed, value), "%s: %q is not one of %s", path, value, strings.Join(allowed, ", "))
+	}
+	httpURL := func(path, value string) {
+		if value == "" {
+			return
+		}
+		u, err := url.Parse(value)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+			"%s: %q is not an http(s) URL", path, value)
+	}
+
+	port, err := strconv.Atoi(c.Server.Port)
+	check(err == nil && port > 0 && port < 65536, "server.port: %q is not a valid port", c.Server.Port)
+	var level slog.Level
+	check(level.UnmarshalText([]byte(c.Server.LogLevel)) == nil,
+		"server.log_level: %q is not one of debug, info, warn, error", c.Server.LogLevel)
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout must be positive")
+
+	check(strings.HasPrefix(c.Mongo.URI, "mongodb://") || strings.HasPrefix(c.Mongo.URI, "mongodb+srv://"),
+		"mongo.uri must start with mongodb:// or mongodb+srv://")
+	check(c.Mongo.Database != "", "mongo.database is required")
+
+	oneOf("ai.provider", string(c.AI.Provider), string(ProviderGemini), string(ProviderOpenRouter))
+	check(c.AI.Provider != ProviderGemini || c.AI.GeminiAPIKey != "",
+		"ai.gemini_api_key (GEMINI_API_KEY) is required with the gemini provider")
+	check(c.AI.Provider != ProviderOpenRouter || c.AI.OpenRouterAPIKey != "",
+		"ai.openrouter_api_key (OPENROUTER_API_KEY) is required with the openrouter provider")
+
+	check(c.Voice.SplitCharLimit > 0 && c.Voice.SplitCharLimit <= maxVoiceSplitCharLimit,
+		"voice.split_char_limit must be between 1 and %d", maxVoiceSplitCharLimit)
+	httpURL("voice.proxy_server", c.Voice.ProxyServer)
+	oneOf("voice.provider", c.Voice.Provider, ttsProviderNames...)
+	if c.Voice.FallbackProvider != "" {
+		oneOf("voice.fallback_provider", c.Voice.FallbackProvider, ttsProviderNames...)
+	}
+	oneOf("voice.offline_engine", c.Voice.OfflineEngine, ttsEnginePiper, ttsEngineEspeak)
+	check(c.Voice.OfflineEngine != ttsEnginePiper || !c.Voice.usesProvider(TTSProviderOffline) || c.Voice.OfflineVoice != "",
+		"voice.offline_voice (OFFLINE_TTS_VOICE) must name a Piper model when offline voices are the default or fallback")
+
+	oneOf("images.tool", c.Images.Tool, "whisk", "imagefx")
+	oneOf("images.seed_mode", c.Images.SeedMode, "random", "static")
+	oneOf("images.aspect_ratio", c.Images.AspectRatio,
+		"IMAGE_ASPECT_RATIO_LANDSCAPE", "IMAGE_ASPECT_RATIO_PORTRAIT", "IMAGE_ASPECT_RATIO_SQUARE")
+	httpURL("images.url", c.Images.URL)
+	check(c.Images.MaxConcurrency > 0, "images.max_concurrency must be at least 1")
+	check(c.Images.Timeout > 0, "images.timeout must be positive")
+	check(c.Images.RequestsPerMinute >= 0, "images.requests_per_minute must not be negative")
+	check(c.Images.RetryAttempts >= 0, "images.retry_attempts must not be negative")
+	check(c.Images.BackoffMultiplier >= 1, "images.backoff_multiplier must be at least 1")
+	check(c.Images.InitialRetryDelay <= c.Images.MaxRetryDelay,
+		"images.initial_retry_delay must not exceed images.max_retry_delay")
+
+	httpURL("services.transcript_url", c.Services.TranscriptURL)
+	httpURL("services.video_url", c.Services.VideoURL)
+
+	check(c.RateLimits.TokenPerMinute >= 0 && c.RateLimits.ChannelPerMinute >= 0 &&
+		c.RateLimits.JobsPerToken >= 0 && c.RateLimits.JobsPerChannel >= 0,
+		"rate_limits must not be negative (0 disables a limit)")
+
+	check(c.Batches.SchedulerInterval > 0, "batches.scheduler_interval must be positive")
+	check(c.Batches.ItemTimeout > 0, "batches.item_timeout must be positive")
+
+	oneOf("uploads.uploader", c.Uploads.Uploader, "youtube", "fake")
+	httpURL("uploads.youtube_redirect_url", c.Uploads.YouTubeRedirectURL)
+
+	if _, err := newKeyRing(c.Security); err != nil {
+		errs = append(errs, fmt.Errorf("security: %w", err))
+	}
+	return errs
+}
+
+// redacted returns the configuration as nested maps keyed like the YAML file, with
+// secrets hidden
+func (c *Config) redacted() map[string]interface{} {
+	out := map[string]interface{}{}
+	for _, field := range c.fields() {
+		sectionName, name, _ := strings.Cut(field.path, ".")
+		section, ok := out[sectionName].(map[string]interface{})
+		if !ok {
+			section = map[string]interface{}{}
+			out[sectionName] = section
+		}
+
+		value := field.value.Interface()
+		switch v := value.(type) {
+		case time.Duration:
+			value = v.String()
+		case string:
+			if field.secret == "url" {
+				value = redactURL(v)
+			} else if field.secret != "" && v != "" {
+				value = redactedValue
+			}
+		case []string:
+			if field.secret != "" && len(v) > 0 {
+				value = []string{redactedValue}
+			}
+		}
+		section[name] = value
+	}
+	return out
+}
+
+const redactedValue = "[redacted]"
+
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return redactedValue
+	}
+	return u.Redacted()
+}
+
+// ConfigResponse is the /config body
+type ConfigResponse struct {
+	File     string                 `json:"file,omitempty"` // Empty when only defaults and environment apply
+	LoadedAt time.Time              `json:"loaded_at"`
+	Config   map[string]interface{} `json:"config"`
+}
+
+// configHandler serves GET /config, the configuration in effect with secrets redacted
+func (yt *YtAutomation) configHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, ConfigResponse{
+		File:     configFile,
+		LoadedAt: *configLoadedAt.Load(),
+		Config:   appConfig().redacted(),
+	})
+}
+
+// watchConfigReload reloads the configuration on SIGHUP
+func watchConfigReload() {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			reloadConfig()
+		}
+	}()
+}
+
+// reloadConfig applies the reload:"true" settings of a fresh load. An invalid file keeps
+// the current configuration, and changes to other settings are logged as needing a
+// restart. The environment keeps the values the process started with, so settings given
+// there win over the file on every reload.
+func reloadConfig() {
+	fresh, _, err := loadConfig()
+	if err != nil {
+		slog.Error("Config reload failed, keeping the current configuration", "error", err)
+		return
+	}
+
+	current := appConfig()
+	next := *current
+	var applied, ignored []string
+	freshFields := fresh.fields()
+	for i, field := range next.fields() {
+		value := freshFields[i].value
+		if reflect.DeepEqual(field.value.Interface(), value.Interface()) {
+			continue
+		}
+		if !field.reload {
+			ignored = append(ignored, field.path)
+			continue
+		}
+		field.value.Set(value)
+		applied = append(applied, field.path)
+	}
+
+	setConfig(&next)
+	logLevel.Set(logging.ParseLevel(next.Server.LogLevel))
+	slog.Info("Config reloaded", "changed", applied)
+	if len(ignored) > 0 {
+		slog.Warn("Config changes need a restart to apply", "settings", ignored)
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronErrors(t *testing.T) {
+	for _, expression := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"5-1 * * * *",
+		"1-x * * * *",
+		"a * * * *",
+		"@yearly",
+	} {
+		if _, err := ParseCron(expression); err == nil {
+			t.Errorf("ParseCron(%q) succeeded, want an error", expression)
+		}
+	}
+}
+
+func TestParseCronField(t *testing.T) {
+	tests := []struct {
+		field    string
+		min, max int
+		want     []int
+	}{
+		{"*", 0, 5, []int{0, 1, 2, 3, 4, 5}},
+		{"3", 0, 59, []int{3}},
+		{"1,15,30", 0, 59, []int{1, 15, 30}},
+		{"1-5", 0, 59, []int{1, 2, 3, 4, 5}},
+		{"*/15", 0, 59, []int{0, 15, 30, 45}},
+		{"0-30/10", 0, 59, []int{0, 10, 20, 30}},
+		{"5/20", 0, 59, []int{5, 25, 45}},
+		{"1-3,10-12", 1, 31, []int{1, 2, 3, 10, 11, 12}},
+		{"*/2,7", 1, 12, []int{1, 3, 5, 7, 9, 11}},
+	}
+	for _, tt := range tests {
+		got, err := parseCronField(tt.field, tt.min, tt.max)
+		if err != nil {
+			t.Errorf("parseCronField(%q) error: %v", tt.field, err)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("parseCronField(%q) = %v, want %v", tt.field, got, tt.want)
+			continue
+		}
+		for _, v := range tt.want {
+			if !got[v] {
+				t.Errorf("parseCronField(%q) = %v, want %v", tt.field, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestCronScheduleNext(t *testing.T) {
+	at := func(s string) time.Time {
+		layout := "2006-01-02 15:04"
+		if len(s) > len(layout) {
+			layout += ":05"
+		}
+		parsed, err := time.ParseInLocation(layout, s, time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+
+	// 2026-03-02 is a Monday
+	tests := []struct {
+		name       string
+		expression string
+		from       string
+		want       string
+	}{
+		{"every minute is strictly after", "* * * * *", "2026-03-02 10:00", "2026-03-02 10:01"},
+		{"seconds are dropped", "* * * * *", "2026-03-02 10:00:42", "2026-03-02 10:01"},
+		{"minute step", "*/15 * * * *", "2026-03-02 10:16", "2026-03-02 10:30"},
+		{"minute step rolls the hour", "*/15 * * * *", "2026-03-02 10:45", "2026-03-02 11:00"},
+		{"hour range", "0 9-17 * * *", "2026-03-02 17:30", "2026-03-03 09:00"},
+		{"hour range with step", "30 8-20/4 * * *", "2026-03-02 12:31", "2026-03-02 16:30"},
+		{"list", "0 6,18 * * *", "2026-03-02 07:00", "2026-03-02 18:00"},
+		{"daily rolls the day", "@daily", "2026-03-02 00:00", "2026-03-03 00:00"},
+		{"hourly", "@hourly", "2026-03-02 10:59", "2026-03-02 11:00"},
+		{"weekly is Sunday", "@weekly", "2026-03-02 10:00", "2026-03-08 00:00"},
+		{"7 is Sunday", "0 9 * * 7", "2026-03-02 10:00", "2026-03-08 09:00"},
+		{"weekday range", "0 9 * * 1-5", "2026-03-06 10:00", "2026-03-09 09:00"},
+		{"day of month rolls the month", "0 0 31 * *", "2026-03-31 00:00", "2026-05-31 00:00"},
+		{"monthly rolls the year", "@monthly", "2026-12-15 00:00", "2027-01-01 00:00"},
+		{"month list", "0 0 1 1,7 *", "2026-03-02 00:00", "2026-07-01 00:00"},
+		{"leap day", "0 0 29 2 *", "2026-03-02 00:00", "2028-02-29 00:00"},
+		// Both day fields restricted: either one matching is enough
+		{"day of month or weekday, weekday first", "0 0 15 * 5", "2026-03-02 00:00", "2026-03-06 00:00"},
+		{"day of month or weekday, day first", "0 0 3 * 5", "2026-03-02 00:00", "2026-03-03 00:00"},
+		// Only one restricted: that one must match
+		{"day of month only", "0 0 15 * *", "2026-03-02 00:00", "2026-03-15 00:00"},
+		{"weekday only", "0 0 * * 5", "2026-03-02 00:00", "2026-03-06 00:00"},
+		{"weekday in a month", "0 0 * 4 1", "2026-03-02 00:00", "2026-04-06 00:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseCron(tt.expression)
+			if err != nil {
+				t.Fatalf("ParseCron(%q): %v", tt.expression, err)
+			}
+			got, err := schedule.Next(at(tt.from))
+			if err != nil {
+				t.Fatalf("Next: %v", err)
+			}
+			if want := at(tt.want); !got.Equal(want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.from, got.Format(time.RFC3339), want.Format(time.RFC3339))
+			}
+		})
+	}
+}
+
+func TestCronScheduleNextKeepsLocation(t *testing.T) {
+	location := time.FixedZone("UTC+5", 5*60*60)
+	schedule, err := ParseCron("0 9 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := schedule.Next(time.Date(2026, 3, 2, 10, 0, 0, 0, location))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, 3, 3, 9, 0, 0, 0, location); !got.Equal(want) || got.Location() != location {
+		t.Errorf("Next = %s, want %s", got, want)
+	}
+}
+
+func TestCronScheduleNeverMatches(t *testing.T) {
+	schedule, err := ParseCron("0 0 31 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := schedule.Next(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)); err == nil {
+		t.Errorf("Next = %s, want an error for February 31st", got)
+	}
+}
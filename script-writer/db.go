@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"log/slog"
 	"time"
 )
 
@@ -26,6 +27,15 @@ func (yt *YtAutomation) getChannelByID(channelID primitive.ObjectID) (*Channel,
 	return &channel, nil
 }
 
+func (yt *YtAutomation) getChannelByName(channelName string) (*Channel, error) {
+	var channel Channel
+	err := channelsCollection.FindOne(context.Background(), bson.M{"channel_name": channelName}).Decode(&channel)
+	if err != nil {
+		return nil, err
+	}
+	return &channel, nil
+}
+
 func (yt *YtAutomation) getScriptAudioByID(scriptAudioID primitive.ObjectID) (*ScriptAudio, error) {
 	var scriptAudio ScriptAudio
 	err := scriptAudiosCollection.FindOne(context.Background(), bson.M{"_id": scriptAudioID}).Decode(&scriptAudio)
@@ -72,7 +82,7 @@ func (yt *YtAutomation) SaveScriptAudioFile(chunkID primitive.ObjectID, filepath
 		bson.M{"$set": bson.M{"audio_file": filepath}},
 	)
 	if err != nil {
-		fmt.Printf("Warning: Failed to save chunk audio file path: %v\n", err)
+		slog.Warn("Failed to save chunk audio file path", "chunk_id", chunkID.Hex(), "error", err)
 	}
 }
 
@@ -83,7 +93,7 @@ func (yt *YtAutomation) UpdateScriptCollection(scriptID primitive.ObjectID, file
 		bson.M{"$set": bson.M{"full_audio_file": filepath}},
 	)
 	if err != nil {
-		fmt.Printf("Warning: Failed to save merged audio file path: %v\n", err)
+		slog.Warn("Failed to save merged audio file path", logKeyScriptID, scriptID.Hex(), "error", err)
 	}
 }
 func (yt *YtAutomation) updateVisualChunkStatus(chunkID primitive.ObjectID, status string) {
@@ -111,7 +121,7 @@ func (yt *YtAutomation) updateVisualChunkStatus(chunkID primitive.ObjectID, stat
 		updateDoc,
 	)
 	if err != nil {
-		fmt.Printf("Warning: Failed to update chunk status to %s: %v\n", status, err)
+		slog.Warn("Failed to update chunk status", "chunk_id", chunkID.Hex(), "status", status, "error", err)
 	}
 }
 func (yt *YtAutomation) updateVisualChunkWithAPIKeyError(chunkID primitive.ObjectID, errorMsg string) {
@@ -129,7 +139,7 @@ func (yt *YtAutomation) updateVisualChunkWithAPIKeyError(chunkID primitive.Objec
 		},
 	)
 	if err != nil {
-		fmt.Printf("Warning: Failed to update chunk with API key error: %v\n", err)
+		slog.Warn("Failed to update chunk with API key error", "chunk_id", chunkID.Hex(), "error", err)
 	}
 }
 
@@ -147,7 +157,7 @@ func (yt *YtAutomation) updateVisualChunkWithAPIKey(chunkID primitive.ObjectID,
 		},
 	)
 	if err != nil {
-		fmt.Printf("Warning: Failed to update chunk with API key info: %v\n", err)
+		slog.Warn("Failed to update chunk with API key info", "chunk_id", chunkID.Hex(), "error", err)
 	}
 }
 
@@ -165,7 +175,7 @@ func (yt *YtAutomation) updateVisualChunkWithProcessingInfo(chunkID primitive.Ob
 		},
 	)
 	if err != nil {
-		fmt.Printf("Warning: Failed to update chunk with processing info: %v\n", err)
+		slog.Warn("Failed to update chunk with processing info", "chunk_id", chunkID.Hex(), "error", err)
 	}
 }
 func (yt *YtAutomation) checkExistingVisuals(scriptID primitive.ObjectID, chunk ScriptSrt, force bool) (bool, error) {
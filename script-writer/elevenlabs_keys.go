@@ -0,0 +1,152 @@
+// File: elevenlabs_keys.go
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+	"unicode/utf8"
+	"youtube_automation/elevenlabs"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// synthesizeSpeech voices text with a pooled ElevenLabs key that has the characters for
+// it, failing over to another key when one is exhausted or rejected
+func (yt *YtAutomation) synthesizeSpeech(ctx context.Context, text, voiceID string) ([]byte, error) {
+	need := utf8.RuneCountInString(text)
+	var tried []primitive.ObjectID
+	var lastErr error
+
+	for range maxVoiceKeyAttempts {
+		key, err := yt.apiKeyManager.GetActiveKey(ProviderElevenLabs, tried...)
+		if err != nil {
+			if lastErr != nil {
+				return nil, fmt.Errorf("%w (last key error: %v)", err, lastErr)
+			}
+			return nil, err
+		}
+		tried = append(tried, key.ID)
+		client := yt.elevenLabsClient.WithAPIKey(key.KeyValue)
+
+		// Remaining characters, or -1 when the subscription could not be read
+		remaining := -1
+		sub, err := client.GetSubscriptionInfo()
+		recordProviderCall(ProviderElevenLabs, err)
+		switch {
+		case err != nil && yt.reportElevenLabsFailure(key, err):
+			lastErr = err
+			continue
+		case err != nil:
+			// Let the request itself find out whether the key can afford it
+			slog.WarnContext(ctx, "Could not fetch ElevenLabs subscription info", logKeyProvider, ProviderElevenLabs,
+				"key", key.Fingerprint, "error", err)
+		default:
+			remaining = sub.CharacterLimit - sub.CharacterCount
+			elevenLabsCharactersRemaining.Set(float64(remaining), key.Fingerprint)
+			if remaining < need {
+				yt.apiKeyManager.RecordCharacters(key, 0, remaining)
+				if remaining <= 0 {
+					failure := quotaFailure(sub, need)
+					if err := yt.apiKeyManager.ReportFailure(key, failure); err != nil {
+						slog.WarnContext(ctx, "Failed to record API key failure", logKeyProvider, ProviderElevenLabs, "error", err)
+					}
+					lastErr = errors.New(failure.Message)
+					continue
+				}
+				// Smaller chunks may still fit, so the key only sits this one out
+				slog.InfoContext(ctx, "ElevenLabs key cannot afford chunk, trying another", logKeyProvider, ProviderElevenLabs,
+					"key", key.Fingerprint, "remaining", remaining, "needed", need)
+				lastErr = fmt.Errorf("%d characters left, %d needed", remaining, need)
+				continue
+			}
+			if remaining-need < lowElevenLabsCredits {
+				slog.WarnContext(ctx, "Low ElevenLabs credits remaining", logKeyProvider, ProviderElevenLabs,
+					"key", key.Fingerprint, "remaining", remaining-need)
+			}
+		}
+
+		audio, err := client.TextToSpeech(text, voiceID)
+		recordProviderCall(ProviderElevenLabs, err)
+		if err != nil {
+			if !yt.reportElevenLabsFailure(key, err) {
+				return nil, err
+			}
+			slog.WarnContext(ctx, "ElevenLabs key failed, trying another", logKeyProvider, ProviderElevenLabs,
+				"key", key.Fingerprint, "error", err)
+			lastErr = err
+			continue
+		}
+
+		yt.apiKeyManager.ReportSuccess(key)
+		if remaining >= 0 {
+			remaining -= need
+		}
+		yt.apiKeyManager.RecordCharacters(key, need, remaining)
+		return audio, nil
+	}
+
+	return nil, fmt.Errorf("no ElevenLabs key succeeded after %d attempts: %w", maxVoiceKeyAttempts, lastErr)
+}
+
+// reportElevenLabsFailure records an error that is the key's fault (rejected, exhausted,
+// rate limited or a provider outage) and reports whether another key is worth trying.
+// Anything else, such as a bad voice ID, would fail with every key.
+func (yt *YtAutomation) reportElevenLabsFailure(key *APIKey, err error) bool {
+	var apiErr *elevenlabs.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	failure := elevenLabsKeyFailure(apiErr)
+	if !failure.Quota && apiErr.StatusCode != http.StatusUnauthorized && apiErr.StatusCode != http.StatusForbidden &&
+		apiErr.StatusCode != http.StatusTooManyRequests && apiErr.StatusCode < http.StatusInternalServerError {
+		return false
+	}
+	if reportErr := yt.apiKeyManager.ReportFailure(key, failure); reportErr != nil {
+		slog.Warn("Failed to record API key failure", logKeyProvider, ProviderElevenLabs, "error", reportErr)
+	}
+	return true
+}
+
+// elevenLabsKeyFailure classifies an ElevenLabs error. It reports a used-up character
+// quota as a 401 with the quota_exceeded status, which must not revoke the key.
+func elevenLabsKeyFailure(apiErr *elevenlabs.APIError) keyFailure {
+	failure := newKeyFailure(apiErr.StatusCode, apiErr.RetryAfter, []byte(apiErr.Body))
+	failure.Quota = apiErr.DetailStatus() == "quota_exceeded"
+	return failure
+}
+
+// quotaFailure marks a key exhausted until its subscription's character count resets
+func quotaFailure(sub *elevenlabs.SubscriptionInfo, need int) keyFailure {
+	failure := keyFailure{
+		Status:  http.StatusTooManyRequests,
+		Quota:   true,
+		Message: fmt.Sprintf("%d characters left, %d needed", sub.CharacterLimit-sub.CharacterCount, need),
+	}
+	if sub.NextCharacterCountResetUnix > 0 {
+		failure.RetryAfter = time.Until(time.Unix(sub.NextCharacterCountResetUnix, 0))
+	}
+	return failure
+}
+
+// probeElevenLabsKey brings an ElevenLabs key back once it is accepted and has characters left
+func (yt *YtAutomation) probeElevenLabsKey(ctx context.Context, value string) *keyFailure {
+	sub, err := yt.elevenLabsClient.WithAPIKey(value).GetSubscriptionInfo()
+	if err != nil {
+		var apiErr *elevenlabs.APIError
+		if errors.As(err, &apiErr) {
+			failure := elevenLabsKeyFailure(apiErr)
+			return &failure
+		}
+		// Unreachable says nothing about the key; try again after a short cooldown
+		return &keyFailure{Status: http.StatusServiceUnavailable, Message: err.Error()}
+	}
+	if sub.CharacterCount >= sub.CharacterLimit {
+		failure := quotaFailure(sub, 1)
+		return &failure
+	}
+	return nil
+}
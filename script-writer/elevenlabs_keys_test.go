@@ -0,0 +1,36 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+	"youtube_automation/elevenlabs"
+)
+
+func TestElevenLabsKeyFailure(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       elevenlabs.APIError
+		wantQuota bool
+		wantState string
+	}{
+		{"quota exceeded is exhausted, not revoked", elevenlabs.APIError{StatusCode: http.StatusUnauthorized,
+			Body: `{"detail":{"status":"quota_exceeded","message":"This request exceeds your quota."}}`}, true, KeyStateExhausted},
+		{"invalid key is revoked", elevenlabs.APIError{StatusCode: http.StatusUnauthorized,
+			Body: `{"detail":{"status":"invalid_api_key","message":"Invalid API key"}}`}, false, KeyStateRevoked},
+		{"rate limit cools down", elevenlabs.APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: "5",
+			Body: `{"detail":{"status":"too_many_concurrent_requests","message":"check your quota of concurrent requests"}}`}, false, KeyStateCoolingDown},
+		{"unparsable body", elevenlabs.APIError{StatusCode: http.StatusUnauthorized, Body: "quota_exceeded"}, false, KeyStateRevoked},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failure := elevenLabsKeyFailure(&tt.err)
+			if failure.Quota != tt.wantQuota {
+				t.Errorf("Quota = %v, want %v", failure.Quota, tt.wantQuota)
+			}
+			if state, _ := nextKeyState(&APIKey{State: KeyStateHealthy}, failure, time.Now()); state != tt.wantState {
+				t.Errorf("state = %q, want %q", state, tt.wantState)
+			}
+		})
+	}
+}
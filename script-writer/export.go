@@ -0,0 +1,381 @@
+// File: export.go
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ExportManifest describes every file in a project export and where it sits on the timeline
+type ExportManifest struct {
+	ScriptID    string             `json:"script_id"`
+	ChannelName string             `json:"channel_name"`
+	Topic       string             `json:"topic"`
+	Duration    float64            `json:"duration"`
+	Sections    []string           `json:"sections"`
+	Voiceover   string             `json:"voiceover,omitempty"`
+	AudioChunks []ExportAudioChunk `json:"audio_chunks"`
+	Images      []ExportImage      `json:"images"`
+	Subtitles   []string           `json:"subtitles,omitempty"`
+	Timelines   []string           `json:"timelines,omitempty"` // FCPXML, EDL and OTIO versions of the cut
+	Missing     []string           `json:"missing,omitempty"`   // Source files that could not be read
+	ExportedAt  time.Time          `json:"exported_at"`
+}
+
+type ExportAudioChunk struct {
+	File       string `json:"file"`
+	ChunkIndex int    `json:"chunk_index"`
+}
+
+type ExportImage struct {
+	File        string  `json:"file"`
+	ChunkIndex  int     `json:"chunk_index"`
+	PromptIndex int     `json:"prompt_index"`
+	StartTime   float64 `json:"start_time"`
+	EndTime     float64 `json:"end_time"`
+	Duration    float64 `json:"duration"`
+	Prompt      string  `json:"prompt"`
+}
+
+// timelineClip is a completed chunk visual with its parsed timing
+type timelineClip struct {
+	Visual ChunkVisual
+	Start  float64
+	End    float64
+	File   string // Path inside the export, e.g. images/0001.jpg
+}
+
+// buildTimelineClips orders the script's images by start time and names them in that order
+func buildTimelineClips(chunkVisuals []ChunkVisual) []timelineClip {
+	var clips []timelineClip
+	for _, visual := range chunkVisuals {
+		if visual.ImagePath == "" {
+			continue
+		}
+		start, err := strconv.ParseFloat(visual.StartTime, 64)
+		if err != nil {
+			slog.Warn("Could not parse visual start_time", logKeyScriptID, visual.ScriptID.Hex(), logKeyChunkIndex, visual.ChunkIndex, "value", visual.StartTime, "error", err)
+			continue
+		}
+		end, err := strconv.ParseFloat(visual.EndTime, 64)
+		if err != nil {
+			slog.Warn("Could not parse visual end_time", logKeyScriptID, visual.ScriptID.Hex(), logKeyChunkIndex, visual.ChunkIndex, "value", visual.EndTime, "error", err)
+			continue
+		}
+		if end <= start {
+			end = start + 1.0 // Same minimum the video renderer uses
+		}
+		clips = append(clips, timelineClip{Visual: visual, Start: start, End: end})
+	}
+
+	sort.SliceStable(clips, func(i, j int) bool { return clips[i].Start < clips[j].Start })
+	for i := range clips {
+		ext := filepath.Ext(clips[i].Visual.ImagePath)
+		if ext == "" || len(ext) > 5 {
+			ext = ".jpg"
+		}
+		clips[i].File = fmt.Sprintf("images/%04d%s", i+1, ext)
+	}
+	return clips
+}
+
+// voiceoverExportPath is where the merged voiceover sits inside an export
+func voiceoverExportPath(script *Script) string {
+	ext := filepath.Ext(script.FullAudioFile)
+	if ext == "" {
+		ext = ".mp3"
+	}
+	return "audio/voiceover" + ext
+}
+
+// srtToVTT converts SRT subtitles to WebVTT
+func srtToVTT(srt string) (string, error) {
+	entries, err := parseSRT(srt)
+	if err != nil {
+		return "", err
+	}
+	var vtt strings.Builder
+	vtt.WriteString("WEBVTT\n\n")
+	for _, entry := range entries {
+		vtt.WriteString(fmt.Sprintf("%s --> %s\n%s\n\n",
+			strings.Replace(formatTime(entry.StartTime), ",", ".", 1),
+			strings.Replace(formatTime(entry.EndTime), ",", ".", 1),
+			entry.Text))
+	}
+	return vtt.String(), nil
+}
+
+func (yt *YtAutomation) getScriptAudioChunks(scriptID primitive.ObjectID) ([]ScriptAudio, error) {
+	var chunks []ScriptAudio
+	cursor, err := scriptAudiosCollection.Find(
+		context.Background(),
+		bson.M{"script_id": scriptID},
+		options.Find().SetSort(bson.M{"chunk_index": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	err = cursor.All(context.Background(), &chunks)
+	return chunks, err
+}
+
+// projectExporter writes files into a zip stream, recording sources it cannot read
+type projectExporter struct {
+	ctx     context.Context // The export request's, so a client hanging up stops downloads
+	yt      *YtAutomation
+	zw      *zip.Writer
+	missing []string
+}
+
+func (e *projectExporter) writeBytes(name string, data []byte) error {
+	f, err := e.zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	return err
+}
+
+func (e *projectExporter) writeJSON(name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return e.writeBytes(name, data)
+}
+
+// copyMedia streams a local file or URL into the archive without compressing it again.
+// It returns false when the source could not be opened.
+func (e *projectExporter) copyMedia(name, source string) (bool, error) {
+	var reader io.ReadCloser
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		req, err := http.NewRequestWithContext(e.ctx, "GET", source, nil)
+		if err != nil {
+			e.missing = append(e.missing, source)
+			return false, nil
+		}
+		resp, err := e.yt.client.Do(req)
+		if err != nil || resp.StatusCode != http.StatusOK {
+			if err == nil {
+				resp.Body.Close()
+			}
+			e.missing = append(e.missing, source)
+			return false, nil
+		}
+		reader = resp.Body
+	} else {
+		file, err := os.Open(source)
+		if err != nil {
+			e.missing = append(e.missing, source)
+			return false, nil
+		}
+		reader = file
+	}
+	defer reader.Close()
+
+	f, err := e.zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Store,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return false, err
+	}
+	if _, err := io.Copy(f, reader); err != nil {
+		return false, fmt.Errorf("copying %s: %w", source, err)
+	}
+	return true, nil
+}
+
+// writeProject streams the full project package for a script into w. Media that cannot
+// be copied is listed as missing and left out of the timelines.
+func (yt *YtAutomation) writeProject(ctx context.Context, w io.Writer, script *Script, audioChunks []ScriptAudio, timeline *nleTimeline, clips []timelineClip) error {
+	e := &projectExporter{ctx: ctx, yt: yt, zw: zip.NewWriter(w)}
+	manifest := ExportManifest{
+		ScriptID:    script.ID.Hex(),
+		ChannelName: script.ChannelName,
+		Topic:       script.Topic,
+		ExportedAt:  time.Now(),
+	}
+
+	// Script text, one file per section
+	var blocks []string
+	for _, block := range strings.Split(script.FullScript, sectionSeparator) {
+		if strings.TrimSpace(block) != "" {
+			blocks = append(blocks, strings.TrimSpace(block))
+		}
+	}
+	for i, block := range blocks {
+		name := fmt.Sprintf("script/%02d.txt", i+1)
+		if err := e.writeBytes(name, []byte(block+"\n")); err != nil {
+			return err
+		}
+		manifest.Sections = append(manifest.Sections, name)
+	}
+	if err := e.writeJSON("meta.json", script.Meta); err != nil {
+		return err
+	}
+
+	// Subtitles
+	if strings.TrimSpace(script.SRT) != "" {
+		if err := e.writeBytes("subtitles/subtitles.srt", []byte(script.SRT)); err != nil {
+			return err
+		}
+		manifest.Subtitles = append(manifest.Subtitles, "subtitles/subtitles.srt")
+
+		if vtt, err := srtToVTT(script.SRT); err == nil {
+			if err := e.writeBytes("subtitles/subtitles.vtt", []byte(vtt)); err != nil {
+				return err
+			}
+			manifest.Subtitles = append(manifest.Subtitles, "subtitles/subtitles.vtt")
+		} else {
+			slog.Warn("Could not convert SRT to VTT", logKeyScriptID, script.ID.Hex(), "error", err)
+		}
+
+		if duration, err := yt.calculateDurationFromSRT(script.SRT); err == nil {
+			manifest.Duration = duration
+		}
+	}
+
+	// Audio
+	if script.FullAudioFile != "" {
+		name := voiceoverExportPath(script)
+		ok, err := e.copyMedia(name, script.FullAudioFile)
+		if err != nil {
+			return err
+		}
+		if ok {
+			manifest.Voiceover = name
+		} else {
+			timeline.Voiceover = ""
+		}
+	}
+	for _, chunk := range audioChunks {
+		if chunk.AudioFilePath == "" {
+			continue
+		}
+		name := fmt.Sprintf("audio/chunks/%03d%s", chunk.ChunkIndex, filepath.Ext(chunk.AudioFilePath))
+		ok, err := e.copyMedia(name, chunk.AudioFilePath)
+		if err != nil {
+			return err
+		}
+		if ok {
+			manifest.AudioChunks = append(manifest.AudioChunks, ExportAudioChunk{File: name, ChunkIndex: chunk.ChunkIndex})
+		}
+	}
+
+	// Images in timeline order
+	copied := make(map[string]bool, len(clips))
+	for _, clip := range clips {
+		ok, err := e.copyMedia(clip.File, clip.Visual.ImagePath)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		copied[clip.File] = true
+		manifest.Images = append(manifest.Images, ExportImage{
+			File:        clip.File,
+			ChunkIndex:  clip.Visual.ChunkIndex,
+			PromptIndex: clip.Visual.PromptIndex,
+			StartTime:   clip.Start,
+			EndTime:     clip.End,
+			Duration:    clip.End - clip.Start,
+			Prompt:      clip.Visual.Prompt,
+		})
+	}
+
+	// Timelines reference the files above by relative path, so they sit at the root
+	var images []nleClip
+	for _, image := range timeline.Images {
+		if copied[image.File] {
+			images = append(images, image)
+		}
+	}
+	timeline.Images = images
+	if len(timeline.Images) > 0 {
+		for _, format := range []string{"fcpxml", "edl", "otio"} {
+			body, err := renderNLETimeline(format, timeline)
+			if err != nil {
+				return err
+			}
+			name := "timeline" + nleFormats[format].ext
+			if err := e.writeBytes(name, body); err != nil {
+				return err
+			}
+			manifest.Timelines = append(manifest.Timelines, name)
+		}
+	}
+
+	manifest.Missing = e.missing
+	if err := e.writeJSON("manifest.json", manifest); err != nil {
+		return err
+	}
+	return e.zw.Close()
+}
+
+func (yt *YtAutomation) exportScriptHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		respondWithError(w, http.StatusMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+
+	// Extract script ID from URL path (/scripts/{scriptID}/export)
+	path := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/scripts/"), "/export")
+	scriptID, err := primitive.ObjectIDFromHex(path)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid script ID format")
+		return
+	}
+
+	script, err := yt.getScriptByID(scriptID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			respondWithError(w, http.StatusNotFound, "Script not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Database error: %v", err))
+		return
+	}
+	audioChunks, err := yt.getScriptAudioChunks(scriptID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get audio chunks: %v", err))
+		return
+	}
+	timeline, clips, err := yt.loadNLETimeline(script)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	filename := fmt.Sprintf("%s_%s.zip", sanitizeFilename(script.Topic), scriptID.Hex())
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	// Headers are already sent, so a failure can only cut the stream short
+	if err := yt.writeProject(r.Context(), w, script, audioChunks, timeline, clips); err != nil {
+		slog.Error("Export aborted", logKeyScriptID, scriptID.Hex(), "error", err)
+		return
+	}
+	slog.Info("Exported project", logKeyScriptID, scriptID.Hex())
+}
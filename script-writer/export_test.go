@@ -0,0 +1,93 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteProjectLeavesMissingMediaOutOfTimelines(t *testing.T) {
+	dir := t.TempDir()
+	localImage := filepath.Join(dir, "local.jpg")
+	if err := os.WriteFile(localImage, []byte("jpeg"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/remote.jpg" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("jpeg"))
+	}))
+	defer server.Close()
+
+	clips := buildTimelineClips([]ChunkVisual{
+		{ChunkIndex: 0, StartTime: "0", EndTime: "5", ImagePath: localImage},
+		{ChunkIndex: 1, StartTime: "5", EndTime: "10", ImagePath: server.URL + "/gone.jpg"},
+		{ChunkIndex: 2, StartTime: "10", EndTime: "15", ImagePath: server.URL + "/remote.jpg"},
+		{ChunkIndex: 3, StartTime: "15", EndTime: "20", ImagePath: filepath.Join(dir, "deleted.jpg")},
+	})
+	timeline := &nleTimeline{Name: "Test", Width: 1920, Height: 1080, Frames: 600, Voiceover: "audio/voiceover.mp3"}
+	for i, clip := range clips {
+		timeline.Images = append(timeline.Images, nleClip{Name: filepath.Base(clip.File), File: clip.File, Start: i * 150, Frames: 150})
+	}
+	script := &Script{FullScript: "Intro", FullAudioFile: filepath.Join(dir, "missing.mp3")}
+
+	yt := &YtAutomation{client: server.Client()}
+	var buf bytes.Buffer
+	if err := yt.writeProject(context.Background(), &buf, script, nil, timeline, clips); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(data)
+	}
+
+	var manifest ExportManifest
+	if err := json.Unmarshal([]byte(files["manifest.json"]), &manifest); err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Images) != 2 || manifest.Images[0].File != "images/0001.jpg" || manifest.Images[1].File != "images/0003.jpg" {
+		t.Errorf("manifest images = %+v, want images 1 and 3", manifest.Images)
+	}
+	if len(manifest.Missing) != 3 {
+		t.Errorf("missing = %q, want the voiceover and images 2 and 4", manifest.Missing)
+	}
+
+	for _, name := range []string{"timeline.fcpxml", "timeline.edl", "timeline.otio"} {
+		body, ok := files[name]
+		if !ok {
+			t.Errorf("%s not written", name)
+			continue
+		}
+		for _, file := range []string{"images/0002.jpg", "images/0004.jpg", "voiceover.mp3"} {
+			if strings.Contains(body, file) {
+				t.Errorf("%s references %s, which is not in the export", name, file)
+			}
+		}
+		for _, file := range []string{"images/0001.jpg", "images/0003.jpg"} {
+			if !strings.Contains(body, file) {
+				t.Errorf("%s does not reference %s", name, file)
+			}
+		}
+	}
+}
@@ -2,9 +2,11 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"time"
 )
@@ -24,19 +26,19 @@ type GeminiService struct {
 func NewGeminiService(apiKey string) *GeminiService {
 	return &GeminiService{
 		apiKey: apiKey,
-		client: &http.Client{Timeout: timeout},
+		client: providerClient(string(ProviderGemini), timeout),
 	}
 }
-func (g *GeminiService) GenerateContentWithSystem(systemPrompt, userPrompt string) (string, error) {
+func (g *GeminiService) GenerateContentWithSystem(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
 	// For Gemini, we combine them as it doesn't have separate system/user roles like OpenAI
 	combinedPrompt := systemPrompt + "\n\n" + userPrompt
-	return g.GenerateContent(combinedPrompt)
+	return g.GenerateContent(ctx, combinedPrompt)
 }
-func (g *GeminiService) GenerateContent(prompt string) (string, error) {
-	return g.RetryWithExponentialBackoff(prompt, maxRetries)
+func (g *GeminiService) GenerateContent(ctx context.Context, prompt string) (string, error) {
+	return g.RetryWithExponentialBackoff(ctx, prompt, maxRetries)
 }
 
-func (g *GeminiService) callAPI(prompt string) (string, error) {
+func (g *GeminiService) callAPI(ctx context.Context, prompt string) (string, error) {
 	requestBody := GeminiRequest{
 		Contents: []Content{
 			{
@@ -53,7 +55,7 @@ func (g *GeminiService) callAPI(prompt string) (string, error) {
 	}
 
 	url := fmt.Sprintf("%s?key=%s", baseURL, g.apiKey)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", fmt.Errorf("creating request: %w", err)
 	}
@@ -90,14 +92,15 @@ func (g *GeminiService) callAPI(prompt string) (string, error) {
 }
 
 // RetryWithExponentialBackoff implements retry logic for API calls
-func (g *GeminiService) RetryWithExponentialBackoff(prompt string, maxRetries int) (string, error) {
+func (g *GeminiService) RetryWithExponentialBackoff(ctx context.Context, prompt string, maxRetries int) (string, error) {
 	var lastErr error
 
 	if debugMode {
-		fmt.Println(prompt)
+		slog.DebugContext(ctx, "Gemini prompt", logKeyProvider, ProviderGemini, "prompt", prompt)
 	}
 	for attempt := 0; attempt < maxRetries; attempt++ {
-		result, err := g.callAPI(prompt)
+		result, err := g.callAPI(ctx, prompt)
+		recordProviderCall(string(ProviderGemini), err)
 		if err == nil {
 			return result, nil
 		}
@@ -106,11 +109,13 @@ func (g *GeminiService) RetryWithExponentialBackoff(prompt string, maxRetries in
 
 		// Exponential backoff: 1s, 2s, 4s, 8s...
 		backoffDuration := time.Duration(1<<attempt) * time.Second
-		fmt.Printf("API call failed (attempt %d/%d), retrying in %v: %v\n",
-			attempt+1, maxRetries, backoffDuration, err)
+		slog.WarnContext(ctx, "Gemini API call failed, retrying", logKeyProvider, ProviderGemini,
+			"attempt", attempt+1, "max_attempts", maxRetries, "backoff", backoffDuration, "error", err)
 
 		if attempt < maxRetries-1 {
-			time.Sleep(backoffDuration)
+			if err := sleepContext(ctx, backoffDuration); err != nil {
+				return "", err
+			}
 		}
 	}
 
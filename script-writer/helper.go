@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"log/slog"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -357,38 +358,30 @@ func splitSRTByCharLimit(srtContent string, charLimit int) ([]string, error) {
 }
 
 func (yt *YtAutomation) generateVoiceOver1(script Script, chunks []ScriptAudio) error {
-	// Check ElevenLabs credits first
-	subInfo, err := yt.elevenLabsClient.GetSubscriptionInfo()
-	if err != nil {
-		fmt.Printf("Warning: Could not fetch subscription info: %v\n", err)
-	} else {
-		remaining := subInfo.CharacterLimit - subInfo.CharacterCount
-		fmt.Printf("📊 ElevenLabs Credits - Used: %d/%d, Remaining: %d characters\n",
-			subInfo.CharacterCount, subInfo.CharacterLimit, remaining)
-
-		if remaining < 1000 {
-			fmt.Printf("⚠️  Warning: Low credits remaining (%d characters)\n", remaining)
-		}
-	}
+	ctx := withScriptLog(context.Background(), script.ID)
 
 	var audioFiles []string
-	pendingChunks := yt.getPendingChunks(chunks)
+	pendingChunks := yt.getPendingChunks(ctx, chunks)
 
-	fmt.Printf("📋 Total chunks: %d, Pending generation: %d\n", len(chunks), len(pendingChunks))
+	slog.InfoContext(ctx, "Voiceover chunks", "total", len(chunks), "pending", len(pendingChunks))
 
 	// Generate only pending chunks
 	for i, chunk := range pendingChunks {
-		fmt.Printf("🎵 Generating voice for chunk %d/%d (Chunk Index: %d)...\n",
-			i+1, len(pendingChunks), chunk.ChunkIndex)
+		// Chunks not reached stay pending for the next run
+		if err := checkpoint(ctx); err != nil {
+			return err
+		}
+		ctx := withLogAttrs(ctx, slog.Int(logKeyChunkIndex, chunk.ChunkIndex))
+		slog.InfoContext(ctx, "Generating voice for chunk", "position", i+1, "pending", len(pendingChunks))
 
 		// Update status to generating
-		yt.updateChunkStatus(chunk.ID, "generating", "")
+		yt.updateChunkStatus(ctx, chunk.ID, "generating", "")
 
 		// Generate speech
-		audioData, err := yt.elevenLabsClient.TextToSpeech(chunk.Content, os.Getenv("VOICE_ID"))
+		audioData, err := yt.synthesizeNarration(ctx, nil, chunk.Content)
 		if err != nil {
-			fmt.Printf("❌ Error generating speech for chunk %d: %v\n", chunk.ChunkIndex, err)
-			yt.updateChunkStatus(chunk.ID, "failed", "")
+			slog.ErrorContext(ctx, "Error generating speech", logKeyProvider, ProviderElevenLabs, "error", err)
+			yt.updateChunkStatus(ctx, chunk.ID, "failed", "")
 			continue // Continue with next chunk instead of failing completely
 		}
 
@@ -397,42 +390,42 @@ func (yt *YtAutomation) generateVoiceOver1(script Script, chunks []ScriptAudio)
 		path := filepath.Join("assets", "audio", filename)
 
 		if err = saveAudioFile(audioData, path); err != nil {
-			fmt.Printf("❌ Error saving audio file for chunk %d: %v\n", chunk.ChunkIndex, err)
-			yt.updateChunkStatus(chunk.ID, "failed", "")
+			slog.ErrorContext(ctx, "Error saving audio file", "error", err)
+			yt.updateChunkStatus(ctx, chunk.ID, "failed", "")
 			continue
 		}
 
 		// Update status to completed with file path
-		yt.updateChunkStatus(chunk.ID, "completed", path)
-		fmt.Printf("✅ Voice generation complete for chunk %d\n", chunk.ChunkIndex)
+		yt.updateChunkStatus(ctx, chunk.ID, "completed", path)
+		slog.InfoContext(ctx, "Voice generation complete for chunk")
 	}
 
 	// Collect all completed audio files (including previously generated ones)
-	audioFiles = yt.getCompletedAudioFiles(chunks)
+	audioFiles = yt.getCompletedAudioFiles(ctx, chunks)
 
 	if len(audioFiles) == 0 {
 		return fmt.Errorf("no audio files generated successfully")
 	}
 
 	// Merge all available audio files
-	fmt.Printf("🔄 Merging %d audio files...\n", len(audioFiles))
+	slog.InfoContext(ctx, "Merging audio files", "count", len(audioFiles))
 	mergedFilename := fmt.Sprintf("%s_complete_voiceover_%s.mp3",
 		script.ChannelName,
 		time.Now().Format("20060102_15_04_05"))
 	mergedPath := filepath.Join("assets", "audio", mergedFilename)
 
-	if err := yt.mergeAudioFiles(audioFiles, mergedPath); err != nil {
+	if err := yt.mergeAudioFiles(ctx, audioFiles, mergedPath); err != nil {
 		return fmt.Errorf("error merging audio files: %v", err)
 	}
 
 	// Update script collection with merged audio file
 	yt.UpdateScriptCollection(script.ID, mergedPath)
 
-	completedCount := len(yt.getCompletedChunks(chunks))
-	fmt.Printf("✅ Voiceover generation finished! Completed: %d/%d chunks\n", completedCount, len(chunks))
+	completedCount := len(yt.getCompletedChunks(ctx, chunks))
+	slog.InfoContext(ctx, "Voiceover generation finished", "completed", completedCount, "total", len(chunks))
 
 	if completedCount < len(chunks) {
-		fmt.Printf("ℹ️  %d chunks still pending. Run again to resume.\n", len(chunks)-completedCount)
+		slog.InfoContext(ctx, "Chunks still pending, run again to resume", "pending", len(chunks)-completedCount)
 	}
 
 	return nil
@@ -452,7 +445,7 @@ func (yt *YtAutomation) getCompletedChunks1(chunks []ScriptAudio) []ScriptAudio
 	for _, chunk := range chunks {
 		updatedScriptAudio, err := yt.getScriptAudioByID(chunk.ID)
 		if err != nil {
-			fmt.Printf("Warning: Failed to load chunk %s: %v\n", chunk.ID.Hex(), err)
+			slog.Warn("Failed to load chunk", "chunk_id", chunk.ID.Hex(), "error", err)
 			continue // Skip this chunk if it can't be loaded
 		}
 		if updatedScriptAudio.GenerationStatus == "completed" && yt.audioFileExists(chunk.AudioFilePath) {
@@ -464,7 +457,7 @@ func (yt *YtAutomation) getCompletedChunks1(chunks []ScriptAudio) []ScriptAudio
 
 func (yt *YtAutomation) getCompletedAudioFiles1(chunks []ScriptAudio) []string {
 	var audioFiles []string
-	completed := yt.getCompletedChunks(chunks)
+	completed := yt.getCompletedChunks(context.Background(), chunks)
 
 	// Sort by chunk index to maintain order
 	sort.Slice(completed, func(i, j int) bool {
@@ -505,12 +498,12 @@ func (yt *YtAutomation) updateChunkStatus1(chunkID primitive.ObjectID, status, a
 		update,
 	)
 	if err != nil {
-		fmt.Printf("Warning: Failed to update chunk status: %v\n", err)
+		slog.Warn("Failed to update chunk status", "chunk_id", chunkID.Hex(), "error", err)
 	}
 }
 
 // mergeAudioFiles combines multiple audio files into one using FFmpeg
-func (yt *YtAutomation) mergeAudioFiles(inputFiles []string, outputFile string) error {
+func (yt *YtAutomation) mergeAudioFiles(ctx context.Context, inputFiles []string, outputFile string) error {
 	if len(inputFiles) == 0 {
 		return fmt.Errorf("no input files to merge")
 	}
@@ -565,7 +558,7 @@ func (yt *YtAutomation) mergeAudioFiles(inputFiles []string, outputFile string)
 		return fmt.Errorf("ffmpeg failed: %v, output: %s", err, string(output))
 	}
 
-	fmt.Printf("✅ Successfully merged %d audio files into: %s\n", len(inputFiles), outputFile)
+	slog.InfoContext(ctx, "Merged audio files", "count", len(inputFiles), "output", outputFile)
 	return nil
 }
 
@@ -582,7 +575,7 @@ func (yt *YtAutomation) copyFile(src, dst string) error {
 func (yt *YtAutomation) cleanupTempFiles(files []string) error {
 	for _, file := range files {
 		if err := os.Remove(file); err != nil {
-			fmt.Printf("Warning: Failed to remove temp file %s: %v\n", file, err)
+			slog.Warn("Failed to remove temp file", "file", file, "error", err)
 		}
 	}
 	return nil
@@ -626,31 +619,30 @@ func fixJSONFormatting(jsonStr string) string {
 
 	return strings.Join(cleanLines, "\n")
 }
-func (yt *YtAutomation) filterChunksNeedingGeneration(chunks []ChunkVisual) []ChunkVisual {
+func (yt *YtAutomation) filterChunksNeedingGeneration(ctx context.Context, chunks []ChunkVisual) []ChunkVisual {
 	var needsGeneration []ChunkVisual
 
 	for _, chunk := range chunks {
 		// Skip if already has image_path
 		if chunk.ImagePath != "" {
-			fmt.Printf("Skipping chunk %d - already has image\n", chunk.ChunkIndex)
+			slog.DebugContext(ctx, "Skipping chunk that already has an image", logKeyChunkIndex, chunk.ChunkIndex)
 			continue
 		}
 		needsGeneration = append(needsGeneration, chunk)
 	}
 
-	fmt.Printf("Found %d chunks needing generation out of %d total\n",
-		len(needsGeneration), len(chunks))
+	slog.InfoContext(ctx, "Chunks needing image generation", "pending", len(needsGeneration), "total", len(chunks))
 	return needsGeneration
 }
 
 // Fixed method to get pending chunks
-func (yt *YtAutomation) getPendingChunks(chunks []ScriptAudio) []ScriptAudio {
+func (yt *YtAutomation) getPendingChunks(ctx context.Context, chunks []ScriptAudio) []ScriptAudio {
 	var pending []ScriptAudio
 	for _, chunk := range chunks {
 		// Refresh chunk data from database to get latest status
 		updatedChunk, err := yt.getScriptAudioByID(chunk.ID)
 		if err != nil {
-			fmt.Printf("Warning: Failed to load chunk %s, treating as pending: %v\n", chunk.ID.Hex(), err)
+			slog.WarnContext(ctx, "Failed to load chunk, treating as pending", logKeyChunkIndex, chunk.ChunkIndex, "error", err)
 			pending = append(pending, chunk)
 			continue
 		}
@@ -660,18 +652,18 @@ func (yt *YtAutomation) getPendingChunks(chunks []ScriptAudio) []ScriptAudio {
 			pending = append(pending, *updatedChunk)
 		}
 	}
-	fmt.Printf("📋 Found %d pending chunks out of %d total\n", len(pending), len(chunks))
+	slog.DebugContext(ctx, "Pending audio chunks", "pending", len(pending), "total", len(chunks))
 	return pending
 }
 
 // Fixed method to get completed chunks
-func (yt *YtAutomation) getCompletedChunks(chunks []ScriptAudio) []ScriptAudio {
+func (yt *YtAutomation) getCompletedChunks(ctx context.Context, chunks []ScriptAudio) []ScriptAudio {
 	var completed []ScriptAudio
 	for _, chunk := range chunks {
 		// Refresh chunk data from database to get latest status
 		updatedChunk, err := yt.getScriptAudioByID(chunk.ID)
 		if err != nil {
-			fmt.Printf("Warning: Failed to load chunk %s: %v\n", chunk.ID.Hex(), err)
+			slog.WarnContext(ctx, "Failed to load chunk", logKeyChunkIndex, chunk.ChunkIndex, "error", err)
 			continue
 		}
 
@@ -680,14 +672,14 @@ func (yt *YtAutomation) getCompletedChunks(chunks []ScriptAudio) []ScriptAudio {
 			completed = append(completed, *updatedChunk)
 		}
 	}
-	fmt.Printf("✅ Found %d completed chunks\n", len(completed))
+	slog.DebugContext(ctx, "Completed audio chunks", "completed", len(completed), "total", len(chunks))
 	return completed
 }
 
 // Fixed method to get completed audio files
-func (yt *YtAutomation) getCompletedAudioFiles(chunks []ScriptAudio) []string {
+func (yt *YtAutomation) getCompletedAudioFiles(ctx context.Context, chunks []ScriptAudio) []string {
 	var audioFiles []string
-	completed := yt.getCompletedChunks(chunks)
+	completed := yt.getCompletedChunks(ctx, chunks)
 
 	// Sort by chunk index to maintain order
 	sort.Slice(completed, func(i, j int) bool {
@@ -697,18 +689,18 @@ func (yt *YtAutomation) getCompletedAudioFiles(chunks []ScriptAudio) []string {
 	for _, chunk := range completed {
 		if chunk.AudioFilePath != "" && yt.audioFileExists(chunk.AudioFilePath) {
 			audioFiles = append(audioFiles, chunk.AudioFilePath)
-			fmt.Printf("📁 Added audio file: %s (Chunk %d)\n", chunk.AudioFilePath, chunk.ChunkIndex)
+			slog.DebugContext(ctx, "Added audio file", logKeyChunkIndex, chunk.ChunkIndex, "path", chunk.AudioFilePath)
 		} else {
-			fmt.Printf("⚠️  Warning: Completed chunk %d has no valid audio file path\n", chunk.ChunkIndex)
+			slog.WarnContext(ctx, "Completed chunk has no valid audio file path", logKeyChunkIndex, chunk.ChunkIndex)
 		}
 	}
 
-	fmt.Printf("📊 Total audio files collected: %d\n", len(audioFiles))
+	slog.DebugContext(ctx, "Audio files collected", "count", len(audioFiles))
 	return audioFiles
 }
 
 // Fixed method to update chunk status
-func (yt *YtAutomation) updateChunkStatus(chunkID primitive.ObjectID, status, audioPath string) {
+func (yt *YtAutomation) updateChunkStatus(ctx context.Context, chunkID primitive.ObjectID, status, audioPath string) {
 	update := bson.M{
 		"$set": bson.M{
 			"generation_status": status,
@@ -727,93 +719,87 @@ func (yt *YtAutomation) updateChunkStatus(chunkID primitive.ObjectID, status, au
 	)
 
 	if err != nil {
-		fmt.Printf("❌ Error updating chunk status: %v\n", err)
+		slog.ErrorContext(ctx, "Error updating chunk status", "chunk_id", chunkID.Hex(), "error", err)
 		return
 	}
 
 	if result.MatchedCount == 0 {
-		fmt.Printf("⚠️  Warning: No chunk found with ID %s\n", chunkID.Hex())
+		slog.WarnContext(ctx, "No chunk found to update", "chunk_id", chunkID.Hex())
 	} else {
-		fmt.Printf("✅ Updated chunk %s: status=%s, path=%s\n", chunkID.Hex(), status, audioPath)
+		slog.DebugContext(ctx, "Updated chunk status", "chunk_id", chunkID.Hex(), "status", status, "path", audioPath)
 	}
 }
 
-// Enhanced debug method
-func (yt *YtAutomation) debugChunks(chunks []ScriptAudio) {
-	fmt.Println("\n🔍 Debug: Chunk Status Overview")
-	fmt.Println("================================")
+// debugChunks logs the stored state of each chunk at debug level
+func (yt *YtAutomation) debugChunks(ctx context.Context, chunks []ScriptAudio) {
+	if !slog.Default().Enabled(ctx, slog.LevelDebug) {
+		return
+	}
 
 	statusCount := make(map[string]int)
 
-	for i, chunk := range chunks {
+	for _, chunk := range chunks {
 		// Get fresh data from database
 		updatedChunk, err := yt.getScriptAudioByID(chunk.ID)
 		if err != nil {
-			fmt.Printf("❌ Chunk %d (Index: %d) - Failed to load from DB: %v\n", i+1, chunk.ChunkIndex, err)
+			slog.WarnContext(ctx, "Failed to load chunk", logKeyChunkIndex, chunk.ChunkIndex, "error", err)
 			continue
 		}
 
 		statusCount[updatedChunk.GenerationStatus]++
 
-		fmt.Printf("Chunk %d (Index: %d):\n", i+1, updatedChunk.ChunkIndex)
-		fmt.Printf("  ID: %s\n", updatedChunk.ID.Hex())
-		fmt.Printf("  Status: %s\n", updatedChunk.GenerationStatus)
-		fmt.Printf("  AudioFilePath: %s\n", updatedChunk.AudioFilePath)
-		fmt.Printf("  Content Length: %d chars\n", len(updatedChunk.Content))
-
-		if updatedChunk.AudioFilePath != "" {
-			if yt.audioFileExists(updatedChunk.AudioFilePath) {
-				fmt.Printf("  File Exists: ✅\n")
-			} else {
-				fmt.Printf("  File Exists: ❌\n")
-			}
-		} else {
-			fmt.Printf("  File Path: ❌ Empty\n")
-		}
-		fmt.Println()
+		slog.DebugContext(ctx, "Chunk status",
+			logKeyChunkIndex, updatedChunk.ChunkIndex,
+			"chunk_id", updatedChunk.ID.Hex(),
+			"status", updatedChunk.GenerationStatus,
+			"audio_file_path", updatedChunk.AudioFilePath,
+			"audio_file_exists", yt.audioFileExists(updatedChunk.AudioFilePath),
+			"content_length", len(updatedChunk.Content))
 	}
 
-	fmt.Printf("📊 Status Summary: %+v\n", statusCount)
-	fmt.Println("================================\n")
+	slog.DebugContext(ctx, "Chunk status summary", "statuses", statusCount)
 }
 
 // Updated generateVoiceOver method with better debugging
-func (yt *YtAutomation) generateVoiceOver(script Script, chunks []ScriptAudio) error {
-	// Check ElevenLabs credits first
-	subInfo, err := yt.elevenLabsClient.GetSubscriptionInfo()
-	if err != nil {
-		fmt.Printf("Warning: Could not fetch subscription info: %v\n", err)
-	} else {
-		remaining := subInfo.CharacterLimit - subInfo.CharacterCount
-		fmt.Printf("📊 ElevenLabs Credits - Used: %d/%d, Remaining: %d characters\n",
-			subInfo.CharacterCount, subInfo.CharacterLimit, remaining)
+func (yt *YtAutomation) generateVoiceOver(ctx context.Context, script Script, chunks []ScriptAudio) error {
+	ctx = withScriptLog(ctx, script.ID)
 
-		if remaining < 1000 {
-			fmt.Printf("⚠️  Warning: Low credits remaining (%d characters)\n", remaining)
-		}
+	channel, err := yt.getChannelByID(script.ChannelID)
+	if err != nil {
+		slog.WarnContext(ctx, "Could not load channel, using the default TTS provider", "error", err)
 	}
+	ttsProvider, _ := channelVoice(channel)
+	slog.InfoContext(ctx, "Voicing narration", "tts_provider", ttsProvider)
 
 	// Debug chunks before processing
-	yt.debugChunks(chunks)
+	yt.debugChunks(ctx, chunks)
 
 	var audioFiles []string
-	pendingChunks := yt.getPendingChunks(chunks)
+	pendingChunks := yt.getPendingChunks(ctx, chunks)
 
-	fmt.Printf("📋 Total chunks: %d, Pending generation: %d\n", len(chunks), len(pendingChunks))
+	slog.InfoContext(ctx, "Voiceover chunks", "total", len(chunks), "pending", len(pendingChunks))
 
 	// Generate only pending chunks
 	for i, chunk := range pendingChunks {
-		fmt.Printf("🎵 Generating voice for chunk %d/%d (Chunk Index: %d)...\n",
-			i+1, len(pendingChunks), chunk.ChunkIndex)
+		// Chunks not reached stay pending for the next run
+		if err := checkpoint(ctx); err != nil {
+			return err
+		}
+		ctx := withLogAttrs(ctx, slog.Int(logKeyChunkIndex, chunk.ChunkIndex))
+		slog.InfoContext(ctx, "Generating voice for chunk", "position", i+1, "pending", len(pendingChunks))
 
 		// Update status to generating
-		yt.updateChunkStatus(chunk.ID, "generating", "")
+		yt.updateChunkStatus(ctx, chunk.ID, "generating", "")
 
 		// Generate speech
-		audioData, err := yt.elevenLabsClient.TextToSpeech(chunk.Content, os.Getenv("VOICE_ID"))
+		ttsCtx, end := startStage(ctx, StageTTSChunk)
+		audioData, err := yt.synthesizeNarration(ttsCtx, channel, chunk.Content)
+		if err != nil {
+			slog.ErrorContext(ttsCtx, "Error generating speech", "tts_provider", ttsProvider, "error", err)
+		}
+		end(&err)
 		if err != nil {
-			fmt.Printf("❌ Error generating speech for chunk %d: %v\n", chunk.ChunkIndex, err)
-			yt.updateChunkStatus(chunk.ID, "failed", "")
+			yt.updateChunkStatus(ctx, chunk.ID, "failed", "")
 			continue // Continue with next chunk instead of failing completely
 		}
 
@@ -823,53 +809,52 @@ func (yt *YtAutomation) generateVoiceOver(script Script, chunks []ScriptAudio) e
 
 		// Ensure directory exists
 		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
-			fmt.Printf("❌ Error creating directory for chunk %d: %v\n", chunk.ChunkIndex, err)
-			yt.updateChunkStatus(chunk.ID, "failed", "")
+			slog.ErrorContext(ctx, "Error creating audio directory", "error", err)
+			yt.updateChunkStatus(ctx, chunk.ID, "failed", "")
 			continue
 		}
 
 		if err = saveAudioFile(audioData, path); err != nil {
-			fmt.Printf("❌ Error saving audio file for chunk %d: %v\n", chunk.ChunkIndex, err)
-			yt.updateChunkStatus(chunk.ID, "failed", "")
+			slog.ErrorContext(ctx, "Error saving audio file", "error", err)
+			yt.updateChunkStatus(ctx, chunk.ID, "failed", "")
 			continue
 		}
 
 		// Update status to completed with file path
-		yt.updateChunkStatus(chunk.ID, "completed", path)
-		fmt.Printf("✅ Voice generation complete for chunk %d, saved to: %s\n", chunk.ChunkIndex, path)
+		yt.updateChunkStatus(ctx, chunk.ID, "completed", path)
+		slog.InfoContext(ctx, "Voice generation complete for chunk", "path", path)
 	}
 
 	// Debug chunks after processing
-	fmt.Println("🔄 After generation:")
-	yt.debugChunks(chunks)
+	yt.debugChunks(ctx, chunks)
 
 	// Collect all completed audio files (including previously generated ones)
-	audioFiles = yt.getCompletedAudioFiles(chunks)
+	audioFiles = yt.getCompletedAudioFiles(ctx, chunks)
 
 	if len(audioFiles) == 0 {
-		fmt.Printf("❌ No completed audio files found!\n")
+		slog.ErrorContext(ctx, "No completed audio files found")
 		return fmt.Errorf("no audio files generated successfully")
 	}
 
 	// Merge all available audio files
-	fmt.Printf("🔄 Merging %d audio files...\n", len(audioFiles))
+	slog.InfoContext(ctx, "Merging audio files", "count", len(audioFiles))
 	mergedFilename := fmt.Sprintf("%s_complete_voiceover_%s.mp3",
 		script.ChannelName,
 		time.Now().Format("20060102_150405"))
 	mergedPath := filepath.Join("assets", "audio", mergedFilename)
 
-	if err := yt.mergeAudioFiles(audioFiles, mergedPath); err != nil {
+	if err := yt.mergeAudioFiles(ctx, audioFiles, mergedPath); err != nil {
 		return fmt.Errorf("error merging audio files: %v", err)
 	}
 
 	// Update script collection with merged audio file
 	yt.UpdateScriptCollection(script.ID, mergedPath)
 
-	completedCount := len(yt.getCompletedChunks(chunks))
-	fmt.Printf("✅ Voiceover generation finished! Completed: %d/%d chunks\n", completedCount, len(chunks))
+	completedCount := len(yt.getCompletedChunks(ctx, chunks))
+	slog.InfoContext(ctx, "Voiceover generation finished", "completed", completedCount, "total", len(chunks))
 
 	if completedCount < len(chunks) {
-		fmt.Printf("ℹ️  %d chunks still pending. Run again to resume.\n", len(chunks)-completedCount)
+		slog.InfoContext(ctx, "Chunks still pending, run again to resume", "pending", len(chunks)-completedCount)
 	}
 
 	return nil
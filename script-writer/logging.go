@@ -0,0 +1,357 @@
+// File: logging.go
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+	"youtube_automation/logging"
+	"youtube_automation/tracing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Attribute keys shared by every log line of the pipeline
+const (
+	logKeyScriptID   = "script_id"
+	logKeyChunkIndex = "chunk_index"
+	logKeyStage      = "stage"
+	logKeyProvider   = "provider"
+)
+
+// ScriptLog is a pipeline log line kept for a script
+type ScriptLog struct {
+	ID       primitive.ObjectID     `bson:"_id,omitempty" json:"-"`
+	ScriptID primitive.ObjectID     `bson:"script_id" json:"-"`
+	Time     time.Time              `bson:"time" json:"time"`
+	Level    string                 `bson:"level" json:"level"`
+	Message  string                 `bson:"msg" json:"msg"`
+	Stage    string                 `bson:"stage,omitempty" json:"stage,omitempty"`
+	Attrs    map[string]interface{} `bson:"attrs,omitempty" json:"attrs,omitempty"`
+	TraceID  string                 `bson:"trace_id,omitempty" json:"trace_id,omitempty"`
+}
+
+// ScriptLogsResponse lists the persisted log lines of a script, oldest first
+type ScriptLogsResponse struct {
+	ScriptID string      `json:"script_id"`
+	Count    int         `json:"count"`
+	Logs     []ScriptLog `json:"logs"`
+}
+
+type logAttrsKey struct{}
+
+// withLogAttrs returns ctx carrying attributes added to every line logged with it,
+// e.g. the script and stage a goroutine works on. An attribute replaces one with the
+// same key already in ctx.
+func withLogAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	existing, _ := ctx.Value(logAttrsKey{}).([]slog.Attr)
+	merged := make([]slog.Attr, 0, len(existing)+len(attrs))
+	for _, attr := range existing {
+		if !hasLogAttr(attrs, attr.Key) {
+			merged = append(merged, attr)
+		}
+	}
+	merged = append(merged, attrs...)
+	return context.WithValue(ctx, logAttrsKey{}, merged)
+}
+
+func hasLogAttr(attrs []slog.Attr, key string) bool {
+	for _, attr := range attrs {
+		if attr.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// withScriptLog is withLogAttrs for the script a pipeline run works on
+func withScriptLog(ctx context.Context, scriptID primitive.ObjectID) context.Context {
+	return withLogAttrs(ctx, slog.String(logKeyScriptID, scriptID.Hex()))
+}
+
+// logLevel is server.log_level, kept in a LevelVar so a config reload can change it
+var logLevel slog.LevelVar
+
+// setupLogging makes slog, and the log package through it, write JSON lines to stdout
+// at server.log_level. Lines carrying a script_id are also stored once startScriptLogs runs.
+func setupLogging() {
+	logLevel.Set(logging.ParseLevel(appConfig().Server.LogLevel))
+	handler := &contextHandler{next: slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		Level: &logLevel,
+	})}
+	slog.SetDefault(slog.New(handler))
+}
+
+// contextHandler adds the ctx attributes and trace ID to each record and hands lines
+// for a script to the script log writer
+type contextHandler struct {
+	next   slog.Handler
+	attrs  []slog.Attr // From WithAttrs, kept for the script log
+	groups []string
+}
+
+func (h *contextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if attrs, ok := ctx.Value(logAttrsKey{}).([]slog.Attr); ok {
+		// An attribute passed to the call itself wins over the ctx one
+		for _, attr := range attrs {
+			if !recordHasAttr(record, attr.Key) {
+				record.AddAttrs(attr)
+			}
+		}
+	}
+	if sc := tracing.SpanFromContext(ctx).SpanContext(); sc.IsValid() {
+		record.AddAttrs(slog.String("trace_id", hex.EncodeToString(sc.TraceID[:])))
+	}
+	if writer := scriptLogs.Load(); writer != nil && len(h.groups) == 0 {
+		writer.add(h.attrs, record)
+	}
+	return h.next.Handle(ctx, record)
+}
+
+func recordHasAttr(record slog.Record, key string) bool {
+	found := false
+	record.Attrs(func(attr slog.Attr) bool {
+		found = attr.Key == key
+		return !found
+	})
+	return found
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{
+		next:   h.next.WithAttrs(attrs),
+		attrs:  append(append([]slog.Attr{}, h.attrs...), attrs...),
+		groups: h.groups,
+	}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{
+		next:   h.next.WithGroup(name),
+		attrs:  h.attrs,
+		groups: append(append([]string{}, h.groups...), name),
+	}
+}
+
+var scriptLogs atomic.Pointer[scriptLogWriter]
+
+// scriptLogWriter stores script log lines from one goroutine, so logging never waits on Mongo
+type scriptLogWriter struct {
+	queue   chan ScriptLog
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// startScriptLogs starts persisting log lines that carry a script_id
+func startScriptLogs() {
+	writer := &scriptLogWriter{
+		queue:   make(chan ScriptLog, scriptLogQueueSize),
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go writer.run()
+	scriptLogs.Store(writer)
+}
+
+// stopScriptLogs writes the queued lines and stops persisting new ones, waiting until
+// ctx ends at most
+func stopScriptLogs(ctx context.Context) {
+	writer := scriptLogs.Swap(nil)
+	if writer == nil {
+		return
+	}
+	close(writer.stop)
+	select {
+	case <-writer.stopped:
+	case <-ctx.Done():
+		slog.Warn("Script logs not fully stored at shutdown", "queued", len(writer.queue))
+	}
+}
+
+func (w *scriptLogWriter) add(handlerAttrs []slog.Attr, record slog.Record) {
+	entry := ScriptLog{
+		Time:    record.Time,
+		Level:   strings.ToLower(record.Level.String()),
+		Message: record.Message,
+		Attrs:   map[string]interface{}{},
+	}
+	collect := func(attr slog.Attr) bool {
+		value := attr.Value.Resolve()
+		switch attr.Key {
+		case logKeyScriptID:
+			if id, err := primitive.ObjectIDFromHex(value.String()); err == nil {
+				entry.ScriptID = id
+			}
+		case logKeyStage:
+			entry.Stage = value.String()
+		case "trace_id":
+			entry.TraceID = value.String()
+		default:
+			entry.Attrs[attr.Key] = logValue(value)
+		}
+		return true
+	}
+	for _, attr := range handlerAttrs {
+		collect(attr)
+	}
+	record.Attrs(collect)
+	if entry.ScriptID.IsZero() {
+		return
+	}
+
+	select {
+	case w.queue <- entry:
+	default:
+		// Dropping keeps a burst of lines from stalling the pipeline; stdout still has them
+	}
+}
+
+func logValue(value slog.Value) interface{} {
+	switch value.Kind() {
+	case slog.KindString:
+		return value.String()
+	case slog.KindInt64:
+		return value.Int64()
+	case slog.KindUint64:
+		return value.Uint64()
+	case slog.KindFloat64:
+		return value.Float64()
+	case slog.KindBool:
+		return value.Bool()
+	case slog.KindDuration:
+		return value.Duration().String()
+	case slog.KindTime:
+		return value.Time()
+	default:
+		if err, ok := value.Any().(error); ok {
+			return err.Error()
+		}
+		return fmt.Sprint(value.Any())
+	}
+}
+
+func (w *scriptLogWriter) run() {
+	defer close(w.stopped)
+	ticker := time.NewTicker(scriptLogFlushInterval)
+	defer ticker.Stop()
+
+	var batch []interface{}
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		_, err := scriptLogsCollection.InsertMany(ctx, batch, options.InsertMany().SetOrdered(false))
+		cancel()
+		if err != nil {
+			// No script_id here, so this line is not stored itself
+			slog.Warn("Failed to store script logs", "count", len(batch), "error", err)
+		}
+		batch = nil
+	}
+	for {
+		select {
+		case entry := <-w.queue:
+			batch = append(batch, entry)
+			if len(batch) >= scriptLogBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-w.stop:
+			for len(w.queue) > 0 {
+				batch = append(batch, <-w.queue)
+				if len(batch) >= scriptLogBatchSize {
+					flush()
+				}
+			}
+			flush()
+			return
+		}
+	}
+}
+
+// getScriptLogsHandler serves GET /scripts/{id}/logs
+func (yt *YtAutomation) getScriptLogsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	idStr := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/scripts/"), "/logs")
+	scriptID, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid script ID format")
+		return
+	}
+
+	query := r.URL.Query()
+	filter := bson.M{"script_id": scriptID}
+	if stage := query.Get("stage"); stage != "" {
+		filter["stage"] = stage
+	}
+	if levelStr := query.Get("level"); levelStr != "" {
+		var minLevel slog.Level
+		if err := minLevel.UnmarshalText([]byte(levelStr)); err != nil {
+			respondWithError(w, http.StatusBadRequest, "level must be debug, info, warn or error")
+			return
+		}
+		var levels []string
+		for _, level := range []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError} {
+			if level >= minLevel {
+				levels = append(levels, strings.ToLower(level.String()))
+			}
+		}
+		filter["level"] = bson.M{"$in": levels}
+	}
+	limit := maxScriptLogPageSize
+	if limitStr := query.Get("limit"); limitStr != "" {
+		n, err := strconv.Atoi(limitStr)
+		if err != nil || n < 1 {
+			respondWithError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = min(n, maxScriptLogPageSize)
+	}
+
+	if err := scriptsCollection.FindOne(r.Context(), bson.M{"_id": scriptID}).Err(); err != nil {
+		if err == mongo.ErrNoDocuments {
+			respondWithError(w, http.StatusNotFound, "Script not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Database error: %v", err))
+		return
+	}
+
+	// The newest lines matter most for a failed run, so take them and return oldest first
+	cursor, err := scriptLogsCollection.Find(r.Context(), filter,
+		options.Find().SetSort(bson.D{{"time", -1}, {"_id", -1}}).SetLimit(int64(limit)))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Database error: %v", err))
+		return
+	}
+	logs := []ScriptLog{}
+	if err := cursor.All(r.Context(), &logs); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Database error: %v", err))
+		return
+	}
+	for i, j := 0, len(logs)-1; i < j; i, j = i+1, j-1 {
+		logs[i], logs[j] = logs[j], logs[i]
+	}
+
+	respondWithJSON(w, http.StatusOK, ScriptLogsResponse{ScriptID: scriptID.Hex(), Count: len(logs), Logs: logs})
+}
@@ -0,0 +1,386 @@
+// File: long_form.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// planLongForm converts a target runtime into word counts and lays out how many
+// chapters each act gets and how many sections each chapter gets.
+func planLongForm(targetMinutes, wordsPerMinute, hookWords, wordsPerSection int) *LongFormPlan {
+	if wordsPerMinute <= 0 {
+		wordsPerMinute = defaultWordsPerMinute
+	}
+	if wordsPerSection <= 0 {
+		wordsPerSection = 500
+	}
+
+	targetWords := targetMinutes * wordsPerMinute
+	bodyWords := targetWords - hookWords
+	sectionCount := int(math.Ceil(float64(bodyWords) / float64(wordsPerSection)))
+	if sectionCount < 1 {
+		sectionCount = 1
+	}
+
+	chapterCount := int(math.Ceil(float64(sectionCount) / float64(longFormSectionsPerChapter)))
+	actCount := longFormActCount
+	if chapterCount < actCount {
+		actCount = chapterCount
+	}
+
+	plan := &LongFormPlan{
+		TargetMinutes:   targetMinutes,
+		WordsPerMinute:  wordsPerMinute,
+		TargetWords:     targetWords,
+		WordsPerSection: wordsPerSection,
+		SectionCount:    sectionCount,
+	}
+
+	// Spread chapters over acts and sections over chapters as evenly as possible,
+	// giving the remainder to the earlier acts/chapters.
+	chapterNumber := 0
+	for a := 0; a < actCount; a++ {
+		act := OutlineAct{ActNumber: a + 1}
+		chaptersInAct := chapterCount / actCount
+		if a < chapterCount%actCount {
+			chaptersInAct++
+		}
+		for c := 0; c < chaptersInAct; c++ {
+			sectionsInChapter := sectionCount / chapterCount
+			if chapterNumber < sectionCount%chapterCount {
+				sectionsInChapter++
+			}
+			chapterNumber++
+			act.Chapters = append(act.Chapters, OutlineChapter{
+				ChapterNumber: chapterNumber,
+				SectionCount:  sectionsInChapter,
+			})
+		}
+		plan.Acts = append(plan.Acts, act)
+	}
+
+	return plan
+}
+
+// measureChannelWordsPerMinute derives the narration pace from the channel's most recent
+// scripts that already have subtitles, falling back to the stored setting or the default.
+func (yt *YtAutomation) measureChannelWordsPerMinute(ctx context.Context, channel *Channel) int {
+	fallback := channel.Settings.WordsPerMinute
+	if fallback <= 0 {
+		fallback = defaultWordsPerMinute
+	}
+
+	cursor, err := scriptsCollection.Find(
+		context.Background(),
+		bson.M{"channel_id": channel.ID, "srt": bson.M{"$ne": ""}, "full_script": bson.M{"$ne": ""}},
+		options.Find().
+			SetSort(bson.D{{"created_at", -1}}).
+			SetLimit(wordsPerMinuteSampleScripts).
+			SetProjection(bson.M{"full_script": 1, "srt": 1}),
+	)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to load scripts for words-per-minute measurement", "error", err)
+		return fallback
+	}
+	defer cursor.Close(context.Background())
+
+	var scripts []Script
+	if err := cursor.All(context.Background(), &scripts); err != nil {
+		slog.WarnContext(ctx, "Failed to decode scripts for words-per-minute measurement", "error", err)
+		return fallback
+	}
+
+	totalWords := 0
+	totalMinutes := 0.0
+	for _, script := range scripts {
+		seconds, err := yt.calculateDurationFromSRT(script.SRT)
+		if err != nil || seconds <= 0 {
+			continue
+		}
+		totalWords += len(strings.Fields(script.FullScript))
+		totalMinutes += seconds / 60
+	}
+
+	if totalWords == 0 || totalMinutes == 0 {
+		return fallback
+	}
+
+	measured := int(math.Round(float64(totalWords) / totalMinutes))
+	if measured != channel.Settings.WordsPerMinute {
+		_, err := channelsCollection.UpdateOne(
+			context.Background(),
+			bson.M{"_id": channel.ID},
+			bson.M{"$set": bson.M{"settings.words_per_minute": measured, "updated_at": time.Now()}},
+		)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to store measured words per minute", "error", err)
+		}
+	}
+
+	slog.InfoContext(ctx, "Measured words per minute", "channel", channel.ChannelName,
+		"words_per_minute", measured, "scripts", len(scripts))
+	return measured
+}
+
+// GenerateLongFormScript runs the act -> chapter -> section pipeline for scripts that were
+// requested with a target runtime.
+func (yt *YtAutomation) GenerateLongFormScript(ctx context.Context, script *Script, channel *Channel) error {
+	scriptID := script.ID
+
+	// A resumed script keeps the plan and outline it already saved
+	if len(script.OutlinePoints) == 0 {
+		if err := checkpoint(ctx); err != nil {
+			return err
+		}
+		wordsPerMinute := yt.measureChannelWordsPerMinute(ctx, channel)
+		plan := planLongForm(script.LongForm.TargetMinutes, wordsPerMinute,
+			channel.Settings.WordLimitForHookIntro, channel.Settings.WordLimitPerSection)
+		script.LongForm = plan
+		if err := yt.updateScriptInDB(scriptID, bson.M{"long_form": plan}); err != nil {
+			return fmt.Errorf("saving long-form plan: %w", err)
+		}
+
+		slog.InfoContext(ctx, "Long-form plan", "target_minutes", plan.TargetMinutes, "words_per_minute", plan.WordsPerMinute,
+			"target_words", plan.TargetWords, "acts", len(plan.Acts), "sections", plan.SectionCount, "words_per_section", plan.WordsPerSection)
+
+		// Step 1: Generate act/chapter outline, then section outline per chapter
+		yt.updateScriptStatus(scriptID, "generating_outline")
+		if err := yt.generateLongFormOutline(ctx, script); err != nil {
+			yt.updateScriptError(scriptID, err.Error())
+			return fmt.Errorf("generating long-form outline: %w", err)
+		}
+	}
+	script, err := yt.getScriptByID(scriptID)
+	if err != nil {
+		return fmt.Errorf("loading script: %w", err)
+	}
+	plan := script.LongForm
+
+	if script.FullScript == "" {
+		if err := checkpoint(ctx); err != nil {
+			return err
+		}
+		// Step 2: Generate hook and introduction
+		yt.updateScriptStatus(scriptID, "generating_hook")
+		if err := yt.generateHookAndIntroduction(ctx, script, channel.Settings.WordLimitForHookIntro); err != nil {
+			yt.updateScriptError(scriptID, err.Error())
+			return fmt.Errorf("generating hook: %w", err)
+		}
+	}
+
+	// Step 3: Generate sections, summarizing as we go
+	yt.updateScriptStatus(scriptID, "generating_sections")
+	for _, point := range script.OutlinePoints {
+		if point.SectionNumber <= script.SectionsGenerated {
+			continue
+		}
+		if err := checkpoint(ctx); err != nil {
+			return err
+		}
+		yt.updateScriptCurrentSection(scriptID, point.SectionNumber)
+		if err := yt.generateLongFormSection(ctx, scriptID, point, plan.WordsPerSection); err != nil {
+			yt.updateScriptError(scriptID, err.Error())
+			return fmt.Errorf("generating section %d: %w", point.SectionNumber, err)
+		}
+		sleepContext(ctx, time.Second*2) // Rate limiting
+	}
+
+	// Step 4: Generate meta tags
+	if err := checkpoint(ctx); err != nil {
+		return err
+	}
+	yt.updateScriptStatus(scriptID, "generating_meta")
+	if err := yt.generateMetaTag(ctx, script); err != nil {
+		slog.WarnContext(ctx, "Meta tag generation failed", "error", err)
+	}
+
+	yt.updateScriptStatus(scriptID, "completed")
+	yt.updateScriptCompletedAt(scriptID)
+
+	slog.InfoContext(ctx, "Long-form script text completed", "topic", script.Topic)
+	return nil
+}
+
+func (yt *YtAutomation) generateLongFormOutline(ctx context.Context, script *Script) (err error) {
+	ctx, end := startStage(ctx, StageOutline)
+	defer end(&err)
+
+	slog.InfoContext(ctx, "Generating long-form act outline")
+	plan := script.LongForm
+
+	systemPrompt, userPrompt, err := yt.templateService.BuildLongFormOutlinePrompt(script)
+	if err != nil {
+		return fmt.Errorf("building long-form outline prompt: %w", err)
+	}
+
+	response, err := yt.aiService.GenerateContentWithSystem(ctx, systemPrompt, userPrompt)
+	if err != nil {
+		return err
+	}
+
+	acts, err := yt.outlineParser.ParseActOutlineJSON(response, plan)
+	if err != nil {
+		return fmt.Errorf("parsing act outline JSON: %w", err)
+	}
+
+	for a, act := range acts {
+		plan.Acts[a].Title = act.Title
+		plan.Acts[a].Summary = act.Summary
+		for c, chapter := range act.Chapters {
+			plan.Acts[a].Chapters[c].Title = chapter.Title
+			plan.Acts[a].Chapters[c].Summary = chapter.Summary
+		}
+	}
+
+	// The act/chapter outline is what hook, meta and chapter prompts see as {OUTLINE};
+	// the full section list would grow with the runtime.
+	script.Outline = formatLongFormOutline(plan)
+
+	var outlinePoints []OutlinePoint
+	for _, act := range plan.Acts {
+		for _, chapter := range act.Chapters {
+			slog.InfoContext(ctx, "Generating section outline for chapter", "chapter", chapter.ChapterNumber, "title", chapter.Title)
+
+			systemPrompt, userPrompt, err := yt.templateService.BuildLongFormChapterPrompt(script, act, chapter)
+			if err != nil {
+				return fmt.Errorf("building chapter outline prompt: %w", err)
+			}
+
+			response, err := yt.aiService.GenerateContentWithSystem(ctx, systemPrompt, userPrompt)
+			if err != nil {
+				return fmt.Errorf("generating outline for chapter %d: %w", chapter.ChapterNumber, err)
+			}
+
+			sections, err := yt.outlineParser.ParseOutlineJSON(response, chapter.SectionCount)
+			if err != nil {
+				return fmt.Errorf("parsing outline JSON for chapter %d: %w", chapter.ChapterNumber, err)
+			}
+
+			for _, section := range sections {
+				outlinePoints = append(outlinePoints, OutlinePoint{
+					SectionNumber: len(outlinePoints) + 1,
+					Title:         section.Title,
+					Summary:       section.Summary,
+					ActNumber:     act.ActNumber,
+					ChapterNumber: chapter.ChapterNumber,
+				})
+			}
+			time.Sleep(time.Second * 1) // Rate limiting
+		}
+	}
+
+	return yt.updateScriptInDB(script.ID, bson.M{
+		"outline":        script.Outline,
+		"outline_points": outlinePoints,
+		"long_form":      plan,
+	})
+}
+
+func (yt *YtAutomation) generateLongFormSection(ctx context.Context, scriptID primitive.ObjectID, point OutlinePoint, wordLimit int) (err error) {
+	ctx, end := startStage(ctx, StageSection)
+	defer end(&err)
+
+	// Reload script to get latest content and rolling summary
+	script, err := yt.getScriptByID(scriptID)
+	if err != nil {
+		return err
+	}
+
+	act, chapter := script.LongForm.locate(point)
+	slog.InfoContext(ctx, "Generating section", "section", point.SectionNumber, "sections", len(script.OutlinePoints),
+		"act", act.ActNumber, "chapter", chapter.ChapterNumber, "title", point.Title)
+
+	systemPrompt, userPrompt, err := yt.templateService.BuildLongFormSectionPrompt(script, point, act, chapter, wordLimit)
+	if err != nil {
+		return fmt.Errorf("building long-form section prompt: %w", err)
+	}
+
+	response, err := yt.aiService.GenerateContentWithSystem(ctx, systemPrompt, userPrompt)
+	if err != nil {
+		return err
+	}
+
+	sectionContent, err := yt.outlineParser.ParseSectionJSON(response)
+	if err != nil {
+		return fmt.Errorf("parsing section JSON: %w", err)
+	}
+
+	updateData := bson.M{
+		"full_script":        script.FullScript + sectionContent.Content + "\n\n\n\n\n\n",
+		"sections_generated": point.SectionNumber,
+	}
+
+	// A failed summary only costs some continuity, so keep the previous one and carry on
+	summary, err := yt.updateRollingSummary(ctx, script, sectionContent.Content)
+	if err != nil {
+		slog.WarnContext(ctx, "Rolling summary update failed", "section", point.SectionNumber, "error", err)
+	} else {
+		updateData["rolling_summary"] = summary
+	}
+
+	return yt.updateScriptInDB(scriptID, updateData)
+}
+
+func (yt *YtAutomation) updateRollingSummary(ctx context.Context, script *Script, newContent string) (string, error) {
+	previousSummary := script.RollingSummary
+	if previousSummary == "" {
+		// Fold the hook/introduction into the first summary
+		previousSummary = "(none yet)"
+		newContent = script.FullScript + newContent
+	}
+
+	systemPrompt, userPrompt, err := yt.templateService.BuildRollingSummaryPrompt(script, previousSummary, newContent, longFormSummaryWordLimit)
+	if err != nil {
+		return "", fmt.Errorf("building rolling summary prompt: %w", err)
+	}
+
+	response, err := yt.aiService.GenerateContentWithSystem(ctx, systemPrompt, userPrompt)
+	if err != nil {
+		return "", err
+	}
+
+	return yt.outlineParser.ParseRollingSummaryJSON(response)
+}
+
+// locate returns the act and chapter an outline point belongs to.
+func (p *LongFormPlan) locate(point OutlinePoint) (OutlineAct, OutlineChapter) {
+	for _, act := range p.Acts {
+		if act.ActNumber != point.ActNumber {
+			continue
+		}
+		for _, chapter := range act.Chapters {
+			if chapter.ChapterNumber == point.ChapterNumber {
+				return act, chapter
+			}
+		}
+		return act, OutlineChapter{}
+	}
+	return OutlineAct{}, OutlineChapter{}
+}
+
+func formatLongFormOutline(plan *LongFormPlan) string {
+	var outline strings.Builder
+	for _, act := range plan.Acts {
+		outline.WriteString(fmt.Sprintf("Act %d: %s\n", act.ActNumber, act.Title))
+		if act.Summary != "" {
+			outline.WriteString(fmt.Sprintf("   Summary: %s\n", act.Summary))
+		}
+		for _, chapter := range act.Chapters {
+			outline.WriteString(fmt.Sprintf("   Chapter %d: %s\n", chapter.ChapterNumber, chapter.Title))
+			if chapter.Summary != "" {
+				outline.WriteString(fmt.Sprintf("      Summary: %s\n", chapter.Summary))
+			}
+		}
+		outline.WriteString("\n")
+	}
+	return strings.TrimSpace(outline.String())
+}
@@ -4,17 +4,18 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"log/slog"
-	"math/rand"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
 	"youtube_automation/elevenlabs"
+	"youtube_automation/metrics"
+	"youtube_automation/tracing"
 
-	"github.com/joho/godotenv"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -35,6 +36,14 @@ var (
 	promptTemplatesCollection *mongo.Collection
 	visualStylesCollection    *mongo.Collection
 	apiKeysCollection         *mongo.Collection
+	topicBacklogCollection    *mongo.Collection
+	batchesCollection         *mongo.Collection
+	youtubeTokensCollection   *mongo.Collection
+	authTokensCollection      *mongo.Collection
+	rateLimitsCollection      *mongo.Collection
+	jobLeasesCollection       *mongo.Collection
+	scriptLogsCollection      *mongo.Collection
+	oauthStatesCollection     *mongo.Collection
 )
 
 const (
@@ -42,6 +51,8 @@ const (
 	StatusProcessing = "processing"
 	StatusCompleted  = "completed"
 	StatusFailed     = "failed"
+	// StatusInterrupted marks work a shutdown stopped at a checkpoint; it resumes from there
+	StatusInterrupted = "interrupted"
 )
 const (
 	defaultAIProvider = ProviderOpenRouter // ProviderGemini or ProviderOpenRouter
@@ -57,142 +68,235 @@ type YtAutomation struct {
 	client           *http.Client
 	googleHttpClient *HTTPClient
 	apiKeyManager    *APIKeyManager
+	batchScheduler   *BatchScheduler
+	uploader         Uploader
 }
 
-func NewYtAutomation(mongoClient *mongo.Client, templateService *TemplateService, geminiService *GeminiService, config HttpConfig) *YtAutomation {
-	var rateLimiter *RateLimiter
-	if config.RequestsPerMinute > 0 {
-		rateLimiter = NewRateLimiter(config.RequestsPerMinute)
-	}
-	geminiKey := os.Getenv("GEMINI_API_KEY")
-	openRouterKey := os.Getenv("OPENROUTER_API_KEY")
-	// Get provider from environment (default to gemini)
-	providerStr := os.Getenv("AI_PROVIDER")
-	if providerStr == "" {
-		providerStr = string(defaultAIProvider)
-	}
-
-	provider := AIProvider(providerStr)
-
-	aiService, err := NewAIService(provider, geminiKey, openRouterKey)
+func NewYtAutomation(mongoClient *mongo.Client, templateService *TemplateService, geminiService *GeminiService, config *Config) *YtAutomation {
+	aiService, err := NewAIService(config.AI.Provider, config.AI.GeminiAPIKey, config.AI.OpenRouterAPIKey)
 	if err != nil {
-		log.Fatalf("Failed to initialize AI service: %v", err)
+		slog.Error("Failed to initialize AI service", "error", err)
+		os.Exit(1)
 	}
 	return &YtAutomation{
 		mongoClient:     mongoClient,
 		templateService: templateService,
 		aiService:       aiService,
 		outlineParser:   NewOutlineParser(),
-		elevenLabsClient: elevenlabs.NewElevenLabsClient(os.Getenv("ELEVENLABS_API_KEY"), &elevenlabs.Proxy{
-			Server:   os.Getenv("PROXY_SERVER"),
-			Username: os.Getenv("PROXY_USERNAME"),
-			Password: os.Getenv("PROXY_PASSWORD"),
+		// Keys come from api_keys per request, see synthesizeSpeech
+		elevenLabsClient: elevenlabs.NewElevenLabsClient("", &elevenlabs.Proxy{
+			Server:   config.Voice.ProxyServer,
+			Username: config.Voice.ProxyUsername,
+			Password: config.Voice.ProxyPassword,
 		}),
-		client: &http.Client{Timeout: timeout},
-		googleHttpClient: &HTTPClient{
-			config: config,
-			httpClient: &http.Client{
-				Timeout: config.Timeout,
-			},
-			rng:         rand.New(rand.NewSource(time.Now().UnixNano())),
-			rateLimiter: rateLimiter,
-		},
-		apiKeyManager: &APIKeyManager{},
+		client:           providerClient(ProviderJSONToVideo, timeout),
+		googleHttpClient: NewHTTPClient(config.Images),
+		apiKeyManager:    NewAPIKeyManager(),
+		uploader:         NewUploader(),
 	}
 }
 func main() {
-	// Load environment variables
-	err := LoadEnvironmentVariables()
-	if err != nil {
+	// The OpenAPI document is built from code alone
+	if len(os.Args) > 1 && os.Args[1] == "openapi" {
+		os.Exit(writeOpenAPISpec())
+	}
+	// Generating the first master key cannot require one
+	if len(os.Args) > 2 && os.Args[1] == "keys" && os.Args[2] == "new-master-key" {
+		os.Exit(runKeysCommand(os.Args[2:]))
+	}
+
+	// Load and validate the configuration
+	if err := initConfig(); err != nil {
 		// Use fmt.Printf since logger is not set up yet
-		fmt.Printf("Failed to load environment variables: %v\n", err)
+		fmt.Printf("Invalid configuration:\n%v\n", err)
 		os.Exit(1)
 	}
-	httpConfig := LoadConfigFromEnv()
-	// Validate tool selection
-	if httpConfig.Tool != "whisk" && httpConfig.Tool != "imagefx" {
-		log.Fatalf("Invalid tool: %s. Must be 'whisk' or 'imagefx'", httpConfig.Tool)
-	}
+	config := appConfig()
+	apiKeyRing, _ = newKeyRing(config.Security) // Validated with the configuration
+	setupLogging()
+	tracing.Init("script-writer")
+	slog.Info("Configuration loaded", "file", configFile, "image_tool", config.Images.Tool, "ai_provider", config.AI.Provider)
 
-	fmt.Printf("Using tool: %s\n", httpConfig.Tool)
 	// Initialize services (same as original logic)
 	templateService = NewTemplateService()
-	geminiService = NewGeminiService(os.Getenv("GEMINI_API_KEY"))
+	geminiService = NewGeminiService(config.AI.GeminiAPIKey)
 
 	// Initialize MongoDB connection
 	mClient, err := initializeMongoDB()
 	if err != nil {
-		log.Fatalf("Failed to initialize MongoDB: %v", err)
+		slog.Error("Failed to initialize MongoDB", "error", err)
+		os.Exit(1)
 	}
 
-	yt := NewYtAutomation(mClient, templateService, geminiService, httpConfig)
+	// CLI subcommands only need the database
+	if len(os.Args) > 1 && os.Args[1] == "tokens" {
+		code := runTokensCommand(os.Args[2:])
+		mClient.Disconnect(context.Background())
+		os.Exit(code)
+	}
+	if len(os.Args) > 1 && os.Args[1] == "keys" {
+		code := runKeysCommand(os.Args[2:])
+		mClient.Disconnect(context.Background())
+		os.Exit(code)
+	}
+
+	startScriptLogs()
+
+	yt := NewYtAutomation(mClient, templateService, geminiService, config)
+	yt.batchScheduler = NewBatchScheduler(yt)
+	yt.registerMetrics()
 
 	defer yt.mongoClient.Disconnect(context.Background())
+	if _, _, err := encryptStoredAPIKeys(context.Background()); err != nil {
+		slog.Error("Failed to encrypt stored API keys", "error", err)
+		os.Exit(1)
+	}
+	if err := migrateAPIKeyStates(context.Background()); err != nil {
+		slog.Warn("Failed to migrate API key states", "error", err)
+	}
 	if err := seedAPIKeys(); err != nil {
-		log.Printf("Warning: Failed to seed API keys: %v", err)
-		log.Printf("You may need to manually add API keys to the database")
+		slog.Warn("Failed to seed API keys; you may need to add them to the database manually", "error", err)
 	}
+	keyProbes[ProviderElevenLabs] = yt.probeElevenLabsKey
+	yt.apiKeyManager.StartProber()
 
 	// List current API keys for debugging
 	if err := listAPIKeys(); err != nil {
-		log.Printf("Warning: Failed to list API keys: %v", err)
+		slog.Warn("Failed to list API keys", "error", err)
 	}
+	// Start scheduled batch production
+	yt.batchScheduler.Start()
+
 	// Setup HTTP routes
-	http.HandleFunc("/generate-script", yt.generateScriptHandler) // step 1
-	http.HandleFunc("/scripts/", yt.getScriptStatusHandler)
-	http.HandleFunc("/generate-audio/", yt.generateAudioHandler)                                     // step 2
-	http.HandleFunc("/generate-subtitle/", yt.generateSubtitleHandler)                               // step 3
-	http.HandleFunc("/generate-visual-prompts-with-style", yt.generateVisualPromptsWithStyleHandler) // step 4
-	http.HandleFunc("/generate-visual-images/", yt.generateVisualImagePromptHandler)                 // step 5
-	http.HandleFunc("/generate-video/", yt.generateVideoHandler)                                     // step 6
-	http.HandleFunc("/scripts-chunks/", yt.getScriptAudiosHandler)
-	http.HandleFunc("/health", yt.healthHandler)
-	http.HandleFunc("/check-missing-srt-ranges", yt.checkMissingSRTRangesHandler)
-	http.HandleFunc("/channels/", func(w http.ResponseWriter, r *http.Request) {
+	yt.registerRoutes(http.DefaultServeMux)
+	checkAPIRoutes(http.DefaultServeMux)
+
+	// Start server
+	port := config.Server.Port
+	// GET /openapi.json lists the endpoints
+	slog.Info("Server starting", "port", port, "mongo", redactURL(config.Mongo.URI))
+	authenticator := NewAuthenticator()
+	authenticator.warnIfNoAuthTokens()
+	limiter := NewRequestLimiter()
+	limiter.Start()
+	handler := authenticator.Middleware(limiter.Middleware(http.DefaultServeMux))
+	server := &http.Server{Addr: ":" + port, Handler: tracing.Middleware(serverSpanName, handler)}
+
+	yt.resumeInterruptedScripts()
+	watchConfigReload()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	serverErr := make(chan error, 1)
+	go func() { serverErr <- server.ListenAndServe() }()
+
+	select {
+	case err := <-serverErr:
+		slog.Error("Server stopped", "error", err)
+		os.Exit(1)
+	case sig := <-stop:
+		slog.Info("Shutting down", "signal", sig.String())
+	}
+	yt.shutdown(server)
+}
+
+// routeMux is where registerRoutes adds routes; tests record them through it
+type routeMux interface {
+	Handle(pattern string, handler http.Handler)
+	HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request))
+}
+
+// registerRoutes adds every endpoint. Each one also needs its entry in apiOperations.
+func (yt *YtAutomation) registerRoutes(mux routeMux) {
+	mux.HandleFunc("/generate-script", yt.generateScriptHandler) // step 1
+	mux.HandleFunc("/scripts", yt.listScriptsHandler)
+	mux.HandleFunc("/scripts/import", yt.importScriptHandler)
+	mux.HandleFunc("/scripts/", func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/export") {
+			yt.exportScriptHandler(w, r)
+		} else if strings.HasSuffix(r.URL.Path, "/logs") {
+			yt.getScriptLogsHandler(w, r)
+		} else if strings.Contains(r.URL.Path, "/export/") {
+			yt.exportTimelineHandler(w, r)
+		} else {
+			yt.getScriptStatusHandler(w, r)
+		}
+	})
+	mux.HandleFunc("/generate-audio/", yt.generateAudioHandler)                                     // step 2
+	mux.HandleFunc("/generate-subtitle/", yt.generateSubtitleHandler)                               // step 3
+	mux.HandleFunc("/generate-chapters/", yt.generateChaptersHandler)                               // runs with step 3, or again on demand
+	mux.HandleFunc("/generate-visual-prompts-with-style", yt.generateVisualPromptsWithStyleHandler) // step 4
+	mux.HandleFunc("/generate-visual-images/", yt.generateVisualImagePromptHandler)                 // step 5
+	mux.HandleFunc("/generate-video/", yt.generateVideoHandler)                                     // step 6
+	mux.HandleFunc("/video-status/", yt.videoStatusHandler)
+	mux.HandleFunc("/generate-thumbnails/", yt.generateThumbnailsHandler)
+	mux.HandleFunc("/thumbnails/", yt.thumbnailsHandler)
+	mux.HandleFunc("/upload-video/", yt.uploadVideoHandler)
+	mux.HandleFunc("/oauth/youtube/callback", yt.youtubeOAuthCallbackHandler)
+	mux.HandleFunc("/search", yt.searchHandler)
+	mux.HandleFunc("/scripts-chunks/", yt.getScriptAudiosHandler)
+	mux.HandleFunc("/health", yt.healthHandler)
+	mux.HandleFunc("/ready", yt.readyHandler)
+	mux.HandleFunc("/config", yt.configHandler)
+	mux.HandleFunc("/api-keys", yt.apiKeysHandler)
+	mux.HandleFunc("/api-keys/", yt.apiKeyHandler)
+	mux.HandleFunc("/openapi.json", openAPIHandler)
+	mux.Handle("/metrics", metrics.Handler())
+	mux.HandleFunc("/check-missing-srt-ranges", yt.checkMissingSRTRangesHandler)
+	mux.HandleFunc("/channels/", func(w http.ResponseWriter, r *http.Request) {
 		path := r.URL.Path
 		if strings.HasSuffix(path, "/scripts") {
 			yt.getChannelScriptsHandler(w, r)
+		} else if strings.HasSuffix(path, "/topics") || strings.HasSuffix(path, "/topics/ideate") {
+			yt.channelTopicsHandler(w, r)
+		} else if strings.HasSuffix(path, "/thumbnail-layout") {
+			yt.channelThumbnailLayoutHandler(w, r)
+		} else if strings.HasSuffix(path, "/voice") {
+			yt.channelVoiceHandler(w, r)
+		} else if strings.HasSuffix(path, "/youtube/auth") {
+			yt.youtubeAuthHandler(w, r)
 		} else {
 			yt.getChannelInfoHandler(w, r)
 		}
 	})
-	http.HandleFunc("/prompt-templates", yt.createPromptTemplateHandler)
-	http.HandleFunc("/prompt-templates/list", yt.getPromptTemplatesHandler)
-	http.HandleFunc("/visual-styles", yt.createVisualStyleHandler)
-	http.HandleFunc("/visual-styles/list", yt.getVisualStylesHandler)
-
-	// Start server
-	port := getPort()
-	fmt.Printf("=== Wisderly YouTube Script Generator API ===\n")
-	fmt.Printf("Server starting on port %s\n", port)
-	fmt.Printf("MongoDB connected: %s\n", getMongoURI())
-	fmt.Printf("Endpoints:\n")
-	fmt.Printf("  POST /generate-script           - Generate YouTube script\n")
-	fmt.Printf("  GET  /scripts/{id}              - Get script status\n")
-	fmt.Printf("  GET  /scripts-chunks/{id}       - Get script chunks\n")
-	fmt.Printf("  GET  /channels/{name}/scripts   - Get channel scripts\n")
-	fmt.Printf("  GET  /channels/{name}           - Get channel info\n")
-	fmt.Printf("  GET  /health                    - Health check\n")
-	fmt.Println(strings.Repeat("=", 50))
-	log.Fatal(http.ListenAndServe(":"+port, nil))
+	mux.HandleFunc("/tts/", yt.ttsHandler)
+	mux.HandleFunc("/topics/", yt.topicHandler)
+	mux.HandleFunc("/batches", yt.batchesHandler)
+	mux.HandleFunc("/batches/", yt.batchHandler)
+	mux.HandleFunc("/prompt-templates", yt.createPromptTemplateHandler)
+	mux.HandleFunc("/prompt-templates/list", yt.getPromptTemplatesHandler)
+	mux.HandleFunc("/visual-styles", yt.createVisualStyleHandler)
+	mux.HandleFunc("/visual-styles/list", yt.getVisualStylesHandler)
 }
-func LoadEnvironmentVariables() error {
-	err := godotenv.Load()
-	if err != nil {
-		return fmt.Errorf("error loading .env file: %w", err)
+
+// shutdown stops accepting requests, lets background jobs reach a checkpoint within
+// server.shutdown_timeout and flushes what is buffered for Mongo and the trace collector
+func (yt *YtAutomation) shutdown(server *http.Server) {
+	deadline := time.Now().Add(appConfig().Server.ShutdownTimeout)
+
+	// From here on jobs stop at their next checkpoint, including the ones run inside
+	// requests still in flight, like voice generation
+	backgroundJobs.draining.Store(true)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Warn("Requests still open at shutdown", "error", err)
 	}
+	cancel()
 
-	slog.Debug("Environment variables loaded successfully")
-	return nil
-}
+	backgroundJobs.drain(time.Until(deadline))
 
+	ctx, cancel = context.WithTimeout(context.Background(), shutdownFlushTimeout)
+	defer cancel()
+	stopScriptLogs(ctx)
+	tracing.Shutdown(ctx)
+	slog.Info("Shutdown complete")
+}
 func initializeMongoDB() (*mongo.Client, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	// Connect to MongoDB
-	mongoURI := getMongoURI()
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(mongoURI))
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(appConfig().Mongo.URI))
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to MongoDB: %v", err)
 	}
@@ -203,7 +307,7 @@ func initializeMongoDB() (*mongo.Client, error) {
 	}
 
 	// Initialize global variables
-	database = client.Database(getMongoDB())
+	database = client.Database(appConfig().Mongo.Database)
 	channelsCollection = database.Collection("channels")
 	scriptsCollection = database.Collection("scripts")
 	scriptAudiosCollection = database.Collection("script_audios")
@@ -213,12 +317,20 @@ func initializeMongoDB() (*mongo.Client, error) {
 	promptTemplatesCollection = database.Collection("prompt_templates")
 	visualStylesCollection = database.Collection("visual_styles")
 	apiKeysCollection = database.Collection("api_keys")
+	topicBacklogCollection = database.Collection("topic_backlog")
+	batchesCollection = database.Collection("batches")
+	youtubeTokensCollection = database.Collection("youtube_tokens")
+	authTokensCollection = database.Collection("auth_tokens")
+	rateLimitsCollection = database.Collection("rate_limits")
+	jobLeasesCollection = database.Collection("job_leases")
+	scriptLogsCollection = database.Collection("script_logs")
+	oauthStatesCollection = database.Collection("oauth_states")
 
 	// Create indexes
 	if err := createIndexes(); err != nil {
 		return nil, fmt.Errorf("failed to create indexes: %v", err)
 	}
-	fmt.Println("✓ MongoDB connected successfully")
+	slog.Info("MongoDB connected")
 	return client, nil
 }
 
@@ -236,6 +348,28 @@ func createIndexes() error {
 		{
 			Keys: bson.D{{"status", 1}, {"created_at", -1}},
 		},
+		{
+			Keys: bson.D{{"created_at", -1}, {"_id", -1}},
+		},
+		{
+			// Full-text search; a collection can only have one text index
+			Keys: bson.D{
+				{"topic", "text"},
+				{"outline", "text"},
+				{"full_script", "text"},
+				{"meta.title", "text"},
+				{"meta.description", "text"},
+				{"meta.tags", "text"},
+			},
+			Options: options.Index().SetName("scripts_text").SetWeights(bson.D{
+				{"topic", 10},
+				{"meta.title", 8},
+				{"meta.tags", 5},
+				{"meta.description", 3},
+				{"outline", 2},
+				{"full_script", 1},
+			}),
+		},
 	})
 	if err != nil {
 		return err
@@ -289,6 +423,94 @@ func createIndexes() error {
 		return err
 	}
 
+	// Index for topic_backlog
+	_, err = topicBacklogCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{"channel_id", 1}, {"status", 1}, {"priority", -1}, {"created_at", 1}},
+		},
+		{
+			Keys: bson.D{{"channel_id", 1}, {"normalized_topic", 1}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Index for batches
+	_, err = batchesCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{"status", 1}},
+		},
+		{
+			Keys: bson.D{{"channel_id", 1}, {"created_at", -1}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Index for youtube_tokens (one token per channel)
+	_, err = youtubeTokensCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{"channel_id", 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Index for auth_tokens (lookup by hash on every request)
+	_, err = authTokensCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{"token_hash", 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Rate limit windows and job leases clean themselves up once expired
+	_, err = rateLimitsCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{"expires_at", 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = jobLeasesCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{"expires_at", 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+		{
+			Keys: bson.D{{"keys", 1}, {"expires_at", 1}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// OAuth states are single-use and expire unused after youtubeOAuthStateTTL
+	_, err = oauthStatesCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{"expires_at", 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Script logs are read per script in time order and expire after scriptLogRetention
+	_, err = scriptLogsCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{"script_id", 1}, {"time", 1}},
+		},
+		{
+			Keys:    bson.D{{"time", 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(scriptLogRetention.Seconds())),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
 	// Index for channels (unique channel_name)
 	_, err = channelsCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
 		Keys:    bson.D{{"channel_name", 1}},
@@ -301,7 +523,6 @@ func createIndexes() error {
 // Get all scripts for a channel
 func (yt *YtAutomation) getChannelScriptsHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
 
 	channelName := strings.TrimPrefix(r.URL.Path, "/channels/")
 	channelName = strings.TrimSuffix(channelName, "/scripts")
@@ -311,31 +532,12 @@ func (yt *YtAutomation) getChannelScriptsHandler(w http.ResponseWriter, r *http.
 		return
 	}
 
-	cursor, err := scriptsCollection.Find(
-		context.Background(),
-		bson.M{"channel_name": channelName},
-		options.Find().SetSort(bson.D{{"created_at", -1}}),
-	)
-	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Database error: %v", err))
-		return
-	}
-	defer cursor.Close(context.Background())
-
-	var scripts []Script
-	if err = cursor.All(context.Background(), &scripts); err != nil {
-		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error decoding scripts: %v", err))
-		return
-	}
-
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(scripts)
+	yt.listScripts(w, r, bson.M{"channel_name": channelName})
 }
 
 // Get channel info with script count
 func (yt *YtAutomation) getChannelInfoHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
 
 	channelName := strings.TrimPrefix(r.URL.Path, "/channels/")
 
@@ -361,9 +563,6 @@ func (yt *YtAutomation) getChannelInfoHandler(w http.ResponseWriter, r *http.Req
 
 func (yt *YtAutomation) generateScriptHandler(w http.ResponseWriter, r *http.Request) {
 	// Set CORS headers
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
 	w.Header().Set("Content-Type", "application/json")
 
 	// Handle preflight OPTIONS request
@@ -394,37 +593,47 @@ func (yt *YtAutomation) generateScriptHandler(w http.ResponseWriter, r *http.Req
 		respondWithError(w, http.StatusBadRequest, "Channel name cannot be empty")
 		return
 	}
-	var channel Channel
-	err := channelsCollection.FindOne(context.Background(), bson.M{"channel_name": strings.TrimSpace(req.ChannelName)}).Decode(&channel)
+	if req.TargetMinutes < 0 || req.TargetMinutes > maxLongFormMinutes {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("target_minutes must be between 1 and %d, or 0 for a regular script", maxLongFormMinutes))
+		return
+	}
+	channel, err := getOrCreateChannel(strings.TrimSpace(req.ChannelName))
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			// Create channel if it doesn't exist
-			newChannel := Channel{
-				ChannelName:  strings.TrimSpace(req.ChannelName),
-				CreatedAt:    time.Now(),
-				UpdatedAt:    time.Now(),
-				TotalScripts: 0,
-				Settings: ChannelSettings{
-					DefaultSectionCount:     defaultSectionCount,
-					PreferredVisualGuidance: false,
-					WordLimitForHookIntro:   200,
-					VisualImageMultiplier:   visualImageMultiplier,
-					WordLimitPerSection:     500,
-				},
-			}
-			result, err := channelsCollection.InsertOne(context.Background(), newChannel)
-			if err != nil {
-				respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create channel: %v", err))
-				return
-			}
-			channel.ID = result.InsertedID.(primitive.ObjectID)
-			channel.ChannelName = newChannel.ChannelName
-		} else {
-			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Database error: %v", err))
-			return
-		}
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
 	}
 
+	scriptGen, config, err := yt.startScriptGeneration(r.Context(), channel, req)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	scriptID := scriptGen.ID
+
+	// Return immediate response
+	response := ScriptResponse{
+		Success:         true,
+		ScriptID:        scriptID.Hex(),
+		Message:         "Script generation started",
+		Status:          StatusProcessing,
+		Topic:           req.Topic,
+		ChannelName:     req.ChannelName,
+		OutputFolder:    config.OutputFolder,
+		OutputFilename:  config.OutputFilename,
+		MetaTagFilename: config.MetaTagFilename,
+		GeneratedAt:     time.Now().Format(time.RFC3339),
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+
+	slog.InfoContext(withScriptLog(r.Context(), scriptID), "Script generation started",
+		"channel", req.ChannelName, "topic", req.Topic)
+}
+
+// startScriptGeneration creates the script record for req and kicks off generation in the background.
+// ctx carries the job slot of the request that started it, if any.
+func (yt *YtAutomation) startScriptGeneration(ctx context.Context, channel Channel, req ScriptRequest) (*Script, *ScriptConfig, error) {
 	// Create script generation record in MongoDB
 	scriptGen := &Script{
 		ChannelID:       channel.ID,
@@ -435,12 +644,15 @@ func (yt *YtAutomation) generateScriptHandler(w http.ResponseWriter, r *http.Req
 		CreatedAt:       time.Now(),
 		OutlinePoints:   []OutlinePoint{},
 	}
+	if req.TargetMinutes > 0 {
+		// The full plan is computed once generation starts and the channel's pace is measured
+		scriptGen.LongForm = &LongFormPlan{TargetMinutes: req.TargetMinutes}
+	}
 
 	// Insert into database
 	result, err := scriptsCollection.InsertOne(context.Background(), scriptGen)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create script record: %v", err))
-		return
+		return nil, nil, fmt.Errorf("Failed to create script record: %v", err)
 	}
 
 	scriptID := result.InsertedID.(primitive.ObjectID)
@@ -450,8 +662,7 @@ func (yt *YtAutomation) generateScriptHandler(w http.ResponseWriter, r *http.Req
 	config, err := createScriptConfig(scriptGen, channel)
 	if err != nil {
 		updateScriptStatus(scriptID, StatusFailed, fmt.Sprintf("Error creating config: %v", err))
-		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error creating config: %v", err))
-		return
+		return nil, nil, fmt.Errorf("Error creating config: %v", err)
 	}
 
 	// Update script generation record with file details
@@ -468,48 +679,38 @@ func (yt *YtAutomation) generateScriptHandler(w http.ResponseWriter, r *http.Req
 		bson.M{"$set": updateData},
 	)
 	if err != nil {
-		log.Printf("Failed to update script record: %v", err)
+		slog.ErrorContext(withScriptLog(ctx, scriptID), "Failed to update script record", "error", err)
 	}
 
 	// Process script generation in goroutine
-	go func() {
-		yt.processScriptGeneration(scriptID, config)
-	}()
+	runJob(ctx, func(ctx context.Context) {
+		yt.processScriptGeneration(ctx, scriptID, config)
+	})
 
 	// Ensure or update channel record
 	go func() {
 		ensureChannelExists(req.ChannelName)
 	}()
 
-	// Return immediate response
-	response := ScriptResponse{
-		Success:         true,
-		ScriptID:        scriptID.Hex(),
-		Message:         "Script generation started",
-		Status:          StatusProcessing,
-		Topic:           req.Topic,
-		ChannelName:     req.ChannelName,
-		OutputFolder:    config.OutputFolder,
-		OutputFilename:  config.OutputFilename,
-		MetaTagFilename: config.MetaTagFilename,
-		GeneratedAt:     time.Now().Format(time.RFC3339),
-	}
-
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
-
-	log.Printf("✓ Script generation started for channel: %s | topic: %s | ID: %s",
-		req.ChannelName, req.Topic, scriptID.Hex())
+	return scriptGen, config, nil
 }
 
-func (yt *YtAutomation) processScriptGeneration(scriptID primitive.ObjectID, config *ScriptConfig) {
+func (yt *YtAutomation) processScriptGeneration(ctx context.Context, scriptID primitive.ObjectID, config *ScriptConfig) {
+	ctx = withScriptLog(ctx, scriptID)
 	startTime := time.Now()
 
 	// Generate script (same logic as original)
-	err := yt.GenerateCompleteScript(scriptID)
+	err := yt.GenerateCompleteScript(ctx, scriptID)
 
 	processingTime := time.Since(startTime).Seconds()
 
+	if interrupted(err) {
+		// Saved outline, hook and sections are kept; resumeInterruptedScripts continues at startup
+		yt.updateScriptInDB(scriptID, bson.M{"status": StatusInterrupted, "error_message": ""})
+		slog.InfoContext(ctx, "Script generation interrupted by shutdown, will resume")
+		return
+	}
+
 	if err != nil {
 		// Update with failure
 		updateData := bson.M{
@@ -523,7 +724,7 @@ func (yt *YtAutomation) processScriptGeneration(scriptID primitive.ObjectID, con
 			bson.M{"_id": scriptID},
 			bson.M{"$set": updateData},
 		)
-		log.Printf("❌ Script generation failed for ID: %s | Error: %v", scriptID.Hex(), err)
+		slog.ErrorContext(ctx, "Script generation failed", "error", err)
 		return
 	}
 
@@ -532,7 +733,10 @@ func (yt *YtAutomation) processScriptGeneration(scriptID primitive.ObjectID, con
 		"status":                  StatusCompleted,
 		"processing_time_seconds": processingTime,
 		"completed_at":            time.Now(),
-		"sections_generated":      config.channel.Settings.DefaultSectionCount,
+	}
+	if config.TargetMinutes == 0 {
+		// Long-form scripts track sections_generated per section
+		updateData["sections_generated"] = config.channel.Settings.DefaultSectionCount
 	}
 	_, updateErr := scriptsCollection.UpdateOne(
 		context.Background(),
@@ -540,19 +744,17 @@ func (yt *YtAutomation) processScriptGeneration(scriptID primitive.ObjectID, con
 		bson.M{"$set": updateData},
 	)
 	if updateErr != nil {
-		log.Printf("Failed to update completed script: %v", updateErr)
+		slog.ErrorContext(ctx, "Failed to update completed script", "error", updateErr)
 	}
 
 	// Update channel statistics
 	updateChannelStats(config.channel.ChannelName, true)
 
-	log.Printf("✅ Script generation completed for ID: %s | Time: %.2fs",
-		scriptID.Hex(), processingTime)
+	slog.InfoContext(ctx, "Script generation completed", "seconds", processingTime)
 }
 
 func (yt *YtAutomation) getScriptStatusHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
 
 	// Extract script ID from URL path
 	path := strings.TrimPrefix(r.URL.Path, "/scripts/")
@@ -585,7 +787,6 @@ func (yt *YtAutomation) getScriptStatusHandler(w http.ResponseWriter, r *http.Re
 }
 func (yt *YtAutomation) getScriptAudiosHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
 
 	// Extract script ID from URL path (/scripts-chunks/{scriptID})
 	path := strings.TrimPrefix(r.URL.Path, "/scripts-chunks/")
@@ -620,10 +821,10 @@ func (yt *YtAutomation) getScriptAudiosHandler(w http.ResponseWriter, r *http.Re
 	}
 
 	// Return response with chunks
-	response := map[string]interface{}{
-		"script_id":    path,
-		"total_chunks": len(chunks),
-		"chunks":       chunks,
+	response := ScriptChunksResponse{
+		ScriptID:    path,
+		TotalChunks: len(chunks),
+		Chunks:      chunks,
 	}
 
 	w.WriteHeader(http.StatusOK)
@@ -631,7 +832,6 @@ func (yt *YtAutomation) getScriptAudiosHandler(w http.ResponseWriter, r *http.Re
 }
 func (yt *YtAutomation) generateAudioHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
 
 	// Extract script ID from URL path (/generate-audio/{scriptID})
 	path := strings.TrimPrefix(r.URL.Path, "/generate-audio/")
@@ -667,15 +867,16 @@ func (yt *YtAutomation) generateAudioHandler(w http.ResponseWriter, r *http.Requ
 		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error checking existing chunks: %v", err))
 		return
 	}
+	ctx := withScriptLog(tracing.Detach(r.Context()), objectID)
 	if err := os.MkdirAll(filepath.Join("assets", "audio"), 0755); err != nil {
-		log.Printf("Warning: Failed to create audio directory: %v", err)
+		slog.WarnContext(ctx, "Failed to create audio directory", "error", err)
 	}
 	var savedChunks []ScriptAudio
-	chunks := splitTextByCharLimit(script.FullScript, splitVoiceByCharLimit)
+	chunks := splitTextByCharLimit(script.FullScript, appConfig().Voice.SplitCharLimit)
 
 	if existingCount > 0 {
 		// Chunks already exist, fetch them instead of creating new ones
-		fmt.Printf("ℹ Script chunks already exist for script %s, fetching existing chunks\n", path)
+		slog.InfoContext(ctx, "Script chunks already exist, fetching existing chunks")
 
 		findOptions := options.Find().SetSort(bson.M{"chunk_index": 1})
 		cursor, err := scriptAudiosCollection.Find(
@@ -725,30 +926,29 @@ func (yt *YtAutomation) generateAudioHandler(w http.ResponseWriter, r *http.Requ
 				savedChunks = append(savedChunks, chunk)
 			}
 
-			fmt.Printf("✓ Saved %d script chunks to database\n", len(chunkDocs))
+			slog.InfoContext(ctx, "Saved script chunks", "count", len(chunkDocs))
 		}
 	}
 
 	// Generate voice over using the current chunks (whether new or existing)
-	if err := yt.generateVoiceOver(script, savedChunks); err != nil {
-		fmt.Printf("Warning: Failed to generate audio for chunks: %v\n", err)
+	if err := yt.generateVoiceOver(ctx, script, savedChunks); interrupted(err) {
+		respondWithError(w, http.StatusServiceUnavailable, "Server shutting down; generated chunks are kept, run again to resume")
+		return
+	} else if err != nil {
+		slog.ErrorContext(ctx, "Failed to generate audio for chunks", "error", err)
 	}
 
 	// Return response with chunks
-	data := map[string]interface{}{
-		"script_id":    path,
-		"total_chunks": len(chunks),
-	}
+	totalChunks := len(chunks)
 
 	// Remove the duplicate WriteHeader call - json.NewEncoder will call it
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Voice generation completed",
-		"data":    data,
+	json.NewEncoder(w).Encode(StepResponse{
+		Message: "Voice generation completed",
+		Data:    StepData{ScriptID: path, TotalChunks: &totalChunks},
 	})
 }
 func (yt *YtAutomation) generateSubtitleHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
 
 	// Extract script ID from URL path (/generate-subtitle/{scriptID})
 	path := strings.TrimPrefix(r.URL.Path, "/generate-subtitle/")
@@ -775,13 +975,14 @@ func (yt *YtAutomation) generateSubtitleHandler(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	srt, err := yt.GenerateSRT(TranscriptPayload{
+	ctx := withScriptLog(tracing.Detach(r.Context()), objectID)
+	srt, err := yt.GenerateSRT(ctx, TranscriptPayload{
 		AudioPath: script.FullAudioFile,
 		Language:  "en",
 		OutputSrt: true,
 	})
 	if err != nil {
-		fmt.Printf("Warning: Failed to generate audio for chunks: %v\n", err)
+		slog.ErrorContext(ctx, "Failed to generate subtitles", "error", err)
 	}
 	_, err = scriptsCollection.UpdateOne(
 		context.Background(),
@@ -789,7 +990,13 @@ func (yt *YtAutomation) generateSubtitleHandler(w http.ResponseWriter, r *http.R
 		bson.M{"$set": bson.M{"srt": srt}},
 	)
 	if err != nil {
-		fmt.Printf("Warning: Failed to save chunk audio file path: %v\n", err)
+		slog.ErrorContext(ctx, "Failed to save subtitles", "error", err)
+	}
+
+	// Chapter markers need the final timeline, so refresh them with every new SRT
+	script.SRT = srt
+	if _, err := yt.GenerateChapters(&script); err != nil {
+		slog.WarnContext(ctx, "Failed to generate chapters", "error", err)
 	}
 
 	// Split the script into chunks
@@ -817,7 +1024,8 @@ func (yt *YtAutomation) generateSubtitleHandler(w http.ResponseWriter, r *http.R
 	if len(chunkDocs) > 0 {
 		result, err := scriptSrtCollection.InsertMany(context.Background(), chunkDocs)
 		if err != nil {
-			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("failed to save script chunks: %w", err))
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("failed to save script chunks: %v", err))
+			return
 		}
 
 		// Prepare saved chunks with IDs for visual generation
@@ -827,23 +1035,19 @@ func (yt *YtAutomation) generateSubtitleHandler(w http.ResponseWriter, r *http.R
 			savedChunks = append(savedChunks, chunk)
 		}
 
-		fmt.Printf("✓ Saved %d script srt chunks to database\n", len(chunkDocs))
+		slog.InfoContext(ctx, "Saved subtitle chunks", "count", len(chunkDocs))
 	}
 
 	// Return response with chunks
-	data := map[string]interface{}{
-		"script_id":    path,
-		"total_chunks": len(chunks),
-	}
+	totalChunks := len(chunks)
 
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Subtitle generation completed",
-		"data":    data,
+	json.NewEncoder(w).Encode(StepResponse{
+		Message: "Subtitle generation completed",
+		Data:    StepData{ScriptID: path, TotalChunks: &totalChunks},
 	})
 }
 func (yt *YtAutomation) generateVisualImagePromptHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
 
 	// Extract script ID from URL path (/generate-visual/{scriptID})
 	path := strings.TrimPrefix(r.URL.Path, "/generate-visual-images/")
@@ -898,46 +1102,41 @@ func (yt *YtAutomation) generateVisualImagePromptHandler(w http.ResponseWriter,
 			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error decoding existing chunks: %v", err))
 			return
 		}
-		chunkVisuals = yt.filterChunksNeedingGeneration(chunkVisuals)
+		chunkVisuals = yt.filterChunksNeedingGeneration(withScriptLog(r.Context(), objectID), chunkVisuals)
 
 		if len(chunkVisuals) == 0 {
-			json.NewEncoder(w).Encode(map[string]interface{}{
-				"message": "All chunks already have images generated",
-				"data":    map[string]interface{}{"script_id": path},
+			json.NewEncoder(w).Encode(StepResponse{
+				Message: "All chunks already have images generated",
+				Data:    StepData{ScriptID: path},
 			})
 			return
 		}
 	} else {
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"message": "No visual chunks found for this script",
-			"data":    map[string]interface{}{"script_id": path},
+		json.NewEncoder(w).Encode(StepResponse{
+			Message: "No visual chunks found for this script",
+			Data:    StepData{ScriptID: path},
 		})
 		return
 	}
-	go func() {
-		if err := yt.generateVisualImagePromptForChunks(objectID, chunkVisuals); err != nil {
-			fmt.Printf("Warning: Failed to generate visuals for chunks: %v\n", err)
+	runJob(r.Context(), func(ctx context.Context) {
+		ctx = withScriptLog(ctx, objectID)
+		if err := yt.generateVisualImagePromptForChunks(ctx, objectID, chunkVisuals); interrupted(err) {
+			slog.InfoContext(ctx, "Image generation interrupted by shutdown, run again to resume")
+		} else if err != nil {
+			slog.ErrorContext(ctx, "Failed to generate visuals for chunks", "error", err)
 		}
-	}()
-	// Return response with chunks
-	data := map[string]interface{}{
-		"script_id": path,
-	}
-
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Visual generation InProgress",
-		"data":    data,
+	})
+	json.NewEncoder(w).Encode(StepResponse{
+		Message: "Visual generation InProgress",
+		Data:    StepData{ScriptID: path},
 	})
 }
 
 func (yt *YtAutomation) generateVideoHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
 
 	// Handle preflight requests
 	if r.Method == "OPTIONS" {
-		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 		w.WriteHeader(http.StatusOK)
 		return
 	}
@@ -981,11 +1180,11 @@ func (yt *YtAutomation) generateVideoHandler(w http.ResponseWriter, r *http.Requ
 	// Check for existing video generation in progress
 	existingStatus, err := yt.getVideoGenerationStatus(scriptID)
 	if err == nil && (existingStatus.Status == "pending" || existingStatus.Status == "processing") {
-		respondWithJSON(w, http.StatusOK, map[string]interface{}{
-			"message":    "Video generation already in progress",
-			"status":     existingStatus.Status,
-			"process_id": existingStatus.ProcessID,
-			"progress":   existingStatus.Progress,
+		respondWithJSON(w, http.StatusOK, VideoJobResponse{
+			Message:   "Video generation already in progress",
+			Status:    existingStatus.Status,
+			ProcessID: existingStatus.ProcessID,
+			Progress:  &existingStatus.Progress,
 		})
 		return
 	}
@@ -1025,25 +1224,33 @@ func (yt *YtAutomation) generateVideoHandler(w http.ResponseWriter, r *http.Requ
 	}
 
 	// Start video generation asynchronously
-	go func() {
-		err := yt.generateVideoAsync(statusID, videoRequest)
-		if err != nil {
-			fmt.Printf("Error in async video generation: %v\n", err)
+	runJob(r.Context(), func(ctx context.Context) {
+		ctx = withScriptLog(ctx, scriptID)
+		err := yt.generateVideoAsync(ctx, statusID, videoRequest)
+		if interrupted(err) {
+			// Unlike pending or processing, this lets the next request start a new render
+			slog.InfoContext(ctx, "Video generation interrupted by shutdown")
+			yt.updateVideoGenerationStatus(statusID, VideoGenerationStatus{
+				Status:    StatusInterrupted,
+				UpdatedAt: time.Now(),
+			})
+		} else if err != nil {
+			slog.ErrorContext(ctx, "Video generation failed", "error", err)
 			yt.updateVideoGenerationStatus(statusID, VideoGenerationStatus{
 				Status:    "failed",
 				ErrorMsg:  err.Error(),
 				UpdatedAt: time.Now(),
 			})
 		}
-	}()
+	})
 
 	// Return immediate response
-	respondWithJSON(w, http.StatusAccepted, map[string]interface{}{
-		"message":   "Video generation started successfully",
-		"status":    "pending",
-		"script_id": scriptID.Hex(),
-		"status_id": statusID.Hex(),
-		"check_url": fmt.Sprintf("/video-status/%s", statusID.Hex()),
+	respondWithJSON(w, http.StatusAccepted, VideoJobResponse{
+		Message:  "Video generation started successfully",
+		Status:   "pending",
+		ScriptID: scriptID.Hex(),
+		StatusID: statusID.Hex(),
+		CheckURL: fmt.Sprintf("/video-status/%s", statusID.Hex()),
 	})
 }
 func ensureChannelExists(channelName string) {
@@ -1066,18 +1273,53 @@ func ensureChannelExists(channelName string) {
 				WordLimitForHookIntro:   200,
 				VisualImageMultiplier:   visualImageMultiplier,
 				WordLimitPerSection:     500,
+				WordsPerMinute:          defaultWordsPerMinute,
 			},
 		}
 
 		_, err := channelsCollection.InsertOne(ctx, newChannel)
 		if err != nil {
-			log.Printf("Failed to create channel %s: %v", channelName, err)
+			slog.Error("Failed to create channel", "channel", channelName, "error", err)
 		} else {
-			log.Printf("✓ Created new channel: %s", channelName)
+			slog.Info("Created new channel", "channel", channelName)
 		}
 	}
 }
 
+// getOrCreateChannel loads a channel by name, creating it with default settings if it doesn't exist
+func getOrCreateChannel(channelName string) (Channel, error) {
+	var channel Channel
+	err := channelsCollection.FindOne(context.Background(), bson.M{"channel_name": channelName}).Decode(&channel)
+	if err == nil {
+		return channel, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return channel, fmt.Errorf("Database error: %v", err)
+	}
+
+	// Create channel if it doesn't exist
+	channel = Channel{
+		ChannelName:  channelName,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+		TotalScripts: 0,
+		Settings: ChannelSettings{
+			DefaultSectionCount:     defaultSectionCount,
+			PreferredVisualGuidance: false,
+			WordLimitForHookIntro:   200,
+			VisualImageMultiplier:   visualImageMultiplier,
+			WordLimitPerSection:     500,
+			WordsPerMinute:          defaultWordsPerMinute,
+		},
+	}
+	result, err := channelsCollection.InsertOne(context.Background(), channel)
+	if err != nil {
+		return channel, fmt.Errorf("Failed to create channel: %v", err)
+	}
+	channel.ID = result.InsertedID.(primitive.ObjectID)
+	return channel, nil
+}
+
 func updateChannelStats(channelName string, success bool) {
 	if success {
 		_, err := channelsCollection.UpdateOne(
@@ -1089,7 +1331,7 @@ func updateChannelStats(channelName string, success bool) {
 			},
 		)
 		if err != nil {
-			log.Printf("Failed to update channel stats for %s: %v", channelName, err)
+			slog.Error("Failed to update channel stats", "channel", channelName, "error", err)
 		}
 	}
 }
@@ -1119,11 +1361,11 @@ func (yt *YtAutomation) healthHandler(w http.ResponseWriter, r *http.Request) {
 		mongoStatus = "unhealthy: " + err.Error()
 	}
 
-	health := map[string]interface{}{
-		"status":    "healthy",
-		"timestamp": time.Now().Format(time.RFC3339),
-		"service":   "Wisderly YouTube Script Generator API",
-		"mongodb":   mongoStatus,
+	health := HealthResponse{
+		Status:    "healthy",
+		Timestamp: time.Now().Format(time.RFC3339),
+		Service:   "Wisderly YouTube Script Generator API",
+		MongoDB:   mongoStatus,
 	}
 
 	w.WriteHeader(http.StatusOK)
@@ -1141,6 +1383,9 @@ func createScriptConfig(scriptGen *Script, channel Channel) (*ScriptConfig, erro
 		channel:              channel,
 		SleepBetweenSections: defaultSleepBetweenSections,
 	}
+	if scriptGen.LongForm != nil {
+		config.TargetMinutes = scriptGen.LongForm.TargetMinutes
+	}
 
 	return config, nil
 }
@@ -1152,28 +1397,7 @@ func respondWithError(w http.ResponseWriter, statusCode int, message string) {
 		Error:   message,
 	}
 	json.NewEncoder(w).Encode(response)
-	log.Printf("❌ Error: %s", message)
-}
-
-func getPort() string {
-	if port := os.Getenv("PORT"); port != "" {
-		return port
-	}
-	return "8085"
-}
-
-func getMongoURI() string {
-	if uri := os.Getenv("MONGODB_URI"); uri != "" {
-		return uri
-	}
-	return "mongodb://localhost:27017"
-}
-
-func getMongoDB() string {
-	if db := os.Getenv("MONGODB_DATABASE"); db != "" {
-		return db
-	}
-	return "youtube_automation"
+	slog.Warn("Request failed", "status", statusCode, "message", message)
 }
 
 // Preserved original utility function
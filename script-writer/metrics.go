@@ -0,0 +1,240 @@
+// File: metrics.go
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+	"youtube_automation/metrics"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Pipeline stages timed by stageDuration
+const (
+	StageOutline       = "outline"
+	StageHook          = "hook"
+	StageSection       = "section"
+	StageMeta          = "meta"
+	StageTTSChunk      = "tts_chunk"
+	StageTranscription = "transcription"
+	StageImage         = "image"
+	StageRender        = "render"
+)
+
+// Outbound providers counted by providerRequests, besides the AI providers and the
+// image tool (whisk or imagefx)
+const (
+	ProviderElevenLabs  = "elevenlabs"
+	ProviderOfflineTTS  = "offline_tts"
+	ProviderWhisper     = "whisper"
+	ProviderJSONToVideo = "json_to_video"
+	ProviderYouTube     = "youtube"
+)
+
+var (
+	stageDuration = metrics.NewHistogramVec("script_writer_stage_duration_seconds",
+		"Time spent in each pipeline stage.", nil, "stage", "outcome")
+	providerRequests = metrics.NewCounterVec("script_writer_provider_requests_total",
+		"Requests to external providers by outcome: ok or the error class.", "provider", "outcome")
+	elevenLabsCharactersRemaining = metrics.NewGaugeVec("script_writer_elevenlabs_characters_remaining",
+		"ElevenLabs characters left per key fingerprint, as of its last quota check.", "key")
+)
+
+// recordProviderCall counts one request to a provider, classifying its error
+func recordProviderCall(provider string, err error) {
+	providerRequests.Inc(provider, errorClass(err))
+}
+
+// recordProviderStatus counts one request to a provider that answered with the given HTTP status
+func recordProviderStatus(provider string, code int) {
+	providerRequests.Inc(provider, metrics.StatusClass(code))
+}
+
+// providerTransport counts every request sent through an http.Client, for providers
+// whose calls are spread over many functions
+type providerTransport struct {
+	provider string
+	next     http.RoundTripper
+}
+
+func (t providerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		recordProviderCall(t.provider, err)
+		return nil, err
+	}
+	recordProviderStatus(t.provider, resp.StatusCode)
+	return resp, nil
+}
+
+// statusCodeRegex finds the HTTP status in provider errors such as
+// "API request failed with status 429" or "API error (401)"
+var statusCodeRegex = regexp.MustCompile(`(?:status|error) \(?(\d{3})\)?`)
+
+func errorClass(err error) string {
+	if err == nil {
+		return "ok"
+	}
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) || isTimeoutError(err) {
+		return "timeout"
+	}
+	if match := statusCodeRegex.FindStringSubmatch(err.Error()); match != nil {
+		code, _ := strconv.Atoi(match[1])
+		return metrics.StatusClass(code)
+	}
+	if errors.As(err, &netErr) {
+		return "network"
+	}
+	return "other"
+}
+
+// registerMetrics adds the gauges read at scrape time: image rate limiter usage,
+// API key pool health and queue depths
+func (yt *YtAutomation) registerMetrics() {
+	metrics.NewGaugeFunc("script_writer_image_rate_limit_requests",
+		"Image generation requests in the last minute, and the per-minute limit.",
+		func() []metrics.Sample {
+			if yt.googleHttpClient.rateLimiter == nil {
+				return nil
+			}
+			current, max := yt.googleHttpClient.rateLimiter.GetCurrentUsage()
+			return []metrics.Sample{
+				{Labels: []string{"used"}, Value: float64(current)},
+				{Labels: []string{"limit"}, Value: float64(max)},
+			}
+		}, "kind")
+
+	metrics.NewGaugeFunc("script_writer_api_keys",
+		"Provider API keys in the pool by state.",
+		collectAPIKeyPool(func(row apiKeyPoolRow) []metrics.Sample {
+			return []metrics.Sample{
+				{Labels: []string{row.Provider, KeyStateHealthy}, Value: float64(row.Healthy)},
+				{Labels: []string{row.Provider, KeyStateCoolingDown}, Value: float64(row.CoolingDown)},
+				{Labels: []string{row.Provider, KeyStateExhausted}, Value: float64(row.Exhausted)},
+				{Labels: []string{row.Provider, KeyStateRevoked}, Value: float64(row.Revoked)},
+				{Labels: []string{row.Provider, "disabled"}, Value: float64(row.Total - row.Active)},
+			}
+		}), "provider", "state")
+
+	metrics.NewGaugeFunc("script_writer_api_key_errors",
+		"Errors recorded against the provider's API keys.",
+		collectAPIKeyPool(func(row apiKeyPoolRow) []metrics.Sample {
+			return []metrics.Sample{{Labels: []string{row.Provider}, Value: float64(row.Errors)}}
+		}), "provider")
+
+	metrics.NewGaugeFunc("script_writer_queue_depth",
+		"Work waiting or in progress, by queue.", collectQueueDepths, "queue")
+}
+
+type apiKeyPoolRow struct {
+	Provider    string `bson:"_id"`
+	Total       int    `bson:"total"`
+	Active      int    `bson:"active"`
+	Healthy     int    `bson:"healthy"`
+	CoolingDown int    `bson:"cooling_down"`
+	Exhausted   int    `bson:"exhausted"`
+	Revoked     int    `bson:"revoked"`
+	Errors      int    `bson:"errors"`
+}
+
+func collectAPIKeyPool(samples func(apiKeyPoolRow) []metrics.Sample) func() []metrics.Sample {
+	return func() []metrics.Sample {
+		ctx, cancel := context.WithTimeout(context.Background(), metricsQueryTimeout)
+		defer cancel()
+
+		cursor, err := apiKeysCollection.Aggregate(ctx, mongo.Pipeline{
+			{{Key: "$group", Value: bson.M{
+				"_id":          "$provider",
+				"total":        bson.M{"$sum": 1},
+				"active":       bson.M{"$sum": bson.M{"$cond": bson.A{"$is_active", 1, 0}}},
+				"healthy":      countActiveInState(KeyStateHealthy),
+				"cooling_down": countActiveInState(KeyStateCoolingDown),
+				"exhausted":    countActiveInState(KeyStateExhausted),
+				"revoked":      countActiveInState(KeyStateRevoked),
+				"errors":       bson.M{"$sum": "$error_count"},
+			}}},
+		})
+		if err != nil {
+			return nil
+		}
+		var rows []apiKeyPoolRow
+		if err := cursor.All(ctx, &rows); err != nil {
+			return nil
+		}
+
+		var out []metrics.Sample
+		for _, row := range rows {
+			out = append(out, samples(row)...)
+		}
+		return out
+	}
+}
+
+// countActiveInState counts enabled keys in a health state; disabled keys count apart
+func countActiveInState(state string) bson.M {
+	return bson.M{"$sum": bson.M{"$cond": bson.A{
+		bson.M{"$and": bson.A{"$is_active", bson.M{"$eq": bson.A{"$state", state}}}}, 1, 0,
+	}}}
+}
+
+func collectQueueDepths() []metrics.Sample {
+	ctx, cancel := context.WithTimeout(context.Background(), metricsQueryTimeout)
+	defer cancel()
+
+	inProgress := bson.M{"$in": []string{StatusPending, StatusProcessing}}
+	counts := []struct {
+		queue      string
+		collection *mongo.Collection
+		filter     bson.M
+	}{
+		{"scripts_processing", scriptsCollection, bson.M{"status": StatusProcessing}},
+		{"visual_images", chunkVisualsCollection, bson.M{"status": inProgress}},
+		{"video_renders", videoStatusCollection, bson.M{"status": inProgress}},
+		{"active_jobs", jobLeasesCollection, bson.M{"expires_at": bson.M{"$gt": time.Now()}}},
+	}
+
+	var out []metrics.Sample
+	for _, c := range counts {
+		n, err := c.collection.CountDocuments(ctx, c.filter)
+		if err != nil {
+			continue
+		}
+		out = append(out, metrics.Sample{Labels: []string{c.queue}, Value: float64(n)})
+	}
+
+	cursor, err := batchesCollection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"status": bson.M{"$in": []string{BatchStatusScheduled, BatchStatusRunning}}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":       nil,
+			"scheduled": bson.M{"$sum": "$progress.scheduled"},
+			"running":   bson.M{"$sum": "$progress.running"},
+		}}},
+	})
+	if err == nil {
+		var rows []struct {
+			Scheduled int `bson:"scheduled"`
+			Running   int `bson:"running"`
+		}
+		if cursor.All(ctx, &rows) == nil {
+			var scheduled, running int
+			for _, row := range rows {
+				scheduled, running = row.Scheduled, row.Running
+			}
+			out = append(out,
+				metrics.Sample{Labels: []string{"batch_items_scheduled"}, Value: float64(scheduled)},
+				metrics.Sample{Labels: []string{"batch_items_running"}, Value: float64(running)})
+		}
+	}
+	return out
+}
@@ -15,17 +15,186 @@ type Channel struct {
 }
 
 type ChannelSettings struct {
-	DefaultSectionCount     int  `bson:"default_section_count" json:"default_section_count"`
-	PreferredVisualGuidance bool `bson:"preferred_visual_guidance" json:"preferred_visual_guidance"`
-	WordLimitForHookIntro   int  `bson:"word_limit_for_hook_intro" json:"word_limit_for_hook_intro"`
-	VisualImageMultiplier   int  `bson:"visual_image_multiplier" json:"visual_image_multiplier"`
-	WordLimitPerSection     int  `bson:"word_limit_per_section" json:"word_limit_per_section"`
+	DefaultSectionCount     int              `bson:"default_section_count" json:"default_section_count"`
+	PreferredVisualGuidance bool             `bson:"preferred_visual_guidance" json:"preferred_visual_guidance"`
+	WordLimitForHookIntro   int              `bson:"word_limit_for_hook_intro" json:"word_limit_for_hook_intro"`
+	VisualImageMultiplier   int              `bson:"visual_image_multiplier" json:"visual_image_multiplier"`
+	WordLimitPerSection     int              `bson:"word_limit_per_section" json:"word_limit_per_section"`
+	WordsPerMinute          int              `bson:"words_per_minute,omitempty" json:"words_per_minute,omitempty"`             // Measured narration pace, used by long-form mode
+	NicheDescription        string           `bson:"niche_description,omitempty" json:"niche_description,omitempty"`           // What the channel is about, used for topic ideation
+	MaxConcurrentScripts    int              `bson:"max_concurrent_scripts,omitempty" json:"max_concurrent_scripts,omitempty"` // Scheduled batch items running at once
+	ThumbnailLayout         *ThumbnailLayout `bson:"thumbnail_layout,omitempty" json:"thumbnail_layout,omitempty"`
+	TTSProvider             string           `bson:"tts_provider,omitempty" json:"tts_provider,omitempty"` // Empty for voice.provider
+	TTSVoice                string           `bson:"tts_voice,omitempty" json:"tts_voice,omitempty"`       // Voice of TTSProvider, empty for its default
+}
+
+// ThumbnailLayout controls how ThumbnailText is drawn over the thumbnail image.
+// Colors are ffmpeg color names or #RRGGBB.
+type ThumbnailLayout struct {
+	FontFile          string   `bson:"font_file,omitempty" json:"font_file,omitempty"` // Path to a .ttf/.otf, defaults to THUMBNAIL_FONT_FILE
+	FontSize          int      `bson:"font_size" json:"font_size"`
+	FontColor         string   `bson:"font_color" json:"font_color"`
+	StrokeColor       string   `bson:"stroke_color" json:"stroke_color"`
+	StrokeWidth       int      `bson:"stroke_width" json:"stroke_width"`
+	Position          string   `bson:"position" json:"position"`                       // "top", "center", "bottom", "left", "right"
+	Uppercase         *bool    `bson:"uppercase,omitempty" json:"uppercase,omitempty"` // Unset keeps the default
+	MaxCharsPerLine   int      `bson:"max_chars_per_line" json:"max_chars_per_line"`
+	GradientColor     string   `bson:"gradient_color" json:"gradient_color"`                         // #RRGGBB
+	GradientOpacity   *float64 `bson:"gradient_opacity,omitempty" json:"gradient_opacity,omitempty"` // 0 disables the gradient, unset keeps the default
+	GradientDirection string   `bson:"gradient_direction" json:"gradient_direction"`                 // Edge the gradient darkens: "top", "bottom", "left", "right"
+}
+
+// UploadStatus tracks publishing of the finished video
+type UploadStatus struct {
+	Status        string     `bson:"status" json:"status"` // "uploading", "completed", "failed"
+	Provider      string     `bson:"provider" json:"provider"`
+	VideoID       string     `bson:"video_id,omitempty" json:"video_id,omitempty"`
+	VideoURL      string     `bson:"video_url,omitempty" json:"video_url,omitempty"`
+	PrivacyStatus string     `bson:"privacy_status" json:"privacy_status"`
+	PublishAt     *time.Time `bson:"publish_at,omitempty" json:"publish_at,omitempty"`
+	Progress      int        `bson:"progress" json:"progress"` // 0-100
+	ThumbnailSet  bool       `bson:"thumbnail_set" json:"thumbnail_set"`
+	ErrorMsg      string     `bson:"error_msg,omitempty" json:"error_msg,omitempty"`
+	StartedAt     time.Time  `bson:"started_at" json:"started_at"`
+	CompletedAt   *time.Time `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+}
+
+// AuthToken is a bearer token for the HTTP API. Only the SHA-256 hash of the token is stored.
+type AuthToken struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name       string             `bson:"name" json:"name"`
+	TokenHash  string             `bson:"token_hash" json:"-"`
+	Prefix     string             `bson:"prefix" json:"prefix"` // First characters, to recognise a token in listings
+	Scopes     []string           `bson:"scopes" json:"scopes"`
+	Channels   []string           `bson:"channels,omitempty" json:"channels,omitempty"`     // Empty means all channels
+	RateLimit  int                `bson:"rate_limit,omitempty" json:"rate_limit,omitempty"` // Requests per minute, 0 uses the default
+	MaxJobs    int                `bson:"max_jobs,omitempty" json:"max_jobs,omitempty"`     // Concurrent jobs, 0 uses the default
+	Revoked    bool               `bson:"revoked" json:"revoked"`
+	ExpiresAt  *time.Time         `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+	LastUsedAt *time.Time         `bson:"last_used_at,omitempty" json:"last_used_at,omitempty"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// YouTubeToken is the OAuth token a channel authorized uploads with
+type YouTubeToken struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ChannelID    primitive.ObjectID `bson:"channel_id" json:"channel_id"`
+	AccessToken  string             `bson:"access_token" json:"-"`
+	RefreshToken string             `bson:"refresh_token" json:"-"`
+	TokenType    string             `bson:"token_type" json:"token_type"`
+	Scope        string             `bson:"scope" json:"scope"`
+	Expiry       time.Time          `bson:"expiry" json:"expiry"`
+	UpdatedAt    time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// OAuthState ties a consent URL to the channel that asked for it; the callback
+// consumes it, so each authorization can complete once
+type OAuthState struct {
+	State     string             `bson:"_id"`
+	ChannelID primitive.ObjectID `bson:"channel_id"`
+	ExpiresAt time.Time          `bson:"expires_at"`
+}
+
+type Thumbnail struct {
+	Variant     int       `bson:"variant" json:"variant"`
+	Path        string    `bson:"path" json:"path"`
+	SourceImage string    `bson:"source_image" json:"source_image"`
+	Position    string    `bson:"position" json:"position"`
+	CreatedAt   time.Time `bson:"created_at" json:"created_at"`
 }
 
 type OutlinePoint struct {
 	SectionNumber int    `bson:"section_number" json:"section_number"`
 	Title         string `bson:"title" json:"title"`
 	Summary       string `bson:"summary" json:"summary"`
+	ActNumber     int    `bson:"act_number,omitempty" json:"act_number,omitempty"`         // Long-form only
+	ChapterNumber int    `bson:"chapter_number,omitempty" json:"chapter_number,omitempty"` // Long-form only
+}
+
+// LongFormPlan describes the act -> chapter -> section hierarchy of a long-form script
+type LongFormPlan struct {
+	TargetMinutes   int          `bson:"target_minutes" json:"target_minutes"`
+	WordsPerMinute  int          `bson:"words_per_minute" json:"words_per_minute"`
+	TargetWords     int          `bson:"target_words" json:"target_words"`
+	WordsPerSection int          `bson:"words_per_section" json:"words_per_section"`
+	SectionCount    int          `bson:"section_count" json:"section_count"`
+	Acts            []OutlineAct `bson:"acts" json:"acts"`
+}
+
+type OutlineAct struct {
+	ActNumber int              `bson:"act_number" json:"act_number"`
+	Title     string           `bson:"title" json:"title"`
+	Summary   string           `bson:"summary" json:"summary"`
+	Chapters  []OutlineChapter `bson:"chapters" json:"chapters"`
+}
+
+type OutlineChapter struct {
+	ChapterNumber int    `bson:"chapter_number" json:"chapter_number"`
+	Title         string `bson:"title" json:"title"`
+	Summary       string `bson:"summary" json:"summary"`
+	SectionCount  int    `bson:"section_count" json:"section_count"`
+}
+
+// TopicIdea is an entry in a channel's topic backlog
+type TopicIdea struct {
+	ID              primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	ChannelID       primitive.ObjectID  `bson:"channel_id" json:"channel_id"`
+	ChannelName     string              `bson:"channel_name" json:"channel_name"`
+	Topic           string              `bson:"topic" json:"topic"`
+	NormalizedTopic string              `bson:"normalized_topic" json:"-"`
+	Angle           string              `bson:"angle,omitempty" json:"angle,omitempty"`
+	Status          string              `bson:"status" json:"status"`     // "proposed", "approved", "rejected", "used"
+	Priority        int                 `bson:"priority" json:"priority"` // Higher runs first
+	Source          string              `bson:"source" json:"source"`     // "ai" or "manual"
+	ScriptID        *primitive.ObjectID `bson:"script_id,omitempty" json:"script_id,omitempty"`
+	CreatedAt       time.Time           `bson:"created_at" json:"created_at"`
+	UpdatedAt       time.Time           `bson:"updated_at" json:"updated_at"`
+}
+
+// Batch is a set of scripts produced for one channel on a schedule
+type Batch struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name            string             `bson:"name,omitempty" json:"name,omitempty"`
+	ChannelID       primitive.ObjectID `bson:"channel_id" json:"channel_id"`
+	ChannelName     string             `bson:"channel_name" json:"channel_name"`
+	Status          string             `bson:"status" json:"status"` // "scheduled", "running", "completed", "completed_with_errors", "cancelled"
+	Schedule        BatchSchedule      `bson:"schedule" json:"schedule"`
+	GenerateVisuals bool               `bson:"generate_visuals" json:"generate_visuals"`
+	TargetMinutes   int                `bson:"target_minutes,omitempty" json:"target_minutes,omitempty"`
+	Items           []BatchItem        `bson:"items" json:"items"`
+	Progress        BatchProgress      `bson:"progress" json:"progress"`
+	CreatedAt       time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt       time.Time          `bson:"updated_at" json:"updated_at"`
+	CompletedAt     *time.Time         `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+}
+
+// BatchSchedule holds either a cron cadence or explicit dates. With neither, items start right away.
+type BatchSchedule struct {
+	Cron     string      `bson:"cron,omitempty" json:"cron,omitempty"`         // e.g. "0 9 * * 1-5"
+	Dates    []time.Time `bson:"dates,omitempty" json:"dates,omitempty"`       // One per item
+	StartAt  *time.Time  `bson:"start_at,omitempty" json:"start_at,omitempty"` // First cron slot is after this
+	Timezone string      `bson:"timezone,omitempty" json:"timezone,omitempty"` // IANA name the cron is evaluated in
+}
+
+type BatchItem struct {
+	Index       int                 `bson:"index" json:"index"`
+	Topic       string              `bson:"topic" json:"topic"`
+	TopicID     *primitive.ObjectID `bson:"topic_id,omitempty" json:"topic_id,omitempty"` // Backlog entry the topic came from
+	ScheduledAt time.Time           `bson:"scheduled_at" json:"scheduled_at"`
+	Status      string              `bson:"status" json:"status"` // "scheduled", "running", "completed", "failed", "cancelled"
+	ScriptID    *primitive.ObjectID `bson:"script_id,omitempty" json:"script_id,omitempty"`
+	Error       string              `bson:"error,omitempty" json:"error,omitempty"`
+	StartedAt   *time.Time          `bson:"started_at,omitempty" json:"started_at,omitempty"`
+	CompletedAt *time.Time          `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+}
+
+type BatchProgress struct {
+	Total     int `bson:"total" json:"total"`
+	Scheduled int `bson:"scheduled" json:"scheduled"`
+	Running   int `bson:"running" json:"running"`
+	Completed int `bson:"completed" json:"completed"`
+	Failed    int `bson:"failed" json:"failed"`
+	Cancelled int `bson:"cancelled" json:"cancelled"`
 }
 
 type ImagePrompt struct {
@@ -41,6 +210,7 @@ type Script struct {
 	Topic           string             `bson:"topic" json:"topic"`
 	Status          string             `bson:"status" json:"status"`
 	GenerateVisuals bool               `bson:"generate_visuals" json:"generate_visuals"`
+	Source          string             `bson:"source,omitempty" json:"source,omitempty"` // "import" for bring-your-own scripts
 
 	// Content stored in DB instead of files
 	Outline       string         `bson:"outline" json:"outline"`
@@ -49,6 +219,12 @@ type Script struct {
 	Meta          MetaContent    `bson:"meta" json:"meta"`
 	SRT           string         `bson:"srt" json:"srt"` // SRT content for subtitles
 	FullAudioFile string         `bson:"full_audio_file,omitempty" json:"full_audio_file,omitempty"`
+	Thumbnails    []Thumbnail    `bson:"thumbnails,omitempty" json:"thumbnails,omitempty"`
+	Upload        *UploadStatus  `bson:"upload,omitempty" json:"upload,omitempty"`
+
+	// Long-form mode: outline hierarchy and the running summary fed to each section prompt
+	LongForm       *LongFormPlan `bson:"long_form,omitempty" json:"long_form,omitempty"`
+	RollingSummary string        `bson:"rolling_summary,omitempty" json:"rolling_summary,omitempty"`
 
 	CreatedAt         time.Time  `bson:"created_at" json:"created_at"`
 	StartedAt         *time.Time `bson:"started_at,omitempty" json:"started_at,omitempty"`
@@ -64,6 +240,7 @@ type ScriptRequest struct {
 	Topic           string `json:"topic"`
 	GenerateVisuals bool   `json:"generate_visuals"`
 	ChannelName     string `json:"channel_name"`
+	TargetMinutes   int    `json:"target_minutes,omitempty"` // > 0 switches to long-form mode
 }
 
 type ScriptResponse struct {
@@ -80,6 +257,31 @@ type ScriptResponse struct {
 	Error           string `json:"error,omitempty"`
 }
 
+// ScriptChunksResponse lists the narration chunks of a script
+type ScriptChunksResponse struct {
+	ScriptID    string        `json:"script_id"`
+	TotalChunks int           `json:"total_chunks"`
+	Chunks      []ScriptAudio `json:"chunks"`
+}
+
+// StepResponse reports a pipeline step that ran, or started in the background, for a script
+type StepResponse struct {
+	Message string   `json:"message"`
+	Data    StepData `json:"data"`
+}
+
+type StepData struct {
+	ScriptID    string `json:"script_id"`
+	TotalChunks *int   `json:"total_chunks,omitempty"` // Audio and subtitle steps only
+}
+
+type HealthResponse struct {
+	Status    string `json:"status"`
+	Timestamp string `json:"timestamp"`
+	Service   string `json:"service"`
+	MongoDB   string `json:"mongodb"`
+}
+
 type ScriptAudio struct {
 	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	ScriptID         primitive.ObjectID `bson:"script_id" json:"script_id"`
@@ -162,6 +364,12 @@ type KenBurnsConfig struct {
 }
 
 // NEW REQUEST STRUCTURES
+type VisualPromptsRequest struct {
+	ScriptID string `json:"script_id"`
+	StyleID  string `json:"style_id"`
+	Force    bool   `json:"force,omitempty"` // Regenerate prompts that already exist
+}
+
 type VisualStyleRequest struct {
 	Name           string   `json:"name"`
 	Category       string   `json:"category"`
@@ -208,6 +416,26 @@ type VisualStyle struct {
 	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
 	UpdatedAt      time.Time          `bson:"updated_at" json:"updated_at"`
 }
+type PromptTemplateResponse struct {
+	Success bool           `json:"success"`
+	Data    PromptTemplate `json:"data"`
+}
+
+type PromptTemplateListResponse struct {
+	Success bool             `json:"success"`
+	Data    []PromptTemplate `json:"data"`
+}
+
+type VisualStyleResponse struct {
+	Success bool        `json:"success"`
+	Data    VisualStyle `json:"data"`
+}
+
+type VisualStyleListResponse struct {
+	Success bool          `json:"success"`
+	Data    []VisualStyle `json:"data"`
+}
+
 type OutlineResponse struct {
 	Sections []OutlineSection `json:"sections"`
 }
@@ -236,24 +464,75 @@ type SectionContent struct {
 	NarrativeFormat string `json:"narrative_format"`
 }
 
+type ActOutlineResponse struct {
+	Acts []ActOutline `json:"acts"`
+}
+
+type ActOutline struct {
+	Title    string           `json:"title"`
+	Summary  string           `json:"summary"`
+	Chapters []OutlineSection `json:"chapters"`
+}
+
+type TopicIdeasResponse struct {
+	Topics []TopicIdeaContent `json:"topics"`
+}
+
+type TopicIdeaContent struct {
+	Title string `json:"title"`
+	Angle string `json:"angle"`
+}
+
+type ThumbnailPromptResponse struct {
+	Prompt string `json:"prompt"`
+}
+
+type RollingSummaryResponse struct {
+	Summary string `json:"summary"`
+}
+
 type MetaResponse struct {
 	Meta MetaContent `json:"meta"`
 }
 
 type MetaContent struct {
-	Title         string   `json:"title"`
-	Description   string   `json:"description"`
-	Tags          []string `json:"tags"`
-	ThumbnailText string   `json:"thumbnail_text"`
+	Title         string    `json:"title"`
+	Description   string    `json:"description"`
+	Tags          []string  `json:"tags"`
+	ThumbnailText string    `json:"thumbnail_text"`
+	Chapters      []Chapter `json:"chapters,omitempty"` // Filled in after subtitles exist
 }
+
+// Chapter is a YouTube chapter marker aligned to the subtitle timeline
+type Chapter struct {
+	Timestamp     string  `json:"timestamp"` // "00:00" or "1:02:03"
+	Seconds       float64 `json:"seconds"`
+	Title         string  `json:"title"`
+	SectionNumber int     `json:"section_number"` // 0 for the introduction
+}
+
+// APIKey is a provider key from the api_keys pool. The value is only stored sealed in
+// Secret; KeyValue holds it in memory after decrypt.
 type APIKey struct {
-	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	KeyValue   string             `bson:"key_value" json:"key_value"`
-	Provider   string             `bson:"provider" json:"provider"` // e.g., "elevenlabs", "whisk"
-	IsActive   bool               `bson:"is_active" json:"is_active"`
-	LastUsed   time.Time          `bson:"last_used" json:"last_used"`
-	ErrorCount int                `bson:"error_count" json:"error_count"`
-	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	ID                  primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	KeyValue            string             `bson:"-" json:"-"`
+	LegacyKeyValue      string             `bson:"key_value,omitempty" json:"-"` // Plaintext of keys stored before encryption, sealed at startup
+	Secret              *EncryptedSecret   `bson:"secret,omitempty" json:"-"`
+	Fingerprint         string             `bson:"fingerprint" json:"fingerprint"`
+	Provider            string             `bson:"provider" json:"provider"`   // e.g., "elevenlabs", "whisk"
+	IsActive            bool               `bson:"is_active" json:"is_active"` // Operator switch, independent of State
+	State               string             `bson:"state" json:"state"`         // KeyStateHealthy, KeyStateCoolingDown, KeyStateExhausted or KeyStateRevoked
+	CooldownUntil       *time.Time         `bson:"cooldown_until,omitempty" json:"cooldown_until,omitempty"`
+	LastUsed            time.Time          `bson:"last_used" json:"last_used"`
+	UsageCount          int                `bson:"usage_count" json:"usage_count"`
+	CharactersUsed      int                `bson:"characters_used,omitempty" json:"characters_used,omitempty"`           // Metered providers such as ElevenLabs
+	CharactersRemaining *int               `bson:"characters_remaining,omitempty" json:"characters_remaining,omitempty"` // As of the last quota check
+	ErrorCount          int                `bson:"error_count" json:"error_count"`
+	ConsecutiveErrors   int                `bson:"consecutive_errors" json:"consecutive_errors"`
+	LastError           string             `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	LastErrorAt         *time.Time         `bson:"last_error_at,omitempty" json:"last_error_at,omitempty"`
+	LastProbedAt        *time.Time         `bson:"last_probed_at,omitempty" json:"last_probed_at,omitempty"`
+	CreatedAt           time.Time          `bson:"created_at" json:"created_at"`
 }
 type GapRecoveryRequest struct {
 	StartTime  float64 `json:"start_time"`
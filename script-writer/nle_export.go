@@ -0,0 +1,591 @@
+// File: nle_export.go
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// nleTimeline is the rendered cut quantized to whole frames, with media paths relative to
+// the project export root so the files open next to an unzipped package
+type nleTimeline struct {
+	Name      string
+	Width     int
+	Height    int
+	Frames    int
+	Images    []nleClip
+	Voiceover string
+	Subtitles []nleCaption
+}
+
+type nleClip struct {
+	Name   string
+	File   string
+	Start  int
+	Frames int
+}
+
+type nleCaption struct {
+	Text   string
+	Start  int
+	Frames int
+}
+
+func secondsToFrames(seconds float64) int {
+	return int(math.Round(seconds * nleFrameRate))
+}
+
+// buildNLETimeline converts the renderer's video request into frame-accurate clips.
+// clips supplies the export file name for each image.
+func buildNLETimeline(script *Script, videoRequest *VideoRequest, clips []timelineClip) *nleTimeline {
+	files := make(map[string]string, len(clips))
+	for _, clip := range clips {
+		files[clip.Visual.ImagePath] = clip.File
+	}
+
+	timeline := &nleTimeline{
+		Name:   videoRequest.Title,
+		Width:  videoRequest.Width,
+		Height: videoRequest.Height,
+		Frames: secondsToFrames(videoRequest.Duration),
+	}
+	if script.FullAudioFile != "" {
+		timeline.Voiceover = voiceoverExportPath(script)
+	}
+
+	images := append([]ImageAsset(nil), videoRequest.Images...)
+	sort.SliceStable(images, func(i, j int) bool { return images[i].StartTime < images[j].StartTime })
+	lastEnd := 0
+	for _, image := range images {
+		file, ok := files[image.URL]
+		if !ok {
+			continue
+		}
+		start := secondsToFrames(image.StartTime)
+		end := secondsToFrames(image.StartTime + image.Duration)
+		// Rounding can make neighbours overlap by a frame; NLE tracks cannot
+		if start < lastEnd {
+			start = lastEnd
+		}
+		if end <= start {
+			continue
+		}
+		timeline.Images = append(timeline.Images, nleClip{
+			Name:   filepath.Base(file),
+			File:   file,
+			Start:  start,
+			Frames: end - start,
+		})
+		lastEnd = end
+	}
+	if lastEnd > timeline.Frames {
+		timeline.Frames = lastEnd
+	}
+
+	if entries, err := parseSRT(videoRequest.Subtitles.SRTData); err == nil {
+		for _, entry := range entries {
+			start := secondsToFrames(entry.StartTime.Seconds())
+			end := secondsToFrames(entry.EndTime.Seconds())
+			if end > start {
+				timeline.Subtitles = append(timeline.Subtitles, nleCaption{Text: entry.Text, Start: start, Frames: end - start})
+			}
+		}
+	}
+	return timeline
+}
+
+// FCPXML
+
+type fcpxmlDocument struct {
+	XMLName   xml.Name        `xml:"fcpxml"`
+	Version   string          `xml:"version,attr"`
+	Resources fcpxmlResources `xml:"resources"`
+	Library   fcpxmlLibrary   `xml:"library"`
+}
+
+type fcpxmlResources struct {
+	Format fcpxmlFormat  `xml:"format"`
+	Assets []fcpxmlAsset `xml:"asset"`
+}
+
+type fcpxmlFormat struct {
+	ID            string `xml:"id,attr"`
+	FrameDuration string `xml:"frameDuration,attr"`
+	Width         int    `xml:"width,attr"`
+	Height        int    `xml:"height,attr"`
+}
+
+type fcpxmlAsset struct {
+	ID       string         `xml:"id,attr"`
+	Name     string         `xml:"name,attr"`
+	Start    string         `xml:"start,attr"`
+	Duration string         `xml:"duration,attr"`
+	HasVideo string         `xml:"hasVideo,attr,omitempty"`
+	HasAudio string         `xml:"hasAudio,attr,omitempty"`
+	Format   string         `xml:"format,attr,omitempty"`
+	MediaRep fcpxmlMediaRep `xml:"media-rep"`
+}
+
+type fcpxmlMediaRep struct {
+	Kind string `xml:"kind,attr"`
+	Src  string `xml:"src,attr"`
+}
+
+type fcpxmlLibrary struct {
+	Event struct {
+		Name    string `xml:"name,attr"`
+		Project struct {
+			Name     string         `xml:"name,attr"`
+			Sequence fcpxmlSequence `xml:"sequence"`
+		} `xml:"project"`
+	} `xml:"event"`
+}
+
+type fcpxmlSequence struct {
+	Format   string `xml:"format,attr"`
+	Duration string `xml:"duration,attr"`
+	TCStart  string `xml:"tcStart,attr"`
+	TCFormat string `xml:"tcFormat,attr"`
+	Spine    struct {
+		Gap fcpxmlGap `xml:"gap"`
+	} `xml:"spine"`
+}
+
+// fcpxmlGap spans the whole timeline; images, voiceover and captions hang off it as connected
+// clips on their own lanes, so their offsets are plain timeline positions
+type fcpxmlGap struct {
+	Name     string           `xml:"name,attr"`
+	Offset   string           `xml:"offset,attr"`
+	Duration string           `xml:"duration,attr"`
+	Videos   []fcpxmlVideo    `xml:"video"`
+	Audio    *fcpxmlAssetClip `xml:"asset-clip,omitempty"`
+	Captions []fcpxmlCaption  `xml:"caption"`
+}
+
+type fcpxmlVideo struct {
+	Ref      string `xml:"ref,attr"`
+	Lane     int    `xml:"lane,attr"`
+	Offset   string `xml:"offset,attr"`
+	Duration string `xml:"duration,attr"`
+	Start    string `xml:"start,attr"`
+	Name     string `xml:"name,attr"`
+}
+
+type fcpxmlAssetClip struct {
+	Ref      string `xml:"ref,attr"`
+	Lane     int    `xml:"lane,attr"`
+	Offset   string `xml:"offset,attr"`
+	Duration string `xml:"duration,attr"`
+	Name     string `xml:"name,attr"`
+}
+
+type fcpxmlCaption struct {
+	Lane     int    `xml:"lane,attr"`
+	Offset   string `xml:"offset,attr"`
+	Duration string `xml:"duration,attr"`
+	Name     string `xml:"name,attr"`
+	Role     string `xml:"role,attr"`
+	Text     struct {
+		Style struct {
+			Ref  string `xml:"ref,attr"`
+			Text string `xml:",chardata"`
+		} `xml:"text-style"`
+	} `xml:"text"`
+	StyleDef struct {
+		ID    string `xml:"id,attr"`
+		Style struct {
+			Font      string `xml:"font,attr"`
+			FontSize  int    `xml:"fontSize,attr"`
+			FontColor string `xml:"fontColor,attr"`
+		} `xml:"text-style"`
+	} `xml:"text-style-def"`
+}
+
+// fcpxmlTime writes a frame count as the rational seconds FCPXML uses
+func fcpxmlTime(frames int) string {
+	if frames == 0 {
+		return "0s"
+	}
+	return fmt.Sprintf("%d/%ds", frames, nleFrameRate)
+}
+
+func renderFCPXML(timeline *nleTimeline) ([]byte, error) {
+	doc := fcpxmlDocument{Version: "1.9"}
+	doc.Resources.Format = fcpxmlFormat{
+		ID:            "r1",
+		FrameDuration: fmt.Sprintf("1/%ds", nleFrameRate),
+		Width:         timeline.Width,
+		Height:        timeline.Height,
+	}
+
+	gap := fcpxmlGap{Name: "Gap", Offset: "0s", Duration: fcpxmlTime(timeline.Frames)}
+	for i, clip := range timeline.Images {
+		id := fmt.Sprintf("r%d", i+2)
+		doc.Resources.Assets = append(doc.Resources.Assets, fcpxmlAsset{
+			ID:       id,
+			Name:     clip.Name,
+			Start:    "0s",
+			Duration: "0s", // Stills have no intrinsic duration
+			HasVideo: "1",
+			Format:   "r1",
+			MediaRep: fcpxmlMediaRep{Kind: "original-media", Src: clip.File},
+		})
+		gap.Videos = append(gap.Videos, fcpxmlVideo{
+			Ref:      id,
+			Lane:     1,
+			Offset:   fcpxmlTime(clip.Start),
+			Duration: fcpxmlTime(clip.Frames),
+			Start:    "0s",
+			Name:     clip.Name,
+		})
+	}
+
+	if timeline.Voiceover != "" {
+		id := fmt.Sprintf("r%d", len(timeline.Images)+2)
+		doc.Resources.Assets = append(doc.Resources.Assets, fcpxmlAsset{
+			ID:       id,
+			Name:     "voiceover",
+			Start:    "0s",
+			Duration: fcpxmlTime(timeline.Frames),
+			HasAudio: "1",
+			MediaRep: fcpxmlMediaRep{Kind: "original-media", Src: timeline.Voiceover},
+		})
+		gap.Audio = &fcpxmlAssetClip{
+			Ref:      id,
+			Lane:     -1,
+			Offset:   "0s",
+			Duration: fcpxmlTime(timeline.Frames),
+			Name:     "voiceover",
+		}
+	}
+
+	for i, subtitle := range timeline.Subtitles {
+		caption := fcpxmlCaption{
+			Lane:     2,
+			Offset:   fcpxmlTime(subtitle.Start),
+			Duration: fcpxmlTime(subtitle.Frames),
+			Name:     truncateString(strings.ReplaceAll(subtitle.Text, "\n", " "), 40),
+			Role:     nleCaptionRole,
+		}
+		caption.StyleDef.ID = fmt.Sprintf("ts%d", i+1)
+		caption.StyleDef.Style.Font = "Helvetica"
+		caption.StyleDef.Style.FontSize = 13
+		caption.StyleDef.Style.FontColor = "1 1 1 1"
+		caption.Text.Style.Ref = caption.StyleDef.ID
+		caption.Text.Style.Text = subtitle.Text
+		gap.Captions = append(gap.Captions, caption)
+	}
+
+	doc.Library.Event.Name = timeline.Name
+	doc.Library.Event.Project.Name = timeline.Name
+	doc.Library.Event.Project.Sequence = fcpxmlSequence{
+		Format:   "r1",
+		Duration: fcpxmlTime(timeline.Frames),
+		TCStart:  "0s",
+		TCFormat: "NDF",
+	}
+	doc.Library.Event.Project.Sequence.Spine.Gap = gap
+
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encoding FCPXML: %w", err)
+	}
+	return append([]byte(xml.Header+"<!DOCTYPE fcpxml>\n"), append(body, '\n')...), nil
+}
+
+// CMX3600 EDL
+
+// edlTimecode writes a frame count as non-drop-frame HH:MM:SS:FF
+func edlTimecode(frames int) string {
+	ff := frames % nleFrameRate
+	totalSeconds := frames / nleFrameRate
+	return fmt.Sprintf("%02d:%02d:%02d:%02d", totalSeconds/3600, (totalSeconds%3600)/60, totalSeconds%60, ff)
+}
+
+// renderEDL writes one event per image plus the voiceover on A. CMX3600 has no caption
+// track, so subtitles are only referenced in a comment.
+func renderEDL(timeline *nleTimeline) []byte {
+	var edl strings.Builder
+	title := strings.ToUpper(strings.NewReplacer("\n", " ", "\r", " ").Replace(timeline.Name))
+	edl.WriteString(fmt.Sprintf("TITLE: %s\n", truncateString(title, 70)))
+	edl.WriteString("FCM: NON-DROP FRAME\n\n")
+
+	event := 1
+	writeEvent := func(track string, start, frames int, name, file string) {
+		edl.WriteString(fmt.Sprintf("%03d  AX       %-4s C        %s %s %s %s\n",
+			event, track,
+			edlTimecode(0), edlTimecode(frames),
+			edlTimecode(start), edlTimecode(start+frames)))
+		edl.WriteString(fmt.Sprintf("* FROM CLIP NAME: %s\n", name))
+		edl.WriteString(fmt.Sprintf("* SOURCE FILE: %s\n\n", file))
+		event++
+	}
+
+	for _, clip := range timeline.Images {
+		writeEvent("V", clip.Start, clip.Frames, clip.Name, clip.File)
+	}
+	if timeline.Voiceover != "" {
+		writeEvent("A", 0, timeline.Frames, filepath.Base(timeline.Voiceover), timeline.Voiceover)
+	}
+	if len(timeline.Subtitles) > 0 {
+		edl.WriteString("* SUBTITLES: subtitles/subtitles.srt\n")
+	}
+	return []byte(edl.String())
+}
+
+// OpenTimelineIO
+
+type otioRationalTime struct {
+	Schema string  `json:"OTIO_SCHEMA"`
+	Rate   float64 `json:"rate"`
+	Value  float64 `json:"value"`
+}
+
+type otioTimeRange struct {
+	Schema    string           `json:"OTIO_SCHEMA"`
+	StartTime otioRationalTime `json:"start_time"`
+	Duration  otioRationalTime `json:"duration"`
+}
+
+type otioMediaReference struct {
+	Schema         string                 `json:"OTIO_SCHEMA"`
+	TargetURL      string                 `json:"target_url"`
+	AvailableRange *otioTimeRange         `json:"available_range"`
+	Metadata       map[string]interface{} `json:"metadata"`
+}
+
+// otioItem covers Clip.1, Gap.1, Track.1 and Stack.1; unused fields are omitted
+type otioItem struct {
+	Schema         string                 `json:"OTIO_SCHEMA"`
+	Name           string                 `json:"name"`
+	Kind           string                 `json:"kind,omitempty"`
+	SourceRange    *otioTimeRange         `json:"source_range"`
+	MediaReference *otioMediaReference    `json:"media_reference,omitempty"`
+	Children       []otioItem             `json:"children,omitempty"`
+	Effects        []interface{}          `json:"effects"`
+	Markers        []otioMarker           `json:"markers"`
+	Metadata       map[string]interface{} `json:"metadata"`
+}
+
+type otioMarker struct {
+	Schema      string                 `json:"OTIO_SCHEMA"`
+	Name        string                 `json:"name"`
+	Color       string                 `json:"color"`
+	MarkedRange otioTimeRange          `json:"marked_range"`
+	Metadata    map[string]interface{} `json:"metadata"`
+}
+
+type otioTimeline struct {
+	Schema          string                 `json:"OTIO_SCHEMA"`
+	Name            string                 `json:"name"`
+	GlobalStartTime *otioRationalTime      `json:"global_start_time"`
+	Tracks          otioItem               `json:"tracks"`
+	Metadata        map[string]interface{} `json:"metadata"`
+}
+
+func otioTime(frames int) otioRationalTime {
+	return otioRationalTime{Schema: "RationalTime.1", Rate: nleFrameRate, Value: float64(frames)}
+}
+
+func otioRange(start, frames int) *otioTimeRange {
+	return &otioTimeRange{Schema: "TimeRange.1", StartTime: otioTime(start), Duration: otioTime(frames)}
+}
+
+func otioClip(name, file string, frames int) otioItem {
+	return otioItem{
+		Schema:      "Clip.1",
+		Name:        name,
+		SourceRange: otioRange(0, frames),
+		MediaReference: &otioMediaReference{
+			Schema:    "ExternalReference.1",
+			TargetURL: file,
+			Metadata:  map[string]interface{}{},
+		},
+		Effects:  []interface{}{},
+		Markers:  []otioMarker{},
+		Metadata: map[string]interface{}{},
+	}
+}
+
+func otioGap(frames int) otioItem {
+	return otioItem{
+		Schema:      "Gap.1",
+		SourceRange: otioRange(0, frames),
+		Effects:     []interface{}{},
+		Markers:     []otioMarker{},
+		Metadata:    map[string]interface{}{},
+	}
+}
+
+func otioTrack(name, kind string, children []otioItem, markers []otioMarker) otioItem {
+	if children == nil {
+		children = []otioItem{}
+	}
+	if markers == nil {
+		markers = []otioMarker{}
+	}
+	return otioItem{
+		Schema:   "Track.1",
+		Name:     name,
+		Kind:     kind,
+		Children: children,
+		Effects:  []interface{}{},
+		Markers:  markers,
+		Metadata: map[string]interface{}{},
+	}
+}
+
+// renderOTIO writes images on a video track with gaps filling the holes, the voiceover on an
+// audio track, and subtitles as markers on the video track
+func renderOTIO(timeline *nleTimeline) ([]byte, error) {
+	var videoItems []otioItem
+	cursor := 0
+	for _, clip := range timeline.Images {
+		if clip.Start > cursor {
+			videoItems = append(videoItems, otioGap(clip.Start-cursor))
+		}
+		videoItems = append(videoItems, otioClip(clip.Name, clip.File, clip.Frames))
+		cursor = clip.Start + clip.Frames
+	}
+
+	var markers []otioMarker
+	for _, subtitle := range timeline.Subtitles {
+		markers = append(markers, otioMarker{
+			Schema:      "Marker.2",
+			Name:        subtitle.Text,
+			Color:       "YELLOW",
+			MarkedRange: *otioRange(subtitle.Start, subtitle.Frames),
+			Metadata:    map[string]interface{}{"kind": "subtitle"},
+		})
+	}
+
+	tracks := []otioItem{otioTrack("Images", "Video", videoItems, markers)}
+	if timeline.Voiceover != "" {
+		tracks = append(tracks, otioTrack("Voiceover", "Audio", []otioItem{
+			otioClip("voiceover", timeline.Voiceover, timeline.Frames),
+		}, nil))
+	}
+
+	doc := otioTimeline{
+		Schema: "Timeline.1",
+		Name:   timeline.Name,
+		Tracks: otioItem{
+			Schema:   "Stack.1",
+			Name:     "tracks",
+			Children: tracks,
+			Effects:  []interface{}{},
+			Markers:  []otioMarker{},
+			Metadata: map[string]interface{}{},
+		},
+		Metadata: map[string]interface{}{"width": timeline.Width, "height": timeline.Height},
+	}
+
+	body, err := json.MarshalIndent(doc, "", "    ")
+	if err != nil {
+		return nil, fmt.Errorf("encoding OTIO: %w", err)
+	}
+	return body, nil
+}
+
+// nleFormats maps the export format name to its file extension and content type
+var nleFormats = map[string]struct {
+	ext         string
+	contentType string
+}{
+	"fcpxml": {".fcpxml", "application/xml"},
+	"edl":    {".edl", "text/plain; charset=utf-8"},
+	"otio":   {".otio", "application/json"},
+}
+
+func renderNLETimeline(format string, timeline *nleTimeline) ([]byte, error) {
+	switch format {
+	case "fcpxml":
+		return renderFCPXML(timeline)
+	case "edl":
+		return renderEDL(timeline), nil
+	case "otio":
+		return renderOTIO(timeline)
+	}
+	return nil, fmt.Errorf("unknown timeline format %q", format)
+}
+
+// loadNLETimeline builds the timeline for a script the same way generateVideoHandler builds
+// the render request
+func (yt *YtAutomation) loadNLETimeline(script *Script) (*nleTimeline, []timelineClip, error) {
+	chunkVisuals, err := yt.getChunkVisuals(script.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get chunk visuals: %w", err)
+	}
+	videoRequest, err := yt.buildVideoRequest(script, chunkVisuals)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to build video request: %w", err)
+	}
+	clips := buildTimelineClips(chunkVisuals)
+	return buildNLETimeline(script, videoRequest, clips), clips, nil
+}
+
+func (yt *YtAutomation) exportTimelineHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		respondWithError(w, http.StatusMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+
+	// Extract script ID and format from URL path (/scripts/{scriptID}/export/{format})
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/scripts/"), "/")
+	if len(parts) != 3 || parts[1] != "export" {
+		respondWithError(w, http.StatusNotFound, "Not found")
+		return
+	}
+	format, ok := nleFormats[parts[2]]
+	if !ok {
+		respondWithError(w, http.StatusBadRequest, "format must be fcpxml, edl or otio")
+		return
+	}
+	scriptID, err := primitive.ObjectIDFromHex(parts[0])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid script ID format")
+		return
+	}
+
+	script, err := yt.getScriptByID(scriptID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			respondWithError(w, http.StatusNotFound, "Script not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Database error: %v", err))
+		return
+	}
+
+	timeline, _, err := yt.loadNLETimeline(script)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if len(timeline.Images) == 0 {
+		respondWithError(w, http.StatusUnprocessableEntity, "Script has no timed images yet")
+		return
+	}
+	body, err := renderNLETimeline(parts[2], timeline)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	filename := fmt.Sprintf("%s_%s%s", sanitizeFilename(script.Topic), scriptID.Hex(), format.ext)
+	w.Header().Set("Content-Type", format.contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
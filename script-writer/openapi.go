@@ -0,0 +1,525 @@
+// File: openapi.go
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"youtube_automation/readiness"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const apiVersion = "1.0.0"
+
+// apiParam is a path or query parameter of an operation
+type apiParam struct {
+	Name        string
+	In          string // "path" or "query"
+	Type        string // "string", "integer" or "boolean"
+	Required    bool
+	Description string
+	Enum        []string
+}
+
+// apiOperation documents one endpoint. Request and Response are values of the Go types
+// the handler decodes and encodes, so the schemas are generated from the same structs.
+type apiOperation struct {
+	Method          string
+	Path            string
+	ID              string
+	Tag             string
+	Summary         string
+	Params          []apiParam
+	Request         interface{}
+	RawRequestTypes []string // Other request content types, sent as the raw body
+	Statuses        []int    // Success codes, 200 when empty
+	Response        interface{}
+	ContentTypes    []string // Non-JSON responses such as files
+}
+
+func pathParam(name, description string) apiParam {
+	return apiParam{Name: name, In: "path", Type: "string", Required: true, Description: description}
+}
+
+func queryParam(name, typ, description string) apiParam {
+	return apiParam{Name: name, In: "query", Type: typ, Description: description}
+}
+
+var (
+	scriptIDParam = pathParam("id", "Script ID")
+	channelParam  = pathParam("name", "Channel name")
+	topicIDParam  = pathParam("id", "Topic ID")
+	batchIDParam  = pathParam("id", "Batch ID")
+	apiKeyIDParam = pathParam("id", "API key ID")
+
+	scriptListParams = []apiParam{
+		queryParam("status", "string", "Comma-separated statuses"),
+		queryParam("from", "string", "Created on or after, YYYY-MM-DD or RFC3339"),
+		queryParam("to", "string", "Created on or before, YYYY-MM-DD or RFC3339"),
+		queryParam("q", "string", "Case-insensitive match on the topic"),
+		queryParam("fields", "string", "Comma-separated fields to return"),
+		{Name: "sort", In: "query", Type: "string", Enum: []string{"created_at", "topic", "status"}},
+		{Name: "order", In: "query", Type: "string", Enum: []string{"asc", "desc"}},
+		queryParam("limit", "integer", fmt.Sprintf("Page size, at most %d", maxScriptPageSize)),
+		queryParam("cursor", "string", "next_cursor of the previous page"),
+	}
+)
+
+// apiOperations lists every endpoint served by main. Keep it next to any route change;
+// checkAPIRoutes warns at startup about operations no handler serves, and the tests fail
+// on routes missing here or a client not regenerated after a change.
+var apiOperations = []apiOperation{
+	// Scripts
+	{Method: "POST", Path: "/generate-script", ID: "generateScript", Tag: "scripts",
+		Summary: "Start generating a script", Request: ScriptRequest{}, Response: ScriptResponse{}},
+	{Method: "GET", Path: "/scripts", ID: "listScripts", Tag: "scripts",
+		Summary:  "List scripts across channels",
+		Params:   append([]apiParam{queryParam("channel", "string", "Comma-separated channel names")}, scriptListParams...),
+		Response: ScriptListResponse{}},
+	{Method: "POST", Path: "/scripts/import", ID: "importScript", Tag: "scripts",
+		Summary: "Import an existing script",
+		Params: []apiParam{
+			queryParam("channel_name", "string", "Raw bodies only"),
+			queryParam("topic", "string", "Raw bodies only"),
+			queryParam("format", "string", "Raw bodies only: text or markdown"),
+			queryParam("generate_meta", "boolean", "Raw bodies only"),
+		},
+		Request: ScriptImportRequest{}, RawRequestTypes: []string{"text/plain", "text/markdown"},
+		Statuses: []int{http.StatusCreated}, Response: ScriptImportResponse{}},
+	{Method: "GET", Path: "/scripts/{id}", ID: "getScript", Tag: "scripts",
+		Summary: "Get a script and its status", Params: []apiParam{scriptIDParam}, Response: Script{}},
+	{Method: "GET", Path: "/scripts/{id}/logs", ID: "getScriptLogs", Tag: "scripts",
+		Summary: "Get the pipeline log lines stored for a script",
+		Params: []apiParam{scriptIDParam,
+			queryParam("stage", "string", "Only lines from this stage"),
+			{Name: "level", In: "query", Type: "string", Description: "Minimum level",
+				Enum: []string{"debug", "info", "warn", "error"}},
+			queryParam("limit", "integer", fmt.Sprintf("Newest lines to return, at most %d", maxScriptLogPageSize)),
+		},
+		Response: ScriptLogsResponse{}},
+	{Method: "GET", Path: "/scripts-chunks/{id}", ID: "listScriptChunks", Tag: "scripts",
+		Summary: "List a script's narration chunks", Params: []apiParam{scriptIDParam}, Response: ScriptChunksResponse{}},
+	{Method: "GET", Path: "/search", ID: "search", Tag: "scripts",
+		Summary: "Full-text search across scripts",
+		Params: []apiParam{
+			{Name: "q", In: "query", Type: "string", Required: true},
+			queryParam("channel", "string", "Comma-separated channel names"),
+			queryParam("limit", "integer", fmt.Sprintf("At most %d", maxScriptPageSize)),
+		},
+		Response: SearchResponse{}},
+	{Method: "GET", Path: "/check-missing-srt-ranges", ID: "checkMissingSRTRanges", Tag: "scripts",
+		Summary:  "Check that visual prompts cover the whole subtitle track",
+		Params:   []apiParam{{Name: "script_id", In: "query", Type: "string", Required: true}},
+		Response: SRTCoverageResponse{}},
+
+	// Pipeline steps
+	{Method: "POST", Path: "/generate-audio/{id}", ID: "generateAudio", Tag: "pipeline",
+		Summary: "Generate the voiceover", Params: []apiParam{scriptIDParam}, Response: StepResponse{}},
+	{Method: "POST", Path: "/generate-subtitle/{id}", ID: "generateSubtitles", Tag: "pipeline",
+		Summary: "Generate subtitles from the voiceover", Params: []apiParam{scriptIDParam}, Response: StepResponse{}},
+	{Method: "POST", Path: "/generate-chapters/{id}", ID: "generateChapters", Tag: "pipeline",
+		Summary: "Add chapter timestamps to the description", Params: []apiParam{scriptIDParam}, Response: ChaptersResponse{}},
+	{Method: "POST", Path: "/generate-visual-prompts-with-style", ID: "generateVisualPrompts", Tag: "pipeline",
+		Summary: "Start generating visual prompts in a style", Request: VisualPromptsRequest{}, Response: StepResponse{}},
+	{Method: "POST", Path: "/generate-visual-images/{id}", ID: "generateVisualImages", Tag: "pipeline",
+		Summary: "Start generating images for the visual prompts", Params: []apiParam{scriptIDParam}, Response: StepResponse{}},
+	{Method: "POST", Path: "/generate-video/{id}", ID: "generateVideo", Tag: "pipeline",
+		Summary: "Start rendering the video", Params: []apiParam{scriptIDParam},
+		Statuses: []int{http.StatusAccepted, http.StatusOK}, Response: VideoJobResponse{}},
+	{Method: "GET", Path: "/video-status/{id}", ID: "getVideoStatus", Tag: "pipeline",
+		Summary: "Get video rendering progress", Params: []apiParam{pathParam("id", "status_id from generateVideo")},
+		Response: VideoGenerationStatus{}},
+
+	// Thumbnails
+	{Method: "POST", Path: "/generate-thumbnails/{id}", ID: "generateThumbnails", Tag: "thumbnails",
+		Summary: "Render thumbnail variants", Params: []apiParam{scriptIDParam},
+		Request: ThumbnailRequest{}, Response: ThumbnailsResponse{}},
+	{Method: "GET", Path: "/thumbnails/{id}", ID: "listThumbnails", Tag: "thumbnails",
+		Summary: "List a script's thumbnails", Params: []apiParam{scriptIDParam}, Response: ThumbnailListResponse{}},
+	{Method: "GET", Path: "/thumbnails/{id}/{variant}", ID: "getThumbnail", Tag: "thumbnails",
+		Summary: "Download a thumbnail", Params: []apiParam{scriptIDParam, pathParam("variant", "Variant number")},
+		ContentTypes: []string{"image/jpeg"}},
+	{Method: "GET", Path: "/channels/{name}/thumbnail-layout", ID: "getThumbnailLayout", Tag: "thumbnails",
+		Summary: "Get a channel's thumbnail layout", Params: []apiParam{channelParam}, Response: ThumbnailLayout{}},
+	{Method: "PUT", Path: "/channels/{name}/thumbnail-layout", ID: "updateThumbnailLayout", Tag: "thumbnails",
+		Summary: "Replace a channel's thumbnail layout", Params: []apiParam{channelParam},
+		Request: ThumbnailLayout{}, Response: ThumbnailLayout{}},
+
+	// Voice
+	{Method: "GET", Path: "/tts/providers", ID: "listTTSProviders", Tag: "voice",
+		Summary: "List text-to-speech providers with their formats and remaining quota", Response: TTSProvidersResponse{}},
+	{Method: "GET", Path: "/tts/voices", ID: "listTTSVoices", Tag: "voice",
+		Summary:  "List a text-to-speech provider's voices",
+		Params:   []apiParam{{Name: "provider", In: "query", Type: "string", Enum: ttsProviderNames, Description: "Defaults to voice.provider"}},
+		Response: TTSVoicesResponse{}},
+	{Method: "GET", Path: "/channels/{name}/voice", ID: "getChannelVoice", Tag: "voice",
+		Summary: "Get a channel's text-to-speech provider and voice", Params: []apiParam{channelParam}, Response: ChannelVoice{}},
+	{Method: "PUT", Path: "/channels/{name}/voice", ID: "updateChannelVoice", Tag: "voice",
+		Summary: "Set a channel's text-to-speech provider and voice", Params: []apiParam{channelParam},
+		Request: ChannelVoice{}, Response: ChannelVoice{}},
+
+	// Export
+	{Method: "GET", Path: "/scripts/{id}/export", ID: "exportScript", Tag: "export",
+		Summary: "Download the project package", Params: []apiParam{scriptIDParam},
+		ContentTypes: []string{"application/zip"}},
+	{Method: "GET", Path: "/scripts/{id}/export/{format}", ID: "exportTimeline", Tag: "export",
+		Summary: "Export the timeline for an editor",
+		Params: []apiParam{scriptIDParam, {Name: "format", In: "path", Type: "string", Required: true,
+			Enum: []string{"fcpxml", "edl", "otio"}}},
+		ContentTypes: []string{"application/xml", "text/plain", "application/json"}},
+
+	// Uploads
+	{Method: "POST", Path: "/upload-video/{id}", ID: "uploadVideo", Tag: "uploads",
+		Summary: "Publish the video to YouTube", Params: []apiParam{scriptIDParam}, Request: PublishRequest{},
+		Statuses: []int{http.StatusAccepted, http.StatusOK}, Response: UploadResponse{}},
+	{Method: "GET", Path: "/channels/{name}/youtube/auth", ID: "getYouTubeAuthURL", Tag: "uploads",
+		Summary: "Get the URL that authorizes uploads for a channel", Params: []apiParam{channelParam},
+		Response: YouTubeAuthResponse{}},
+	{Method: "GET", Path: "/oauth/youtube/callback", ID: "youtubeOAuthCallback", Tag: "uploads",
+		Summary: "OAuth redirect target, opened by the browser",
+		Params: []apiParam{queryParam("code", "string", ""), queryParam("state", "string", ""),
+			queryParam("error", "string", "")},
+		Response: YouTubeAuthorizedResponse{}},
+
+	// Channels and topics
+	{Method: "GET", Path: "/channels/{name}", ID: "getChannel", Tag: "channels",
+		Summary: "Get a channel", Params: []apiParam{channelParam}, Response: Channel{}},
+	{Method: "GET", Path: "/channels/{name}/scripts", ID: "listChannelScripts", Tag: "channels",
+		Summary: "List a channel's scripts", Params: append([]apiParam{channelParam}, scriptListParams...),
+		Response: ScriptListResponse{}},
+	{Method: "GET", Path: "/channels/{name}/topics", ID: "listTopics", Tag: "topics",
+		Summary: "List the topic backlog",
+		Params: []apiParam{channelParam, {Name: "status", In: "query", Type: "string",
+			Enum: []string{TopicStatusProposed, TopicStatusApproved, TopicStatusRejected, TopicStatusUsed}}},
+		Response: TopicListResponse{}},
+	{Method: "POST", Path: "/channels/{name}/topics", ID: "createTopic", Tag: "topics",
+		Summary: "Add a topic to the backlog", Params: []apiParam{channelParam}, Request: TopicCreateRequest{},
+		Statuses: []int{http.StatusCreated}, Response: TopicResponse{}},
+	{Method: "POST", Path: "/channels/{name}/topics/ideate", ID: "ideateTopics", Tag: "topics",
+		Summary: "Generate topic ideas into the backlog", Params: []apiParam{channelParam},
+		Request: TopicIdeationRequest{}, Response: TopicIdeationResponse{}},
+	{Method: "GET", Path: "/topics/{id}", ID: "getTopic", Tag: "topics",
+		Summary: "Get a topic", Params: []apiParam{topicIDParam}, Response: TopicResponse{}},
+	{Method: "PATCH", Path: "/topics/{id}", ID: "updateTopic", Tag: "topics",
+		Summary: "Edit, approve or reject a topic", Params: []apiParam{topicIDParam},
+		Request: TopicUpdateRequest{}, Response: TopicResponse{}},
+	{Method: "DELETE", Path: "/topics/{id}", ID: "deleteTopic", Tag: "topics",
+		Summary: "Delete a topic", Params: []apiParam{topicIDParam}, Response: TopicResponse{}},
+	{Method: "POST", Path: "/topics/{id}/generate", ID: "generateScriptFromTopic", Tag: "topics",
+		Summary: "Start generating a script from a topic", Params: []apiParam{topicIDParam},
+		Request: ScriptRequest{}, Response: ScriptResponse{}},
+
+	// Batches
+	{Method: "POST", Path: "/batches", ID: "createBatch", Tag: "batches",
+		Summary: "Schedule a batch of scripts", Request: BatchRequest{},
+		Statuses: []int{http.StatusCreated}, Response: BatchResponse{}},
+	{Method: "GET", Path: "/batches", ID: "listBatches", Tag: "batches",
+		Summary:  "List batches without their items",
+		Params:   []apiParam{queryParam("channel_name", "string", ""), queryParam("status", "string", "")},
+		Response: BatchListResponse{}},
+	{Method: "GET", Path: "/batches/{id}", ID: "getBatch", Tag: "batches",
+		Summary: "Get a batch and its progress", Params: []apiParam{batchIDParam}, Response: BatchResponse{}},
+	{Method: "POST", Path: "/batches/{id}/cancel", ID: "cancelBatch", Tag: "batches",
+		Summary: "Cancel the items that have not started", Params: []apiParam{batchIDParam}, Response: BatchResponse{}},
+
+	// Templates and styles
+	{Method: "POST", Path: "/prompt-templates", ID: "createPromptTemplate", Tag: "templates",
+		Summary: "Create a prompt template", Request: PromptTemplateRequest{},
+		Statuses: []int{http.StatusCreated}, Response: PromptTemplateResponse{}},
+	{Method: "GET", Path: "/prompt-templates/list", ID: "listPromptTemplates", Tag: "templates",
+		Summary: "List active prompt templates",
+		Params: []apiParam{queryParam("channel_id", "string", "Include this channel's templates"),
+			queryParam("type", "string", "")},
+		Response: PromptTemplateListResponse{}},
+	{Method: "POST", Path: "/visual-styles", ID: "createVisualStyle", Tag: "templates",
+		Summary: "Create a visual style", Request: VisualStyleRequest{},
+		Statuses: []int{http.StatusCreated}, Response: VisualStyleResponse{}},
+	{Method: "GET", Path: "/visual-styles/list", ID: "listVisualStyles", Tag: "templates",
+		Summary: "List active visual styles", Params: []apiParam{queryParam("category", "string", "")},
+		Response: VisualStyleListResponse{}},
+
+	// Service
+	{Method: "GET", Path: "/health", ID: "health", Tag: "service",
+		Summary: "Health check", Response: HealthResponse{}},
+	{Method: "GET", Path: "/ready", ID: "ready", Tag: "service",
+		Summary:  "Check every external dependency; 503 with the same body when one fails",
+		Response: readiness.ReadyResponse{}},
+	{Method: "GET", Path: "/config", ID: "getConfig", Tag: "service",
+		Summary: "Configuration in effect, secrets redacted", Response: ConfigResponse{}},
+	{Method: "GET", Path: "/api-keys", ID: "listAPIKeys", Tag: "service",
+		Summary:  "List provider keys by fingerprint, with usage, errors and health",
+		Params:   []apiParam{queryParam("provider", "string", "Only this provider's keys")},
+		Response: APIKeyListResponse{}},
+	{Method: "POST", Path: "/api-keys", ID: "createAPIKey", Tag: "service",
+		Summary: "Add a key to a provider's pool", Request: APIKeyCreateRequest{},
+		Statuses: []int{http.StatusCreated}, Response: APIKeyResponse{}},
+	{Method: "GET", Path: "/api-keys/{id}", ID: "getAPIKey", Tag: "service",
+		Summary: "Get a provider key", Params: []apiParam{apiKeyIDParam}, Response: APIKeyResponse{}},
+	{Method: "PATCH", Path: "/api-keys/{id}", ID: "updateAPIKey", Tag: "service",
+		Summary: "Replace a key's value, keeping its history", Params: []apiParam{apiKeyIDParam},
+		Request: APIKeyUpdateRequest{}, Response: APIKeyResponse{}},
+	{Method: "DELETE", Path: "/api-keys/{id}", ID: "deleteAPIKey", Tag: "service",
+		Summary: "Delete a provider key", Params: []apiParam{apiKeyIDParam}, Response: APIKeyResponse{}},
+	{Method: "POST", Path: "/api-keys/{id}/enable", ID: "enableAPIKey", Tag: "service",
+		Summary: "Return a key to the pool, clearing any cooldown", Params: []apiParam{apiKeyIDParam}, Response: APIKeyResponse{}},
+	{Method: "POST", Path: "/api-keys/{id}/disable", ID: "disableAPIKey", Tag: "service",
+		Summary: "Take a key out of the pool", Params: []apiParam{apiKeyIDParam}, Response: APIKeyResponse{}},
+	{Method: "GET", Path: "/openapi.json", ID: "getOpenAPISpec", Tag: "service",
+		Summary: "This document", Response: map[string]interface{}{}},
+	{Method: "GET", Path: "/metrics", ID: "getMetrics", Tag: "service",
+		Summary: "Prometheus metrics", ContentTypes: []string{"text/plain"}},
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	objectIDType = reflect.TypeOf(primitive.ObjectID{})
+	pathVarRegex = regexp.MustCompile(`\{[a-z_]+\}`)
+)
+
+// schemaBuilder turns Go types into JSON schemas, collecting named structs as components
+type schemaBuilder struct {
+	components map[string]interface{}
+}
+
+func ref(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
+
+func (b *schemaBuilder) schemaFor(t reflect.Type) map[string]interface{} {
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case objectIDType:
+		return map[string]interface{}{"type": "string", "pattern": "^[0-9a-f]{24}$"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return b.schemaFor(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": b.schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": b.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		if _, ok := b.components[t.Name()]; !ok {
+			b.components[t.Name()] = nil // Reserved first so recursive types terminate
+			b.components[t.Name()] = b.structSchema(t)
+		}
+		return ref(t.Name())
+	}
+	return map[string]interface{}{}
+}
+
+// structSchema follows encoding/json: fields without omitempty are always present, so
+// they are required, and pointers are nullable
+func (b *schemaBuilder) structSchema(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if !field.IsExported() || tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+
+		schema := b.schemaFor(field.Type)
+		if items := field.Tag.Get("openapi"); items != "" {
+			schema = map[string]interface{}{"type": "array", "items": ref(items)}
+		}
+		if field.Type.Kind() == reflect.Pointer {
+			if _, isRef := schema["$ref"]; isRef {
+				schema = map[string]interface{}{"allOf": []interface{}{schema}}
+			}
+			schema["nullable"] = true
+		}
+		properties[name] = schema
+		if !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// samplePath fills in path parameters so the route can be matched like a real request
+func samplePath(path string) string {
+	return pathVarRegex.ReplaceAllString(path, primitive.NilObjectID.Hex())
+}
+
+func (op apiOperation) sampleRequest() *http.Request {
+	return &http.Request{Method: op.Method, URL: &url.URL{Path: samplePath(op.Path)}}
+}
+
+func buildOpenAPISpec() map[string]interface{} {
+	builder := &schemaBuilder{components: map[string]interface{}{}}
+	errorSchema := builder.schemaFor(reflect.TypeOf(ScriptResponse{}))
+
+	paths := map[string]map[string]interface{}{}
+	for _, op := range apiOperations {
+		operation := map[string]interface{}{
+			"operationId": op.ID,
+			"summary":     op.Summary,
+			"tags":        []string{op.Tag},
+		}
+
+		var parameters []interface{}
+		for _, param := range op.Params {
+			schema := map[string]interface{}{"type": param.Type}
+			if len(param.Enum) > 0 {
+				schema["enum"] = param.Enum
+			}
+			parameter := map[string]interface{}{
+				"name":     param.Name,
+				"in":       param.In,
+				"required": param.Required,
+				"schema":   schema,
+			}
+			if param.Description != "" {
+				parameter["description"] = param.Description
+			}
+			parameters = append(parameters, parameter)
+		}
+		if len(parameters) > 0 {
+			operation["parameters"] = parameters
+		}
+
+		if op.Request != nil {
+			content := map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": builder.schemaFor(reflect.TypeOf(op.Request))},
+			}
+			for _, contentType := range op.RawRequestTypes {
+				content[contentType] = map[string]interface{}{"schema": map[string]interface{}{"type": "string"}}
+			}
+			operation["requestBody"] = map[string]interface{}{"content": content}
+		}
+
+		content := map[string]interface{}{}
+		if op.Response != nil {
+			content["application/json"] = map[string]interface{}{"schema": builder.schemaFor(reflect.TypeOf(op.Response))}
+		}
+		for _, contentType := range op.ContentTypes {
+			content[contentType] = map[string]interface{}{"schema": map[string]interface{}{"type": "string", "format": "binary"}}
+		}
+		statuses := op.Statuses
+		if len(statuses) == 0 {
+			statuses = []int{http.StatusOK}
+		}
+		responses := map[string]interface{}{
+			"default": map[string]interface{}{
+				"description": "Error",
+				"content":     map[string]interface{}{"application/json": map[string]interface{}{"schema": errorSchema}},
+			},
+		}
+		for _, status := range statuses {
+			responses[strconv.Itoa(status)] = map[string]interface{}{
+				"description": http.StatusText(status),
+				"content":     content,
+			}
+		}
+
+		if publicPaths[op.Path] {
+			operation["security"] = []interface{}{}
+		} else {
+			scope := requiredScope(op.sampleRequest())
+			operation["x-required-scope"] = scope
+			operation["description"] = fmt.Sprintf("Requires the %s scope.", scope)
+			responses[strconv.Itoa(http.StatusTooManyRequests)] = map[string]interface{}{
+				"description": "Rate or concurrent job limit reached",
+				"headers": map[string]interface{}{
+					"Retry-After": map[string]interface{}{"schema": map[string]interface{}{"type": "integer"}},
+				},
+				"content": map[string]interface{}{"application/json": map[string]interface{}{"schema": errorSchema}},
+			}
+		}
+		operation["responses"] = responses
+
+		if paths[op.Path] == nil {
+			paths[op.Path] = map[string]interface{}{}
+		}
+		paths[op.Path][strings.ToLower(op.Method)] = operation
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "Wisderly YouTube Script Generator API",
+			"version": apiVersion,
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": builder.components,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer"},
+			},
+		},
+		"security": []interface{}{map[string]interface{}{"bearerAuth": []string{}}},
+	}
+}
+
+// checkAPIRoutes warns about documented operations that no registered route serves
+func checkAPIRoutes(mux *http.ServeMux) {
+	var missing []string
+	for _, op := range apiOperations {
+		if _, pattern := mux.Handler(op.sampleRequest()); pattern == "" {
+			missing = append(missing, op.Method+" "+op.Path)
+		}
+	}
+	sort.Strings(missing)
+	for _, route := range missing {
+		slog.Warn("Documented route is not registered", "route", route)
+	}
+}
+
+func openAPIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		respondWithError(w, http.StatusMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+	respondWithJSON(w, http.StatusOK, buildOpenAPISpec())
+}
+
+// writeOpenAPISpec prints the document for `script-writer openapi`, used to regenerate the client
+func writeOpenAPISpec() int {
+	data, err := json.MarshalIndent(buildOpenAPISpec(), "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Println(string(data))
+	return 0
+}
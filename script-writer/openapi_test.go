@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// recordingMux keeps the patterns registerRoutes adds
+type recordingMux struct {
+	*http.ServeMux
+	patterns []string
+}
+
+func (m *recordingMux) Handle(pattern string, handler http.Handler) {
+	m.patterns = append(m.patterns, pattern)
+	m.ServeMux.Handle(pattern, handler)
+}
+
+func (m *recordingMux) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	m.patterns = append(m.patterns, pattern)
+	m.ServeMux.HandleFunc(pattern, handler)
+}
+
+func TestAPIOperationsCoverRoutes(t *testing.T) {
+	mux := &recordingMux{ServeMux: http.NewServeMux()}
+	(&YtAutomation{}).registerRoutes(mux)
+
+	documented := map[string]bool{}
+	for _, op := range apiOperations {
+		_, pattern := mux.Handler(op.sampleRequest())
+		if pattern == "" {
+			t.Errorf("%s %s is in apiOperations but not routed", op.Method, op.Path)
+			continue
+		}
+		documented[pattern] = true
+	}
+
+	var missing []string
+	for _, pattern := range mux.patterns {
+		if !documented[pattern] {
+			missing = append(missing, pattern)
+		}
+	}
+	sort.Strings(missing)
+	for _, pattern := range missing {
+		t.Errorf("route %s has no operation in apiOperations", pattern)
+	}
+}
+
+// TestGeneratedClientUpToDate fails when openapi.json or client/api.go was not regenerated
+// after an API change; run go generate in client/ to fix it
+func TestGeneratedClientUpToDate(t *testing.T) {
+	spec, err := json.MarshalIndent(buildOpenAPISpec(), "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec = append(spec, '\n')
+	committedSpec, err := os.ReadFile(filepath.Join("client", "openapi.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(spec, committedSpec) {
+		t.Error("client/openapi.json is out of date; run go generate ./... in script-writer/client")
+	}
+
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found; cannot regenerate the client")
+	}
+	dir := t.TempDir()
+	specPath, apiPath := filepath.Join(dir, "openapi.json"), filepath.Join(dir, "api.go")
+	if err := os.WriteFile(specPath, spec, 0644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(goTool, "run", "./internal/gen", "-in", specPath, "-out", apiPath)
+	cmd.Dir = "client"
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("generating the client: %v\n%s", err, output)
+	}
+
+	generated, err := os.ReadFile(apiPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	committed, err := os.ReadFile(filepath.Join("client", "api.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(generated, committed) {
+		t.Error("client/api.go is out of date; run go generate ./... in script-writer/client")
+	}
+}
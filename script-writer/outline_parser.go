@@ -206,6 +206,72 @@ func (p *OutlineParser) ParseMetaJSON(response string) (MetaContent, error) {
 	return metaResponse.Meta, nil
 }
 
+func (p *OutlineParser) ParseActOutlineJSON(response string, plan *LongFormPlan) ([]ActOutline, error) {
+	var actResponse ActOutlineResponse
+
+	cleanResponse := p.cleanJSONResponse(response)
+
+	if err := json.Unmarshal([]byte(cleanResponse), &actResponse); err != nil {
+		return nil, fmt.Errorf("failed to parse act outline JSON: %w", err)
+	}
+
+	if len(actResponse.Acts) != len(plan.Acts) {
+		return nil, fmt.Errorf("expected %d acts, got %d", len(plan.Acts), len(actResponse.Acts))
+	}
+	for i, act := range actResponse.Acts {
+		if len(act.Chapters) != len(plan.Acts[i].Chapters) {
+			return nil, fmt.Errorf("expected %d chapters in act %d, got %d", len(plan.Acts[i].Chapters), i+1, len(act.Chapters))
+		}
+	}
+
+	return actResponse.Acts, nil
+}
+
+func (p *OutlineParser) ParseRollingSummaryJSON(response string) (string, error) {
+	var summaryResponse RollingSummaryResponse
+
+	cleanResponse := p.cleanJSONResponse(response)
+
+	if err := json.Unmarshal([]byte(cleanResponse), &summaryResponse); err != nil {
+		return "", fmt.Errorf("failed to parse rolling summary JSON: %w", err)
+	}
+	if strings.TrimSpace(summaryResponse.Summary) == "" {
+		return "", fmt.Errorf("rolling summary is empty")
+	}
+
+	return strings.TrimSpace(summaryResponse.Summary), nil
+}
+
+func (p *OutlineParser) ParseTopicIdeasJSON(response string) ([]TopicIdeaContent, error) {
+	var topicsResponse TopicIdeasResponse
+
+	cleanResponse := p.cleanJSONResponse(response)
+
+	if err := json.Unmarshal([]byte(cleanResponse), &topicsResponse); err != nil {
+		return nil, fmt.Errorf("failed to parse topic ideas JSON: %w", err)
+	}
+	if len(topicsResponse.Topics) == 0 {
+		return nil, fmt.Errorf("no topic ideas returned")
+	}
+
+	return topicsResponse.Topics, nil
+}
+
+func (p *OutlineParser) ParseThumbnailPromptJSON(response string) (string, error) {
+	var promptResponse ThumbnailPromptResponse
+
+	cleanResponse := p.cleanJSONResponse(response)
+
+	if err := json.Unmarshal([]byte(cleanResponse), &promptResponse); err != nil {
+		return "", fmt.Errorf("failed to parse thumbnail prompt JSON: %w", err)
+	}
+	if strings.TrimSpace(promptResponse.Prompt) == "" {
+		return "", fmt.Errorf("thumbnail prompt is empty")
+	}
+
+	return strings.TrimSpace(promptResponse.Prompt), nil
+}
+
 func (p *OutlineParser) cleanJSONResponse(response string) string {
 	cleanResponse := strings.TrimSpace(response)
 	cleanResponse = strings.TrimPrefix(cleanResponse, "```json")
@@ -0,0 +1,310 @@
+// File: ratelimit.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"youtube_automation/tracing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// jobRoutes start work that spends LLM, TTS, image or upload quota, whatever the method
+var jobRoutes = []string{
+	"/generate-script",
+	"/generate-audio/",
+	"/generate-subtitle/",
+	"/generate-visual-prompts-with-style",
+	"/generate-visual-images/",
+	"/generate-video/",
+	"/generate-thumbnails/",
+	"/upload-video/",
+}
+
+func isJobRequest(r *http.Request) bool {
+	path := r.URL.Path
+	if strings.HasPrefix(path, "/topics/") && strings.HasSuffix(path, "/generate") {
+		return true
+	}
+	for _, route := range jobRoutes {
+		if path == route || (strings.HasSuffix(route, "/") && strings.HasPrefix(path, route)) {
+			return true
+		}
+	}
+	return false
+}
+
+// JobLease holds one concurrent-job slot for a client and the channels it works on.
+// Leases are stored in Mongo so every instance sees them, and renewed while the job runs;
+// after a crash they expire on their own.
+type JobLease struct {
+	ID      primitive.ObjectID `bson:"_id"`
+	Keys    []string           `bson:"keys"`
+	Route   string             `bson:"route"`
+	Started time.Time          `bson:"started_at"`
+	Expires time.Time          `bson:"expires_at"`
+
+	limiter  *RequestLimiter
+	adopted  atomic.Bool
+	released atomic.Bool
+}
+
+// Release frees the slot; safe to call more than once
+func (l *JobLease) Release() {
+	if l == nil || l.released.Swap(true) {
+		return
+	}
+	l.limiter.mu.Lock()
+	delete(l.limiter.leases, l.ID)
+	l.limiter.mu.Unlock()
+
+	if _, err := jobLeasesCollection.DeleteOne(context.Background(), bson.M{"_id": l.ID}); err != nil {
+		slog.Warn("Failed to release job lease", "lease_id", l.ID.Hex(), "error", err)
+	}
+}
+
+type jobLeaseContextKey struct{}
+
+// runJob runs background work started by a request, keeping the request's job slot
+// until fn returns. fn gets the request's trace but not its cancellation; a shutdown
+// waits for it and only cancels it once the drain timeout runs out.
+func runJob(ctx context.Context, fn func(ctx context.Context)) {
+	lease, _ := ctx.Value(jobLeaseContextKey{}).(*JobLease)
+	if lease != nil {
+		lease.adopted.Store(true)
+	}
+	jobCtx, done := backgroundJobs.start(tracing.Detach(ctx))
+	go func() {
+		defer done()
+		defer lease.Release()
+		fn(jobCtx)
+	}()
+}
+
+type channelsContextKey struct{}
+
+// RequestLimiter enforces per-client and per-channel request rates and concurrent jobs,
+// with the limits from appConfig().RateLimits
+type RequestLimiter struct {
+	mu     sync.Mutex
+	leases map[primitive.ObjectID]*JobLease // Held by this process, renewed in the background
+}
+
+func NewRequestLimiter() *RequestLimiter {
+	return &RequestLimiter{
+		leases: make(map[primitive.ObjectID]*JobLease),
+	}
+}
+
+// Start renews the leases of jobs still running in this process
+func (l *RequestLimiter) Start() {
+	go func() {
+		ticker := time.NewTicker(jobLeaseRenewInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			l.mu.Lock()
+			ids := make([]primitive.ObjectID, 0, len(l.leases))
+			for id := range l.leases {
+				ids = append(ids, id)
+			}
+			l.mu.Unlock()
+			if len(ids) == 0 {
+				continue
+			}
+
+			_, err := jobLeasesCollection.UpdateMany(context.Background(),
+				bson.M{"_id": bson.M{"$in": ids}},
+				bson.M{"$set": bson.M{"expires_at": time.Now().Add(jobLeaseTTL)}},
+			)
+			if err != nil {
+				slog.Warn("Failed to renew job leases", "count", len(ids), "error", err)
+			}
+		}
+	}()
+}
+
+// clientKey identifies the caller: its token, or its address when auth is disabled
+func clientKey(r *http.Request) string {
+	if token := authTokenFromContext(r.Context()); token != nil {
+		return "token:" + token.ID.Hex()
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// countRequest adds a request to key's current one-minute window and returns the new count
+// and when the window ends
+func countRequest(ctx context.Context, key string) (int, time.Time, error) {
+	windowStart := time.Now().Truncate(time.Minute)
+	windowEnd := windowStart.Add(time.Minute)
+
+	var window struct {
+		Count int `bson:"count"`
+	}
+	update := bson.M{
+		"$inc": bson.M{"count": 1},
+		"$setOnInsert": bson.M{
+			"key":          key,
+			"window_start": windowStart,
+			"expires_at":   windowEnd.Add(time.Minute),
+		},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	id := fmt.Sprintf("%s|%d", key, windowStart.Unix())
+
+	err := rateLimitsCollection.FindOneAndUpdate(ctx, bson.M{"_id": id}, update, opts).Decode(&window)
+	if mongo.IsDuplicateKeyError(err) {
+		// Two requests raced to create the window; the second attempt updates it
+		err = rateLimitsCollection.FindOneAndUpdate(ctx, bson.M{"_id": id}, update, opts).Decode(&window)
+	}
+	return window.Count, windowEnd, err
+}
+
+func countActiveJobs(ctx context.Context, key string) (int, error) {
+	count, err := jobLeasesCollection.CountDocuments(ctx, bson.M{
+		"keys":       key,
+		"expires_at": bson.M{"$gt": time.Now()},
+	})
+	return int(count), err
+}
+
+// acquireJob takes a job slot for every key. Counting and inserting are not atomic, so
+// simultaneous requests can overshoot a limit by a job or two.
+func (l *RequestLimiter) acquireJob(ctx context.Context, route string, keys []string, limits []int) (*JobLease, string, error) {
+	for i, key := range keys {
+		if limits[i] <= 0 {
+			continue
+		}
+		active, err := countActiveJobs(ctx, key)
+		if err != nil {
+			return nil, "", err
+		}
+		if active >= limits[i] {
+			return nil, key, nil
+		}
+	}
+
+	now := time.Now()
+	lease := &JobLease{
+		ID:      primitive.NewObjectID(),
+		Keys:    keys,
+		Route:   route,
+		Started: now,
+		Expires: now.Add(jobLeaseTTL),
+		limiter: l,
+	}
+	if _, err := jobLeasesCollection.InsertOne(ctx, lease); err != nil {
+		return nil, "", err
+	}
+	l.mu.Lock()
+	l.leases[lease.ID] = lease
+	l.mu.Unlock()
+	return lease, "", nil
+}
+
+func tooManyRequests(w http.ResponseWriter, retryAfter time.Duration, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	respondWithError(w, http.StatusTooManyRequests, message)
+}
+
+// Middleware applies request rates to every call and job limits to job routes. It runs
+// after authentication so requests are attributed to their token.
+func (l *RequestLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if publicPaths[r.URL.Path] || r.Method == "OPTIONS" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx := r.Context()
+
+		client := clientKey(r)
+		limits := appConfig().RateLimits
+		tokenRate, tokenJobs := limits.TokenPerMinute, limits.JobsPerToken
+		if token := authTokenFromContext(ctx); token != nil {
+			if token.RateLimit > 0 {
+				tokenRate = token.RateLimit
+			}
+			if token.MaxJobs > 0 {
+				tokenJobs = token.MaxJobs
+			}
+		}
+
+		channels, ok := ctx.Value(channelsContextKey{}).([]string)
+		if !ok {
+			var err error
+			if channels, _, err = resolveRequestChannels(r); err != nil {
+				respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to resolve channel: %v", err))
+				return
+			}
+		}
+
+		keys := []string{client}
+		rates := []int{tokenRate}
+		jobLimits := []int{tokenJobs}
+		for _, channel := range channels {
+			keys = append(keys, "channel:"+channel)
+			rates = append(rates, limits.ChannelPerMinute)
+			jobLimits = append(jobLimits, limits.JobsPerChannel)
+		}
+
+		for i, key := range keys {
+			if rates[i] <= 0 {
+				continue
+			}
+			count, windowEnd, err := countRequest(ctx, key)
+			if err != nil {
+				// Failing open keeps the API usable when the limiter's storage hiccups
+				slog.WarnContext(ctx, "Rate limit check failed", "key", key, "error", err)
+				continue
+			}
+			if i == 0 {
+				w.Header().Set("X-RateLimit-Limit", strconv.Itoa(rates[i]))
+				w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(max(rates[i]-count, 0)))
+				w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(windowEnd.Unix(), 10))
+			}
+			if count > rates[i] {
+				tooManyRequests(w, time.Until(windowEnd), fmt.Sprintf("Rate limit of %d requests per minute exceeded for %s", rates[i], key))
+				return
+			}
+		}
+
+		if !isJobRequest(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		lease, limitedKey, err := l.acquireJob(ctx, r.URL.Path, keys, jobLimits)
+		if err != nil {
+			slog.WarnContext(ctx, "Job limit check failed", "path", r.URL.Path, "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+		if lease == nil {
+			tooManyRequests(w, jobRetryAfter, fmt.Sprintf("Too many concurrent jobs for %s", limitedKey))
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, jobLeaseContextKey{}, lease)))
+
+		// Synchronous jobs are done once the handler returns; background ones adopted the lease
+		if !lease.adopted.Load() {
+			lease.Release()
+		}
+	})
+}
@@ -0,0 +1,124 @@
+// File: readiness.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"youtube_automation/readiness"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// pipelineTemplateTypes are the prompt template types the pipeline builds prompts from
+var pipelineTemplateTypes = []string{
+	"outline", "hook_intro", "section", "meta_tag", "visual_prompts", "visual_guidance",
+	"long_form_outline", "long_form_chapter", "long_form_section", "rolling_summary",
+	"thumbnail_image", "topic_ideas",
+}
+
+// ffmpeg features used for merging audio and rendering thumbnails
+var (
+	requiredFFmpegEncoders = []string{"mjpeg"}
+	requiredFFmpegFilters  = []string{"scale", "crop", "setsar", "overlay", "drawtext"}
+)
+
+// readyHandler serves GET /ready
+func (yt *YtAutomation) readyHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	readiness.Handler(readiness.DefaultTimeout, yt.readinessChecks).ServeHTTP(w, r)
+}
+
+// readinessChecks lists everything a script needs between its request and its video
+func (yt *YtAutomation) readinessChecks() []readiness.Check {
+	checks := []readiness.Check{
+		{Name: "mongodb", Run: func(ctx context.Context) error { return yt.mongoClient.Ping(ctx, nil) }},
+		readiness.HTTP("transcript_server", serviceHealthURL(appConfig().Services.TranscriptURL)),
+		readiness.HTTP("video_server", serviceHealthURL(appConfig().Services.VideoURL)),
+		readiness.FFmpeg(requiredFFmpegEncoders, requiredFFmpegFilters),
+		readiness.Binary("ffprobe"),
+		{Name: "prompt_templates", Run: checkPromptTemplates},
+		readiness.Writable("assets"),
+	}
+	for _, provider := range yt.apiKeyProviders() {
+		checks = append(checks, readiness.Check{
+			Name: "api_keys:" + provider,
+			Run:  func(ctx context.Context) error { return checkActiveAPIKey(ctx, provider) },
+		})
+	}
+	if voice := appConfig().Voice; voice.usesProvider(TTSProviderOffline) {
+		offline := newOfflineTTS(voice)
+		checks = append(checks, readiness.Binary(offline.binary))
+		if offline.engine == ttsEnginePiper {
+			if model, err := offline.piperModel(""); err != nil {
+				checks = append(checks, readiness.Check{Name: "piper_model", Run: func(context.Context) error { return err }})
+			} else {
+				checks = append(checks, readiness.File("piper_model", model))
+			}
+		}
+	}
+	return checks
+}
+
+// serviceHealthURL turns a service's API base URL, which may end in /api, into its /health URL
+func serviceHealthURL(apiURL string) string {
+	if apiURL == "" {
+		return ""
+	}
+	base := strings.TrimSuffix(strings.TrimSuffix(apiURL, "/"), "/api")
+	return base + "/health"
+}
+
+// apiKeyProviders are the providers whose keys the pipeline takes from api_keys. Channels
+// may still pick ElevenLabs when it is neither the default nor the fallback voice.
+func (yt *YtAutomation) apiKeyProviders() []string {
+	providers := []string{appConfig().Images.Tool}
+	if appConfig().Voice.usesProvider(TTSProviderElevenLabs) {
+		providers = append(providers, ProviderElevenLabs)
+	}
+	return providers
+}
+
+// checkPromptTemplates fails when a template type has no active template, global or per channel
+func checkPromptTemplates(ctx context.Context) error {
+	types, err := promptTemplatesCollection.Distinct(ctx, "type", bson.M{"is_active": true})
+	if err != nil {
+		return err
+	}
+	present := map[string]bool{}
+	for _, t := range types {
+		if s, ok := t.(string); ok {
+			present[s] = true
+		}
+	}
+	var missing []string
+	for _, t := range pipelineTemplateTypes {
+		if !present[t] {
+			missing = append(missing, t)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("no active template for %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// checkActiveAPIKey fails when a provider has no enabled key that can recover on its own.
+// Cooling down and exhausted keys count: a burst of 429s makes the pipeline wait, not the
+// instance unready. Revoked keys need an operator, so they do not.
+func checkActiveAPIKey(ctx context.Context, provider string) error {
+	count, err := apiKeysCollection.CountDocuments(ctx, bson.M{
+		"provider": provider, "is_active": true, "state": bson.M{"$ne": KeyStateRevoked},
+	})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("no active %s API key that is not revoked", provider)
+	}
+	return nil
+}
@@ -0,0 +1,401 @@
+// File: script_import.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ImportFormatText     = "text"
+	ImportFormatMarkdown = "markdown"
+	ImportFormatJSON     = "json"
+
+	ScriptSourceImport = "import"
+
+	maxImportBodyBytes   = 10 << 20 // 10MB
+	importTitleWordLimit = 8
+	importSectionWords   = 500            // Size paragraphs are grouped to when the text has no separators
+	sectionSeparator     = "\n\n\n\n\n\n" // Same separator the generator puts between sections
+)
+
+var (
+	markdownHeadingRegex = regexp.MustCompile(`^\s{0,3}#{1,6}\s+(.+?)\s*#*\s*$`)
+	markdownLinkRegex    = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+	markdownEmphasis     = strings.NewReplacer("**", "", "__", "", "`", "")
+	blankLineRegex       = regexp.MustCompile(`\n\s*\n`)
+	sectionBreakRegex    = regexp.MustCompile(`\n\s*\n\s*\n\s*\n`)
+)
+
+// ImportedSection is a single section of a bring-your-own script
+type ImportedSection struct {
+	Title   string `json:"title"`
+	Summary string `json:"summary,omitempty"`
+	Content string `json:"content"`
+}
+
+// ScriptImportRequest is the JSON body of POST /scripts/import. Plain text and Markdown
+// can also be posted raw with a text/plain or text/markdown Content-Type, in which case
+// the remaining fields are read from the query string.
+type ScriptImportRequest struct {
+	ChannelName  string            `json:"channel_name"`
+	Topic        string            `json:"topic"`
+	Format       string            `json:"format"`            // text, markdown or json
+	Content      string            `json:"content,omitempty"` // text and markdown formats
+	Intro        string            `json:"intro,omitempty"`   // json format: hook/introduction before the first section
+	Sections     []ImportedSection `json:"sections,omitempty"`
+	Meta         *MetaContent      `json:"meta,omitempty"` // Use as-is instead of generating
+	GenerateMeta bool              `json:"generate_meta"`
+}
+
+type ScriptImportResponse struct {
+	Success       bool           `json:"success"`
+	Message       string         `json:"message"`
+	ScriptID      string         `json:"script_id"`
+	Status        string         `json:"status"`
+	Topic         string         `json:"topic"`
+	ChannelName   string         `json:"channel_name"`
+	Sections      int            `json:"sections"`
+	OutlinePoints []OutlinePoint `json:"outline_points"`
+}
+
+func (yt *YtAutomation) importScriptHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if r.Method != "POST" {
+		respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed. Use POST.")
+		return
+	}
+
+	req, err := decodeScriptImportRequest(w, r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	req.ChannelName = strings.TrimSpace(req.ChannelName)
+	req.Topic = strings.TrimSpace(req.Topic)
+	if req.ChannelName == "" {
+		respondWithError(w, http.StatusBadRequest, "Channel name cannot be empty")
+		return
+	}
+
+	intro, sections, err := parseImportedScript(req)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(sections) == 0 {
+		respondWithError(w, http.StatusBadRequest, "No script content found")
+		return
+	}
+
+	if req.Topic == "" {
+		req.Topic = sections[0].Title
+	}
+
+	channel, err := getOrCreateChannel(req.ChannelName)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	now := time.Now()
+	script := buildImportedScript(channel, req.Topic, intro, sections)
+	script.CreatedAt = now
+	script.StartedAt = &now
+	script.CompletedAt = &now
+	if req.Meta != nil {
+		script.Meta = *req.Meta
+	}
+
+	result, err := scriptsCollection.InsertOne(context.Background(), script)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create script record: %v", err))
+		return
+	}
+	script.ID = result.InsertedID.(primitive.ObjectID)
+
+	updateChannelStats(channel.ChannelName, true)
+
+	message := "Script imported"
+	if req.Meta == nil && req.GenerateMeta {
+		message = "Script imported, meta generation started"
+		runJob(r.Context(), func(ctx context.Context) {
+			ctx = withScriptLog(ctx, script.ID)
+			if err := yt.generateMetaTag(ctx, script); err != nil {
+				slog.ErrorContext(ctx, "Meta generation failed for imported script", "error", err)
+			}
+		})
+	}
+
+	respondWithJSON(w, http.StatusCreated, ScriptImportResponse{
+		Success:       true,
+		Message:       message,
+		ScriptID:      script.ID.Hex(),
+		Status:        script.Status,
+		Topic:         script.Topic,
+		ChannelName:   script.ChannelName,
+		Sections:      len(script.OutlinePoints),
+		OutlinePoints: script.OutlinePoints,
+	})
+
+	slog.Info("Script imported", "channel", script.ChannelName, "topic", script.Topic,
+		"sections", len(script.OutlinePoints), logKeyScriptID, script.ID.Hex())
+}
+
+func decodeScriptImportRequest(w http.ResponseWriter, r *http.Request) (*ScriptImportRequest, error) {
+	body := http.MaxBytesReader(w, r.Body, maxImportBodyBytes)
+	contentType := strings.ToLower(r.Header.Get("Content-Type"))
+
+	if strings.HasPrefix(contentType, "text/") {
+		content, err := io.ReadAll(body)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read request body: %v", err)
+		}
+
+		query := r.URL.Query()
+		format := query.Get("format")
+		if format == "" {
+			format = ImportFormatText
+			if strings.HasPrefix(contentType, "text/markdown") || strings.HasPrefix(contentType, "text/x-markdown") {
+				format = ImportFormatMarkdown
+			}
+		}
+		generateMeta, _ := strconv.ParseBool(query.Get("generate_meta"))
+
+		return &ScriptImportRequest{
+			ChannelName:  query.Get("channel_name"),
+			Topic:        query.Get("topic"),
+			Format:       format,
+			Content:      string(content),
+			GenerateMeta: generateMeta,
+		}, nil
+	}
+
+	var req ScriptImportRequest
+	if err := json.NewDecoder(body).Decode(&req); err != nil {
+		return nil, fmt.Errorf("Invalid JSON request body")
+	}
+	if req.Format == "" {
+		req.Format = ImportFormatText
+		if len(req.Sections) > 0 {
+			req.Format = ImportFormatJSON
+		}
+	}
+	return &req, nil
+}
+
+// parseImportedScript turns the request into the hook/introduction and the list of sections
+func parseImportedScript(req *ScriptImportRequest) (string, []ImportedSection, error) {
+	switch strings.ToLower(req.Format) {
+	case ImportFormatText, "txt", "plain":
+		intro, sections := parsePlainTextScript(req.Content)
+		return intro, sections, nil
+	case ImportFormatMarkdown, "md":
+		intro, sections := parseMarkdownScript(req.Content)
+		return intro, sections, nil
+	case ImportFormatJSON:
+		var sections []ImportedSection
+		for i, section := range req.Sections {
+			content := strings.TrimSpace(section.Content)
+			if content == "" {
+				return "", nil, fmt.Errorf("section %d has no content", i+1)
+			}
+			title := strings.TrimSpace(section.Title)
+			if title == "" {
+				title = importTitleFromContent(content)
+			}
+			sections = append(sections, ImportedSection{
+				Title:   title,
+				Summary: strings.TrimSpace(section.Summary),
+				Content: content,
+			})
+		}
+		return strings.TrimSpace(req.Intro), sections, nil
+	default:
+		return "", nil, fmt.Errorf("Unsupported format %q. Use text, markdown or json", req.Format)
+	}
+}
+
+// parsePlainTextScript splits on the generator's section separator (three or more blank
+// lines) when present. Otherwise paragraphs are grouped into sections of about
+// importSectionWords, so a pasted transcript does not become dozens of sections.
+func parsePlainTextScript(content string) (string, []ImportedSection) {
+	content = strings.TrimSpace(strings.ReplaceAll(content, "\r\n", "\n"))
+	if content == "" {
+		return "", nil
+	}
+
+	blocks := sectionBreakRegex.Split(content, -1)
+	if len(blocks) == 1 {
+		blocks = groupParagraphs(blankLineRegex.Split(content, -1), importSectionWords)
+	}
+
+	var sections []ImportedSection
+	for _, block := range blocks {
+		block = strings.TrimSpace(block)
+		if block == "" {
+			continue
+		}
+		sections = append(sections, ImportedSection{
+			Title:   importTitleFromContent(block),
+			Content: block,
+		})
+	}
+	return "", sections
+}
+
+// groupParagraphs joins consecutive paragraphs until each group has at least words words.
+// A short remainder is added to the last group rather than left as a section of its own.
+func groupParagraphs(paragraphs []string, words int) []string {
+	var groups, current []string
+	count := 0
+	for _, paragraph := range paragraphs {
+		if paragraph = strings.TrimSpace(paragraph); paragraph == "" {
+			continue
+		}
+		current = append(current, paragraph)
+		if count += len(strings.Fields(paragraph)); count >= words {
+			groups = append(groups, strings.Join(current, "\n\n"))
+			current, count = nil, 0
+		}
+	}
+	if len(current) > 0 {
+		if len(groups) > 0 && count < words/2 {
+			groups[len(groups)-1] += "\n\n" + strings.Join(current, "\n\n")
+		} else {
+			groups = append(groups, strings.Join(current, "\n\n"))
+		}
+	}
+	return groups
+}
+
+// parseMarkdownScript makes every heading a section; text before the first heading is the intro
+func parseMarkdownScript(content string) (string, []ImportedSection) {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+
+	var intro strings.Builder
+	var sections []ImportedSection
+	var current *ImportedSection
+	var body strings.Builder
+
+	flush := func() {
+		if current == nil {
+			return
+		}
+		current.Content = strings.TrimSpace(body.String())
+		if current.Content != "" {
+			sections = append(sections, *current)
+		}
+		body.Reset()
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		if match := markdownHeadingRegex.FindStringSubmatch(line); match != nil {
+			flush()
+			current = &ImportedSection{Title: stripMarkdown(match[1])}
+			continue
+		}
+
+		line = stripMarkdown(line)
+		if current == nil {
+			intro.WriteString(line + "\n")
+		} else {
+			body.WriteString(line + "\n")
+		}
+	}
+	flush()
+
+	// No headings at all: treat it like plain text
+	if current == nil {
+		return parsePlainTextScript(intro.String())
+	}
+
+	return strings.TrimSpace(intro.String()), sections
+}
+
+// stripMarkdown removes inline formatting that would otherwise be read out by TTS
+func stripMarkdown(line string) string {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "---" || trimmed == "***" || trimmed == "___" {
+		return ""
+	}
+	trimmed = strings.TrimLeft(trimmed, "> ")
+	trimmed = markdownLinkRegex.ReplaceAllString(trimmed, "$1")
+	return markdownEmphasis.Replace(trimmed)
+}
+
+func importTitleFromContent(content string) string {
+	firstLine := strings.SplitN(strings.TrimSpace(content), "\n", 2)[0]
+	words := strings.Fields(firstLine)
+	if len(words) > importTitleWordLimit {
+		return strings.Join(words[:importTitleWordLimit], " ") + "..."
+	}
+	return strings.Join(words, " ")
+}
+
+func importSummaryFromContent(content string) string {
+	sentences := splitTextByCharLimit(strings.Join(strings.Fields(content), " "), 200)
+	if len(sentences) == 0 {
+		return ""
+	}
+	return sentences[0]
+}
+
+// buildImportedScript lays the sections out exactly like a generated script so the
+// audio, subtitle, visual and video steps work unchanged.
+func buildImportedScript(channel Channel, topic, intro string, sections []ImportedSection) *Script {
+	var fullScript strings.Builder
+	if intro != "" {
+		fullScript.WriteString(intro + sectionSeparator)
+	}
+
+	var outline strings.Builder
+	var outlinePoints []OutlinePoint
+	for i, section := range sections {
+		summary := section.Summary
+		if summary == "" {
+			summary = importSummaryFromContent(section.Content)
+		}
+
+		outline.WriteString(fmt.Sprintf("%d. %s\n", i+1, section.Title))
+		if summary != "" {
+			outline.WriteString(fmt.Sprintf("   Summary: %s\n", summary))
+		}
+		outline.WriteString("\n")
+
+		outlinePoints = append(outlinePoints, OutlinePoint{
+			SectionNumber: i + 1,
+			Title:         section.Title,
+			Summary:       summary,
+		})
+		fullScript.WriteString(section.Content + sectionSeparator)
+	}
+
+	return &Script{
+		ChannelID:         channel.ID,
+		ChannelName:       channel.ChannelName,
+		Topic:             topic,
+		Status:            StatusCompleted,
+		Source:            ScriptSourceImport,
+		Outline:           strings.TrimSpace(outline.String()),
+		OutlinePoints:     outlinePoints,
+		FullScript:        fullScript.String(),
+		SectionsGenerated: len(outlinePoints),
+	}
+}
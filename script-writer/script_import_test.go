@@ -0,0 +1,184 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// words returns a paragraph of n words starting with the given first word
+func words(first string, n int) string {
+	return first + strings.Repeat(" word", n-1)
+}
+
+func TestParsePlainTextScript(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string // First word of each section
+	}{
+		{"empty", "  \n\n ", nil},
+		{"single paragraph", "Just one paragraph.", []string{"Just"}},
+		{"generator separator", "Intro text.\n\n\n\n\n\nFirst section.\n\nMore of it.\n\n\n\n\n\nSecond section.",
+			[]string{"Intro", "First", "Second"}},
+		{"separator with CRLF", "One.\r\n\r\n\r\n\r\nTwo.", []string{"One.", "Two."}},
+		{"short paragraphs stay together", "First.\n\nSecond.\n\nThird.", []string{"First."}},
+		{"long text is grouped by size",
+			words("a", 300) + "\n\n" + words("b", 300) + "\n\n" + words("c", 300) + "\n\n" + words("d", 300),
+			[]string{"a", "c"}},
+		{"short remainder joins the last group",
+			words("a", 600) + "\n\n" + words("b", 100), []string{"a"}},
+		{"long remainder is its own section",
+			words("a", 600) + "\n\n" + words("b", 300), []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			intro, sections := parsePlainTextScript(tt.content)
+			if intro != "" {
+				t.Errorf("intro = %q, want none", intro)
+			}
+			if len(sections) != len(tt.want) {
+				t.Fatalf("%d sections, want %d", len(sections), len(tt.want))
+			}
+			for i, section := range sections {
+				if first := strings.Fields(section.Content)[0]; first != tt.want[i] {
+					t.Errorf("section %d starts with %q, want %q", i+1, first, tt.want[i])
+				}
+				if section.Title == "" {
+					t.Errorf("section %d has no title", i+1)
+				}
+			}
+		})
+	}
+}
+
+func TestParsePlainTextScriptKeepsEveryParagraph(t *testing.T) {
+	var paragraphs []string
+	for i := 0; i < 40; i++ {
+		paragraphs = append(paragraphs, words("p", 60))
+	}
+	_, sections := parsePlainTextScript(strings.Join(paragraphs, "\n\n"))
+
+	total := 0
+	for _, section := range sections {
+		total += len(strings.Fields(section.Content))
+	}
+	if total != 40*60 {
+		t.Errorf("sections hold %d words, want %d", total, 40*60)
+	}
+	if len(sections) != 40*60/importSectionWords {
+		t.Errorf("%d sections, want %d", len(sections), 40*60/importSectionWords)
+	}
+}
+
+func TestParseMarkdownScript(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		wantIntro  string
+		wantTitles []string
+		wantBodies []string
+	}{
+		{
+			name:       "intro and headings",
+			content:    "Welcome to the show.\n\n## The Beginning\nIt started **small**.\n\n### Growth #\nThen it [grew](https://example.com).\n",
+			wantIntro:  "Welcome to the show.",
+			wantTitles: []string{"The Beginning", "Growth"},
+			wantBodies: []string{"It started small.", "Then it grew."},
+		},
+		{
+			name:       "emphasis in headings and quotes",
+			content:    "# **Bold** `title`\n> Quoted line\n---\nAfter the rule.",
+			wantTitles: []string{"Bold title"},
+			wantBodies: []string{"Quoted line\n\nAfter the rule."},
+		},
+		{
+			name:       "empty sections are dropped",
+			content:    "# Empty\n\n# Full\nText.",
+			wantTitles: []string{"Full"},
+			wantBodies: []string{"Text."},
+		},
+		{
+			name:       "images keep their alt text",
+			content:    "# Pictures\n![A mountain](mountain.png) at dawn.",
+			wantTitles: []string{"Pictures"},
+			wantBodies: []string{"A mountain at dawn."},
+		},
+		{
+			name:       "no headings falls back to plain text",
+			content:    "First paragraph.\n\n\n\nSecond paragraph.",
+			wantTitles: []string{"First paragraph.", "Second paragraph."},
+			wantBodies: []string{"First paragraph.", "Second paragraph."},
+		},
+		{
+			name:       "CRLF line endings",
+			content:    "Intro.\r\n# Title\r\nBody.\r\n",
+			wantIntro:  "Intro.",
+			wantTitles: []string{"Title"},
+			wantBodies: []string{"Body."},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			intro, sections := parseMarkdownScript(tt.content)
+			if intro != tt.wantIntro {
+				t.Errorf("intro = %q, want %q", intro, tt.wantIntro)
+			}
+			if len(sections) != len(tt.wantTitles) {
+				t.Fatalf("sections = %+v, want titles %q", sections, tt.wantTitles)
+			}
+			for i, section := range sections {
+				if section.Title != tt.wantTitles[i] {
+					t.Errorf("section %d title = %q, want %q", i+1, section.Title, tt.wantTitles[i])
+				}
+				if section.Content != tt.wantBodies[i] {
+					t.Errorf("section %d content = %q, want %q", i+1, section.Content, tt.wantBodies[i])
+				}
+			}
+		})
+	}
+}
+
+func TestImportTitleFromContent(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"Short title\nrest of the text", "Short title"},
+		{"  one two three four five six seven eight nine ten", "one two three four five six seven eight..."},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := importTitleFromContent(tt.content); got != tt.want {
+			t.Errorf("importTitleFromContent(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestParseImportedScriptJSON(t *testing.T) {
+	req := &ScriptImportRequest{
+		Format: ImportFormatJSON,
+		Intro:  "  Hook.  ",
+		Sections: []ImportedSection{
+			{Title: "Given", Content: "Body one."},
+			{Content: "Untitled body gets a title from its first words."},
+		},
+	}
+	intro, sections, err := parseImportedScript(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if intro != "Hook." {
+		t.Errorf("intro = %q, want %q", intro, "Hook.")
+	}
+	if len(sections) != 2 || sections[0].Title != "Given" || sections[1].Title != "Untitled body gets a title from its first..." {
+		t.Errorf("sections = %+v", sections)
+	}
+
+	req.Sections = append(req.Sections, ImportedSection{Title: "Blank", Content: "  "})
+	if _, _, err := parseImportedScript(req); err == nil {
+		t.Error("a section without content was accepted")
+	}
+	if _, _, err := parseImportedScript(&ScriptImportRequest{Format: "docx"}); err == nil {
+		t.Error("an unknown format was accepted")
+	}
+}
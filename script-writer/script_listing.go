@@ -0,0 +1,358 @@
+// File: script_listing.go
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// defaultExcludedScriptFields are left out of listings unless requested through fields,
+// since they make up most of a script document
+var defaultExcludedScriptFields = []string{"full_script", "srt"}
+
+// scriptSortFields are the fields a listing can be ordered by
+var scriptSortFields = map[string]bool{
+	"created_at": true,
+	"topic":      true,
+	"status":     true,
+}
+
+var scriptFieldRegex = regexp.MustCompile(`^[a-z_]+(\.[a-z_]+)*$`)
+
+// ScriptListQuery holds the filters, projection and page requested by a listing
+type ScriptListQuery struct {
+	Statuses []string
+	From     *time.Time
+	To       *time.Time
+	Text     string
+	Fields   []string // Inclusion projection; empty means everything except the defaults excluded
+	SortBy   string
+	Order    int // 1 ascending, -1 descending
+	Limit    int
+	Cursor   *scriptCursor
+}
+
+// ScriptListResponse is one page of a listing. Scripts hold only the fields that were asked for.
+type ScriptListResponse struct {
+	Scripts    []map[string]interface{} `json:"scripts" openapi:"Script"`
+	Count      int                      `json:"count"`
+	HasMore    bool                     `json:"has_more"`
+	NextCursor string                   `json:"next_cursor"`
+}
+
+// scriptCursor is the sort value and ID of the last script on the previous page
+type scriptCursor struct {
+	Value interface{}        `bson:"v"`
+	ID    primitive.ObjectID `bson:"id"`
+}
+
+func encodeScriptCursor(cursor scriptCursor) (string, error) {
+	// BSON keeps the value's type, so dates still compare as dates when decoded
+	data, err := bson.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeScriptCursor(encoded string) (*scriptCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	var raw struct {
+		Value bson.RawValue      `bson:"v"`
+		ID    primitive.ObjectID `bson:"id"`
+	}
+	if err := bson.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	cursor := &scriptCursor{ID: raw.ID}
+	switch raw.Value.Type {
+	case bson.TypeDateTime:
+		cursor.Value = raw.Value.Time()
+	case bson.TypeString:
+		cursor.Value = raw.Value.StringValue()
+	default:
+		return nil, fmt.Errorf("unsupported cursor value")
+	}
+	return cursor, nil
+}
+
+// parseListDate accepts RFC3339 or a plain date. endOfDay moves a plain date to the end of
+// that day so ?to=2024-05-01 includes the whole day.
+func parseListDate(value string, endOfDay bool) (*time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q, use YYYY-MM-DD or RFC3339", value)
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return &t, nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func parseScriptListQuery(r *http.Request) (*ScriptListQuery, error) {
+	params := r.URL.Query()
+	query := &ScriptListQuery{
+		Statuses: splitList(params.Get("status")),
+		Text:     strings.TrimSpace(params.Get("q")),
+		SortBy:   "created_at",
+		Order:    -1,
+		Limit:    defaultScriptPageSize,
+	}
+
+	if from := params.Get("from"); from != "" {
+		t, err := parseListDate(from, false)
+		if err != nil {
+			return nil, err
+		}
+		query.From = t
+	}
+	if to := params.Get("to"); to != "" {
+		t, err := parseListDate(to, true)
+		if err != nil {
+			return nil, err
+		}
+		query.To = t
+	}
+
+	for _, field := range splitList(params.Get("fields")) {
+		if field == "id" {
+			field = "_id"
+		}
+		if field != "_id" && !scriptFieldRegex.MatchString(field) {
+			return nil, fmt.Errorf("invalid field %q", field)
+		}
+		query.Fields = append(query.Fields, field)
+	}
+
+	if sortBy := params.Get("sort"); sortBy != "" {
+		if !scriptSortFields[sortBy] {
+			return nil, fmt.Errorf("sort must be created_at, topic or status")
+		}
+		query.SortBy = sortBy
+	}
+	switch params.Get("order") {
+	case "", "desc":
+	case "asc":
+		query.Order = 1
+	default:
+		return nil, fmt.Errorf("order must be asc or desc")
+	}
+
+	if limit := params.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("limit must be a positive number")
+		}
+		if n > maxScriptPageSize {
+			n = maxScriptPageSize
+		}
+		query.Limit = n
+	}
+
+	if encoded := params.Get("cursor"); encoded != "" {
+		cursor, err := decodeScriptCursor(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cursor")
+		}
+		query.Cursor = cursor
+	}
+	return query, nil
+}
+
+// filter combines the base filter (e.g. the channel) with the query's filters and cursor
+func (q *ScriptListQuery) filter(base bson.M) bson.M {
+	filter := bson.M{}
+	for k, v := range base {
+		filter[k] = v
+	}
+	if len(q.Statuses) == 1 {
+		filter["status"] = q.Statuses[0]
+	} else if len(q.Statuses) > 1 {
+		filter["status"] = bson.M{"$in": q.Statuses}
+	}
+	if q.From != nil || q.To != nil {
+		createdAt := bson.M{}
+		if q.From != nil {
+			createdAt["$gte"] = *q.From
+		}
+		if q.To != nil {
+			createdAt["$lte"] = *q.To
+		}
+		filter["created_at"] = createdAt
+	}
+	if q.Text != "" {
+		filter["topic"] = primitive.Regex{Pattern: regexp.QuoteMeta(q.Text), Options: "i"}
+	}
+
+	if q.Cursor != nil {
+		op := "$lt"
+		if q.Order == 1 {
+			op = "$gt"
+		}
+		filter["$or"] = bson.A{
+			bson.M{q.SortBy: bson.M{op: q.Cursor.Value}},
+			bson.M{q.SortBy: q.Cursor.Value, "_id": bson.M{op: q.Cursor.ID}},
+		}
+	}
+	return filter
+}
+
+func (q *ScriptListQuery) findOptions() *options.FindOptions {
+	opts := options.Find().
+		SetSort(bson.D{{q.SortBy, q.Order}, {"_id", q.Order}}).
+		SetLimit(int64(q.Limit + 1)) // One extra to know whether another page exists
+
+	projection := bson.M{}
+	if len(q.Fields) > 0 {
+		for _, field := range q.Fields {
+			projection[field] = 1
+		}
+		// The cursor needs the sort value of the last script
+		projection[q.SortBy] = 1
+	} else {
+		for _, field := range defaultExcludedScriptFields {
+			projection[field] = 0
+		}
+	}
+	return opts.SetProjection(projection)
+}
+
+func (q *ScriptListQuery) cursorFor(script Script) scriptCursor {
+	cursor := scriptCursor{ID: script.ID}
+	switch q.SortBy {
+	case "topic":
+		cursor.Value = script.Topic
+	case "status":
+		cursor.Value = script.Status
+	default:
+		cursor.Value = script.CreatedAt
+	}
+	return cursor
+}
+
+// listItem trims a script down to the fields the query asked for. Decoding a projected
+// document into Script leaves zero values behind, so they are removed from the JSON here.
+func (q *ScriptListQuery) listItem(script Script) (map[string]interface{}, error) {
+	data, err := json.Marshal(script)
+	if err != nil {
+		return nil, err
+	}
+	var item map[string]interface{}
+	if err := json.Unmarshal(data, &item); err != nil {
+		return nil, err
+	}
+
+	if len(q.Fields) == 0 {
+		for _, field := range defaultExcludedScriptFields {
+			delete(item, field)
+		}
+		return item, nil
+	}
+
+	kept := map[string]interface{}{"id": item["id"]}
+	for _, field := range q.Fields {
+		key := strings.SplitN(field, ".", 2)[0]
+		if key == "_id" {
+			continue
+		}
+		if value, ok := item[key]; ok {
+			kept[key] = value
+		}
+	}
+	return kept, nil
+}
+
+// listScripts writes one page of scripts matching base and the request's query parameters
+func (yt *YtAutomation) listScripts(w http.ResponseWriter, r *http.Request, base bson.M) {
+	query, err := parseScriptListQuery(r)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	cursor, err := scriptsCollection.Find(context.Background(), query.filter(base), query.findOptions())
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Database error: %v", err))
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	var scripts []Script
+	if err = cursor.All(context.Background(), &scripts); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error decoding scripts: %v", err))
+		return
+	}
+
+	hasMore := len(scripts) > query.Limit
+	if hasMore {
+		scripts = scripts[:query.Limit]
+	}
+
+	items := make([]map[string]interface{}, 0, len(scripts))
+	for _, script := range scripts {
+		item, err := query.listItem(script)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error encoding scripts: %v", err))
+			return
+		}
+		items = append(items, item)
+	}
+
+	nextCursor := ""
+	if hasMore {
+		if nextCursor, err = encodeScriptCursor(query.cursorFor(scripts[len(scripts)-1])); err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error encoding cursor: %v", err))
+			return
+		}
+	}
+
+	respondWithJSON(w, http.StatusOK, ScriptListResponse{
+		Scripts:    items,
+		Count:      len(items),
+		HasMore:    hasMore,
+		NextCursor: nextCursor,
+	})
+}
+
+// listScriptsHandler lists scripts across all channels (GET /scripts?channel=...)
+func (yt *YtAutomation) listScriptsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		respondWithError(w, http.StatusMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+
+	base := bson.M{}
+	if channels := splitList(r.URL.Query().Get("channel")); len(channels) > 0 {
+		base["channel_name"] = bson.M{"$in": channels}
+	}
+	yt.listScripts(w, r, base)
+}
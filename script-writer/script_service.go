@@ -2,14 +2,17 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"log/slog"
 	"strings"
 	"time"
 )
 
-func (yt *YtAutomation) GenerateCompleteScript(scriptID primitive.ObjectID) error {
+func (yt *YtAutomation) GenerateCompleteScript(ctx context.Context, scriptID primitive.ObjectID) error {
+	ctx = withScriptLog(ctx, scriptID)
 	// Load script from DB
 	script, err := yt.getScriptByID(scriptID)
 	if err != nil {
@@ -22,61 +25,85 @@ func (yt *YtAutomation) GenerateCompleteScript(scriptID primitive.ObjectID) erro
 		return fmt.Errorf("loading channel: %w", err)
 	}
 
-	// Update status
-	yt.updateScriptStatus(scriptID, "generating_outline")
-
-	// Step 1: Generate outline
-	if err := yt.generateOutline(script, channel.Settings.DefaultSectionCount); err != nil {
-		yt.updateScriptError(scriptID, err.Error())
-		return fmt.Errorf("generating outline: %w", err)
+	if script.LongForm != nil {
+		return yt.GenerateLongFormScript(ctx, script, channel)
 	}
-	// reload updated script from db
-	script, err = yt.getScriptByID(scriptID)
-	if err != nil {
-		return fmt.Errorf("loading script: %w", err)
+
+	// A resumed script skips the steps it already saved
+	if len(script.OutlinePoints) == 0 {
+		if err := checkpoint(ctx); err != nil {
+			return err
+		}
+		// Update status
+		yt.updateScriptStatus(scriptID, "generating_outline")
+
+		// Step 1: Generate outline
+		if err := yt.generateOutline(ctx, script, channel.Settings.DefaultSectionCount); err != nil {
+			yt.updateScriptError(scriptID, err.Error())
+			return fmt.Errorf("generating outline: %w", err)
+		}
+		// reload updated script from db
+		script, err = yt.getScriptByID(scriptID)
+		if err != nil {
+			return fmt.Errorf("loading script: %w", err)
+		}
 	}
 
-	// Step 2: Generate hook and introduction
-	yt.updateScriptStatus(scriptID, "generating_hook")
-	if err := yt.generateHookAndIntroduction(script, channel.Settings.WordLimitForHookIntro); err != nil {
-		yt.updateScriptError(scriptID, err.Error())
-		return fmt.Errorf("generating hook: %w", err)
+	if script.FullScript == "" {
+		if err := checkpoint(ctx); err != nil {
+			return err
+		}
+		// Step 2: Generate hook and introduction
+		yt.updateScriptStatus(scriptID, "generating_hook")
+		if err := yt.generateHookAndIntroduction(ctx, script, channel.Settings.WordLimitForHookIntro); err != nil {
+			yt.updateScriptError(scriptID, err.Error())
+			return fmt.Errorf("generating hook: %w", err)
+		}
 	}
 
 	// Step 3: Generate sections
 	yt.updateScriptStatus(scriptID, "generating_sections")
-	for i := 1; i <= channel.Settings.DefaultSectionCount; i++ {
+	for i := script.SectionsGenerated + 1; i <= channel.Settings.DefaultSectionCount; i++ {
+		if err := checkpoint(ctx); err != nil {
+			return err
+		}
 		yt.updateScriptCurrentSection(scriptID, i)
-		if err := yt.generateSection(script, i, channel.Settings.WordLimitPerSection); err != nil {
+		if err := yt.generateSection(ctx, script, i, channel.Settings.WordLimitPerSection); err != nil {
 			yt.updateScriptError(scriptID, err.Error())
 			return fmt.Errorf("generating section %d: %w", i, err)
 		}
-		time.Sleep(time.Second * 2) // Rate limiting
+		sleepContext(ctx, time.Second*2) // Rate limiting
 	}
 
 	// Step 4: Generate meta tags
+	if err := checkpoint(ctx); err != nil {
+		return err
+	}
 	yt.updateScriptStatus(scriptID, "generating_meta")
-	if err := yt.generateMetaTag(script); err != nil {
-		fmt.Printf("Warning: Meta tag generation failed: %v\n", err)
+	if err := yt.generateMetaTag(ctx, script); err != nil {
+		slog.WarnContext(ctx, "Meta tag generation failed", "error", err)
 	}
 
 	// Mark as completed
 	yt.updateScriptStatus(scriptID, "completed")
 	yt.updateScriptCompletedAt(scriptID)
 
-	fmt.Printf("🎉 Script generation completed for: %s\n", script.Topic)
+	slog.InfoContext(ctx, "Script text completed", "topic", script.Topic)
 	return nil
 }
 
-func (yt *YtAutomation) generateOutline(script *Script, sectionCount int) error {
-	fmt.Println("Generating outline...")
+func (yt *YtAutomation) generateOutline(ctx context.Context, script *Script, sectionCount int) (err error) {
+	ctx, end := startStage(ctx, StageOutline)
+	defer end(&err)
+
+	slog.InfoContext(ctx, "Generating outline", "sections", sectionCount)
 
 	systemPrompt, userPrompt, err := yt.templateService.BuildOutlinePrompt(script, sectionCount)
 	if err != nil {
 		return fmt.Errorf("building outline prompt: %w", err)
 	}
 
-	response, err := yt.aiService.GenerateContentWithSystem(systemPrompt, userPrompt)
+	response, err := yt.aiService.GenerateContentWithSystem(ctx, systemPrompt, userPrompt)
 	if err != nil {
 		return err
 	}
@@ -114,15 +141,18 @@ func (yt *YtAutomation) generateOutline(script *Script, sectionCount int) error
 	return yt.updateScriptInDB(script.ID, updateData)
 }
 
-func (yt *YtAutomation) generateHookAndIntroduction(script *Script, wordLimit int) error {
-	fmt.Println("Generating Hook and Introduction...")
+func (yt *YtAutomation) generateHookAndIntroduction(ctx context.Context, script *Script, wordLimit int) (err error) {
+	ctx, end := startStage(ctx, StageHook)
+	defer end(&err)
+
+	slog.InfoContext(ctx, "Generating hook and introduction")
 
 	systemPrompt, userPrompt, err := yt.templateService.BuildHookIntroPrompt(script, wordLimit)
 	if err != nil {
 		return fmt.Errorf("building hook prompt: %w", err)
 	}
 
-	response, err := yt.aiService.GenerateContentWithSystem(systemPrompt, userPrompt)
+	response, err := yt.aiService.GenerateContentWithSystem(ctx, systemPrompt, userPrompt)
 	if err != nil {
 		return err
 	}
@@ -141,7 +171,10 @@ func (yt *YtAutomation) generateHookAndIntroduction(script *Script, wordLimit in
 	return yt.updateScriptInDB(script.ID, updateData)
 }
 
-func (yt *YtAutomation) generateSection(script *Script, sectionNumber int, wordLimit int) error {
+func (yt *YtAutomation) generateSection(ctx context.Context, script *Script, sectionNumber int, wordLimit int) (err error) {
+	ctx, end := startStage(ctx, StageSection)
+	defer end(&err)
+
 	// Reload script to get latest content
 	updatedScript, err := yt.getScriptByID(script.ID)
 	if err != nil {
@@ -153,14 +186,14 @@ func (yt *YtAutomation) generateSection(script *Script, sectionNumber int, wordL
 		outlinePoint = updatedScript.OutlinePoints[sectionNumber-1].Title
 	}
 
-	fmt.Printf("Generating Section %d: %s\n", sectionNumber, outlinePoint)
+	slog.InfoContext(ctx, "Generating section", "section", sectionNumber, "title", outlinePoint)
 
 	systemPrompt, userPrompt, err := yt.templateService.BuildSectionPrompt(updatedScript, sectionNumber, outlinePoint, wordLimit)
 	if err != nil {
 		return fmt.Errorf("building section prompt: %w", err)
 	}
 
-	response, err := yt.aiService.GenerateContentWithSystem(systemPrompt, userPrompt)
+	response, err := yt.aiService.GenerateContentWithSystem(ctx, systemPrompt, userPrompt)
 	if err != nil {
 		return err
 	}
@@ -181,8 +214,11 @@ func (yt *YtAutomation) generateSection(script *Script, sectionNumber int, wordL
 	return yt.updateScriptInDB(script.ID, updateData)
 }
 
-func (yt *YtAutomation) generateMetaTag(script *Script) error {
-	fmt.Println("Generating Meta Tags...")
+func (yt *YtAutomation) generateMetaTag(ctx context.Context, script *Script) (err error) {
+	ctx, end := startStage(ctx, StageMeta)
+	defer end(&err)
+
+	slog.InfoContext(ctx, "Generating meta tags")
 
 	updatedScript, err := yt.getScriptByID(script.ID)
 	if err != nil {
@@ -194,7 +230,7 @@ func (yt *YtAutomation) generateMetaTag(script *Script) error {
 		return fmt.Errorf("building meta tag prompt: %w", err)
 	}
 
-	response, err := yt.aiService.GenerateContentWithSystem(systemPrompt, userPrompt)
+	response, err := yt.aiService.GenerateContentWithSystem(ctx, systemPrompt, userPrompt)
 	if err != nil {
 		return err
 	}
@@ -210,11 +246,7 @@ func (yt *YtAutomation) generateMetaTag(script *Script) error {
 	})
 }
 func (yt *YtAutomation) displayOutlinePoints(points []string) {
-	if len(points) > 0 {
-		fmt.Println("\nParsed Outline Points:")
-		for i, point := range points {
-			fmt.Printf("  %d. %s\n", i+1, point)
-		}
-		fmt.Println()
+	for i, point := range points {
+		slog.Debug("Parsed outline point", "section", i+1, "title", point)
 	}
 }
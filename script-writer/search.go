@@ -0,0 +1,270 @@
+// File: search.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	searchSnippetRadius = 80 // Characters of context on each side of the first match
+	searchStemLength    = 5  // Prefix used when Mongo matched a different form of the word
+	highlightOpen       = "<mark>"
+	highlightClose      = "</mark>"
+)
+
+type SearchHighlight struct {
+	Field   string `json:"field"`
+	Snippet string `json:"snippet"`
+}
+
+type SearchHit struct {
+	ScriptID      primitive.ObjectID `json:"script_id"`
+	ChannelName   string             `json:"channel_name"`
+	Topic         string             `json:"topic"`
+	Status        string             `json:"status"`
+	Score         float64            `json:"score"`
+	SectionNumber *int               `json:"section_number,omitempty"` // 0 is the introduction
+	SectionTitle  string             `json:"section_title,omitempty"`
+	Highlights    []SearchHighlight  `json:"highlights"`
+	CreatedAt     time.Time          `json:"created_at"`
+}
+
+type SearchResponse struct {
+	Query string      `json:"query"`
+	Count int         `json:"count"`
+	Hits  []SearchHit `json:"hits"`
+}
+
+// scoredScript is a script with the text score Mongo ranked it by
+type scoredScript struct {
+	Script `bson:",inline"`
+	Score  float64 `bson:"score"`
+}
+
+var searchPhraseRegex = regexp.MustCompile(`"([^"]+)"|(\S+)`)
+
+// searchTerms pulls phrases and words out of a $text query, skipping negated terms
+func searchTerms(query string) []string {
+	var terms []string
+	for _, match := range searchPhraseRegex.FindAllStringSubmatch(query, -1) {
+		term := match[1]
+		if term == "" {
+			term = match[2]
+			if strings.HasPrefix(term, "-") {
+				continue
+			}
+			term = strings.Trim(term, `"`)
+		}
+		if term = strings.TrimSpace(term); term != "" {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// termRegex matches the terms as whole words, or by their stem since the text index
+// also matches other forms of a word
+func termRegex(terms []string) *regexp.Regexp {
+	var patterns []string
+	for _, term := range terms {
+		if strings.Contains(term, " ") {
+			patterns = append(patterns, regexp.QuoteMeta(term))
+			continue
+		}
+		patterns = append(patterns, regexp.QuoteMeta(term)+`\w*`)
+		if len([]rune(term)) > searchStemLength {
+			patterns = append(patterns, regexp.QuoteMeta(string([]rune(term)[:searchStemLength]))+`\w*`)
+		}
+	}
+	if len(patterns) == 0 {
+		return nil
+	}
+	return regexp.MustCompile(`(?i)\b(?:` + strings.Join(patterns, "|") + `)`)
+}
+
+// snippet cuts the text around the first match and highlights every match inside it
+func snippet(text string, re *regexp.Regexp) (string, bool) {
+	loc := re.FindStringIndex(text)
+	if loc == nil {
+		return "", false
+	}
+
+	start, end := loc[0]-searchSnippetRadius, loc[1]+searchSnippetRadius
+	prefix, suffix := "…", "…"
+	if start <= 0 {
+		start, prefix = 0, ""
+	} else if idx := strings.IndexAny(text[start:loc[0]], " \n"); idx >= 0 {
+		start += idx + 1
+	}
+	if end >= len(text) {
+		end, suffix = len(text), ""
+	} else if idx := strings.LastIndexAny(text[loc[1]:end], " \n"); idx >= 0 {
+		end = loc[1] + idx
+	}
+	window := strings.ToValidUTF8(text[start:end], "")
+
+	var out strings.Builder
+	last := 0
+	for _, m := range re.FindAllStringIndex(window, -1) {
+		out.WriteString(html.EscapeString(window[last:m[0]]))
+		out.WriteString(highlightOpen + html.EscapeString(window[m[0]:m[1]]) + highlightClose)
+		last = m[1]
+	}
+	out.WriteString(html.EscapeString(window[last:]))
+
+	return prefix + strings.Join(strings.Fields(out.String()), " ") + suffix, true
+}
+
+// matchingSection finds the first script section containing a match. Generated scripts
+// start with a hook and introduction block, reported as section 0.
+func matchingSection(script *Script, re *regexp.Regexp) (int, string, bool) {
+	var blocks []string
+	for _, block := range strings.Split(script.FullScript, sectionSeparator) {
+		if strings.TrimSpace(block) != "" {
+			blocks = append(blocks, block)
+		}
+	}
+	hasIntro := len(blocks) == len(script.OutlinePoints)+1
+
+	for i, block := range blocks {
+		if !re.MatchString(block) {
+			continue
+		}
+		switch {
+		case hasIntro && i == 0:
+			return 0, "Introduction", true
+		case hasIntro && i-1 < len(script.OutlinePoints):
+			return script.OutlinePoints[i-1].SectionNumber, script.OutlinePoints[i-1].Title, true
+		case !hasIntro && i < len(script.OutlinePoints):
+			return script.OutlinePoints[i].SectionNumber, script.OutlinePoints[i].Title, true
+		default:
+			return i + 1, "", true
+		}
+	}
+	return 0, "", false
+}
+
+func buildSearchHit(result scoredScript, re *regexp.Regexp) SearchHit {
+	script := &result.Script
+	hit := SearchHit{
+		ScriptID:    script.ID,
+		ChannelName: script.ChannelName,
+		Topic:       script.Topic,
+		Status:      script.Status,
+		Score:       result.Score,
+		Highlights:  []SearchHighlight{},
+		CreatedAt:   script.CreatedAt,
+	}
+	if re == nil {
+		return hit
+	}
+
+	fields := []struct {
+		name string
+		text string
+	}{
+		{"topic", script.Topic},
+		{"meta.title", script.Meta.Title},
+		{"meta.tags", strings.Join(script.Meta.Tags, ", ")},
+		{"meta.description", script.Meta.Description},
+		{"outline", script.Outline},
+		{"full_script", script.FullScript},
+	}
+	for _, field := range fields {
+		if s, ok := snippet(field.text, re); ok {
+			hit.Highlights = append(hit.Highlights, SearchHighlight{Field: field.name, Snippet: s})
+		}
+	}
+
+	if number, title, ok := matchingSection(script, re); ok {
+		hit.SectionNumber = &number
+		hit.SectionTitle = title
+	}
+	return hit
+}
+
+// searchHandler runs a full-text search over scripts (GET /search?q=&channel=&limit=)
+func (yt *YtAutomation) searchHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		respondWithError(w, http.StatusMethodNotAllowed, "Only GET method is allowed")
+		return
+	}
+
+	params := r.URL.Query()
+	q := strings.TrimSpace(params.Get("q"))
+	if q == "" {
+		respondWithError(w, http.StatusBadRequest, "q is required")
+		return
+	}
+	limit := defaultScriptPageSize
+	if value := params.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			respondWithError(w, http.StatusBadRequest, "limit must be a positive number")
+			return
+		}
+		if n > maxScriptPageSize {
+			n = maxScriptPageSize
+		}
+		limit = n
+	}
+
+	filter := bson.M{"$text": bson.M{"$search": q}}
+	if channels := splitList(params.Get("channel")); len(channels) > 0 {
+		filter["channel_name"] = bson.M{"$in": channels}
+	}
+
+	textScore := bson.M{"$meta": "textScore"}
+	cursor, err := scriptsCollection.Find(context.Background(), filter, options.Find().
+		SetProjection(bson.M{
+			"score":     textScore,
+			"srt":       0,
+			"long_form": 0,
+		}).
+		SetSort(bson.D{{"score", textScore}}).
+		SetLimit(int64(limit)))
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Search failed: %v", err))
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	var results []scoredScript
+	if err := cursor.All(context.Background(), &results); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error decoding scripts: %v", err))
+		return
+	}
+
+	re := termRegex(searchTerms(q))
+	hits := make([]SearchHit, 0, len(results))
+	for _, result := range results {
+		hits = append(hits, buildSearchHit(result, re))
+	}
+	// Mongo already sorted by score; keep ties stable by recency
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].CreatedAt.After(hits[j].CreatedAt)
+	})
+
+	respondWithJSON(w, http.StatusOK, SearchResponse{
+		Query: q,
+		Count: len(hits),
+		Hits:  hits,
+	})
+}
@@ -0,0 +1,168 @@
+// File: shutdown.go
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// errShuttingDown stops a job at a checkpoint once the server is draining. Work done so
+// far is kept, and the job's rows are marked StatusInterrupted so it can resume.
+var errShuttingDown = errors.New("server shutting down")
+
+// jobTracker follows the background jobs started by runJob so a shutdown can wait for them
+type jobTracker struct {
+	draining atomic.Bool
+	wg       sync.WaitGroup
+
+	mu      sync.Mutex
+	nextID  int
+	cancels map[int]context.CancelFunc
+}
+
+var backgroundJobs = &jobTracker{cancels: make(map[int]context.CancelFunc)}
+
+// start registers a job and returns its context, cancelled only when draining runs out
+// of time, and the func to call when the job returns
+func (t *jobTracker) start(ctx context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	t.mu.Lock()
+	id := t.nextID
+	t.nextID++
+	t.cancels[id] = cancel
+	t.mu.Unlock()
+	t.wg.Add(1)
+
+	return ctx, func() {
+		t.mu.Lock()
+		delete(t.cancels, id)
+		t.mu.Unlock()
+		cancel()
+		t.wg.Done()
+	}
+}
+
+func (t *jobTracker) running() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.cancels)
+}
+
+// wait returns false if the jobs have not all returned within timeout
+func (t *jobTracker) wait(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// drain makes running jobs stop at their next checkpoint and waits up to timeout for
+// them. Jobs still running then are cancelled and get shutdownCancelGrace to record
+// where they stopped.
+func (t *jobTracker) drain(timeout time.Duration) {
+	t.draining.Store(true)
+	slog.Info("Draining background jobs", "running", t.running(), "timeout", timeout)
+	if t.wait(timeout) {
+		slog.Info("Background jobs drained")
+		return
+	}
+
+	slog.Warn("Background jobs still running, cancelling", "running", t.running())
+	t.mu.Lock()
+	for _, cancel := range t.cancels {
+		cancel()
+	}
+	t.mu.Unlock()
+	if !t.wait(shutdownCancelGrace) {
+		slog.Error("Background jobs did not stop; their rows stay in progress", "running", t.running())
+	}
+}
+
+// shuttingDown reports whether jobs should stop at their next checkpoint
+func shuttingDown() bool {
+	return backgroundJobs.draining.Load()
+}
+
+// checkpoint is called between units of work that are saved on their own, e.g. script
+// sections or TTS chunks. It returns errShuttingDown while draining and ctx's error once
+// the job is cancelled.
+func checkpoint(ctx context.Context) error {
+	if shuttingDown() {
+		return errShuttingDown
+	}
+	return ctx.Err()
+}
+
+// interrupted reports whether err ended a job because of the shutdown, rather than a
+// real failure
+func interrupted(err error) bool {
+	return shuttingDown() && (errors.Is(err, errShuttingDown) || errors.Is(err, context.Canceled))
+}
+
+// sleepContext waits for d, returning early with ctx's error when ctx ends first
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// resumeInterruptedScripts restarts the text generation of scripts an earlier shutdown
+// interrupted. Each script is claimed with a status change first, so with several
+// instances only one resumes it.
+func (yt *YtAutomation) resumeInterruptedScripts() {
+	ctx := context.Background()
+	for {
+		var script Script
+		err := scriptsCollection.FindOneAndUpdate(ctx,
+			bson.M{"status": StatusInterrupted},
+			bson.M{"$set": bson.M{"status": StatusProcessing}},
+			options.FindOneAndUpdate().SetReturnDocument(options.After),
+		).Decode(&script)
+		if err == mongo.ErrNoDocuments {
+			return
+		}
+		if err != nil {
+			slog.Error("Failed to load interrupted scripts", "error", err)
+			return
+		}
+
+		channel, err := yt.getChannelByID(script.ChannelID)
+		if err != nil {
+			slog.Error("Cannot resume script, channel not found", logKeyScriptID, script.ID.Hex(), "error", err)
+			updateScriptStatus(script.ID, StatusFailed, "Resume failed: channel not found")
+			continue
+		}
+		config, err := createScriptConfig(&script, *channel)
+		if err != nil {
+			updateScriptStatus(script.ID, StatusFailed, "Resume failed: "+err.Error())
+			continue
+		}
+
+		slog.Info("Resuming interrupted script", logKeyScriptID, script.ID.Hex(),
+			"sections_generated", script.SectionsGenerated)
+		scriptID := script.ID
+		runJob(ctx, func(ctx context.Context) {
+			yt.processScriptGeneration(ctx, scriptID, config)
+		})
+	}
+}
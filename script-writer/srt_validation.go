@@ -9,7 +9,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
-	"log"
+	"log/slog"
 	"math"
 	"net/http"
 	"sort"
@@ -17,6 +17,12 @@ import (
 	"strings"
 )
 
+// SRTCoverageResponse lists the visual prompts covering a script's subtitles, with gaps recovered
+type SRTCoverageResponse struct {
+	Success bool                   `json:"success"`
+	Data    []VisualPromptResponse `json:"data"`
+}
+
 // SRTTimeRange represents a time range from SRT content
 type SRTTimeRange struct {
 	StartTime float64
@@ -44,13 +50,13 @@ func extractSRTTimeRanges(srtContent string) ([]SRTTimeRange, error) {
 				// Convert SRT time format (00:00:01,930) to seconds
 				startTime, err := srtTimeToSeconds(startStr)
 				if err != nil {
-					log.Printf("Warning: Failed to parse start time '%s': %v", startStr, err)
+					slog.Warn("Failed to parse SRT start time", "start_time", startStr, "error", err)
 					continue
 				}
 
 				endTime, err := srtTimeToSeconds(endStr)
 				if err != nil {
-					log.Printf("Warning: Failed to parse end time '%s': %v", endStr, err)
+					slog.Warn("Failed to parse SRT end time", "end_time", endStr, "error", err)
 					continue
 				}
 
@@ -101,11 +107,11 @@ func validateVisualPromptSequence(srtContent string, visualPrompts []VisualPromp
 	}
 
 	if len(srtRanges) == 0 {
-		log.Printf("Warning: No SRT time ranges found to validate against")
+		slog.Warn("No SRT time ranges found to validate against")
 		return visualPrompts, nil
 	}
 
-	log.Printf("Found %d SRT time ranges for validation", len(srtRanges))
+	slog.Debug("SRT time ranges for validation", "ranges", len(srtRanges))
 
 	// Sort SRT ranges by start time
 	sort.Slice(srtRanges, func(i, j int) bool {
@@ -139,10 +145,7 @@ func validateVisualPromptSequence(srtContent string, visualPrompts []VisualPromp
 
 	// Log invalid prompts
 	if len(invalidPrompts) > 0 {
-		log.Printf("Found %d invalid visual prompts:", len(invalidPrompts))
-		for _, invalid := range invalidPrompts {
-			log.Printf("  - %s", invalid)
-		}
+		slog.Warn("Found invalid visual prompts", "count", len(invalidPrompts), "invalid", invalidPrompts)
 	}
 
 	// Sort valid prompts by start time
@@ -181,8 +184,7 @@ func validateVisualPromptSequence(srtContent string, visualPrompts []VisualPromp
 		if covered {
 			coveredRanges++
 		} else {
-			log.Printf("Warning: SRT range %.2f-%.2f seconds not covered by any visual prompt",
-				srtRange.StartTime, srtRange.EndTime)
+			slog.Warn("SRT range not covered by any visual prompt", "range_start", srtRange.StartTime, "range_end", srtRange.EndTime)
 		}
 	}
 
@@ -192,8 +194,8 @@ func validateVisualPromptSequence(srtContent string, visualPrompts []VisualPromp
 		coveragePercent = (coveredDuration / totalSRTDuration) * 100
 	}
 
-	log.Printf("Visual prompt coverage: %d/%d ranges covered (%.1f%% time coverage)",
-		coveredRanges, len(srtRanges), coveragePercent)
+	slog.Info("Visual prompt coverage", "covered_ranges", coveredRanges, "ranges", len(srtRanges),
+		"time_coverage_percent", math.Round(coveragePercent*10)/10)
 
 	// Check for sequence gaps
 	gapCount := 0
@@ -203,13 +205,12 @@ func validateVisualPromptSequence(srtContent string, visualPrompts []VisualPromp
 
 		if currStart > prevEnd+0.5 { // Gap threshold: 0.5 seconds
 			gapCount++
-			log.Printf("Warning: Gap detected between prompts: %.2f to %.2f seconds (%.2f second gap)",
-				prevEnd, currStart, currStart-prevEnd)
+			slog.Warn("Gap between visual prompts", "gap_start", prevEnd, "gap_end", currStart, "seconds", currStart-prevEnd)
 		}
 	}
 
 	if gapCount > 0 {
-		log.Printf("Found %d gaps in visual prompt sequence", gapCount)
+		slog.Warn("Found gaps in visual prompt sequence", "gaps", gapCount)
 	}
 
 	return validPrompts, nil
@@ -217,45 +218,42 @@ func validateVisualPromptSequence(srtContent string, visualPrompts []VisualPromp
 
 // Add this test function to verify your SRT parsing works correctly
 func (yt *YtAutomation) testSRTValidation(scriptSrt ScriptSrt) ([]VisualPromptResponse, error) {
-	ctx := context.Background()
+	ctx := withScriptLog(context.Background(), scriptSrt.ScriptID)
 	// Get all visual prompts for the script
 	visualCursor, err := chunkVisualsCollection.Find(ctx, bson.M{"script_id": scriptSrt.ScriptID})
 	if err != nil {
-		fmt.Printf("failed to fetch visual prompts: %w", err)
+		slog.ErrorContext(ctx, "Failed to fetch visual prompts", "error", err)
 	}
 	defer visualCursor.Close(ctx)
 
 	var visualPrompts []VisualPromptResponse
 	if err = visualCursor.All(ctx, &visualPrompts); err != nil {
-		fmt.Printf("failed to decode visual prompts: %w", err)
+		slog.ErrorContext(ctx, "Failed to decode visual prompts", "error", err)
 	}
 	ranges, err := extractSRTTimeRanges(scriptSrt.Content)
 	if err != nil {
-		log.Printf("Error parsing SRT: %v", err)
+		slog.ErrorContext(ctx, "Error parsing SRT", "error", err)
 		return nil, fmt.Errorf("failed to extract SRT time ranges: %w", err)
 	}
 
-	log.Printf("Parsed %d SRT ranges:", len(ranges))
+	slog.DebugContext(ctx, "Parsed SRT ranges", "ranges", len(ranges))
 	for i, r := range ranges {
-		log.Printf("Range %d: %.3f - %.3f seconds", i+1, r.StartTime, r.EndTime)
+		slog.DebugContext(ctx, "SRT range", "range", i+1, "range_start", r.StartTime, "range_end", r.EndTime)
 	}
 
 	validated, err := validateVisualPromptSequence(scriptSrt.Content, visualPrompts)
 	if err != nil {
-		log.Printf("Validation error: %v", err)
+		slog.ErrorContext(ctx, "Visual prompt validation failed", "error", err)
 		return nil, fmt.Errorf("failed to validate visual prompts: %w", err)
 	}
 
-	log.Printf("Validation complete. Valid prompts: %d", len(validated))
+	slog.InfoContext(ctx, "Visual prompt validation complete", "valid_prompts", len(validated))
 	return validated, nil
 }
 
 // HTTP Handler for the missing SRT range check
 func (yt *YtAutomation) checkMissingSRTRangesHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
 
 	if r.Method == "OPTIONS" {
 		w.WriteHeader(http.StatusOK)
@@ -294,9 +292,9 @@ func (yt *YtAutomation) checkMissingSRTRangesHandler(w http.ResponseWriter, r *h
 	}
 
 	// Return detailed report
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"data":    report,
+	json.NewEncoder(w).Encode(SRTCoverageResponse{
+		Success: true,
+		Data:    report,
 	})
 }
 func detectGapsInSequence(srtRanges []SRTTimeRange, visualPrompts []VisualPromptResponse) ([]GapRecoveryRequest, error) {
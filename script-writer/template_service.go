@@ -9,7 +9,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
-	"log"
+	"log/slog"
 	"net/http"
 	"sort"
 	"strings"
@@ -175,7 +175,106 @@ func (t *TemplateService) BuildSectionPrompt(script *Script, sectionNumber int,
 	return t.BuildDynamicPrompt(script.ChannelID, "section", variables)
 }
 
+// BuildLongFormOutlinePrompt asks for the act and chapter level outline of a long-form script.
+// Section level outlines are requested per chapter with BuildLongFormChapterPrompt.
+func (t *TemplateService) BuildLongFormOutlinePrompt(script *Script) (string, string, error) {
+	plan := script.LongForm
+
+	var structure strings.Builder
+	chapterCount := 0
+	for _, act := range plan.Acts {
+		structure.WriteString(fmt.Sprintf("Act %d: %d chapters\n", act.ActNumber, len(act.Chapters)))
+		chapterCount += len(act.Chapters)
+	}
+
+	variables := map[string]string{
+		"{TOPIC}":          script.Topic,
+		"{TARGET_MINUTES}": fmt.Sprintf("%d", plan.TargetMinutes),
+		"{ACT_COUNT}":      fmt.Sprintf("%d", len(plan.Acts)),
+		"{CHAPTER_COUNT}":  fmt.Sprintf("%d", chapterCount),
+		"{ACT_STRUCTURE}":  strings.TrimSpace(structure.String()),
+	}
+	return t.BuildDynamicPrompt(script.ChannelID, "long_form_outline", variables)
+}
+
+func (t *TemplateService) BuildLongFormChapterPrompt(script *Script, act OutlineAct, chapter OutlineChapter) (string, string, error) {
+	variables := map[string]string{
+		"{TOPIC}":           script.Topic,
+		"{OUTLINE}":         script.Outline,
+		"{ACT_TITLE}":       act.Title,
+		"{ACT_SUMMARY}":     act.Summary,
+		"{CHAPTER_TITLE}":   chapter.Title,
+		"{CHAPTER_SUMMARY}": chapter.Summary,
+		"{SECTION_COUNT}":   fmt.Sprintf("%d", chapter.SectionCount),
+	}
+	return t.BuildDynamicPrompt(script.ChannelID, "long_form_chapter", variables)
+}
+
+// BuildLongFormSectionPrompt replaces the full outline with the rolling summary of everything
+// written so far, so the prompt size stays flat no matter how long the script gets.
+func (t *TemplateService) BuildLongFormSectionPrompt(script *Script, point OutlinePoint, act OutlineAct, chapter OutlineChapter, wordLimit int) (string, string, error) {
+	rollingSummary := script.RollingSummary
+	if rollingSummary == "" {
+		rollingSummary = "Nothing has been narrated yet besides the hook and introduction."
+	}
+
+	variables := map[string]string{
+		"{TOPIC}":           script.Topic,
+		"{SECTION_NUMBER}":  fmt.Sprintf("%d", point.SectionNumber),
+		"{TOTAL_SECTIONS}":  fmt.Sprintf("%d", len(script.OutlinePoints)),
+		"{OUTLINE_POINT}":   point.Title,
+		"{SECTION_SUMMARY}": point.Summary,
+		"{ACT_TITLE}":       act.Title,
+		"{CHAPTER_TITLE}":   chapter.Title,
+		"{CHAPTER_SUMMARY}": chapter.Summary,
+		"{ROLLING_SUMMARY}": rollingSummary,
+		"{WORD_LIMIT}":      fmt.Sprintf("%d", wordLimit),
+	}
+	return t.BuildDynamicPrompt(script.ChannelID, "long_form_section", variables)
+}
+
+func (t *TemplateService) BuildRollingSummaryPrompt(script *Script, previousSummary, newContent string, wordLimit int) (string, string, error) {
+	variables := map[string]string{
+		"{TOPIC}":            script.Topic,
+		"{PREVIOUS_SUMMARY}": previousSummary,
+		"{NEW_CONTENT}":      newContent,
+		"{WORD_LIMIT}":       fmt.Sprintf("%d", wordLimit),
+	}
+	return t.BuildDynamicPrompt(script.ChannelID, "rolling_summary", variables)
+}
+
 // Replace existing BuildVisualGuidancePrompt method
+// BuildThumbnailImagePrompt asks for an image prompt for a dedicated thumbnail background
+func (t *TemplateService) BuildThumbnailImagePrompt(script *Script) (string, string, error) {
+	variables := map[string]string{
+		"{TOPIC}":          script.Topic,
+		"{TITLE}":          script.Meta.Title,
+		"{THUMBNAIL_TEXT}": script.Meta.ThumbnailText,
+		"{OUTLINE}":        script.Outline,
+	}
+	return t.BuildDynamicPrompt(script.ChannelID, "thumbnail_image", variables)
+}
+
+// BuildTopicIdeasPrompt asks for count new video topics for the channel's niche, avoiding past topics
+func (t *TemplateService) BuildTopicIdeasPrompt(channel Channel, count int, pastTopics []string) (string, string, error) {
+	niche := channel.Settings.NicheDescription
+	if niche == "" {
+		niche = channel.ChannelName
+	}
+	past := "None"
+	if len(pastTopics) > 0 {
+		past = "- " + strings.Join(pastTopics, "\n- ")
+	}
+
+	variables := map[string]string{
+		"{CHANNEL_NAME}": channel.ChannelName,
+		"{NICHE}":        niche,
+		"{COUNT}":        fmt.Sprintf("%d", count),
+		"{PAST_TOPICS}":  past,
+	}
+	return t.BuildDynamicPrompt(channel.ID, "topic_ideas", variables)
+}
+
 func (t *TemplateService) BuildVisualGuidancePrompt(script *Script, sectionCount int, visualImageMultiplier int) (string, string, error) {
 	variables := map[string]string{
 		"{TOPIC}":                   script.Topic,
@@ -186,11 +285,11 @@ func (t *TemplateService) BuildVisualGuidancePrompt(script *Script, sectionCount
 
 	return t.BuildDynamicPrompt(script.ChannelID, "visual_guidance", variables)
 }
-func (yt *YtAutomation) generateVisualPrompts(srtContent string, script *Script, styleID primitive.ObjectID) ([]VisualPromptResponse, error) {
-	return yt.generateVisualPromptsWithStyle(srtContent, script, styleID)
+func (yt *YtAutomation) generateVisualPrompts(ctx context.Context, srtContent string, script *Script, styleID primitive.ObjectID) ([]VisualPromptResponse, error) {
+	return yt.generateVisualPromptsWithStyle(ctx, srtContent, script, styleID)
 }
 
-func (yt *YtAutomation) generateVisualPromptsWithStyle(srtContent string, script *Script, styleID primitive.ObjectID) ([]VisualPromptResponse, error) {
+func (yt *YtAutomation) generateVisualPromptsWithStyle(ctx context.Context, srtContent string, script *Script, styleID primitive.ObjectID) ([]VisualPromptResponse, error) {
 	templateService := NewTemplateService()
 	variables := map[string]string{
 		"{SRT_CONTENT}": srtContent,
@@ -222,7 +321,7 @@ CRITICAL JSON RESPONSE REQUIREMENTS:
 - Ensure all JSON is properly formatted and valid`
 
 	// Use the enhanced system prompt
-	response, err := yt.aiService.GenerateContentWithSystem(enhancedSystemPrompt, userPrompt)
+	response, err := yt.aiService.GenerateContentWithSystem(ctx, enhancedSystemPrompt, userPrompt)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate visual prompts: %w", err)
 	}
@@ -231,26 +330,24 @@ CRITICAL JSON RESPONSE REQUIREMENTS:
 	cleanResponse := cleanJSONResponse(response)
 
 	// Log for debugging
-	log.Printf("Original response length: %d", len(response))
-	log.Printf("Cleaned response length: %d", len(cleanResponse))
-	log.Printf("First 100 chars of cleaned response: %s", truncateString(cleanResponse, 100))
+	slog.DebugContext(ctx, "Visual prompts response", "length", len(response), "cleaned_length", len(cleanResponse),
+		"cleaned_start", truncateString(cleanResponse, 100))
 
 	// Additional JSON formatting fixes
 	cleanResponse = fixJSONFormatting(cleanResponse)
 
 	var visualPrompts []VisualPromptResponse
 	if err := json.Unmarshal([]byte(cleanResponse), &visualPrompts); err != nil {
-		log.Printf("JSON parsing error: %v", err)
-		log.Printf("Problematic response (first 500 chars): %s", truncateString(cleanResponse, 500))
+		slog.WarnContext(ctx, "Visual prompts JSON parsing failed", "error", err, "response_start", truncateString(cleanResponse, 500))
 
 		// Try one more cleaning attempt - look for JSON array in the middle of text
 		if jsonStart := strings.Index(cleanResponse, "[{"); jsonStart != -1 {
 			if jsonEnd := strings.LastIndex(cleanResponse, "}]"); jsonEnd != -1 && jsonEnd > jsonStart {
 				fallbackJSON := cleanResponse[jsonStart : jsonEnd+2]
-				log.Printf("Attempting fallback JSON extraction: %s", truncateString(fallbackJSON, 200))
+				slog.DebugContext(ctx, "Attempting fallback JSON extraction", "json_start", truncateString(fallbackJSON, 200))
 
 				if err := json.Unmarshal([]byte(fallbackJSON), &visualPrompts); err == nil {
-					log.Printf("Fallback JSON parsing successful!")
+					slog.InfoContext(ctx, "Fallback JSON extraction succeeded")
 					return visualPrompts, nil
 				}
 			}
@@ -335,22 +432,23 @@ CRITICAL: Return ONLY the JSON array above. No explanations, no markdown, no add
 
 	return systemPrompt, userPrompt, nil
 }
-func (yt *YtAutomation) generateGapRecoveryPrompts(gaps []GapRecoveryRequest, script *Script, styleID primitive.ObjectID) ([]VisualPromptResponse, error) {
+func (yt *YtAutomation) generateGapRecoveryPrompts(ctx context.Context, gaps []GapRecoveryRequest, script *Script, styleID primitive.ObjectID) ([]VisualPromptResponse, error) {
 	var recoveryPrompts []VisualPromptResponse
 	templateService := NewTemplateService()
 
 	for _, gap := range gaps {
-		log.Printf("Generating recovery prompts for gap: %.2f-%.2f seconds", gap.StartTime, gap.EndTime)
+		ctx := withLogAttrs(ctx, slog.Float64("gap_start", gap.StartTime), slog.Float64("gap_end", gap.EndTime))
+		slog.InfoContext(ctx, "Generating recovery prompts for gap")
 
 		systemPrompt, userPrompt, err := templateService.buildGapRecoveryPrompt(script.ChannelID, styleID, gap)
 		if err != nil {
-			log.Printf("Failed to build gap recovery prompt: %v", err)
+			slog.WarnContext(ctx, "Failed to build gap recovery prompt", "error", err)
 			continue
 		}
 
-		response, err := yt.aiService.GenerateContentWithSystem(systemPrompt, userPrompt)
+		response, err := yt.aiService.GenerateContentWithSystem(ctx, systemPrompt, userPrompt)
 		if err != nil {
-			log.Printf("Failed to generate gap recovery prompts: %v", err)
+			slog.WarnContext(ctx, "Failed to generate gap recovery prompts", "error", err)
 			continue
 		}
 
@@ -369,19 +467,19 @@ func (yt *YtAutomation) generateGapRecoveryPrompts(gaps []GapRecoveryRequest, sc
 
 		var gapPrompts []VisualPromptResponse
 		if err := json.Unmarshal([]byte(cleanResponse), &gapPrompts); err != nil {
-			log.Printf("Failed to parse gap recovery JSON: %v\nResponse: %s", err, cleanResponse)
+			slog.WarnContext(ctx, "Failed to parse gap recovery JSON", "error", err, "response", cleanResponse)
 			continue
 		}
 
 		recoveryPrompts = append(recoveryPrompts, gapPrompts...)
-		log.Printf("Generated %d recovery prompts for gap", len(gapPrompts))
+		slog.InfoContext(ctx, "Generated recovery prompts for gap", "count", len(gapPrompts))
 	}
 
 	return recoveryPrompts, nil
 }
 
 // Enhanced validation function that also performs gap recovery
-func (yt *YtAutomation) validateAndRecoverVisualPrompts1(srtContent string, script *Script, styleID primitive.ObjectID, existingPrompts []VisualPromptResponse) ([]VisualPromptResponse, error) {
+func (yt *YtAutomation) validateAndRecoverVisualPrompts1(ctx context.Context, srtContent string, script *Script, styleID primitive.ObjectID, existingPrompts []VisualPromptResponse) ([]VisualPromptResponse, error) {
 	// Extract SRT time ranges
 	srtRanges, err := extractSRTTimeRanges(srtContent)
 	if err != nil {
@@ -395,16 +493,16 @@ func (yt *YtAutomation) validateAndRecoverVisualPrompts1(srtContent string, scri
 	}
 
 	if len(gaps) == 0 {
-		log.Printf("No gaps detected in visual prompt sequence")
+		slog.InfoContext(ctx, "No gaps detected in visual prompt sequence")
 		return existingPrompts, nil
 	}
 
-	log.Printf("Detected %d gaps, initiating recovery process", len(gaps))
+	slog.InfoContext(ctx, "Detected gaps in visual prompts, recovering", "gaps", len(gaps))
 
 	// Generate recovery prompts
-	recoveryPrompts, err := yt.generateGapRecoveryPrompts(gaps, script, styleID)
+	recoveryPrompts, err := yt.generateGapRecoveryPrompts(ctx, gaps, script, styleID)
 	if err != nil {
-		log.Printf("Gap recovery failed: %v", err)
+		slog.WarnContext(ctx, "Gap recovery failed", "error", err)
 		return existingPrompts, nil // Return original prompts if recovery fails
 	}
 
@@ -418,28 +516,21 @@ func (yt *YtAutomation) validateAndRecoverVisualPrompts1(srtContent string, scri
 		return startI < startJ
 	})
 
-	log.Printf("Gap recovery complete. Total prompts: %d (original: %d, recovered: %d)",
-		len(allPrompts), len(existingPrompts), len(recoveryPrompts))
+	slog.InfoContext(ctx, "Gap recovery complete", "total", len(allPrompts),
+		"original", len(existingPrompts), "recovered", len(recoveryPrompts))
 
 	return allPrompts, nil
 }
 
 func (yt *YtAutomation) generateVisualPromptsWithStyleHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
 
 	if r.Method == "OPTIONS" {
 		w.WriteHeader(http.StatusOK)
 		return
 	}
 
-	var req struct {
-		ScriptID string `json:"script_id"`
-		StyleID  string `json:"style_id"`
-		Force    bool   `json:"force,omitempty"`
-	}
+	var req VisualPromptsRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respondWithError(w, http.StatusBadRequest, "Invalid JSON")
@@ -499,26 +590,22 @@ func (yt *YtAutomation) generateVisualPromptsWithStyleHandler(w http.ResponseWri
 			return
 		}
 	}
-	go func() {
-		if err := yt.generateVisualPromptForChunksWithRecovery(scriptID, scriptSrtChunks, styleID, req.Force); err != nil {
-			fmt.Printf("Warning: Failed to generate visuals for chunks: %v\n", err)
+	runJob(r.Context(), func(ctx context.Context) {
+		ctx = withScriptLog(ctx, scriptID)
+		if err := yt.generateVisualPromptForChunksWithRecovery(ctx, scriptID, scriptSrtChunks, styleID, req.Force); interrupted(err) {
+			slog.InfoContext(ctx, "Visual prompt generation interrupted by shutdown, run again to resume")
+		} else if err != nil {
+			slog.ErrorContext(ctx, "Failed to generate visual prompts for chunks", "error", err)
 		}
-	}()
-
-	data := map[string]interface{}{
-		"script_id": scriptID,
-	}
+	})
 
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Visual Prompt generation InProgress",
-		"data":    data,
+	json.NewEncoder(w).Encode(StepResponse{
+		Message: "Visual Prompt generation InProgress",
+		Data:    StepData{ScriptID: scriptID.Hex()},
 	})
 }
 func (yt *YtAutomation) createPromptTemplateHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
 
 	if r.Method == "OPTIONS" {
 		w.WriteHeader(http.StatusOK)
@@ -575,15 +662,14 @@ func (yt *YtAutomation) createPromptTemplateHandler(w http.ResponseWriter, r *ht
 
 	template.ID = result.InsertedID.(primitive.ObjectID)
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"data":    template,
+	json.NewEncoder(w).Encode(PromptTemplateResponse{
+		Success: true,
+		Data:    template,
 	})
 }
 
 func (yt *YtAutomation) getPromptTemplatesHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
 
 	channelID := r.URL.Query().Get("channel_id")
 	templateType := r.URL.Query().Get("type")
@@ -619,16 +705,13 @@ func (yt *YtAutomation) getPromptTemplatesHandler(w http.ResponseWriter, r *http
 	var templates []PromptTemplate
 	cursor.All(context.Background(), &templates)
 
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"data":    templates,
+	json.NewEncoder(w).Encode(PromptTemplateListResponse{
+		Success: true,
+		Data:    templates,
 	})
 }
 func (yt *YtAutomation) createVisualStyleHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
 
 	if r.Method == "OPTIONS" {
 		w.WriteHeader(http.StatusOK)
@@ -660,15 +743,14 @@ func (yt *YtAutomation) createVisualStyleHandler(w http.ResponseWriter, r *http.
 
 	style.ID = result.InsertedID.(primitive.ObjectID)
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"data":    style,
+	json.NewEncoder(w).Encode(VisualStyleResponse{
+		Success: true,
+		Data:    style,
 	})
 }
 
 func (yt *YtAutomation) getVisualStylesHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
 
 	category := r.URL.Query().Get("category")
 	filter := bson.M{"is_active": true}
@@ -689,9 +771,9 @@ func (yt *YtAutomation) getVisualStylesHandler(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"success": true,
-		"data":    styles,
+	json.NewEncoder(w).Encode(VisualStyleListResponse{
+		Success: true,
+		Data:    styles,
 	})
 }
 
@@ -0,0 +1,586 @@
+// File: thumbnail.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"youtube_automation/tracing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var thumbnailPositions = []string{"bottom", "top", "center", "left", "right"}
+
+type ThumbnailRequest struct {
+	Variants  int              `json:"variants"`
+	Dedicated bool             `json:"dedicated"`        // Generate a dedicated background image instead of reusing chunk visuals
+	Text      string           `json:"text,omitempty"`   // Overrides meta.thumbnail_text
+	Layout    *ThumbnailLayout `json:"layout,omitempty"` // Overrides the channel layout for this run
+}
+
+type ThumbnailsResponse struct {
+	Message string         `json:"message"`
+	Data    ThumbnailsData `json:"data"`
+}
+
+type ThumbnailsData struct {
+	ScriptID   string      `json:"script_id"`
+	Thumbnails []Thumbnail `json:"thumbnails"`
+}
+
+type ThumbnailListResponse struct {
+	ScriptID      string      `json:"script_id"`
+	ThumbnailText string      `json:"thumbnail_text"`
+	Thumbnails    []Thumbnail `json:"thumbnails"`
+}
+
+func defaultThumbnailLayout() ThumbnailLayout {
+	return ThumbnailLayout{
+		FontFile:          appConfig().Thumbnails.FontFile,
+		FontSize:          110,
+		FontColor:         "white",
+		StrokeColor:       "black",
+		StrokeWidth:       6,
+		Position:          "bottom",
+		Uppercase:         ptr(true),
+		MaxCharsPerLine:   18,
+		GradientColor:     "#000000",
+		GradientOpacity:   ptr(0.75),
+		GradientDirection: "bottom",
+	}
+}
+
+// resolveThumbnailLayout fills unset fields of layout from the defaults
+func resolveThumbnailLayout(layout *ThumbnailLayout) ThumbnailLayout {
+	resolved := defaultThumbnailLayout()
+	if layout == nil {
+		return resolved
+	}
+
+	if layout.FontFile != "" {
+		resolved.FontFile = layout.FontFile
+	}
+	if layout.FontSize > 0 {
+		resolved.FontSize = layout.FontSize
+	}
+	if layout.FontColor != "" {
+		resolved.FontColor = layout.FontColor
+	}
+	if layout.StrokeColor != "" {
+		resolved.StrokeColor = layout.StrokeColor
+	}
+	if layout.StrokeWidth > 0 {
+		resolved.StrokeWidth = layout.StrokeWidth
+	}
+	if layout.Position != "" {
+		resolved.Position = layout.Position
+	}
+	if layout.MaxCharsPerLine > 0 {
+		resolved.MaxCharsPerLine = layout.MaxCharsPerLine
+	}
+	if layout.GradientColor != "" {
+		resolved.GradientColor = layout.GradientColor
+	}
+	if layout.GradientDirection != "" {
+		resolved.GradientDirection = layout.GradientDirection
+	}
+	if layout.Uppercase != nil {
+		resolved.Uppercase = ptr(*layout.Uppercase)
+	}
+	if layout.GradientOpacity != nil {
+		resolved.GradientOpacity = ptr(*layout.GradientOpacity)
+	}
+	return resolved
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// uppercase and gradientOpacity read the optional fields of a resolved layout
+func (l ThumbnailLayout) uppercase() bool {
+	return l.Uppercase != nil && *l.Uppercase
+}
+
+func (l ThumbnailLayout) gradientOpacity() float64 {
+	if l.GradientOpacity == nil {
+		return 0
+	}
+	return *l.GradientOpacity
+}
+
+func validateThumbnailLayout(layout ThumbnailLayout) error {
+	if !containsString(thumbnailPositions, layout.Position) {
+		return fmt.Errorf("position must be one of %s", strings.Join(thumbnailPositions, ", "))
+	}
+	if !containsString([]string{"top", "bottom", "left", "right"}, layout.GradientDirection) {
+		return fmt.Errorf("gradient_direction must be top, bottom, left or right")
+	}
+	if opacity := layout.gradientOpacity(); opacity < 0 || opacity > 1 {
+		return fmt.Errorf("gradient_opacity must be between 0 and 1")
+	}
+	if _, err := parseHexColor(layout.GradientColor); err != nil {
+		return err
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func parseHexColor(hex string) (color.NRGBA, error) {
+	hex = strings.TrimPrefix(hex, "#")
+	if len(hex) != 6 {
+		return color.NRGBA{}, fmt.Errorf("gradient_color must be #RRGGBB")
+	}
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("gradient_color must be #RRGGBB")
+	}
+	return color.NRGBA{R: uint8(value >> 16), G: uint8(value >> 8), B: uint8(value), A: 255}, nil
+}
+
+// wrapThumbnailText breaks text into lines of at most maxChars characters
+func wrapThumbnailText(text string, maxChars int) string {
+	var lines []string
+	var line string
+	for _, word := range strings.Fields(text) {
+		if line != "" && len(line)+1+len(word) > maxChars {
+			lines = append(lines, line)
+			line = word
+			continue
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// writeGradientPNG renders a full-size overlay that fades from transparent to the layout's
+// gradient color at the chosen edge
+func writeGradientPNG(path string, layout ThumbnailLayout) error {
+	base, err := parseHexColor(layout.GradientColor)
+	if err != nil {
+		return err
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, thumbnailWidth, thumbnailHeight))
+	for y := 0; y < thumbnailHeight; y++ {
+		for x := 0; x < thumbnailWidth; x++ {
+			// t is 0 at the far side and 1 at the darkened edge
+			var t float64
+			switch layout.GradientDirection {
+			case "top":
+				t = 1 - float64(y)/float64(thumbnailHeight)
+			case "left":
+				t = 1 - float64(x)/float64(thumbnailWidth)
+			case "right":
+				t = float64(x) / float64(thumbnailWidth)
+			default:
+				t = float64(y) / float64(thumbnailHeight)
+			}
+			// Keep the far 40% of the image untouched
+			t = (t - 0.4) / 0.6
+			if t < 0 {
+				t = 0
+			}
+			c := base
+			c.A = uint8(255 * layout.gradientOpacity() * t)
+			img.SetNRGBA(x, y, c)
+		}
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return png.Encode(file, img)
+}
+
+// escapeFilterValue escapes a value for use inside an ffmpeg filter option
+func escapeFilterValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `:`, `\:`, `'`, `\'`, `,`, `\,`).Replace(value)
+}
+
+func thumbnailTextPosition(position string) (string, string) {
+	switch position {
+	case "top":
+		return "(w-text_w)/2", "h*0.08"
+	case "center":
+		return "(w-text_w)/2", "(h-text_h)/2"
+	case "left":
+		return "w*0.06", "(h-text_h)/2"
+	case "right":
+		return "w-text_w-w*0.06", "(h-text_h)/2"
+	default:
+		return "(w-text_w)/2", "h-text_h-h*0.08"
+	}
+}
+
+// renderThumbnail crops the source image to 1280x720, lays the gradient over it and
+// draws the text with ffmpeg
+func renderThumbnail(sourceImage, text string, layout ThumbnailLayout, outputPath string) error {
+	workDir, err := os.MkdirTemp("", "thumbnail-")
+	if err != nil {
+		return fmt.Errorf("creating temp dir: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	if layout.uppercase() {
+		text = strings.ToUpper(text)
+	}
+	textFile := filepath.Join(workDir, "text.txt")
+	if err := os.WriteFile(textFile, []byte(wrapThumbnailText(text, layout.MaxCharsPerLine)), 0644); err != nil {
+		return fmt.Errorf("writing text file: %w", err)
+	}
+
+	x, y := thumbnailTextPosition(layout.Position)
+	drawtext := fmt.Sprintf("drawtext=textfile=%s:fontsize=%d:fontcolor=%s:borderw=%d:bordercolor=%s:line_spacing=%d:x=%s:y=%s",
+		escapeFilterValue(textFile), layout.FontSize, escapeFilterValue(layout.FontColor),
+		layout.StrokeWidth, escapeFilterValue(layout.StrokeColor), layout.FontSize/8, x, y)
+	if layout.FontFile != "" {
+		drawtext += fmt.Sprintf(":fontfile=%s", escapeFilterValue(layout.FontFile))
+	}
+
+	background := fmt.Sprintf("[0:v]scale=%d:%d:force_original_aspect_ratio=increase,crop=%d:%d,setsar=1",
+		thumbnailWidth, thumbnailHeight, thumbnailWidth, thumbnailHeight)
+	args := []string{"-y", "-i", sourceImage}
+	var filter string
+	if layout.gradientOpacity() > 0 {
+		gradientFile := filepath.Join(workDir, "gradient.png")
+		if err := writeGradientPNG(gradientFile, layout); err != nil {
+			return fmt.Errorf("rendering gradient: %w", err)
+		}
+		args = append(args, "-i", gradientFile)
+		filter = background + "[bg];[bg][1:v]overlay=0:0," + drawtext
+	} else {
+		filter = background + "," + drawtext
+	}
+	args = append(args, "-filter_complex", filter, "-frames:v", "1", "-q:v", "2", outputPath)
+
+	cmd := exec.Command("ffmpeg", args...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("ffmpeg failed: %v\nOutput: %s", err, string(output))
+	}
+	return nil
+}
+
+// selectThumbnailImages picks up to count generated chunk images spread evenly over the video
+func selectThumbnailImages(scriptID primitive.ObjectID, count int) ([]string, error) {
+	cursor, err := chunkVisualsCollection.Find(
+		context.Background(),
+		bson.M{"script_id": scriptID, "status": "completed", "image_path": bson.M{"$nin": []interface{}{"", nil}}},
+		options.Find().SetSort(bson.D{{"chunk_index", 1}, {"prompt_index", 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var visuals []ChunkVisual
+	if err := cursor.All(context.Background(), &visuals); err != nil {
+		return nil, err
+	}
+
+	var images []string
+	for _, visual := range visuals {
+		if _, err := os.Stat(visual.ImagePath); err == nil {
+			images = append(images, visual.ImagePath)
+		}
+	}
+	if len(images) <= count {
+		return images, nil
+	}
+
+	selected := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		selected = append(selected, images[i*len(images)/count])
+	}
+	return selected, nil
+}
+
+// generateThumbnailImage asks the LLM for a background prompt and renders it with the image tool
+func (yt *YtAutomation) generateThumbnailImage(ctx context.Context, script *Script) (string, error) {
+	systemPrompt, userPrompt, err := yt.templateService.BuildThumbnailImagePrompt(script)
+	if err != nil {
+		return "", fmt.Errorf("building thumbnail image prompt: %w", err)
+	}
+	response, err := yt.aiService.GenerateContentWithSystem(ctx, systemPrompt, userPrompt)
+	if err != nil {
+		return "", fmt.Errorf("generating thumbnail image prompt: %w", err)
+	}
+	prompt, err := yt.outlineParser.ParseThumbnailPromptJSON(response)
+	if err != nil {
+		return "", err
+	}
+
+	jobOptions := map[string]interface{}{
+		"imageModel":  appConfig().Images.ImageModel,
+		"aspectRatio": appConfig().Images.AspectRatio,
+		"prompt":      prompt,
+		"seed":        yt.GenerateSeed(),
+	}
+	var payload interface{}
+	if appConfig().Images.Tool == "imagefx" {
+		payload = CreateImageFXPayload(jobOptions)
+	} else {
+		payload = CreateWhiskPayload(jobOptions)
+	}
+
+	apiResponse, err := yt.MakeRequestWithRetry(ctx, payload, 2)
+	if err != nil {
+		return "", fmt.Errorf("generating thumbnail image: %w", err)
+	}
+	for _, panel := range apiResponse.ImagePanels {
+		for _, img := range panel.GeneratedImages {
+			return yt.SaveImage(img.EncodedImage, fmt.Sprintf("thumbnail_%s_seed_%d.jpg", script.ID.Hex(), img.Seed))
+		}
+	}
+	return "", fmt.Errorf("image tool returned no images")
+}
+
+// GenerateThumbnails renders variants of the script's thumbnail and stores them on the script
+func (yt *YtAutomation) GenerateThumbnails(ctx context.Context, script *Script, req ThumbnailRequest) ([]Thumbnail, error) {
+	text := strings.TrimSpace(req.Text)
+	if text == "" {
+		text = strings.TrimSpace(script.Meta.ThumbnailText)
+	}
+	if text == "" {
+		return nil, fmt.Errorf("script has no thumbnail text")
+	}
+
+	channel, err := yt.getChannelByID(script.ChannelID)
+	if err != nil {
+		return nil, fmt.Errorf("loading channel: %w", err)
+	}
+	layoutSource := channel.Settings.ThumbnailLayout
+	if req.Layout != nil {
+		layoutSource = req.Layout
+	}
+	layout := resolveThumbnailLayout(layoutSource)
+	if err := validateThumbnailLayout(layout); err != nil {
+		return nil, fmt.Errorf("invalid thumbnail layout: %w", err)
+	}
+
+	var images []string
+	if !req.Dedicated {
+		if images, err = selectThumbnailImages(script.ID, req.Variants); err != nil {
+			return nil, fmt.Errorf("loading chunk visuals: %w", err)
+		}
+	}
+	if len(images) == 0 {
+		slog.InfoContext(ctx, "Generating a dedicated thumbnail image", logKeyScriptID, script.ID.Hex())
+		imagePath, err := yt.generateThumbnailImage(ctx, script)
+		if err != nil {
+			return nil, err
+		}
+		images = []string{imagePath}
+	}
+
+	outputDir := filepath.Join(appConfig().Thumbnails.OutputDir, script.ID.Hex())
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, fmt.Errorf("creating thumbnail directory: %w", err)
+	}
+
+	// With fewer images than variants, later variants move the text around instead
+	positions := []string{layout.Position}
+	for _, position := range thumbnailPositions {
+		if position != layout.Position {
+			positions = append(positions, position)
+		}
+	}
+
+	var thumbnails []Thumbnail
+	for i := 0; i < req.Variants; i++ {
+		variantLayout := layout
+		variantLayout.Position = positions[(i/len(images))%len(positions)]
+		source := images[i%len(images)]
+		outputPath := filepath.Join(outputDir, fmt.Sprintf("variant_%d.jpg", i+1))
+
+		if err := renderThumbnail(source, text, variantLayout, outputPath); err != nil {
+			return nil, fmt.Errorf("rendering variant %d: %w", i+1, err)
+		}
+		thumbnails = append(thumbnails, Thumbnail{
+			Variant:     i + 1,
+			Path:        outputPath,
+			SourceImage: source,
+			Position:    variantLayout.Position,
+			CreatedAt:   time.Now(),
+		})
+		slog.InfoContext(ctx, "Thumbnail variant saved", logKeyScriptID, script.ID.Hex(), "variant", i+1, "path", outputPath)
+	}
+
+	if err := yt.updateScriptInDB(script.ID, bson.M{"thumbnails": thumbnails}); err != nil {
+		return nil, fmt.Errorf("saving thumbnails: %w", err)
+	}
+	return thumbnails, nil
+}
+
+func (yt *YtAutomation) generateThumbnailsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed. Use POST.")
+		return
+	}
+
+	// Extract script ID from URL path (/generate-thumbnails/{scriptID})
+	path := strings.TrimPrefix(r.URL.Path, "/generate-thumbnails/")
+	objectID, err := primitive.ObjectIDFromHex(path)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid script ID format")
+		return
+	}
+
+	var req ThumbnailRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid JSON request body")
+			return
+		}
+	}
+	if req.Variants == 0 {
+		req.Variants = defaultThumbnailVariants
+	}
+	if req.Variants < 1 || req.Variants > maxThumbnailVariants {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("variants must be between 1 and %d", maxThumbnailVariants))
+		return
+	}
+
+	script, err := yt.getScriptByID(objectID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			respondWithError(w, http.StatusNotFound, "Script not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Database error: %v", err))
+		return
+	}
+
+	thumbnails, err := yt.GenerateThumbnails(tracing.Detach(r.Context()), script, req)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to generate thumbnails: %v", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, ThumbnailsResponse{
+		Message: "Thumbnail generation completed",
+		Data:    ThumbnailsData{ScriptID: path, Thumbnails: thumbnails},
+	})
+}
+
+// thumbnailsHandler serves GET /thumbnails/{scriptID} (list) and /thumbnails/{scriptID}/{variant} (JPEG)
+func (yt *YtAutomation) thumbnailsHandler(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/thumbnails/"), "/"), "/")
+	objectID, err := primitive.ObjectIDFromHex(parts[0])
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		respondWithError(w, http.StatusBadRequest, "Invalid script ID format")
+		return
+	}
+
+	script, err := yt.getScriptByID(objectID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		if err == mongo.ErrNoDocuments {
+			respondWithError(w, http.StatusNotFound, "Script not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Database error: %v", err))
+		return
+	}
+
+	if len(parts) == 1 {
+		respondWithJSON(w, http.StatusOK, ThumbnailListResponse{
+			ScriptID:      parts[0],
+			ThumbnailText: script.Meta.ThumbnailText,
+			Thumbnails:    script.Thumbnails,
+		})
+		return
+	}
+
+	variant, err := strconv.Atoi(strings.TrimSuffix(parts[1], ".jpg"))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		respondWithError(w, http.StatusBadRequest, "Invalid variant number")
+		return
+	}
+	for _, thumbnail := range script.Thumbnails {
+		if thumbnail.Variant == variant {
+			w.Header().Set("Content-Type", "image/jpeg")
+			http.ServeFile(w, r, thumbnail.Path)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	respondWithError(w, http.StatusNotFound, "Thumbnail variant not found")
+}
+
+// channelThumbnailLayoutHandler serves GET/PUT /channels/{name}/thumbnail-layout
+func (yt *YtAutomation) channelThumbnailLayoutHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	channelName := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/channels/"), "/thumbnail-layout")
+	channel, err := yt.getChannelByName(channelName)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			respondWithError(w, http.StatusNotFound, "Channel not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Database error: %v", err))
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		respondWithJSON(w, http.StatusOK, resolveThumbnailLayout(channel.Settings.ThumbnailLayout))
+	case "PUT":
+		var layout ThumbnailLayout
+		if err := json.NewDecoder(r.Body).Decode(&layout); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid JSON request body")
+			return
+		}
+		resolved := resolveThumbnailLayout(&layout)
+		if err := validateThumbnailLayout(resolved); err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		_, err := channelsCollection.UpdateOne(
+			context.Background(),
+			bson.M{"_id": channel.ID},
+			bson.M{"$set": bson.M{"settings.thumbnail_layout": resolved, "updated_at": time.Now()}},
+		)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to save thumbnail layout: %v", err))
+			return
+		}
+		respondWithJSON(w, http.StatusOK, resolved)
+	default:
+		respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
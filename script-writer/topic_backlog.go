@@ -0,0 +1,650 @@
+// File: topic_backlog.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"youtube_automation/tracing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	TopicStatusProposed = "proposed"
+	TopicStatusApproved = "approved"
+	TopicStatusRejected = "rejected"
+	TopicStatusUsed     = "used"
+
+	TopicSourceAI     = "ai"
+	TopicSourceManual = "manual"
+)
+
+// Words that carry no meaning when comparing two topic titles
+var topicStopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "of": true, "and": true, "or": true, "to": true,
+	"in": true, "on": true, "for": true, "with": true, "about": true, "how": true, "why": true,
+	"what": true, "is": true, "are": true, "was": true, "were": true, "you": true, "your": true,
+	"that": true, "this": true, "it": true, "its": true, "from": true, "by": true, "at": true,
+	"s": true, "t": true, // Left of possessives and contractions, as in Rome's or don't
+}
+
+type TopicIdeationRequest struct {
+	Count            int    `json:"count"`
+	NicheDescription string `json:"niche_description,omitempty"` // Saved to the channel settings when given
+}
+
+type TopicCreateRequest struct {
+	Topic    string `json:"topic"`
+	Angle    string `json:"angle,omitempty"`
+	Priority int    `json:"priority"`
+}
+
+type TopicUpdateRequest struct {
+	Topic    *string `json:"topic,omitempty"`
+	Angle    *string `json:"angle,omitempty"`
+	Status   *string `json:"status,omitempty"`
+	Priority *int    `json:"priority,omitempty"`
+}
+
+type TopicIdeationResponse struct {
+	Success           bool        `json:"success"`
+	Message           string      `json:"message"`
+	Topics            []TopicIdea `json:"topics"`
+	SkippedDuplicates []string    `json:"skipped_duplicates"`
+}
+
+type TopicListResponse struct {
+	Success bool        `json:"success"`
+	Count   int         `json:"count"`
+	Topics  []TopicIdea `json:"topics"`
+}
+
+// TopicResponse carries the topic for reads and writes, and only a message for deletes
+type TopicResponse struct {
+	Success bool       `json:"success"`
+	Message string     `json:"message,omitempty"`
+	Topic   *TopicIdea `json:"topic,omitempty"`
+}
+
+// normalizeTopic lowercases, strips punctuation and stop words, and crudely singularizes
+// so "The 10 Best Habits of Stoics" and "best habits of the stoic" compare equal.
+func normalizeTopic(topic string) string {
+	fields := strings.FieldsFunc(strings.ToLower(topic), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	seen := make(map[string]bool)
+	var tokens []string
+	for _, field := range fields {
+		if topicStopWords[field] {
+			continue
+		}
+		if len(field) > 3 && strings.HasSuffix(field, "s") && !strings.HasSuffix(field, "ss") {
+			field = strings.TrimSuffix(field, "s")
+		}
+		if !seen[field] {
+			seen[field] = true
+			tokens = append(tokens, field)
+		}
+	}
+	sort.Strings(tokens)
+	return strings.Join(tokens, " ")
+}
+
+// topicSimilarity is the Jaccard similarity of two normalized topics' token sets
+func topicSimilarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	tokensA := strings.Fields(a)
+	tokensB := strings.Fields(b)
+	if len(tokensA) == 0 || len(tokensB) == 0 {
+		return 0
+	}
+
+	setA := make(map[string]bool, len(tokensA))
+	for _, token := range tokensA {
+		setA[token] = true
+	}
+	intersection := 0
+	for _, token := range tokensB {
+		if setA[token] {
+			intersection++
+		}
+	}
+	union := len(tokensA) + len(tokensB) - intersection
+	return float64(intersection) / float64(union)
+}
+
+// findSimilarTopic returns the first existing topic that is a near duplicate of normalized
+func findSimilarTopic(normalized string, existing map[string]string) (string, bool) {
+	for existingNormalized, original := range existing {
+		if topicSimilarity(normalized, existingNormalized) >= topicSimilarityThreshold {
+			return original, true
+		}
+	}
+	return "", false
+}
+
+// loadKnownTopics returns every topic the channel has already produced or has in its backlog,
+// keyed by normalized title, plus the most recent past topics for the ideation prompt.
+// Backlog entries in exclude are left out, so a topic being edited does not match itself.
+func loadKnownTopics(channelID primitive.ObjectID, exclude ...primitive.ObjectID) (map[string]string, []string, error) {
+	ctx := context.Background()
+	known := make(map[string]string)
+
+	cursor, err := scriptsCollection.Find(ctx,
+		bson.M{"channel_id": channelID},
+		options.Find().
+			SetSort(bson.D{{"created_at", -1}}).
+			SetProjection(bson.M{"topic": 1}),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("loading past scripts: %w", err)
+	}
+	var scripts []Script
+	if err := cursor.All(ctx, &scripts); err != nil {
+		return nil, nil, fmt.Errorf("decoding past scripts: %w", err)
+	}
+
+	var pastTopics []string
+	for _, script := range scripts {
+		known[normalizeTopic(script.Topic)] = script.Topic
+		if len(pastTopics) < topicHistoryLimit {
+			pastTopics = append(pastTopics, script.Topic)
+		}
+	}
+
+	filter := bson.M{"channel_id": channelID}
+	if len(exclude) > 0 {
+		filter["_id"] = bson.M{"$nin": exclude}
+	}
+	cursor, err = topicBacklogCollection.Find(ctx, filter,
+		options.Find().SetProjection(bson.M{"topic": 1, "normalized_topic": 1}),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("loading topic backlog: %w", err)
+	}
+	var ideas []TopicIdea
+	if err := cursor.All(ctx, &ideas); err != nil {
+		return nil, nil, fmt.Errorf("decoding topic backlog: %w", err)
+	}
+	for _, idea := range ideas {
+		known[idea.NormalizedTopic] = idea.Topic
+		if len(pastTopics) < topicHistoryLimit {
+			pastTopics = append(pastTopics, idea.Topic)
+		}
+	}
+
+	return known, pastTopics, nil
+}
+
+// GenerateTopicIdeas asks the LLM for count topics and stores the ones that are not near
+// duplicates of past or backlogged topics. Rejected duplicates are returned for reference.
+func (yt *YtAutomation) GenerateTopicIdeas(ctx context.Context, channel *Channel, count int) ([]TopicIdea, []string, error) {
+	known, pastTopics, err := loadKnownTopics(channel.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	systemPrompt, userPrompt, err := yt.templateService.BuildTopicIdeasPrompt(*channel, count, pastTopics)
+	if err != nil {
+		return nil, nil, fmt.Errorf("building topic ideas prompt: %w", err)
+	}
+
+	response, err := yt.aiService.GenerateContentWithSystem(ctx, systemPrompt, userPrompt)
+	if err != nil {
+		return nil, nil, fmt.Errorf("generating topic ideas: %w", err)
+	}
+
+	suggestions, err := yt.outlineParser.ParseTopicIdeasJSON(response)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	var ideas []TopicIdea
+	var duplicates []string
+	for _, suggestion := range suggestions {
+		title := strings.TrimSpace(suggestion.Title)
+		normalized := normalizeTopic(title)
+		if normalized == "" {
+			continue
+		}
+		if match, ok := findSimilarTopic(normalized, known); ok {
+			duplicates = append(duplicates, fmt.Sprintf("%s (similar to: %s)", title, match))
+			continue
+		}
+		known[normalized] = title
+
+		ideas = append(ideas, TopicIdea{
+			ChannelID:       channel.ID,
+			ChannelName:     channel.ChannelName,
+			Topic:           title,
+			NormalizedTopic: normalized,
+			Angle:           strings.TrimSpace(suggestion.Angle),
+			Status:          TopicStatusProposed,
+			Source:          TopicSourceAI,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		})
+	}
+
+	if len(ideas) == 0 {
+		return nil, duplicates, nil
+	}
+
+	docs := make([]interface{}, len(ideas))
+	for i := range ideas {
+		docs[i] = ideas[i]
+	}
+	result, err := topicBacklogCollection.InsertMany(context.Background(), docs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("saving topic ideas: %w", err)
+	}
+	for i, id := range result.InsertedIDs {
+		ideas[i].ID = id.(primitive.ObjectID)
+	}
+
+	slog.Info("Generated topic ideas", "channel", channel.ChannelName,
+		"ideas", len(ideas), "duplicates_skipped", len(duplicates))
+	return ideas, duplicates, nil
+}
+
+func getTopicIdeaByID(topicID primitive.ObjectID) (*TopicIdea, error) {
+	var idea TopicIdea
+	err := topicBacklogCollection.FindOne(context.Background(), bson.M{"_id": topicID}).Decode(&idea)
+	if err != nil {
+		return nil, err
+	}
+	return &idea, nil
+}
+
+// errTopicUnavailable is returned when a topic was used or rejected before it could be claimed
+var errTopicUnavailable = errors.New("topic has already been used or rejected")
+
+// claimTopic marks a topic used before its script is started, so two requests cannot
+// produce the same topic. It returns the status to restore with releaseTopic if the
+// script does not start.
+func claimTopic(topicID primitive.ObjectID) (string, error) {
+	var previous TopicIdea
+	err := topicBacklogCollection.FindOneAndUpdate(
+		context.Background(),
+		bson.M{"_id": topicID, "status": bson.M{"$nin": []string{TopicStatusUsed, TopicStatusRejected}}},
+		bson.M{"$set": bson.M{"status": TopicStatusUsed, "updated_at": time.Now()}},
+		options.FindOneAndUpdate().SetProjection(bson.M{"status": 1}),
+	).Decode(&previous)
+	if err == mongo.ErrNoDocuments {
+		return "", errTopicUnavailable
+	}
+	if err != nil {
+		return "", err
+	}
+	return previous.Status, nil
+}
+
+// releaseTopic undoes claimTopic after the script failed to start
+func releaseTopic(topicID primitive.ObjectID, status string) {
+	_, err := topicBacklogCollection.UpdateOne(
+		context.Background(),
+		bson.M{"_id": topicID, "status": TopicStatusUsed, "script_id": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"status": status, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		slog.Warn("Failed to release topic", "topic_id", topicID.Hex(), "error", err)
+	}
+}
+
+// markTopicUsed links a backlog topic to the script that was produced from it
+func markTopicUsed(topicID, scriptID primitive.ObjectID) error {
+	_, err := topicBacklogCollection.UpdateOne(
+		context.Background(),
+		bson.M{"_id": topicID},
+		bson.M{"$set": bson.M{"status": TopicStatusUsed, "script_id": scriptID, "updated_at": time.Now()}},
+	)
+	return err
+}
+
+// channelTopicsHandler serves /channels/{name}/topics and /channels/{name}/topics/ideate
+func (yt *YtAutomation) channelTopicsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/channels/")
+	ideate := strings.HasSuffix(path, "/topics/ideate")
+	channelName := strings.TrimSuffix(strings.TrimSuffix(path, "/ideate"), "/topics")
+	if channelName == "" {
+		respondWithError(w, http.StatusBadRequest, "Channel name is required")
+		return
+	}
+
+	channel, err := yt.getChannelByName(channelName)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			respondWithError(w, http.StatusNotFound, "Channel not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Database error: %v", err))
+		return
+	}
+
+	switch {
+	case ideate && r.Method == "POST":
+		yt.ideateTopics(w, r, channel)
+	case !ideate && r.Method == "GET":
+		listChannelTopics(w, r, channel)
+	case !ideate && r.Method == "POST":
+		createChannelTopic(w, r, channel)
+	default:
+		respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (yt *YtAutomation) ideateTopics(w http.ResponseWriter, r *http.Request, channel *Channel) {
+	var req TopicIdeationRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid JSON request body")
+			return
+		}
+	}
+	if req.Count == 0 {
+		req.Count = defaultTopicIdeaCount
+	}
+	if req.Count < 1 || req.Count > maxTopicIdeaCount {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("count must be between 1 and %d", maxTopicIdeaCount))
+		return
+	}
+
+	if niche := strings.TrimSpace(req.NicheDescription); niche != "" && niche != channel.Settings.NicheDescription {
+		_, err := channelsCollection.UpdateOne(
+			context.Background(),
+			bson.M{"_id": channel.ID},
+			bson.M{"$set": bson.M{"settings.niche_description": niche, "updated_at": time.Now()}},
+		)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to save niche description: %v", err))
+			return
+		}
+		channel.Settings.NicheDescription = niche
+	}
+
+	ideas, duplicates, err := yt.GenerateTopicIdeas(tracing.Detach(r.Context()), channel, req.Count)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Topic ideation failed: %v", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, TopicIdeationResponse{
+		Success:           true,
+		Message:           fmt.Sprintf("%d topic ideas added to the backlog", len(ideas)),
+		Topics:            ideas,
+		SkippedDuplicates: duplicates,
+	})
+}
+
+func listChannelTopics(w http.ResponseWriter, r *http.Request, channel *Channel) {
+	filter := bson.M{"channel_id": channel.ID}
+	if status := r.URL.Query().Get("status"); status != "" {
+		filter["status"] = status
+	}
+
+	cursor, err := topicBacklogCollection.Find(
+		context.Background(),
+		filter,
+		options.Find().SetSort(bson.D{{"priority", -1}, {"created_at", 1}}),
+	)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Database error: %v", err))
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	topics := []TopicIdea{}
+	if err := cursor.All(context.Background(), &topics); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Error decoding topics: %v", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, TopicListResponse{
+		Success: true,
+		Count:   len(topics),
+		Topics:  topics,
+	})
+}
+
+func createChannelTopic(w http.ResponseWriter, r *http.Request, channel *Channel) {
+	var req TopicCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid JSON request body")
+		return
+	}
+	req.Topic = strings.TrimSpace(req.Topic)
+	normalized := normalizeTopic(req.Topic)
+	if normalized == "" {
+		respondWithError(w, http.StatusBadRequest, "Topic cannot be empty")
+		return
+	}
+
+	known, _, err := loadKnownTopics(channel.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if match, ok := findSimilarTopic(normalized, known); ok {
+		respondWithError(w, http.StatusConflict, fmt.Sprintf("Topic is too similar to existing topic: %s", match))
+		return
+	}
+
+	// Topics added by hand are approved right away
+	now := time.Now()
+	idea := TopicIdea{
+		ChannelID:       channel.ID,
+		ChannelName:     channel.ChannelName,
+		Topic:           req.Topic,
+		NormalizedTopic: normalized,
+		Angle:           strings.TrimSpace(req.Angle),
+		Status:          TopicStatusApproved,
+		Priority:        req.Priority,
+		Source:          TopicSourceManual,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	result, err := topicBacklogCollection.InsertOne(context.Background(), idea)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create topic: %v", err))
+		return
+	}
+	idea.ID = result.InsertedID.(primitive.ObjectID)
+
+	respondWithJSON(w, http.StatusCreated, TopicResponse{
+		Success: true,
+		Topic:   &idea,
+	})
+}
+
+// topicHandler serves /topics/{id} (GET, PATCH, DELETE) and /topics/{id}/generate (POST)
+func (yt *YtAutomation) topicHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/topics/")
+	generate := strings.HasSuffix(path, "/generate")
+	topicID, err := primitive.ObjectIDFromHex(strings.TrimSuffix(path, "/generate"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid topic ID format")
+		return
+	}
+
+	idea, err := getTopicIdeaByID(topicID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			respondWithError(w, http.StatusNotFound, "Topic not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Database error: %v", err))
+		return
+	}
+
+	switch {
+	case generate && r.Method == "POST":
+		yt.generateScriptFromTopic(w, r, idea)
+	case !generate && r.Method == "GET":
+		respondWithJSON(w, http.StatusOK, TopicResponse{Success: true, Topic: idea})
+	case !generate && r.Method == "PATCH":
+		updateTopic(w, r, idea)
+	case !generate && r.Method == "DELETE":
+		if _, err := topicBacklogCollection.DeleteOne(context.Background(), bson.M{"_id": idea.ID}); err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to delete topic: %v", err))
+			return
+		}
+		respondWithJSON(w, http.StatusOK, TopicResponse{Success: true, Message: "Topic deleted"})
+	default:
+		respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func updateTopic(w http.ResponseWriter, r *http.Request, idea *TopicIdea) {
+	var req TopicUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid JSON request body")
+		return
+	}
+
+	update := bson.M{"updated_at": time.Now()}
+	if req.Topic != nil {
+		topic := strings.TrimSpace(*req.Topic)
+		normalized := normalizeTopic(topic)
+		if normalized == "" {
+			respondWithError(w, http.StatusBadRequest, "Topic cannot be empty")
+			return
+		}
+		if normalized != idea.NormalizedTopic {
+			known, _, err := loadKnownTopics(idea.ChannelID, idea.ID)
+			if err != nil {
+				respondWithError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			if match, ok := findSimilarTopic(normalized, known); ok {
+				respondWithError(w, http.StatusConflict, fmt.Sprintf("Topic is too similar to existing topic: %s", match))
+				return
+			}
+		}
+		update["topic"] = topic
+		update["normalized_topic"] = normalized
+	}
+	if req.Angle != nil {
+		update["angle"] = strings.TrimSpace(*req.Angle)
+	}
+	if req.Priority != nil {
+		update["priority"] = *req.Priority
+	}
+	if req.Status != nil {
+		switch *req.Status {
+		case TopicStatusProposed, TopicStatusApproved, TopicStatusRejected:
+			update["status"] = *req.Status
+		default:
+			respondWithError(w, http.StatusBadRequest, "status must be proposed, approved or rejected")
+			return
+		}
+	}
+
+	var updated TopicIdea
+	err := topicBacklogCollection.FindOneAndUpdate(
+		context.Background(),
+		bson.M{"_id": idea.ID},
+		bson.M{"$set": update},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to update topic: %v", err))
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, TopicResponse{Success: true, Topic: &updated})
+}
+
+func (yt *YtAutomation) generateScriptFromTopic(w http.ResponseWriter, r *http.Request, idea *TopicIdea) {
+	if idea.Status == TopicStatusUsed {
+		respondWithError(w, http.StatusConflict, "Topic has already been turned into a script")
+		return
+	}
+	if idea.Status == TopicStatusRejected {
+		respondWithError(w, http.StatusConflict, "Topic has been rejected")
+		return
+	}
+
+	// Optional generation options; topic and channel come from the backlog entry
+	var req ScriptRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid JSON request body")
+			return
+		}
+	}
+	if req.TargetMinutes < 0 || req.TargetMinutes > maxLongFormMinutes {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("target_minutes must be between 1 and %d, or 0 for a regular script", maxLongFormMinutes))
+		return
+	}
+	req.Topic = idea.Topic
+	req.ChannelName = idea.ChannelName
+
+	channel, err := yt.getChannelByID(idea.ChannelID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Database error: %v", err))
+		return
+	}
+
+	// The status read above may be stale; claiming settles which request gets the topic
+	previousStatus, err := claimTopic(idea.ID)
+	if err != nil {
+		if errors.Is(err, errTopicUnavailable) {
+			respondWithError(w, http.StatusConflict, "Topic has already been turned into a script or rejected")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to claim topic: %v", err))
+		return
+	}
+
+	script, _, err := yt.startScriptGeneration(r.Context(), *channel, req)
+	if err != nil {
+		releaseTopic(idea.ID, previousStatus)
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := markTopicUsed(idea.ID, script.ID); err != nil {
+		slog.Warn("Failed to mark topic as used", "topic_id", idea.ID.Hex(), logKeyScriptID, script.ID.Hex(), "error", err)
+	}
+
+	respondWithJSON(w, http.StatusOK, ScriptResponse{
+		Success:     true,
+		ScriptID:    script.ID.Hex(),
+		Message:     "Script generation started",
+		Status:      StatusProcessing,
+		Topic:       script.Topic,
+		ChannelName: script.ChannelName,
+		GeneratedAt: time.Now().Format(time.RFC3339),
+	})
+
+	slog.Info("Script generation started from backlog topic", "topic_id", idea.ID.Hex(),
+		"channel", idea.ChannelName, logKeyScriptID, script.ID.Hex())
+}
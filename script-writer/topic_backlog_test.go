@@ -0,0 +1,75 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNormalizeTopic(t *testing.T) {
+	tests := []struct {
+		topic string
+		want  string
+	}{
+		{"The Fall of Rome", "fall rome"},
+		{"the FALL of rome!!!", "fall rome"},
+		{"Rome's fall: what happened?", "fall happened rome"},
+		{"How to train your dog", "dog train"},
+		{"Why the Romans built roads", "built road roman"},
+		{"Ancient glass and brass", "ancient brass glass"},
+		{"Bus", "bus"},
+		{"Cats, cats and more cats", "cat more"},
+		{"Top 10 Mysteries of 2024", "10 2024 mysterie top"},
+		{"Ça va? Café culture", "café culture va ça"},
+		{"Don't miss Rome’s best sights", "best don miss rome sight"},
+		{"the of and", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeTopic(tt.topic); got != tt.want {
+			t.Errorf("normalizeTopic(%q) = %q, want %q", tt.topic, got, tt.want)
+		}
+	}
+}
+
+func TestTopicSimilarity(t *testing.T) {
+	tests := []struct {
+		name      string
+		a, b      string
+		want      float64
+		duplicate bool
+	}{
+		{"case and punctuation", "The Fall of Rome", "the fall of ROME?!", 1, true},
+		{"stop words and word order", "How the Roman Empire fell", "Roman empire: why it fell", 1, true},
+		{"plurals", "Secrets of the pyramids", "The secret pyramid", 1, true},
+		{"just over the threshold", "Hidden secrets of ancient Egyptian tombs",
+			"Hidden secrets of ancient Egyptian temples", 4.0 / 6, true},
+		{"at the threshold", "Ancient Rome empire fall", "Ancient Rome empire rise", 3.0 / 5, true},
+		{"just under the threshold", "Hidden secrets of ancient Egyptian royal tombs",
+			"Hidden secrets of ancient Egyptian temples and pyramids", 4.0 / 8, false},
+		{"unrelated", "Deep sea creatures", "The history of jazz", 0, false},
+		{"empty topic", "", "The history of jazz", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := topicSimilarity(normalizeTopic(tt.a), normalizeTopic(tt.b))
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("similarity = %.3f, want %.3f", got, tt.want)
+			}
+			if duplicate := got >= topicSimilarityThreshold; duplicate != tt.duplicate {
+				t.Errorf("duplicate = %v, want %v (threshold %.2f)", duplicate, tt.duplicate, topicSimilarityThreshold)
+			}
+		})
+	}
+}
+
+func TestFindSimilarTopic(t *testing.T) {
+	existing := map[string]string{
+		normalizeTopic("The Fall of Rome"):         "The Fall of Rome",
+		normalizeTopic("How octopuses see colour"): "How octopuses see colour",
+	}
+	if original, ok := findSimilarTopic(normalizeTopic("Rome: the fall"), existing); !ok || original != "The Fall of Rome" {
+		t.Errorf("findSimilarTopic = %q, %v; want the Rome topic", original, ok)
+	}
+	if original, ok := findSimilarTopic(normalizeTopic("Why jazz was born in New Orleans"), existing); ok {
+		t.Errorf("findSimilarTopic matched %q for an unrelated topic", original)
+	}
+}
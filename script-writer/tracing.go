@@ -0,0 +1,47 @@
+// File: tracing.go
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+	"youtube_automation/tracing"
+)
+
+// startStage opens a span for a pipeline stage. The returned func ends it and records
+// the stage duration; defer it with a pointer to the named error result.
+func startStage(ctx context.Context, stage string) (context.Context, func(*error)) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, stage, tracing.KindInternal)
+	ctx = withLogAttrs(ctx, slog.String(logKeyStage, stage))
+	slog.DebugContext(ctx, "Stage started")
+	return ctx, func(err *error) {
+		outcome := "ok"
+		if err != nil && *err != nil {
+			outcome = "error"
+			span.SetError(*err)
+			slog.ErrorContext(ctx, "Stage failed", "duration", time.Since(start), "error", *err)
+		} else {
+			slog.DebugContext(ctx, "Stage finished", "duration", time.Since(start))
+		}
+		stageDuration.ObserveSince(start, stage, outcome)
+		span.End()
+	}
+}
+
+// providerClient is an http.Client whose requests get a client span named after the
+// provider and carry the trace to it
+func providerClient(provider string, timeout time.Duration) *http.Client {
+	return &http.Client{Timeout: timeout, Transport: tracing.Transport{Name: provider}}
+}
+
+// serverSpanName names request spans after the route pattern, so IDs in the path
+// don't end up in span names
+func serverSpanName(r *http.Request) string {
+	_, pattern := http.DefaultServeMux.Handler(r)
+	if pattern == "" {
+		pattern = "unmatched"
+	}
+	return r.Method + " " + pattern
+}
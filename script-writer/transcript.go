@@ -2,9 +2,11 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"mime/multipart"
 	"net"
 	"net/http"
@@ -30,12 +32,11 @@ type TranscriptResponse struct {
 	Format    string  `json:"format"` // "text" or "srt"
 }
 
-func (yt *YtAutomation) callTranscriptAPI(payload TranscriptPayload) (string, error) {
-	// Debug environment variable
-	apiURL := os.Getenv("TRANSCRIPT_SERVER_API_URL")
-	fmt.Printf("TRANSCRIPT_SERVER_API_URL: '%s'\n", apiURL)
+func (yt *YtAutomation) callTranscriptAPI(ctx context.Context, payload TranscriptPayload) (string, error) {
+	ctx = withLogAttrs(ctx, slog.String(logKeyProvider, ProviderWhisper))
+	apiURL := appConfig().Services.TranscriptURL
 	if apiURL == "" {
-		return "", fmt.Errorf("TRANSCRIPT_SERVER_API_URL environment variable is not set")
+		return "", fmt.Errorf("services.transcript_url (TRANSCRIPT_SERVER_API_URL) is not set")
 	}
 
 	// Check if file exists and get its size
@@ -43,7 +44,6 @@ func (yt *YtAutomation) callTranscriptAPI(payload TranscriptPayload) (string, er
 	if err != nil {
 		return "", fmt.Errorf("audio file stat error: %w", err)
 	}
-	fmt.Printf("Audio file: %s, Size: %d bytes\n", payload.AudioPath, fileInfo.Size())
 
 	// Create a buffer to store the form data
 	var requestBody bytes.Buffer
@@ -63,11 +63,9 @@ func (yt *YtAutomation) callTranscriptAPI(payload TranscriptPayload) (string, er
 	}
 
 	// Copy file content to form
-	bytesWritten, err := io.Copy(audioWriter, audioFile)
-	if err != nil {
+	if _, err := io.Copy(audioWriter, audioFile); err != nil {
 		return "", fmt.Errorf("copying file content: %w", err)
 	}
-	fmt.Printf("Bytes written to form: %d\n", bytesWritten)
 
 	// Add other form fields
 	err = writer.WriteField("language", payload.Language)
@@ -86,13 +84,10 @@ func (yt *YtAutomation) callTranscriptAPI(payload TranscriptPayload) (string, er
 		return "", fmt.Errorf("closing form writer: %w", err)
 	}
 
-	fmt.Printf("Total request body size: %d bytes\n", requestBody.Len())
-
 	url := fmt.Sprintf("%s/transcribe", apiURL)
-	fmt.Printf("Request URL: %s\n", url)
 
 	// Create the HTTP request
-	req, err := http.NewRequest("POST", url, &requestBody)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, &requestBody)
 	if err != nil {
 		return "", fmt.Errorf("creating request: %w", err)
 	}
@@ -100,52 +95,38 @@ func (yt *YtAutomation) callTranscriptAPI(payload TranscriptPayload) (string, er
 	// Set headers
 	contentType := writer.FormDataContentType()
 	req.Header.Set("Content-Type", contentType)
-	fmt.Printf("Content-Type: %s\n", contentType)
 
 	// Only set User-Agent if it's not empty
-	userAgent := os.Getenv("USER_AGENT")
+	userAgent := appConfig().Server.UserAgent
 	if userAgent != "" {
 		req.Header.Set("User-Agent", userAgent)
-		fmt.Printf("User-Agent: %s\n", userAgent)
-	} else {
-		fmt.Println("User-Agent not set (empty environment variable)")
 	}
 
-	fmt.Printf("Request headers: %+v\n", req.Header)
-
 	// Use longer timeout for large files
-	client := &http.Client{
-		Timeout: 10 * time.Minute, // Increased timeout
-	}
+	client := providerClient(ProviderWhisper, 10*time.Minute) // Increased timeout
 
-	fmt.Println("Sending HTTP request...")
+	slog.DebugContext(ctx, "Sending transcription request", "url", url, "audio_file", payload.AudioPath,
+		"audio_bytes", fileInfo.Size(), "body_bytes", requestBody.Len(), "user_agent_set", userAgent != "")
 	startTime := time.Now()
 
 	// Send the request
 	resp, err := client.Do(req)
 	if err != nil {
-		fmt.Printf("HTTP request failed after %v: %v\n", time.Since(startTime), err)
-
+		attrs := []any{"duration", time.Since(startTime), "error", err}
 		// Check for specific error types
 		if netErr, ok := err.(net.Error); ok {
-			if netErr.Timeout() {
-				fmt.Println("Error type: Network timeout")
-			}
-			if netErr.Temporary() {
-				fmt.Println("Error type: Temporary network error")
-			}
+			attrs = append(attrs, "timeout", netErr.Timeout(), "temporary", netErr.Temporary())
 		}
+		slog.WarnContext(ctx, "Transcription request failed", attrs...)
 
 		return "", fmt.Errorf("sending request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	fmt.Printf("Response received after %v, Status: %d\n", time.Since(startTime), resp.StatusCode)
-	fmt.Printf("Response headers: %+v\n", resp.Header)
+	slog.DebugContext(ctx, "Transcription response received", "duration", time.Since(startTime), "status", resp.StatusCode)
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		fmt.Printf("Error response body: %s\n", string(body))
 		return "", fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
 	}
 
@@ -154,27 +135,29 @@ func (yt *YtAutomation) callTranscriptAPI(payload TranscriptPayload) (string, er
 		return "", fmt.Errorf("reading response: %w", err)
 	}
 
-	fmt.Printf("Response body length: %d bytes\n", len(body))
-
 	var transcriptResponse TranscriptResponse
 	if err := json.Unmarshal(body, &transcriptResponse); err != nil {
-		fmt.Printf("JSON unmarshal error. Response body: %s\n", string(body))
+		slog.WarnContext(ctx, "Transcription response is not valid JSON", "body", string(body))
 		return "", fmt.Errorf("unmarshalling response: %w", err)
 	}
 
 	if len(transcriptResponse.SRT) == 0 || transcriptResponse.SRT == "" {
-		fmt.Printf("Empty SRT in response: %+v\n", transcriptResponse)
+		slog.WarnContext(ctx, "Transcription response has no SRT", "text_length", len(transcriptResponse.Text))
 		return "", fmt.Errorf("no content in response")
 	}
 
-	fmt.Printf("Successfully received SRT content, length: %d\n", len(transcriptResponse.SRT))
+	slog.InfoContext(ctx, "Received subtitles", "srt_length", len(transcriptResponse.SRT))
 	return transcriptResponse.SRT, nil
 }
-func (yt *YtAutomation) GenerateSRT(payload TranscriptPayload) (string, error) {
+func (yt *YtAutomation) GenerateSRT(ctx context.Context, payload TranscriptPayload) (_ string, err error) {
+	ctx, end := startStage(ctx, StageTranscription)
+	defer end(&err)
+
 	var lastErr error
 
 	for attempt := 0; attempt < maxRetries; attempt++ {
-		result, err := yt.callTranscriptAPI(payload)
+		result, err := yt.callTranscriptAPI(ctx, payload)
+		recordProviderCall(ProviderWhisper, err)
 		if err == nil {
 			return result, nil
 		}
@@ -183,11 +166,13 @@ func (yt *YtAutomation) GenerateSRT(payload TranscriptPayload) (string, error) {
 
 		// Exponential backoff: 1s, 2s, 4s, 8s...
 		backoffDuration := time.Duration(1<<attempt) * time.Second
-		fmt.Printf("API call failed (attempt %d/%d), retrying in %v: %v\n",
-			attempt+1, maxRetries, backoffDuration, err)
+		slog.WarnContext(ctx, "Transcription failed, retrying", logKeyProvider, ProviderWhisper,
+			"attempt", attempt+1, "max_attempts", maxRetries, "backoff", backoffDuration, "error", err)
 
 		if attempt < maxRetries-1 {
-			time.Sleep(backoffDuration)
+			if err := sleepContext(ctx, backoffDuration); err != nil {
+				return "", err
+			}
 		}
 	}
 
@@ -0,0 +1,303 @@
+// File: tts.go
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// TTSProvider voices narration. Channels pick one in settings.tts_provider, voice.provider
+// is the default and voice.fallback_provider stands in when it fails.
+type TTSProvider interface {
+	Name() string
+	// Synthesize voices text in one of Formats; voice is provider specific, empty for the default
+	Synthesize(ctx context.Context, text, voice, format string) ([]byte, error)
+	ListVoices(ctx context.Context) ([]TTSVoice, error)
+	// Quota is what the provider still allows; local engines are unlimited
+	Quota(ctx context.Context) (*TTSQuota, error)
+	Formats() []string
+}
+
+type TTSVoice struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Language string `json:"language,omitempty"`
+}
+
+type TTSQuota struct {
+	Unlimited bool       `json:"unlimited"`
+	Used      int        `json:"used"`
+	Limit     int        `json:"limit"`
+	Remaining int        `json:"remaining"`
+	Keys      int        `json:"keys,omitempty"`      // Pooled keys the figures add up
+	ResetsAt  *time.Time `json:"resets_at,omitempty"` // Earliest reset among them
+}
+
+// ttsProviderNames are the values of voice.provider and settings.tts_provider
+var ttsProviderNames = []string{TTSProviderElevenLabs, TTSProviderOffline}
+
+func (yt *YtAutomation) ttsProvider(name string) (TTSProvider, error) {
+	switch name {
+	case TTSProviderElevenLabs:
+		return &elevenLabsTTS{yt: yt}, nil
+	case TTSProviderOffline:
+		return newOfflineTTS(appConfig().Voice), nil
+	}
+	return nil, fmt.Errorf("unknown TTS provider %q", name)
+}
+
+// usesProvider reports whether channels without their own provider can end up on name
+func (c VoiceConfig) usesProvider(name string) bool {
+	return c.Provider == name || c.FallbackProvider == name
+}
+
+// channelVoice is the provider and voice a channel narrates with
+func channelVoice(channel *Channel) (provider, voice string) {
+	if channel != nil && channel.Settings.TTSProvider != "" {
+		return channel.Settings.TTSProvider, channel.Settings.TTSVoice
+	}
+	return appConfig().Voice.Provider, ""
+}
+
+// synthesizeNarration voices a chunk for a channel, trying voice.fallback_provider with its
+// default voice when the channel's provider fails
+func (yt *YtAutomation) synthesizeNarration(ctx context.Context, channel *Channel, text string) ([]byte, error) {
+	name, voice := channelVoice(channel)
+	audio, err := yt.synthesizeWith(ctx, name, voice, text)
+	fallback := appConfig().Voice.FallbackProvider
+	if err == nil || fallback == "" || fallback == name || ctx.Err() != nil {
+		return audio, err
+	}
+
+	slog.WarnContext(ctx, "TTS provider failed, using the fallback", "tts_provider", name, "fallback", fallback, "error", err)
+	audio, fallbackErr := yt.synthesizeWith(ctx, fallback, "", text)
+	if fallbackErr != nil {
+		return nil, fmt.Errorf("%s: %w; fallback %s: %v", name, err, fallback, fallbackErr)
+	}
+	return audio, nil
+}
+
+func (yt *YtAutomation) synthesizeWith(ctx context.Context, name, voice, text string) ([]byte, error) {
+	provider, err := yt.ttsProvider(name)
+	if err != nil {
+		return nil, err
+	}
+	return provider.Synthesize(ctx, text, voice, narrationFormat)
+}
+
+// elevenLabsTTS voices with ElevenLabs, taking keys from the pool
+type elevenLabsTTS struct {
+	yt *YtAutomation
+}
+
+func (p *elevenLabsTTS) Name() string      { return TTSProviderElevenLabs }
+func (p *elevenLabsTTS) Formats() []string { return []string{"mp3"} }
+
+func (p *elevenLabsTTS) Synthesize(ctx context.Context, text, voice, format string) ([]byte, error) {
+	if !slices.Contains(p.Formats(), format) {
+		return nil, fmt.Errorf("elevenlabs does not produce %s", format)
+	}
+	return p.yt.synthesizeSpeech(ctx, text, cmp.Or(voice, appConfig().Voice.VoiceID))
+}
+
+func (p *elevenLabsTTS) ListVoices(ctx context.Context) ([]TTSVoice, error) {
+	key, err := p.yt.apiKeyManager.GetActiveKey(ProviderElevenLabs)
+	if err != nil {
+		return nil, err
+	}
+	voices, err := p.yt.elevenLabsClient.WithAPIKey(key.KeyValue).GetVoices()
+	recordProviderCall(ProviderElevenLabs, err)
+	if err != nil {
+		p.yt.reportElevenLabsFailure(key, err)
+		return nil, err
+	}
+
+	var out []TTSVoice
+	for _, voice := range voices {
+		id, _ := voice["voice_id"].(string)
+		name, _ := voice["name"].(string)
+		labels, _ := voice["labels"].(map[string]interface{})
+		language, _ := labels["language"].(string)
+		out = append(out, TTSVoice{ID: id, Name: name, Language: language})
+	}
+	return out, nil
+}
+
+// Quota adds up the characters left on every healthy key of the pool
+func (p *elevenLabsTTS) Quota(ctx context.Context) (*TTSQuota, error) {
+	cursor, err := apiKeysCollection.Find(ctx, bson.M{"provider": ProviderElevenLabs, "is_active": true, "state": KeyStateHealthy})
+	if err != nil {
+		return nil, err
+	}
+	var keys []APIKey
+	if err := cursor.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+
+	quota := &TTSQuota{}
+	for i := range keys {
+		key := &keys[i]
+		if err := key.decrypt(); err != nil {
+			slog.WarnContext(ctx, "Cannot check ElevenLabs key quota", "key", key.Fingerprint, "error", err)
+			continue
+		}
+		sub, err := p.yt.elevenLabsClient.WithAPIKey(key.KeyValue).GetSubscriptionInfo()
+		recordProviderCall(ProviderElevenLabs, err)
+		if err != nil {
+			slog.WarnContext(ctx, "Cannot check ElevenLabs key quota", "key", key.Fingerprint, "error", err)
+			continue
+		}
+		remaining := sub.CharacterLimit - sub.CharacterCount
+		elevenLabsCharactersRemaining.Set(float64(remaining), key.Fingerprint)
+		p.yt.apiKeyManager.RecordCharacters(key, 0, remaining)
+
+		quota.Keys++
+		quota.Used += sub.CharacterCount
+		quota.Limit += sub.CharacterLimit
+		quota.Remaining += remaining
+		if sub.NextCharacterCountResetUnix > 0 {
+			reset := time.Unix(sub.NextCharacterCountResetUnix, 0)
+			if quota.ResetsAt == nil || reset.Before(*quota.ResetsAt) {
+				quota.ResetsAt = &reset
+			}
+		}
+	}
+	if quota.Keys == 0 {
+		return nil, fmt.Errorf("%w for provider '%s'", errNoHealthyAPIKey, ProviderElevenLabs)
+	}
+	return quota, nil
+}
+
+// TTSProviderInfo describes a provider for GET /tts/providers
+type TTSProviderInfo struct {
+	Name       string    `json:"name"`
+	Formats    []string  `json:"formats"`
+	Default    bool      `json:"default"`
+	Fallback   bool      `json:"fallback"`
+	Quota      *TTSQuota `json:"quota,omitempty"`
+	QuotaError string    `json:"quota_error,omitempty"`
+}
+
+type TTSProvidersResponse struct {
+	Success   bool              `json:"success"`
+	Providers []TTSProviderInfo `json:"providers"`
+}
+
+type TTSVoicesResponse struct {
+	Success  bool       `json:"success"`
+	Provider string     `json:"provider"`
+	Voices   []TTSVoice `json:"voices"`
+}
+
+// ChannelVoice is the TTS choice of a channel; an empty provider means voice.provider
+type ChannelVoice struct {
+	Provider string `json:"provider"`
+	Voice    string `json:"voice,omitempty"`
+}
+
+// ttsHandler serves GET /tts/providers and GET /tts/voices?provider=
+func (yt *YtAutomation) ttsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "GET" {
+		respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	switch strings.TrimPrefix(r.URL.Path, "/tts/") {
+	case "providers":
+		var providers []TTSProviderInfo
+		for _, name := range ttsProviderNames {
+			provider, _ := yt.ttsProvider(name)
+			info := TTSProviderInfo{
+				Name:     name,
+				Formats:  provider.Formats(),
+				Default:  appConfig().Voice.Provider == name,
+				Fallback: appConfig().Voice.FallbackProvider == name,
+			}
+			quota, err := provider.Quota(r.Context())
+			if err != nil {
+				info.QuotaError = err.Error()
+			}
+			info.Quota = quota
+			providers = append(providers, info)
+		}
+		respondWithJSON(w, http.StatusOK, TTSProvidersResponse{Success: true, Providers: providers})
+	case "voices":
+		name := cmp.Or(r.URL.Query().Get("provider"), appConfig().Voice.Provider)
+		provider, err := yt.ttsProvider(name)
+		if err != nil {
+			respondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		voices, err := provider.ListVoices(r.Context())
+		if err != nil {
+			respondWithError(w, http.StatusBadGateway, fmt.Sprintf("Failed to list %s voices: %v", name, err))
+			return
+		}
+		respondWithJSON(w, http.StatusOK, TTSVoicesResponse{Success: true, Provider: name, Voices: voices})
+	default:
+		respondWithError(w, http.StatusNotFound, "Not found")
+	}
+}
+
+// channelVoiceHandler serves GET/PUT /channels/{name}/voice
+func (yt *YtAutomation) channelVoiceHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	channelName := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/channels/"), "/voice")
+	channel, err := yt.getChannelByName(channelName)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			respondWithError(w, http.StatusNotFound, "Channel not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Database error: %v", err))
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		respondWithJSON(w, http.StatusOK, ChannelVoice{Provider: channel.Settings.TTSProvider, Voice: channel.Settings.TTSVoice})
+	case "PUT":
+		var voice ChannelVoice
+		if err := json.NewDecoder(r.Body).Decode(&voice); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid JSON request body")
+			return
+		}
+		if voice.Provider != "" && !slices.Contains(ttsProviderNames, voice.Provider) {
+			respondWithError(w, http.StatusBadRequest, fmt.Sprintf("provider must be one of %s", strings.Join(ttsProviderNames, ", ")))
+			return
+		}
+		if voice.Provider == "" && voice.Voice != "" {
+			respondWithError(w, http.StatusBadRequest, "A voice needs its provider")
+			return
+		}
+		_, err := channelsCollection.UpdateOne(
+			context.Background(),
+			bson.M{"_id": channel.ID},
+			bson.M{"$set": bson.M{
+				"settings.tts_provider": voice.Provider,
+				"settings.tts_voice":    voice.Voice,
+				"updated_at":            time.Now(),
+			}},
+		)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to save channel voice: %v", err))
+			return
+		}
+		respondWithJSON(w, http.StatusOK, voice)
+	default:
+		respondWithError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
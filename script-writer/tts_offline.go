@@ -0,0 +1,142 @@
+// File: tts_offline.go
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// offlineTTS voices with a local engine, Piper or espeak-ng, so drafts cost no credits
+// and voiceovers go on when ElevenLabs is down
+type offlineTTS struct {
+	engine   string
+	binary   string
+	voice    string
+	modelDir string
+}
+
+func newOfflineTTS(c VoiceConfig) *offlineTTS {
+	return &offlineTTS{
+		engine:   c.OfflineEngine,
+		binary:   cmp.Or(c.OfflineBinary, c.OfflineEngine),
+		voice:    c.OfflineVoice,
+		modelDir: c.PiperModelDir,
+	}
+}
+
+func (p *offlineTTS) Name() string      { return TTSProviderOffline }
+func (p *offlineTTS) Formats() []string { return []string{"wav", "mp3"} }
+
+func (p *offlineTTS) Quota(ctx context.Context) (*TTSQuota, error) {
+	return &TTSQuota{Unlimited: true}, nil
+}
+
+func (p *offlineTTS) Synthesize(ctx context.Context, text, voice, format string) ([]byte, error) {
+	if !slices.Contains(p.Formats(), format) {
+		return nil, fmt.Errorf("%s does not produce %s", p.engine, format)
+	}
+
+	dir, err := os.MkdirTemp("", "tts-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	wavPath := filepath.Join(dir, "speech.wav")
+
+	var cmd *exec.Cmd
+	switch p.engine {
+	case ttsEnginePiper:
+		model, err := p.piperModel(voice)
+		if err != nil {
+			return nil, err
+		}
+		cmd = exec.CommandContext(ctx, p.binary, "--model", model, "--output_file", wavPath)
+	case ttsEngineEspeak:
+		cmd = exec.CommandContext(ctx, p.binary, "-v", cmp.Or(voice, p.voice, "en-us"), "-w", wavPath, "--stdin")
+	default:
+		return nil, fmt.Errorf("unknown offline TTS engine %q", p.engine)
+	}
+	cmd.Stdin = strings.NewReader(text)
+	output, err := cmd.CombinedOutput()
+	recordProviderCall(ProviderOfflineTTS, err)
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %v, output: %s", p.engine, err, string(output))
+	}
+
+	if format == "wav" {
+		return os.ReadFile(wavPath)
+	}
+
+	// Encode like ElevenLabs' default output so chunks from both concatenate without re-encoding
+	mp3Path := filepath.Join(dir, "speech.mp3")
+	output, err = exec.CommandContext(ctx, "ffmpeg", "-y", "-i", wavPath,
+		"-ar", "44100", "-ac", "1", "-b:a", "128k", mp3Path).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg failed: %v, output: %s", err, string(output))
+	}
+	return os.ReadFile(mp3Path)
+}
+
+// piperModel resolves a voice to a Piper model file, relative names against the model dir
+func (p *offlineTTS) piperModel(voice string) (string, error) {
+	model := cmp.Or(voice, p.voice)
+	if model == "" {
+		return "", fmt.Errorf("no Piper model: set voice.offline_voice or the channel's voice")
+	}
+	if !strings.HasSuffix(model, ".onnx") {
+		model += ".onnx"
+	}
+	if !filepath.IsAbs(model) && p.modelDir != "" {
+		model = filepath.Join(p.modelDir, model)
+	}
+	return model, nil
+}
+
+func (p *offlineTTS) ListVoices(ctx context.Context) ([]TTSVoice, error) {
+	switch p.engine {
+	case ttsEnginePiper:
+		if p.modelDir == "" {
+			return nil, fmt.Errorf("voice.piper_model_dir is not set")
+		}
+		models, err := filepath.Glob(filepath.Join(p.modelDir, "*.onnx"))
+		if err != nil {
+			return nil, err
+		}
+		var voices []TTSVoice
+		for _, model := range models {
+			// Piper models are named like en_US-lessac-medium
+			id := strings.TrimSuffix(filepath.Base(model), ".onnx")
+			language, _, _ := strings.Cut(id, "-")
+			voices = append(voices, TTSVoice{ID: id, Name: id, Language: language})
+		}
+		return voices, nil
+	case ttsEngineEspeak:
+		output, err := exec.CommandContext(ctx, p.binary, "--voices").Output()
+		if err != nil {
+			return nil, fmt.Errorf("%s --voices: %w", p.binary, err)
+		}
+		return parseEspeakVoices(output), nil
+	}
+	return nil, fmt.Errorf("unknown offline TTS engine %q", p.engine)
+}
+
+// parseEspeakVoices reads the table of `espeak-ng --voices`:
+// Pty Language Age/Gender VoiceName File Other Languages
+func parseEspeakVoices(output []byte) []TTSVoice {
+	var voices []TTSVoice
+	for i, line := range bytes.Split(output, []byte("\n")) {
+		fields := strings.Fields(string(line))
+		if i == 0 || len(fields) < 5 {
+			continue
+		}
+		voices = append(voices, TTSVoice{ID: fields[1], Name: fields[3], Language: fields[1]})
+	}
+	return voices
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// espeakVoices is `espeak-ng --voices` output from espeak-ng 1.51, trimmed
+const espeakVoices = `Pty Language       Age/Gender VoiceName          File                 Other Languages
+ 5  af              --/M      Afrikaans          gmw/af               
+ 5  cmn             --/M      Chinese_(Mandarin,_latin_as_English) sit/cmn              (zh-cmn 5)(zh 5)
+ 2  en-gb           --/M      English_(Great_Britain) gmw/en               (en 2)
+ 5  en-gb-scotland  --/M      English_(Scotland) gmw/en-GB-scotland   (en 4)
+ 5  en-us           --/M      English_(America)  gmw/en-US            (en 3)
+ 5  pt-br           --/M      Portuguese_(Brazil) roa/pt-BR            (pt 5)
+`
+
+func TestParseEspeakVoices(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []string // "id name"
+	}{
+		{"voices table", espeakVoices, []string{
+			"af Afrikaans",
+			"cmn Chinese_(Mandarin,_latin_as_English)",
+			"en-gb English_(Great_Britain)",
+			"en-gb-scotland English_(Scotland)",
+			"en-us English_(America)",
+			"pt-br Portuguese_(Brazil)",
+		}},
+		{"header only", "Pty Language       Age/Gender VoiceName          File                 Other Languages\n", nil},
+		{"empty", "", nil},
+		{"short lines are skipped", "Pty Language Age/Gender VoiceName File\n 5  xx  --/M\n\n 5  fr  --/M  French  roa/fr\n",
+			[]string{"fr French"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			voices := parseEspeakVoices([]byte(tt.output))
+			var got []string
+			for _, voice := range voices {
+				if voice.Language != voice.ID {
+					t.Errorf("voice %s has language %q, want its ID", voice.ID, voice.Language)
+				}
+				got = append(got, voice.ID+" "+voice.Name)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("voices = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPiperModel(t *testing.T) {
+	tests := []struct {
+		name     string
+		voice    string // The channel's
+		fallback string // voice.offline_voice
+		modelDir string
+		want     string
+		wantErr  bool
+	}{
+		{"channel voice in the model dir", "en_US-lessac-medium", "en_GB-alan-low", "/models", "/models/en_US-lessac-medium.onnx", false},
+		{"default voice", "", "en_GB-alan-low", "/models", "/models/en_GB-alan-low.onnx", false},
+		{"extension kept", "en_US-lessac-medium.onnx", "", "/models", "/models/en_US-lessac-medium.onnx", false},
+		{"absolute path ignores the model dir", "/opt/piper/voice.onnx", "", "/models", "/opt/piper/voice.onnx", false},
+		{"absolute path gets the extension", "/opt/piper/voice", "", "/models", "/opt/piper/voice.onnx", false},
+		{"relative name without a model dir", "en_US-lessac-medium", "", "", "en_US-lessac-medium.onnx", false},
+		{"nested relative name", "en/en_US-lessac-medium", "", "/models", "/models/en/en_US-lessac-medium.onnx", false},
+		{"no voice at all", "", "", "/models", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &offlineTTS{engine: ttsEnginePiper, voice: tt.fallback, modelDir: tt.modelDir}
+			got, err := p.piperModel(tt.voice)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("piperModel(%q) error = %v, want error %v", tt.voice, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("piperModel(%q) = %q, want %q", tt.voice, got, tt.want)
+			}
+		})
+	}
+}
@@ -12,6 +12,7 @@ type ScriptConfig struct {
 	MetaTagFilename      string // New field for meta tag filename
 	OutputFolder         string // New field for output folder path (video title)
 	SleepBetweenSections time.Duration
+	TargetMinutes        int // Long-form runtime target, 0 for the regular section count
 	channel              Channel
 }
 
@@ -29,9 +30,119 @@ const (
 	visualImageMultiplier       = 5
 	defaultSleepBetweenSections = 1 * time.Second
 	maxRetries                  = 5
-	splitVoiceByCharLimit       = 4990 // Maximum character limit for splitting text into manageable chunks for voice generation
+	defaultVoiceSplitCharLimit  = 4990 // Maximum character limit for splitting text into manageable chunks for voice generation
+	maxVoiceSplitCharLimit      = 5000 // Largest request ElevenLabs accepts
+	maxVoiceKeyAttempts         = 5    // ElevenLabs keys tried for one chunk before it fails
+	lowElevenLabsCredits        = 1000 // Characters left on a key below which voiceovers warn
 	splitSrtByCharLimit         = 280
 	splitByCharLimit            = 1000 // Maximum character limit for splitting text into manageable chunks for visual generation
+
+	// Long-form mode
+	defaultWordsPerMinute       = 140 // Narration pace used until a channel has measured scripts
+	maxLongFormMinutes          = 180
+	longFormActCount            = 3
+	longFormSectionsPerChapter  = 4
+	longFormSummaryWordLimit    = 350 // Size of the rolling summary passed to each section prompt
+	wordsPerMinuteSampleScripts = 10  // Recent scripts used to measure a channel's words per minute
+
+	// Topic backlog
+	defaultTopicIdeaCount    = 10
+	maxTopicIdeaCount        = 50
+	topicHistoryLimit        = 200 // Past topics sent to the LLM so it avoids repeating them
+	topicSimilarityThreshold = 0.6 // Token overlap above which two topics count as duplicates
+
+	// Chapters (YouTube requires at least 3, the first at 0:00, each at least 10s long)
+	minChapterCount       = 3
+	minChapterSeconds     = 10.0
+	chapterMatchWords     = 8   // Opening words of a section searched for in the subtitles
+	chapterMatchThreshold = 0.6 // Share of those words that must line up
+	chaptersHeader        = "Chapters:"
+
+	// Thumbnails
+	thumbnailWidth           = 1280
+	thumbnailHeight          = 720
+	defaultThumbnailVariants = 3
+	maxThumbnailVariants     = 10
+
+	// Uploads
+	uploadChunkSize      = 8 * 1024 * 1024 // Multiple of 256KB as the resumable protocol requires
+	uploadMaxRetries     = 5
+	youtubeTitleLimit    = 100
+	youtubeDescLimit     = 5000
+	youtubeTagsCharLimit = 500
+	youtubeOAuthStateTTL = 10 * time.Minute // How long a consent URL stays usable
+
+	// Script listings
+	defaultScriptPageSize = 20
+	maxScriptPageSize     = 100
+
+	// NLE export
+	nleFrameRate   = 30
+	nleCaptionRole = "SRT?captionFormat=SRT.en"
+
+	// API authentication
+	authTokenPrefix      = "ytk_"
+	authTokenBytes       = 32
+	authLastUsedInterval = time.Minute // Minimum gap between last_used_at writes
+	authBodyPeekLimit    = 1 << 20     // Largest body read to find the channel a request targets
+
+	// CORS, for origins in server.cors_allowed_origins
+	corsAllowedMethods = "GET, POST, PUT, PATCH, DELETE, OPTIONS"
+	corsAllowedHeaders = "Authorization, Content-Type"
+	corsExposedHeaders = "Retry-After, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset"
+
+	// Inbound rate limits (0 disables a limit)
+	defaultTokenRequestsPerMinute   = 300
+	defaultChannelRequestsPerMinute = 120
+	defaultTokenConcurrentJobs      = 4
+	defaultChannelConcurrentJobs    = 2
+	jobLeaseTTL                     = 5 * time.Minute // Leases not renewed for this long belong to a dead process
+	jobLeaseRenewInterval           = time.Minute
+	jobRetryAfter                   = 30 * time.Second
+
+	// Batches
+	maxBatchItems                 = 500
+	defaultChannelConcurrency     = 1
+	defaultBatchSchedulerInterval = 30 * time.Second
+	defaultBatchItemTimeout       = 6 * time.Hour // Running items older than this are marked failed
+
+	// Metrics
+	metricsQueryTimeout = 5 * time.Second // Per scrape, for the gauges counted in Mongo
+
+	// Script logs
+	scriptLogRetention     = 30 * 24 * time.Hour
+	scriptLogQueueSize     = 4096 // Lines beyond this are only written to stdout
+	scriptLogBatchSize     = 200
+	scriptLogFlushInterval = 2 * time.Second
+	maxScriptLogPageSize   = 2000
+
+	// Shutdown
+	defaultShutdownTimeout = 2 * time.Minute  // For requests and jobs to reach a checkpoint, server.shutdown_timeout overrides
+	shutdownCancelGrace    = 15 * time.Second // For cancelled jobs to mark their rows interrupted
+	shutdownFlushTimeout   = 10 * time.Second // For script logs and spans still buffered
+
+	// Configuration
+	defaultConfigFile = "config.yaml" // CONFIG_FILE overrides
+
+	// API key encryption
+	masterKeySize = 32 // AES-256, for master and data keys
+
+	// Text to speech
+	TTSProviderElevenLabs = "elevenlabs"
+	TTSProviderOffline    = "offline" // A local engine, see voice.offline_engine
+	ttsEnginePiper        = "piper"
+	ttsEngineEspeak       = "espeak-ng"
+	narrationFormat       = "mp3" // Format of voiceover chunks, which are concatenated as they are
+
+	// API key health
+	keyRateLimitCooldown    = time.Minute      // First cooldown after a 429, doubling with each failure in a row
+	keyServerErrorCooldown  = 30 * time.Second // First cooldown after a 5xx
+	maxKeyCooldown          = time.Hour
+	keyProbeInterval        = 30 * time.Second
+	keyProbeTimeout         = 15 * time.Second
+	revokedKeyProbeInterval = 6 * time.Hour // Revoked keys are only re-checked by providers with a probe
+	maxKeyIdleWeight        = time.Hour     // Idle time beyond this no longer raises a key's odds of being picked
+	maxKeyErrorLength       = 500
 )
 
 // Gemini API types
@@ -0,0 +1,425 @@
+// File: uploader.go
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	UploadStatusUploading = "uploading"
+	UploadStatusCompleted = "completed"
+	UploadStatusFailed    = "failed"
+	// A shutdown cancelled the upload; publishing again starts a new one
+	UploadStatusInterrupted = StatusInterrupted
+
+	PrivacyPrivate  = "private"
+	PrivacyUnlisted = "unlisted"
+	PrivacyPublic   = "public"
+)
+
+// UploadRequest is everything an Uploader needs to publish one video
+type UploadRequest struct {
+	VideoPath     string
+	ThumbnailPath string // Optional
+	Title         string
+	Description   string
+	Tags          []string
+	CategoryID    string
+	PrivacyStatus string
+	PublishAt     *time.Time // Scheduled publish time, requires private
+}
+
+type UploadResult struct {
+	VideoID      string
+	VideoURL     string
+	ThumbnailSet bool
+}
+
+// Uploader publishes a finished video to a hosting platform.
+// progress is called with the number of bytes sent so far.
+type Uploader interface {
+	Name() string
+	Upload(ctx context.Context, channel *Channel, req UploadRequest, progress func(sent, total int64)) (*UploadResult, error)
+}
+
+// NewUploader picks the implementation from uploads.uploader ("youtube" or "fake")
+func NewUploader() Uploader {
+	uploads := appConfig().Uploads
+	switch uploads.Uploader {
+	case "fake":
+		return &FakeUploader{OutputDir: uploads.FakeDir}
+	default:
+		return NewYouTubeUploader()
+	}
+}
+
+// FakeUploader copies the video into a local folder instead of publishing it, for local testing
+type FakeUploader struct {
+	OutputDir string
+}
+
+func (f *FakeUploader) Name() string {
+	return "fake"
+}
+
+func (f *FakeUploader) Upload(ctx context.Context, channel *Channel, req UploadRequest, progress func(sent, total int64)) (*UploadResult, error) {
+	src, err := os.Open(req.VideoPath)
+	if err != nil {
+		return nil, fmt.Errorf("opening video: %w", err)
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("reading video size: %w", err)
+	}
+
+	videoID := primitive.NewObjectID().Hex()
+	dir := filepath.Join(f.OutputDir, channel.ChannelName, videoID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating upload directory: %w", err)
+	}
+
+	dst, err := os.Create(filepath.Join(dir, "video"+filepath.Ext(req.VideoPath)))
+	if err != nil {
+		return nil, fmt.Errorf("creating upload file: %w", err)
+	}
+	defer dst.Close()
+
+	written, err := io.Copy(dst, src)
+	if err != nil {
+		return nil, fmt.Errorf("copying video: %w", err)
+	}
+	progress(written, info.Size())
+
+	metadata, _ := json.MarshalIndent(req, "", "  ")
+	if err := os.WriteFile(filepath.Join(dir, "metadata.json"), metadata, 0644); err != nil {
+		return nil, fmt.Errorf("writing metadata: %w", err)
+	}
+
+	slog.InfoContext(ctx, "Fake upload stored", "dir", dir)
+	return &UploadResult{
+		VideoID:      videoID,
+		VideoURL:     "file://" + dir,
+		ThumbnailSet: req.ThumbnailPath != "",
+	}, nil
+}
+
+type PublishRequest struct {
+	PrivacyStatus    string     `json:"privacy_status"`
+	PublishAt        *time.Time `json:"publish_at,omitempty"`
+	CategoryID       string     `json:"category_id,omitempty"`
+	VideoPath        string     `json:"video_path,omitempty"`        // Relative to uploads.video_dir; defaults to the latest generated video
+	ThumbnailVariant int        `json:"thumbnail_variant,omitempty"` // Defaults to the first variant
+}
+
+// UploadResponse carries the running upload directly, or under data when a new one starts
+type UploadResponse struct {
+	Message string        `json:"message"`
+	Upload  *UploadStatus `json:"upload,omitempty"`
+	Data    *UploadData   `json:"data,omitempty"`
+}
+
+type UploadData struct {
+	ScriptID string       `json:"script_id"`
+	Upload   UploadStatus `json:"upload"`
+}
+
+// truncateTags keeps whole tags up to YouTube's combined tag length limit
+func truncateTags(tags []string) []string {
+	var kept []string
+	total := 0
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		if total+len(tag) > youtubeTagsCharLimit {
+			break
+		}
+		total += len(tag) + 1
+		kept = append(kept, tag)
+	}
+	return kept
+}
+
+func buildUploadRequest(script *Script, req PublishRequest, videoPath string) UploadRequest {
+	title := strings.NewReplacer("<", "", ">", "").Replace(strings.TrimSpace(script.Meta.Title))
+	if title == "" {
+		title = script.Topic
+	}
+	if len([]rune(title)) > youtubeTitleLimit {
+		title = string([]rune(title)[:youtubeTitleLimit])
+	}
+	description := script.Meta.Description
+	if len(description) > youtubeDescLimit {
+		description = strings.ToValidUTF8(truncateString(description, youtubeDescLimit-3), "")
+	}
+
+	upload := UploadRequest{
+		VideoPath:     videoPath,
+		Title:         title,
+		Description:   description,
+		Tags:          truncateTags(script.Meta.Tags),
+		CategoryID:    req.CategoryID,
+		PrivacyStatus: req.PrivacyStatus,
+		PublishAt:     req.PublishAt,
+	}
+	for _, thumbnail := range script.Thumbnails {
+		if req.ThumbnailVariant == 0 || thumbnail.Variant == req.ThumbnailVariant {
+			upload.ThumbnailPath = thumbnail.Path
+			break
+		}
+	}
+	return upload
+}
+
+// uploadVideoPath resolves a publish request's video_path inside uploads.video_dir, so a
+// client can only upload files that were put there for publishing
+func uploadVideoPath(videoPath string) (string, error) {
+	dir, err := filepath.Abs(appConfig().Uploads.VideoDir)
+	if err == nil {
+		dir, err = filepath.EvalSymlinks(dir)
+	}
+	if err != nil {
+		return "", fmt.Errorf("uploads.video_dir: %w", err)
+	}
+	// Cleaning against the root drops any leading .. before joining
+	path, err := filepath.EvalSymlinks(filepath.Join(dir, filepath.Clean("/"+videoPath)))
+	if err != nil {
+		return "", fmt.Errorf("video_path %q not found", videoPath)
+	}
+	// Checked after resolving so a symlink cannot lead out of the directory either
+	if !strings.HasPrefix(path, dir+string(filepath.Separator)) {
+		return "", fmt.Errorf("video_path must name a file in %s", appConfig().Uploads.VideoDir)
+	}
+	if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+		return "", fmt.Errorf("video_path %q not found", videoPath)
+	}
+	return path, nil
+}
+
+// latestVideo returns where the script's latest completed render is, a local path or a URL
+func (yt *YtAutomation) latestVideo(scriptID primitive.ObjectID) (string, error) {
+	status, err := yt.getVideoGenerationStatus(scriptID)
+	if err != nil || status.Status != "completed" || status.VideoURL == "" {
+		return "", fmt.Errorf("no completed video found for script")
+	}
+	return status.VideoURL, nil
+}
+
+// localVideoFile returns a local path for a video, downloading it first when the renderer
+// only gave back a URL. cleanup removes any temporary download.
+func (yt *YtAutomation) localVideoFile(ctx context.Context, video string) (string, func(), error) {
+	noop := func() {}
+	if !strings.HasPrefix(video, "http://") && !strings.HasPrefix(video, "https://") {
+		return video, noop, nil
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", video, nil)
+	if err != nil {
+		return "", noop, fmt.Errorf("downloading video: %w", err)
+	}
+	resp, err := yt.client.Do(httpReq)
+	if err != nil {
+		return "", noop, fmt.Errorf("downloading video: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", noop, fmt.Errorf("downloading video: status %d", resp.StatusCode)
+	}
+
+	file, err := os.CreateTemp("", "upload-*.mp4")
+	if err != nil {
+		return "", noop, fmt.Errorf("creating temp file: %w", err)
+	}
+	cleanup := func() { os.Remove(file.Name()) }
+	if _, err := io.Copy(file, resp.Body); err != nil {
+		file.Close()
+		cleanup()
+		return "", noop, fmt.Errorf("downloading video: %w", err)
+	}
+	file.Close()
+	return file.Name(), cleanup, nil
+}
+
+func (yt *YtAutomation) updateUploadStatus(scriptID primitive.ObjectID, fields bson.M) {
+	update := bson.M{}
+	for k, v := range fields {
+		update["upload."+k] = v
+	}
+	if err := yt.updateScriptInDB(scriptID, update); err != nil {
+		slog.Warn("Failed to update upload status", logKeyScriptID, scriptID.Hex(), "error", err)
+	}
+}
+
+// PublishScript fetches the video upload.VideoPath names, a local path or the renderer's
+// URL, uploads it and records the result on the script
+func (yt *YtAutomation) PublishScript(ctx context.Context, script *Script, channel *Channel, upload UploadRequest) {
+	scriptID := script.ID
+	ctx = withScriptLog(ctx, scriptID)
+
+	videoPath, cleanup, err := yt.localVideoFile(ctx, upload.VideoPath)
+	if err != nil {
+		yt.failUpload(ctx, scriptID, err)
+		return
+	}
+	defer cleanup()
+	upload.VideoPath = videoPath
+
+	lastProgress := -1
+	progress := func(sent, total int64) {
+		if total <= 0 {
+			return
+		}
+		percent := int(sent * 100 / total)
+		// Only write every 5% to keep database traffic down
+		if percent/5 != lastProgress/5 {
+			lastProgress = percent
+			yt.updateUploadStatus(scriptID, bson.M{"progress": percent})
+		}
+	}
+
+	result, err := yt.uploader.Upload(ctx, channel, upload, progress)
+	now := time.Now()
+	if interrupted(err) {
+		// Uploads have no checkpoint, so they run until the drain timeout cancels them
+		yt.updateUploadStatus(scriptID, bson.M{"status": UploadStatusInterrupted, "completed_at": now})
+		slog.InfoContext(ctx, "Upload interrupted by shutdown", "uploader", yt.uploader.Name())
+		return
+	}
+	if err != nil {
+		yt.failUpload(ctx, scriptID, err)
+		return
+	}
+
+	yt.updateUploadStatus(scriptID, bson.M{
+		"status":        UploadStatusCompleted,
+		"progress":      100,
+		"video_id":      result.VideoID,
+		"video_url":     result.VideoURL,
+		"thumbnail_set": result.ThumbnailSet,
+		"completed_at":  now,
+	})
+	slog.InfoContext(ctx, "Uploaded video", "uploader", yt.uploader.Name(), "video_url", result.VideoURL)
+}
+
+func (yt *YtAutomation) failUpload(ctx context.Context, scriptID primitive.ObjectID, err error) {
+	yt.updateUploadStatus(scriptID, bson.M{
+		"status":       UploadStatusFailed,
+		"error_msg":    err.Error(),
+		"completed_at": time.Now(),
+	})
+	slog.ErrorContext(ctx, "Upload failed", "uploader", yt.uploader.Name(), "error", err)
+}
+
+func (yt *YtAutomation) uploadVideoHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if r.Method != "POST" {
+		respondWithError(w, http.StatusMethodNotAllowed, "Only POST method is allowed")
+		return
+	}
+
+	// Extract script ID from URL path (/upload-video/{scriptID})
+	path := strings.TrimPrefix(r.URL.Path, "/upload-video/")
+	scriptID, err := primitive.ObjectIDFromHex(path)
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid script ID format")
+		return
+	}
+
+	var req PublishRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondWithError(w, http.StatusBadRequest, "Invalid JSON request body")
+			return
+		}
+	}
+	if req.PrivacyStatus == "" {
+		req.PrivacyStatus = PrivacyPrivate
+	}
+	if req.PrivacyStatus != PrivacyPrivate && req.PrivacyStatus != PrivacyUnlisted && req.PrivacyStatus != PrivacyPublic {
+		respondWithError(w, http.StatusBadRequest, "privacy_status must be private, unlisted or public")
+		return
+	}
+	if req.PublishAt != nil {
+		if req.PublishAt.Before(time.Now()) {
+			respondWithError(w, http.StatusBadRequest, "publish_at must be in the future")
+			return
+		}
+		// YouTube only schedules private videos; they flip to public at publish_at
+		req.PrivacyStatus = PrivacyPrivate
+	}
+
+	script, err := yt.getScriptByID(scriptID)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			respondWithError(w, http.StatusNotFound, "Script not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Database error: %v", err))
+		return
+	}
+	if script.Upload != nil && script.Upload.Status == UploadStatusUploading {
+		respondWithJSON(w, http.StatusOK, UploadResponse{
+			Message: "Upload already in progress",
+			Upload:  script.Upload,
+		})
+		return
+	}
+	if script.Upload != nil && script.Upload.Status == UploadStatusCompleted {
+		respondWithError(w, http.StatusConflict, fmt.Sprintf("Script already uploaded as %s", script.Upload.VideoURL))
+		return
+	}
+
+	channel, err := yt.getChannelByID(script.ChannelID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Database error: %v", err))
+		return
+	}
+
+	// Only find the video here; a URL is downloaded by the job
+	var video string
+	if req.VideoPath != "" {
+		video, err = uploadVideoPath(req.VideoPath)
+	} else {
+		video, err = yt.latestVideo(scriptID)
+	}
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	upload := buildUploadRequest(script, req, video)
+
+	status := UploadStatus{
+		Status:        UploadStatusUploading,
+		Provider:      yt.uploader.Name(),
+		PrivacyStatus: upload.PrivacyStatus,
+		PublishAt:     upload.PublishAt,
+		StartedAt:     time.Now(),
+	}
+	if err := yt.updateScriptInDB(scriptID, bson.M{"upload": status}); err != nil {
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to save upload status: %v", err))
+		return
+	}
+
+	runJob(r.Context(), func(ctx context.Context) { yt.PublishScript(ctx, script, channel, upload) })
+
+	respondWithJSON(w, http.StatusAccepted, UploadResponse{
+		Message: "Upload started",
+		Data:    &UploadData{ScriptID: path, Upload: status},
+	})
+}
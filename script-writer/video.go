@@ -2,12 +2,13 @@ package main
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
-	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -69,6 +70,17 @@ type VideoResponse struct {
 	ProcessID string `json:"process_id,omitempty"`
 }
 
+// VideoJobResponse is returned when video generation starts or is already running
+type VideoJobResponse struct {
+	Message   string `json:"message"`
+	Status    string `json:"status"`
+	ScriptID  string `json:"script_id,omitempty"`
+	StatusID  string `json:"status_id,omitempty"`
+	CheckURL  string `json:"check_url,omitempty"` // Poll for progress
+	ProcessID string `json:"process_id,omitempty"`
+	Progress  *int   `json:"progress,omitempty"`
+}
+
 // VideoGenerationStatus tracks the video generation process
 type VideoGenerationStatus struct {
 	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
@@ -154,20 +166,23 @@ func (yt *YtAutomation) buildVideoRequest(script *Script, chunkVisuals []ChunkVi
 		// Parse actual start and end times as float64
 		startTime, err := strconv.ParseFloat(chunk.StartTime, 64)
 		if err != nil {
-			fmt.Printf("Warning: Could not parse start_time '%s' for chunk %d: %v\n", chunk.StartTime, i, err)
+			slog.Warn("Could not parse visual start_time", logKeyScriptID, script.ID.Hex(),
+				logKeyChunkIndex, chunk.ChunkIndex, "start_time", chunk.StartTime, "error", err)
 			continue
 		}
 
 		endTime, err := strconv.ParseFloat(chunk.EndTime, 64)
 		if err != nil {
-			fmt.Printf("Warning: Could not parse end_time '%s' for chunk %d: %v\n", chunk.EndTime, i, err)
+			slog.Warn("Could not parse visual end_time", logKeyScriptID, script.ID.Hex(),
+				logKeyChunkIndex, chunk.ChunkIndex, "end_time", chunk.EndTime, "error", err)
 			continue
 		}
 
 		// Calculate precise duration
 		actualDuration := endTime - startTime
 		if actualDuration <= 0 {
-			fmt.Printf("Warning: Invalid duration for chunk %d (start: %f, end: %f)\n", i, startTime, endTime)
+			slog.Warn("Invalid visual duration, using 1s", logKeyScriptID, script.ID.Hex(),
+				logKeyChunkIndex, chunk.ChunkIndex, "start_time", startTime, "end_time", endTime)
 			actualDuration = 1.0 // minimum 1 second
 		}
 
@@ -202,9 +217,16 @@ func (yt *YtAutomation) buildVideoRequest(script *Script, chunkVisuals []ChunkVi
 }
 
 // Enhanced video generation with proper error handling and status updates
-func (yt *YtAutomation) generateVideoAsync(statusID primitive.ObjectID, videoRequest *VideoRequest) error {
+func (yt *YtAutomation) generateVideoAsync(ctx context.Context, statusID primitive.ObjectID, videoRequest *VideoRequest) (err error) {
+	ctx, end := startStage(ctx, StageRender)
+	defer end(&err)
+
+	if err := checkpoint(ctx); err != nil {
+		return err
+	}
+
 	// Update status to processing
-	err := yt.updateVideoGenerationStatus(statusID, VideoGenerationStatus{
+	err = yt.updateVideoGenerationStatus(statusID, VideoGenerationStatus{
 		Status:    "processing",
 		Progress:  10,
 		UpdatedAt: time.Now(),
@@ -232,15 +254,15 @@ func (yt *YtAutomation) generateVideoAsync(statusID primitive.ObjectID, videoReq
 	})
 
 	// Make API call
-	apiURL := os.Getenv("VIDEO_SERVER_API_URL")
+	apiURL := appConfig().Services.VideoURL
 	if apiURL == "" {
-		return fmt.Errorf("VIDEO_SERVER_API_URL environment variable not set")
+		return fmt.Errorf("services.video_url (VIDEO_SERVER_API_URL) is not set")
 	}
 
 	url := fmt.Sprintf("%s/generate", strings.TrimSuffix(apiURL, "/"))
 
 	// Create request with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(requestBody))
@@ -250,10 +272,10 @@ func (yt *YtAutomation) generateVideoAsync(statusID primitive.ObjectID, videoReq
 
 	// Set headers
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", getEnvWithDefault("USER_AGENT", "YT-Automation/1.0"))
+	req.Header.Set("User-Agent", cmp.Or(appConfig().Server.UserAgent, "YT-Automation/1.0"))
 
 	// Add API key if available
-	if apiKey := os.Getenv("VIDEO_API_KEY"); apiKey != "" {
+	if apiKey := appConfig().Services.VideoAPIKey; apiKey != "" {
 		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", apiKey))
 	}
 
@@ -270,10 +292,14 @@ func (yt *YtAutomation) generateVideoAsync(statusID primitive.ObjectID, videoReq
 	for i := 0; i < maxRetries; i++ {
 		resp, err = yt.client.Do(req)
 		if err == nil {
+			recordProviderStatus(ProviderJSONToVideo, resp.StatusCode)
 			break
 		}
+		recordProviderCall(ProviderJSONToVideo, err)
 		if i < maxRetries-1 {
-			time.Sleep(time.Duration(i+1) * time.Second)
+			if err := sleepContext(ctx, time.Duration(i+1)*time.Second); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -311,7 +337,7 @@ func (yt *YtAutomation) generateVideoAsync(statusID primitive.ObjectID, videoReq
 		} else {
 			errorMsg += fmt.Sprintf(": %s", string(responseBody))
 		}
-		return fmt.Errorf(errorMsg)
+		return fmt.Errorf("%s", errorMsg)
 	}
 
 	// Update final status
@@ -340,7 +366,6 @@ func (yt *YtAutomation) generateVideoAsync(statusID primitive.ObjectID, videoReq
 // Video status endpoint
 func (yt *YtAutomation) videoStatusHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
 
 	// Extract status ID from URL
 	path := strings.TrimPrefix(r.URL.Path, "/video-status/")
@@ -498,13 +523,6 @@ func (yt *YtAutomation) parseTimestampFloat(timestamp string) (float64, error) {
 	return float64(hours)*3600 + float64(minutes)*60 + seconds, nil
 }
 
-func getEnvWithDefault(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
-}
-
 func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	response, _ := json.Marshal(payload)
 	w.Header().Set("Content-Type", "application/json")
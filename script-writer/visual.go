@@ -5,100 +5,109 @@ import (
 	"fmt"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"log"
-	"os"
+	"log/slog"
 	"sort"
 	"strings"
 	"time"
 )
 
-func (yt *YtAutomation) generateVisualPromptForChunks(scriptID primitive.ObjectID, chunks []ScriptSrt, styleID primitive.ObjectID, force bool) error {
-	fmt.Printf("🎨 Starting visual prompt generation for %d chunks...\n", len(chunks))
+func (yt *YtAutomation) generateVisualPromptForChunks(ctx context.Context, scriptID primitive.ObjectID, chunks []ScriptSrt, styleID primitive.ObjectID, force bool) error {
+	slog.InfoContext(ctx, "Starting visual prompt generation", "chunks", len(chunks))
 	script, err := yt.getScriptByID(scriptID)
 	if err != nil {
 		return fmt.Errorf("loading script: %w", err)
 	}
 
 	for i, chunk := range chunks {
-		fmt.Printf("Processing chunk %d/%d...\n", i+1, len(chunks))
+		// Chunks not reached have no visuals yet, so the next run picks them up
+		if err := checkpoint(ctx); err != nil {
+			return err
+		}
+		ctx := withLogAttrs(ctx, slog.Int(logKeyChunkIndex, chunk.ChunkIndex))
+		slog.DebugContext(ctx, "Processing chunk", "position", i+1, "chunks", len(chunks))
 
 		// Check if visuals already exist for this chunk
 		shouldSkip, err := yt.checkExistingVisuals(scriptID, chunk, force)
 		if err != nil {
-			fmt.Printf("Warning: Failed to check existing visuals for chunk %d: %v\n", chunk.ChunkIndex, err)
+			slog.WarnContext(ctx, "Failed to check existing visuals", "error", err)
 			continue
 		}
 
 		if shouldSkip {
-			fmt.Printf("Skipping chunk %d - visuals already exist\n", chunk.ChunkIndex)
+			slog.InfoContext(ctx, "Skipping chunk, visuals already exist")
 			continue
 		}
 
-		fmt.Printf("Generating visual prompt for chunk %d/%d...\n", i+1, len(chunks))
+		slog.InfoContext(ctx, "Generating visual prompts for chunk", "position", i+1, "chunks", len(chunks))
 
 		// Generate visual prompt using Gemini (expensive API call)
-		visualPrompts, err := yt.generateVisualPrompts(chunk.Content, script, styleID)
+		visualPrompts, err := yt.generateVisualPrompts(ctx, chunk.Content, script, styleID)
 		if err != nil {
-			fmt.Printf("Warning: Failed to generate prompt visual for chunk %d: %v\n", chunk.ChunkIndex, err)
+			slog.WarnContext(ctx, "Failed to generate visual prompts", "error", err)
 			continue
 		}
 
 		// Save visual prompts to database
-		if err := yt.saveChunkVisuals(scriptID, chunk, visualPrompts, force); err != nil {
-			fmt.Printf("Warning: Failed to save visuals prompt for chunk %d: %v\n", chunk.ChunkIndex, err)
+		if err := yt.saveChunkVisuals(ctx, scriptID, chunk, visualPrompts, force); err != nil {
+			slog.WarnContext(ctx, "Failed to save visual prompts", "error", err)
 			continue
 		}
 
 		// Update chunk to mark it has visual
-		yt.updateChunkVisualStatus(chunk.ID, true)
+		yt.updateChunkVisualStatus(ctx, chunk.ID, true)
 
 		// Small delay between API calls
 		time.Sleep(1 * time.Second)
 	}
 
-	fmt.Printf("✓ Completed visual prompt generation for all chunks\n")
+	slog.InfoContext(ctx, "Completed visual prompt generation")
 	return nil
 }
 
-func (yt *YtAutomation) generateVisualPromptForChunksWithRecovery(scriptID primitive.ObjectID, scriptSrtChunks []ScriptSrt, styleID primitive.ObjectID, force bool) error {
-	fmt.Printf("🎨 Starting visual prompt generation for %d chunks...\n", len(scriptSrtChunks))
+func (yt *YtAutomation) generateVisualPromptForChunksWithRecovery(ctx context.Context, scriptID primitive.ObjectID, scriptSrtChunks []ScriptSrt, styleID primitive.ObjectID, force bool) error {
+	slog.InfoContext(ctx, "Starting visual prompt generation", "chunks", len(scriptSrtChunks))
 	script, err := yt.getScriptByID(scriptID)
 	if err != nil {
 		return fmt.Errorf("loading script: %w", err)
 	}
 
 	for i, chunk := range scriptSrtChunks {
-		fmt.Printf("Processing chunk %d/%d...\n", i+1, len(scriptSrtChunks))
+		// Chunks not reached have no visuals yet, so the next run picks them up
+		if err := checkpoint(ctx); err != nil {
+			return err
+		}
+		ctx := withLogAttrs(ctx, slog.Int(logKeyChunkIndex, chunk.ChunkIndex))
+		slog.DebugContext(ctx, "Processing chunk", "position", i+1, "chunks", len(scriptSrtChunks))
 
 		// Check if visuals already exist for this chunk
 		shouldSkip, err := yt.checkExistingVisuals(scriptID, chunk, force)
 		if err != nil {
-			fmt.Printf("Warning: Failed to check existing visuals for chunk %d: %v\n", chunk.ChunkIndex, err)
+			slog.WarnContext(ctx, "Failed to check existing visuals", "error", err)
 			continue
 		}
 
 		if shouldSkip {
-			fmt.Printf("Skipping chunk %d - visuals already exist\n", chunk.ChunkIndex)
+			slog.InfoContext(ctx, "Skipping chunk, visuals already exist")
 			continue
 		}
 
-		fmt.Printf("Generating visual prompt for chunk %d/%d...\n", i+1, len(scriptSrtChunks))
+		slog.InfoContext(ctx, "Generating visual prompts for chunk", "position", i+1, "chunks", len(scriptSrtChunks))
 
 		// Generate visual prompt using Gemini (expensive API call)
-		visualPrompts, err := yt.generateVisualPrompts(chunk.Content, script, styleID)
+		visualPrompts, err := yt.generateVisualPrompts(ctx, chunk.Content, script, styleID)
 		if err != nil {
-			fmt.Printf("Warning: Failed to generate prompt visual for chunk %d: %v\n", chunk.ChunkIndex, err)
+			slog.WarnContext(ctx, "Failed to generate visual prompts", "error", err)
 			continue
 		}
 
 		// Save visual prompts to database
-		if err := yt.saveChunkVisuals(scriptID, chunk, visualPrompts, force); err != nil {
-			fmt.Printf("Warning: Failed to save visuals prompt for chunk %d: %v\n", chunk.ChunkIndex, err)
+		if err := yt.saveChunkVisuals(ctx, scriptID, chunk, visualPrompts, force); err != nil {
+			slog.WarnContext(ctx, "Failed to save visual prompts", "error", err)
 			continue
 		}
 
 		// Update chunk to mark it has visual
-		yt.updateChunkVisualStatus(chunk.ID, true)
+		yt.updateChunkVisualStatus(ctx, chunk.ID, true)
 
 		// Small delay between API calls
 		time.Sleep(1 * time.Second)
@@ -106,8 +115,8 @@ func (yt *YtAutomation) generateVisualPromptForChunksWithRecovery(scriptID primi
 
 	// After generating all visual prompts for chunks, perform gap analysis and recovery
 	for _, chunk := range scriptSrtChunks {
+		ctx := withLogAttrs(ctx, slog.Int(logKeyChunkIndex, chunk.ChunkIndex))
 		// Get existing visual prompts for this chunk
-		ctx := context.Background()
 		visualCursor, err := chunkVisualsCollection.Find(ctx, bson.M{
 			"script_id":   scriptID,
 			"chunk_index": chunk.ChunkIndex,
@@ -130,9 +139,9 @@ func (yt *YtAutomation) generateVisualPromptForChunksWithRecovery(scriptID primi
 		}
 
 		// Validate and recover gaps
-		recoveredPrompts, err := yt.validateAndRecoverVisualPrompts(chunk.Content, &script, styleID, existingPrompts)
+		recoveredPrompts, err := yt.validateAndRecoverVisualPrompts(ctx, chunk.Content, &script, styleID, existingPrompts)
 		if err != nil {
-			log.Printf("Gap recovery failed for chunk %d: %v", chunk.ChunkIndex, err)
+			slog.WarnContext(ctx, "Gap recovery failed", "error", err)
 			continue
 		}
 
@@ -176,10 +185,10 @@ func (yt *YtAutomation) generateVisualPromptForChunksWithRecovery(scriptID primi
 				}
 
 				if _, err := chunkVisualsCollection.InsertOne(ctx, chunkVisual); err != nil {
-					log.Printf("Failed to save recovery prompt: %v", err)
+					slog.WarnContext(ctx, "Failed to save recovery prompt", "error", err)
 				}
 			}
-			log.Printf("Saved %d recovery prompts for chunk %d", len(newPrompts), chunk.ChunkIndex)
+			slog.InfoContext(ctx, "Saved recovery prompts", "count", len(newPrompts))
 		}
 	}
 
@@ -187,7 +196,7 @@ func (yt *YtAutomation) generateVisualPromptForChunksWithRecovery(scriptID primi
 }
 
 // Helper function to validate and recover gaps (enhanced version)
-func (yt *YtAutomation) validateAndRecoverVisualPrompts(srtContent string, script *Script, styleID primitive.ObjectID, existingPrompts []VisualPromptResponse) ([]VisualPromptResponse, error) {
+func (yt *YtAutomation) validateAndRecoverVisualPrompts(ctx context.Context, srtContent string, script *Script, styleID primitive.ObjectID, existingPrompts []VisualPromptResponse) ([]VisualPromptResponse, error) {
 	// Extract SRT time ranges
 	srtRanges, err := extractSRTTimeRanges(srtContent)
 	if err != nil {
@@ -195,7 +204,7 @@ func (yt *YtAutomation) validateAndRecoverVisualPrompts(srtContent string, scrip
 	}
 
 	if len(srtRanges) == 0 {
-		log.Printf("No SRT ranges found for validation")
+		slog.DebugContext(ctx, "No SRT ranges found for validation")
 		return existingPrompts, nil
 	}
 
@@ -206,25 +215,25 @@ func (yt *YtAutomation) validateAndRecoverVisualPrompts(srtContent string, scrip
 	}
 
 	if len(gaps) == 0 {
-		log.Printf("No gaps detected in visual prompt sequence")
+		slog.DebugContext(ctx, "No gaps detected in visual prompt sequence")
 		return existingPrompts, nil
 	}
 
-	log.Printf("Detected %d gaps, initiating recovery process", len(gaps))
+	slog.InfoContext(ctx, "Detected gaps in visual prompts, recovering", "gaps", len(gaps))
 	for i, gap := range gaps {
-		log.Printf("Gap %d: %.2f-%.2f seconds (%.2f second duration)",
-			i+1, gap.StartTime, gap.EndTime, gap.EndTime-gap.StartTime)
+		slog.DebugContext(ctx, "Visual prompt gap", "gap", i+1, "gap_start", gap.StartTime, "gap_end", gap.EndTime,
+			"seconds", gap.EndTime-gap.StartTime)
 	}
 
 	// Generate recovery prompts
-	recoveryPrompts, err := yt.generateGapRecoveryPrompts(gaps, script, styleID)
+	recoveryPrompts, err := yt.generateGapRecoveryPrompts(ctx, gaps, script, styleID)
 	if err != nil {
-		log.Printf("Gap recovery failed: %v", err)
+		slog.WarnContext(ctx, "Gap recovery failed", "error", err)
 		return existingPrompts, nil // Return original prompts if recovery fails
 	}
 
 	if len(recoveryPrompts) == 0 {
-		log.Printf("No recovery prompts generated")
+		slog.WarnContext(ctx, "No recovery prompts generated")
 		return existingPrompts, nil
 	}
 
@@ -238,41 +247,44 @@ func (yt *YtAutomation) validateAndRecoverVisualPrompts(srtContent string, scrip
 		return startI < startJ
 	})
 
-	log.Printf("Gap recovery complete. Total prompts: %d (original: %d, recovered: %d)",
-		len(allPrompts), len(existingPrompts), len(recoveryPrompts))
+	slog.InfoContext(ctx, "Gap recovery complete", "total", len(allPrompts),
+		"original", len(existingPrompts), "recovered", len(recoveryPrompts))
 
 	// Validate the recovery was successful
 	finalGaps, err := detectGapsInSequence(srtRanges, allPrompts)
 	if err == nil {
 		if len(finalGaps) < len(gaps) {
-			log.Printf("Gap recovery successful: reduced gaps from %d to %d", len(gaps), len(finalGaps))
+			slog.InfoContext(ctx, "Gap recovery reduced gaps", "before", len(gaps), "after", len(finalGaps))
 		} else if len(finalGaps) == len(gaps) {
-			log.Printf("Gap recovery had no effect: %d gaps remain", len(finalGaps))
+			slog.WarnContext(ctx, "Gap recovery had no effect", "gaps", len(finalGaps))
 		} else {
-			log.Printf("Gap recovery may have introduced new issues: gaps increased from %d to %d", len(gaps), len(finalGaps))
+			slog.WarnContext(ctx, "Gap recovery increased gaps", "before", len(gaps), "after", len(finalGaps))
 		}
 	}
 
 	return allPrompts, nil
 }
-func (yt *YtAutomation) generateVisualImagePromptForChunks(scriptID primitive.ObjectID, chunks []ChunkVisual) error {
-	fmt.Printf("🎨 Starting visual generation for %d chunks...\n", len(chunks))
+func (yt *YtAutomation) generateVisualImagePromptForChunks(ctx context.Context, scriptID primitive.ObjectID, chunks []ChunkVisual) error {
+	slog.InfoContext(ctx, "Starting image generation", "prompts", len(chunks))
 	globalOptions := map[string]interface{}{
-		"imageModel":  os.Getenv("IMAGE_MODEL"),
-		"aspectRatio": ASPECT_RATIO,
+		"imageModel":  appConfig().Images.ImageModel,
+		"aspectRatio": appConfig().Images.AspectRatio,
 	}
 	jobs := yt.CreateJobsFromPrompts(chunks, globalOptions)
 
-	err := yt.MakeConcurrentRequests(jobs)
+	err := yt.MakeConcurrentRequests(ctx, jobs)
+	if interrupted(err) {
+		return err
+	}
 	if err != nil {
-		log.Printf("Some requests encountered critical errors: %v", err)
+		slog.ErrorContext(ctx, "Some image requests encountered critical errors", "error", err)
 		// Don't exit fatally - let the program complete and show summary
 	}
 
-	fmt.Printf("✓ Completed visual generation for all chunks\n")
+	slog.InfoContext(ctx, "Completed image generation")
 	return nil
 }
-func (yt *YtAutomation) saveChunkVisuals(scriptID primitive.ObjectID, chunk ScriptSrt, visualPrompts []VisualPromptResponse, force bool) error {
+func (yt *YtAutomation) saveChunkVisuals(ctx context.Context, scriptID primitive.ObjectID, chunk ScriptSrt, visualPrompts []VisualPromptResponse, force bool) error {
 	// Check if collection is initialized
 	if chunkVisualsCollection == nil {
 		return fmt.Errorf("chunk visuals collection is not initialized")
@@ -314,19 +326,19 @@ func (yt *YtAutomation) saveChunkVisuals(scriptID primitive.ObjectID, chunk Scri
 		if err != nil {
 			return fmt.Errorf("failed to save chunk visuals: %w", err)
 		}
-		fmt.Printf("Saved %d visual prompts for chunk %d\n", len(visualDocs), chunk.ChunkIndex)
+		slog.InfoContext(ctx, "Saved visual prompts", "count", len(visualDocs))
 	}
 
 	return nil
 }
-func (yt *YtAutomation) updateChunkVisualStatus(chunkID primitive.ObjectID, hasVisual bool) {
+func (yt *YtAutomation) updateChunkVisualStatus(ctx context.Context, chunkID primitive.ObjectID, hasVisual bool) {
 	_, err := scriptAudiosCollection.UpdateOne(
 		context.Background(),
 		bson.M{"_id": chunkID},
 		bson.M{"$set": bson.M{"has_visual": hasVisual}},
 	)
 	if err != nil {
-		fmt.Printf("Warning: Failed to update chunk visual status: %v\n", err)
+		slog.WarnContext(ctx, "Failed to update chunk visual status", "error", err)
 	}
 }
 
@@ -2,44 +2,49 @@ package main
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"go.mongodb.org/mongo-driver/bson"
 	"io"
-	"log"
+	"log/slog"
 	"math"
 	"math/rand"
 	"net/http"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
+	"youtube_automation/tracing"
 )
 
 const (
 	ASPECT_RATIO = "IMAGE_ASPECT_RATIO_LANDSCAPE" // Options: IMAGE_ASPECT_RATIO_LANDSCAPE, IMAGE_ASPECT_RATIO_PORTRAIT, IMAGE_ASPECT_RATIO_SQUARE
 )
 
-// HttpConfig holds all configuration for the HTTP client
+// HttpConfig holds all configuration for the image generation client; it is the images
+// section of Config
 type HttpConfig struct {
-	URL               string            `json:"url"`
-	AuthToken         string            `json:"auth_token"`
-	Headers           map[string]string `json:"headers"`
-	OutputDirectory   string            `json:"output_directory"`
-	MaxConcurrency    int               `json:"max_concurrency"`
-	Timeout           time.Duration     `json:"timeout"`
-	SeedMode          string            `json:"seed_mode"` // "random" or "static"
-	StaticSeed        int               `json:"static_seed"`
-	RequestsPerMinute int               `json:"requests_per_minute"` // Rate limit: requests per minute
-	RetryAttempts     int               `json:"retry_attempts"`      // Number of retry attempts for failed requests
-	InitialRetryDelay time.Duration     `json:"initial_retry_delay"` // Initial delay for exponential backoff
-	BackoffMultiplier float64           `json:"backoff_multiplier"`  // Multiplier for exponential backoff
-	MaxRetryDelay     time.Duration     `json:"max_retry_delay"`     // Maximum delay between retries
-	Tool              string            `json:"tool"`                // "whisk" or "imagefx"
+	URL               string        `json:"url" yaml:"url" env:"API_URL"`
+	AuthToken         string        `json:"auth_token" yaml:"auth_token" env:"API_AUTH_TOKEN" secret:"true"` // Seeds api_keys when it is empty
+	Accept            string        `json:"accept" yaml:"accept" env:"ACCEPT_HEADER" reload:"true"`
+	OutputDirectory   string        `json:"output_directory" yaml:"output_directory" env:"OUTPUT_DIRECTORY" reload:"true"`
+	MaxConcurrency    int           `json:"max_concurrency" yaml:"max_concurrency" env:"MAX_CONCURRENCY" reload:"true"`
+	Timeout           time.Duration `json:"timeout" yaml:"timeout" env:"TIMEOUT"`
+	SeedMode          string        `json:"seed_mode" yaml:"seed_mode" env:"SEED_MODE" reload:"true"` // "random" or "static"
+	StaticSeed        int           `json:"static_seed" yaml:"static_seed" env:"STATIC_SEED" reload:"true"`
+	RequestsPerMinute int           `json:"requests_per_minute" yaml:"requests_per_minute" env:"REQUESTS_PER_MINUTE"`               // Rate limit: requests per minute
+	RetryAttempts     int           `json:"retry_attempts" yaml:"retry_attempts" env:"RETRY_ATTEMPTS" reload:"true"`                // Number of retry attempts for failed requests
+	InitialRetryDelay time.Duration `json:"initial_retry_delay" yaml:"initial_retry_delay" env:"INITIAL_RETRY_DELAY" reload:"true"` // Initial delay for exponential backoff
+	BackoffMultiplier float64       `json:"backoff_multiplier" yaml:"backoff_multiplier" env:"BACKOFF_MULTIPLIER" reload:"true"`    // Multiplier for exponential backoff
+	MaxRetryDelay     time.Duration `json:"max_retry_delay" yaml:"max_retry_delay" env:"MAX_RETRY_DELAY" reload:"true"`             // Maximum delay between retries
+	Tool              string        `json:"tool" yaml:"tool" env:"TOOL"`                                                            // "whisk" or "imagefx"
+	ImageModel        string        `json:"image_model" yaml:"image_model" env:"IMAGE_MODEL" reload:"true"`                         // Empty for the tool's default
+	AspectRatio       string        `json:"aspect_ratio" yaml:"aspect_ratio" env:"IMAGE_ASPECT_RATIO" reload:"true"`                // See ASPECT_RATIO for the options
 }
 
 // ClientContext represents the client context in the payload
@@ -174,8 +179,8 @@ func (rl *RateLimiter) Wait() {
 		waitDuration := waitUntil.Sub(now)
 
 		if waitDuration > 0 {
-			fmt.Printf("Rate limit reached (%d/%d requests in last minute), waiting %v...\n",
-				len(rl.requests), rl.requestsPerMinute, waitDuration.Round(time.Second))
+			slog.Info("Image rate limit reached, waiting", "requests", len(rl.requests),
+				"limit", rl.requestsPerMinute, "wait", waitDuration.Round(time.Second))
 			rl.mu.Unlock()
 			time.Sleep(waitDuration)
 			rl.mu.Lock()
@@ -213,9 +218,8 @@ func (rl *RateLimiter) GetCurrentUsage() (int, int) {
 	return count, rl.requestsPerMinute
 }
 
-// HTTPClient wraps the configuration and provides methods for making requests
+// HTTPClient holds the image API connection; its settings are read from appConfig().Images
 type HTTPClient struct {
-	config      HttpConfig
 	httpClient  *http.Client
 	rng         *rand.Rand
 	rateLimiter *RateLimiter
@@ -228,22 +232,19 @@ func NewHTTPClient(config HttpConfig) *HTTPClient {
 	}
 
 	return &HTTPClient{
-		config: config,
-		httpClient: &http.Client{
-			Timeout: config.Timeout,
-		},
+		httpClient:  providerClient(config.Tool, config.Timeout),
 		rng:         rand.New(rand.NewSource(time.Now().UnixNano())),
 		rateLimiter: rateLimiter,
 	}
 }
 
-func (yt *YtAutomation) MakeRequest(payload interface{}) (*APIResponse, error) {
+func (yt *YtAutomation) MakeRequest(ctx context.Context, payload interface{}) (*APIResponse, error) {
 	var lastErr error
 
-	for attempt := 0; attempt <= yt.googleHttpClient.config.RetryAttempts; attempt++ {
+	for attempt := 0; attempt <= appConfig().Images.RetryAttempts; attempt++ {
 		// Get API key from database based on tool
 		provider := "whisk"
-		if yt.googleHttpClient.config.Tool == "imagefx" {
+		if appConfig().Images.Tool == "imagefx" {
 			provider = "imagefx" // or whatever provider name you use for imagefx
 		}
 
@@ -256,12 +257,12 @@ func (yt *YtAutomation) MakeRequest(payload interface{}) (*APIResponse, error) {
 		if yt.googleHttpClient.rateLimiter != nil {
 			yt.googleHttpClient.rateLimiter.Wait()
 			current, max := yt.googleHttpClient.rateLimiter.GetCurrentUsage()
-			fmt.Printf("Rate limit usage: %d/%d requests in last minute\n", current, max)
+			slog.DebugContext(ctx, "Image rate limit usage", "requests", current, "limit", max)
 		}
 
 		// Determine URL based on tool configuration
-		url := yt.googleHttpClient.config.URL
-		if yt.googleHttpClient.config.Tool == "imagefx" {
+		url := appConfig().Images.URL
+		if appConfig().Images.Tool == "imagefx" {
 			url = "https://aisandbox-pa.googleapis.com/v1:runImageFx"
 		}
 
@@ -272,7 +273,7 @@ func (yt *YtAutomation) MakeRequest(payload interface{}) (*APIResponse, error) {
 		}
 
 		// Create HTTP request with the determined URL
-		req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 		if err != nil {
 			return nil, fmt.Errorf("failed to create request: %w", err)
 		}
@@ -281,17 +282,16 @@ func (yt *YtAutomation) MakeRequest(payload interface{}) (*APIResponse, error) {
 		req.Header.Set("Content-Type", "application/json")
 		req.Header.Set("Authorization", apiKey.KeyValue)
 
-		// Add custom headers
-		for key, value := range yt.googleHttpClient.config.Headers {
-			req.Header.Set(key, value)
-		}
+		req.Header.Set("User-Agent", cmp.Or(appConfig().Server.UserAgent, "Go HTTP Client"))
+		req.Header.Set("Accept", appConfig().Images.Accept)
 
 		// Make the request
 		resp, err := yt.googleHttpClient.httpClient.Do(req)
 		if err != nil {
+			recordProviderCall(provider, err)
 			lastErr = fmt.Errorf("failed to make request: %w", err)
-			if attempt < yt.googleHttpClient.config.RetryAttempts {
-				yt.waitWithBackoff(attempt)
+			if attempt < appConfig().Images.RetryAttempts {
+				yt.waitWithBackoff(ctx, attempt)
 				continue
 			}
 			return nil, lastErr
@@ -302,37 +302,34 @@ func (yt *YtAutomation) MakeRequest(payload interface{}) (*APIResponse, error) {
 		body, err := io.ReadAll(resp.Body)
 		if err != nil {
 			lastErr = fmt.Errorf("failed to read response body: %w", err)
-			if attempt < yt.googleHttpClient.config.RetryAttempts {
-				yt.waitWithBackoff(attempt)
+			if attempt < appConfig().Images.RetryAttempts {
+				yt.waitWithBackoff(ctx, attempt)
 				continue
 			}
 			return nil, lastErr
 		}
 
+		recordProviderStatus(provider, resp.StatusCode)
+
 		// Log API key usage for successful requests
 		if resp.StatusCode == http.StatusOK {
-			fmt.Printf("Successfully used API key: %s (Provider: %s)\n",
-				apiKey.ID.Hex()[:8]+"...", apiKey.Provider)
+			slog.DebugContext(ctx, "Used API key", logKeyProvider, apiKey.Provider, "key", apiKey.Fingerprint)
+			yt.apiKeyManager.ReportSuccess(apiKey)
 		}
 
 		// Handle rate limiting (429) and server errors (5xx) with retry
-		// Flag the current API key as problematic and try with a new one
+		// Cool the current API key down and try with a new one
 		if resp.StatusCode == 429 || (resp.StatusCode >= 500 && resp.StatusCode < 600) {
 			lastErr = fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
 
-			// Flag the current API key as problematic
-			flagErr := yt.apiKeyManager.FlagKeyAsProblematic(apiKey.ID, fmt.Sprintf("HTTP %d: %s", resp.StatusCode, string(body)))
-			if flagErr != nil {
-				log.Printf("Warning: Failed to flag API key as problematic: %v", flagErr)
-			} else {
-				fmt.Printf("Flagged API key %s as problematic due to %d error\n",
-					apiKey.ID.Hex()[:8]+"...", resp.StatusCode)
+			if reportErr := yt.apiKeyManager.ReportFailure(apiKey, keyFailureFromResponse(resp, body)); reportErr != nil {
+				slog.WarnContext(ctx, "Failed to record API key failure", logKeyProvider, provider, "error", reportErr)
 			}
 
-			if attempt < yt.googleHttpClient.config.RetryAttempts {
-				fmt.Printf("Request failed with status %d, flagging API key and retrying with new key (attempt %d/%d)...\n",
-					resp.StatusCode, attempt+1, yt.googleHttpClient.config.RetryAttempts+1)
-				yt.waitWithBackoff(attempt)
+			if attempt < appConfig().Images.RetryAttempts {
+				slog.WarnContext(ctx, "Image request failed, retrying with a new key", logKeyProvider, provider,
+					"status", resp.StatusCode, "attempt", attempt+1, "max_attempts", appConfig().Images.RetryAttempts+1)
+				yt.waitWithBackoff(ctx, attempt)
 				continue
 			}
 			return nil, lastErr
@@ -345,14 +342,10 @@ func (yt *YtAutomation) MakeRequest(payload interface{}) (*APIResponse, error) {
 
 		// Check for other error status codes (don't retry client errors except 429)
 		if resp.StatusCode != http.StatusOK {
-			// For other 4xx errors that might indicate API key issues, flag the key
+			// 401 and 403 revoke the key; other client errors leave its state alone
 			if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != 400 {
-				flagErr := yt.apiKeyManager.FlagKeyAsProblematic(apiKey.ID, fmt.Sprintf("HTTP %d: %s", resp.StatusCode, string(body)))
-				if flagErr != nil {
-					log.Printf("Warning: Failed to flag API key as problematic: %v", flagErr)
-				} else {
-					fmt.Printf("Flagged API key %s as problematic due to %d error\n",
-						apiKey.ID.Hex()[:8]+"...", resp.StatusCode)
+				if reportErr := yt.apiKeyManager.ReportFailure(apiKey, keyFailureFromResponse(resp, body)); reportErr != nil {
+					slog.WarnContext(ctx, "Failed to record API key failure", logKeyProvider, provider, "error", reportErr)
 				}
 			}
 			return nil, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
@@ -373,17 +366,16 @@ func (yt *YtAutomation) MakeRequest(payload interface{}) (*APIResponse, error) {
 
 // calculateBackoffDelay calculates the delay for exponential backoff
 func (yt *YtAutomation) calculateBackoffDelay(attempt int) time.Duration {
-	delay := time.Duration(float64(yt.googleHttpClient.config.InitialRetryDelay) * math.Pow(yt.googleHttpClient.config.BackoffMultiplier, float64(attempt)))
-	if delay > yt.googleHttpClient.config.MaxRetryDelay {
-		delay = yt.googleHttpClient.config.MaxRetryDelay
+	delay := time.Duration(float64(appConfig().Images.InitialRetryDelay) * math.Pow(appConfig().Images.BackoffMultiplier, float64(attempt)))
+	if delay > appConfig().Images.MaxRetryDelay {
+		delay = appConfig().Images.MaxRetryDelay
 	}
 	return delay
 }
 
-// waitWithBackoff waits with exponential backoff
-func (yt *YtAutomation) waitWithBackoff(attempt int) {
-	delay := yt.calculateBackoffDelay(attempt)
-	time.Sleep(delay)
+// waitWithBackoff waits with exponential backoff, or until ctx is cancelled
+func (yt *YtAutomation) waitWithBackoff(ctx context.Context, attempt int) {
+	sleepContext(ctx, yt.calculateBackoffDelay(attempt))
 }
 
 func (yt *YtAutomation) SaveImage(encodedImage, filename string) (string, error) {
@@ -394,13 +386,13 @@ func (yt *YtAutomation) SaveImage(encodedImage, filename string) (string, error)
 	}
 
 	// Ensure output directory exists
-	err = os.MkdirAll(yt.googleHttpClient.config.OutputDirectory, 0755)
+	err = os.MkdirAll(appConfig().Images.OutputDirectory, 0755)
 	if err != nil {
 		return "", fmt.Errorf("failed to create output directory: %w", err)
 	}
 
 	// Create full file path
-	fullPath := filepath.Join(yt.googleHttpClient.config.OutputDirectory, filename)
+	fullPath := filepath.Join(appConfig().Images.OutputDirectory, filename)
 
 	// Write image to file
 	err = os.WriteFile(fullPath, imageData, 0644)
@@ -408,7 +400,7 @@ func (yt *YtAutomation) SaveImage(encodedImage, filename string) (string, error)
 		return "", fmt.Errorf("failed to write image file: %w", err)
 	}
 
-	fmt.Printf("Image saved: %s\n", fullPath)
+	slog.Debug("Image saved", "path", fullPath)
 	return fullPath, nil
 }
 
@@ -432,7 +424,7 @@ func getPromptByJobID(jobs []RequestJob, jobID string) string {
 }
 
 // ProcessResponse processes the API response and saves all images
-func (yt *YtAutomation) ProcessResponse(response *APIResponse, job RequestJob) error {
+func (yt *YtAutomation) ProcessResponse(ctx context.Context, response *APIResponse, job RequestJob) error {
 	for _, panel := range response.ImagePanels {
 		for _, img := range panel.GeneratedImages {
 			// Format times by replacing colons and commas with underscores for filesystem safety
@@ -453,7 +445,7 @@ func (yt *YtAutomation) ProcessResponse(response *APIResponse, job RequestJob) e
 				bson.M{"$set": bson.M{"image_path": fullPath}},
 			)
 			if err != nil {
-				fmt.Printf("Warning: Failed to update chunk visual image_path: %v\n", err)
+				slog.WarnContext(ctx, "Failed to update chunk visual image_path", "error", err)
 			}
 		}
 	}
@@ -503,7 +495,7 @@ func (yt *YtAutomation) SanitizePrompt(originalPrompt string) string {
 }
 
 // Modified MakeRequestWithRetry method that handles content policy violations
-func (yt *YtAutomation) MakeRequestWithRetry(originalPayload interface{}, maxContentRetries int) (*APIResponse, error) {
+func (yt *YtAutomation) MakeRequestWithRetry(ctx context.Context, originalPayload interface{}, maxContentRetries int) (*APIResponse, error) {
 	var lastErr error
 
 	for contentRetry := 0; contentRetry <= maxContentRetries; contentRetry++ {
@@ -522,7 +514,7 @@ func (yt *YtAutomation) MakeRequestWithRetry(originalPayload interface{}, maxCon
 					modifiedPayload.Prompt = fmt.Sprintf("a safe and peaceful artistic representation of %s", yt.SanitizePrompt(p.Prompt))
 				}
 				payload = modifiedPayload
-				fmt.Printf("Content retry %d: Modified prompt to: %s\n", contentRetry, modifiedPayload.Prompt)
+				slog.InfoContext(ctx, "Retrying image with a sanitized prompt", "content_retry", contentRetry, "prompt", modifiedPayload.Prompt)
 
 			case ImageFXPayload:
 				modifiedPayload := p
@@ -533,7 +525,7 @@ func (yt *YtAutomation) MakeRequestWithRetry(originalPayload interface{}, maxCon
 						modifiedPayload.UserInput.Prompts[0] = fmt.Sprintf("a safe and peaceful artistic representation of %s", yt.SanitizePrompt(p.UserInput.Prompts[0]))
 					}
 					payload = modifiedPayload
-					fmt.Printf("Content retry %d: Modified prompt to: %s\n", contentRetry, modifiedPayload.UserInput.Prompts[0])
+					slog.InfoContext(ctx, "Retrying image with a sanitized prompt", "content_retry", contentRetry, "prompt", modifiedPayload.UserInput.Prompts[0])
 				}
 			}
 		} else {
@@ -541,14 +533,14 @@ func (yt *YtAutomation) MakeRequestWithRetry(originalPayload interface{}, maxCon
 		}
 
 		// Use the existing MakeRequest method
-		response, err := yt.MakeRequest(payload)
+		response, err := yt.MakeRequest(ctx, payload)
 		if err != nil {
 			// Check if it's a content policy violation
 			if strings.Contains(err.Error(), "content policy violation") {
 				lastErr = err
 				if contentRetry < maxContentRetries {
-					fmt.Printf("Content policy violation detected, retrying with sanitized prompt (attempt %d/%d)\n",
-						contentRetry+1, maxContentRetries+1)
+					slog.WarnContext(ctx, "Content policy violation, retrying with a sanitized prompt",
+						"attempt", contentRetry+1, "max_attempts", maxContentRetries+1)
 					continue
 				}
 			}
@@ -564,9 +556,9 @@ func (yt *YtAutomation) MakeRequestWithRetry(originalPayload interface{}, maxCon
 }
 
 // MakeConcurrentRequests makes multiple requests concurrently with proper rate limiting
-func (yt *YtAutomation) MakeConcurrentRequests(jobs []RequestJob) error {
+func (yt *YtAutomation) MakeConcurrentRequests(ctx context.Context, jobs []RequestJob) error {
 	// Create a semaphore to limit concurrency
-	semaphore := make(chan struct{}, yt.googleHttpClient.config.MaxConcurrency)
+	semaphore := make(chan struct{}, appConfig().Images.MaxConcurrency)
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 	var results []JobResult
@@ -583,23 +575,51 @@ func (yt *YtAutomation) MakeConcurrentRequests(jobs []RequestJob) error {
 			defer func() { <-semaphore }()
 
 			result := JobResult{ID: j.ID}
+			if err := checkpoint(ctx); err != nil {
+				// Not started, so the chunk keeps its status and the next run picks it up
+				result.Error = err
+				mu.Lock()
+				results = append(results, result)
+				mu.Unlock()
+				return
+			}
+			start := time.Now()
+			ctx, span := tracing.Start(ctx, StageImage, tracing.KindInternal)
+			span.SetAttribute("chunk_visual.id", j.chunkVisual.ID.Hex())
+			ctx = withLogAttrs(ctx, slog.String(logKeyStage, StageImage), slog.Int(logKeyChunkIndex, j.chunkVisual.ChunkIndex),
+				slog.Int("prompt_index", j.chunkVisual.PromptIndex))
+			defer func() {
+				outcome := "ok"
+				if result.Skipped {
+					outcome = "skipped"
+				} else if !result.Success {
+					outcome = "error"
+					span.SetError(result.Error)
+				}
+				stageDuration.ObserveSince(start, StageImage, outcome)
+				span.SetAttribute("outcome", outcome)
+				span.End()
+			}()
 			// Update status to processing
 			yt.updateVisualChunkStatus(j.chunkVisual.ID, "processing")
 			// Make the request (with built-in rate limiting and retries)
-			response, err := yt.MakeRequestWithRetry(j.Payload, 3)
+			response, err := yt.MakeRequestWithRetry(ctx, j.Payload, 3)
 			if err != nil {
 				// Check if it's still a content policy violation after retries
 				if strings.Contains(err.Error(), "content policy violation") {
 					result.Skipped = true
 					result.Error = err
 					yt.updateVisualChunkStatus(j.chunkVisual.ID, "skipped")
-					fmt.Printf("Skipping request %s - content policy violation persists after sanitization attempts\n", j.ID)
-				} else if strings.Contains(err.Error(), "no active API keys found") {
+					slog.WarnContext(ctx, "Skipping image, content policy violation persists after sanitization", "job", j.ID)
+				} else if errors.Is(err, errNoHealthyAPIKey) {
 					// Handle case where no API keys are available
 					result.Success = false
 					result.Error = err
-					yt.updateVisualChunkWithAPIKeyError(j.chunkVisual.ID, "No active API keys available")
-					fmt.Printf("Request %s failed due to no available API keys\n", j.ID)
+					yt.updateVisualChunkWithAPIKeyError(j.chunkVisual.ID, "No healthy API keys available")
+					slog.ErrorContext(ctx, "Image request failed, no API keys available", "job", j.ID)
+				} else if interrupted(err) {
+					result.Error = err
+					yt.updateVisualChunkStatus(j.chunkVisual.ID, StatusInterrupted)
 				} else {
 					result.Success = false
 					result.Error = err
@@ -609,13 +629,13 @@ func (yt *YtAutomation) MakeConcurrentRequests(jobs []RequestJob) error {
 				mu.Lock()
 				results = append(results, result)
 				completed++
-				fmt.Printf("Request %s failed/skipped (%d/%d): %v\n", j.ID, completed, len(jobs), err)
+				slog.WarnContext(ctx, "Image request failed or skipped", "job", j.ID, "completed", completed, "total", len(jobs), "error", err)
 				mu.Unlock()
 				return
 			}
 
 			// Process and save images
-			err = yt.ProcessResponse(response, j)
+			err = yt.ProcessResponse(ctx, response, j)
 			if err != nil {
 				result.Success = false
 				result.Error = fmt.Errorf("processing %s failed: %w", j.ID, err)
@@ -624,7 +644,7 @@ func (yt *YtAutomation) MakeConcurrentRequests(jobs []RequestJob) error {
 				mu.Lock()
 				results = append(results, result)
 				completed++
-				fmt.Printf("Request %s processing failed (%d/%d): %v\n", j.ID, completed, len(jobs), err)
+				slog.ErrorContext(ctx, "Image processing failed", "job", j.ID, "completed", completed, "total", len(jobs), "error", err)
 				mu.Unlock()
 				return
 			}
@@ -640,7 +660,7 @@ func (yt *YtAutomation) MakeConcurrentRequests(jobs []RequestJob) error {
 			mu.Lock()
 			results = append(results, result)
 			completed++
-			fmt.Printf("Completed request %s (%d/%d)\n", j.ID, completed, len(jobs))
+			slog.InfoContext(ctx, "Image request completed", "job", j.ID, "completed", completed, "total", len(jobs))
 			mu.Unlock()
 		}(job)
 	}
@@ -648,51 +668,46 @@ func (yt *YtAutomation) MakeConcurrentRequests(jobs []RequestJob) error {
 	wg.Wait()
 
 	// Analyze results
-	var successCount, failureCount, skippedCount int
+	var successCount, failureCount, skippedCount, interruptedCount int
 	var criticalErrors []error
 
 	for _, result := range results {
 		switch {
 		case result.Success:
 			successCount++
+		case interrupted(result.Error):
+			interruptedCount++
 		case result.Skipped:
 			skippedCount++
-			log.Printf("Skipped %s due to content policy violation", result.ID)
-			log.Printf("Prompt was: %s", getPromptByJobID(jobs, result.ID))
+			slog.InfoContext(ctx, "Skipped image due to content policy violation", "job", result.ID,
+				"prompt", getPromptByJobID(jobs, result.ID))
 		default:
 			failureCount++
 			criticalErrors = append(criticalErrors, fmt.Errorf("%s: %w", result.ID, result.Error))
-			log.Printf("Failed %s: %v", result.ID, result.Error)
+			slog.ErrorContext(ctx, "Image request failed", "job", result.ID, "error", result.Error)
 		}
 	}
 
-	// Print summary
-	fmt.Printf("\n=== EXECUTION SUMMARY ===\n")
-	fmt.Printf("Total requests: %d\n", len(jobs))
-	fmt.Printf("Successful: %d\n", successCount)
-	fmt.Printf("Skipped (content policy): %d\n", skippedCount)
-	fmt.Printf("Failed (other errors): %d\n", failureCount)
+	// Skipped requests are content policy violations, which are normal and expected
+	slog.InfoContext(ctx, "Image requests summary", "total", len(jobs), "successful", successCount,
+		"skipped", skippedCount, "failed", failureCount, "interrupted", interruptedCount)
+
+	if interruptedCount > 0 {
+		return errShuttingDown
+	}
 
 	// Only return error if there are critical failures (not content policy violations)
 	if len(criticalErrors) > 0 {
-		fmt.Printf("\nCritical errors encountered:\n")
-		for _, err := range criticalErrors {
-			fmt.Printf("- %v\n", err)
-		}
 		return fmt.Errorf("encountered %d critical errors during concurrent requests", len(criticalErrors))
 	}
 
-	if skippedCount > 0 {
-		fmt.Printf("\nNote: %d requests were skipped due to content policy violations. This is normal and expected.\n", skippedCount)
-	}
-
 	return nil
 }
 
 // GenerateSeed generates a seed based on the configuration
 func (yt *YtAutomation) GenerateSeed() int {
-	if yt.googleHttpClient.config.SeedMode == "static" {
-		return yt.googleHttpClient.config.StaticSeed
+	if appConfig().Images.SeedMode == "static" {
+		return appConfig().Images.StaticSeed
 	}
 	// Generate random seed between 1 and 999999
 	return yt.googleHttpClient.rng.Intn(999999) + 1
@@ -762,7 +777,7 @@ func (yt *YtAutomation) CreateJobsFromPrompts(chunkVisuals []ChunkVisual, option
 
 		// Create the job based on the tool type
 		var payload interface{}
-		if yt.googleHttpClient.config.Tool == "imagefx" {
+		if appConfig().Images.Tool == "imagefx" {
 			payload = CreateImageFXPayload(jobOptions)
 		} else {
 			// Default to whisk
@@ -782,94 +797,6 @@ func (yt *YtAutomation) CreateJobsFromPrompts(chunkVisuals []ChunkVisual, option
 	return jobs
 }
 
-// GetEnv gets an environment variable with a default value
-func GetEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
-}
-
-// GetEnvInt gets an environment variable as integer with a default value
-func GetEnvInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
-		if intValue, err := strconv.Atoi(value); err == nil {
-			return intValue
-		}
-	}
-	return defaultValue
-}
-
-// GetEnvDuration gets an environment variable as duration with a default value
-func GetEnvDuration(key string, defaultValue time.Duration) time.Duration {
-	if value := os.Getenv(key); value != "" {
-		if duration, err := time.ParseDuration(value); err == nil {
-			return duration
-		}
-	}
-	return defaultValue
-}
-
-// GetEnvFloat gets an environment variable as float64 with a default value
-func GetEnvFloat(key string, defaultValue float64) float64 {
-	if value := os.Getenv(key); value != "" {
-		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
-			return floatValue
-		}
-	}
-	return defaultValue
-}
-
-// LoadConfigFromEnv loads configuration from environment variables
-func LoadConfigFromEnv() HttpConfig {
-	return HttpConfig{
-		URL:       GetEnv("API_URL", "https://aisandbox-pa.googleapis.com/v1/whisk:generateImage"),
-		AuthToken: GetEnv("API_AUTH_TOKEN", ""),
-		Headers: map[string]string{
-			"User-Agent": GetEnv("USER_AGENT", "Go HTTP Client"),
-			"Accept":     GetEnv("ACCEPT_HEADER", "application/json"),
-		},
-		OutputDirectory:   GetEnv("OUTPUT_DIRECTORY", "./assets/images/"),
-		MaxConcurrency:    GetEnvInt("MAX_CONCURRENCY", 2),
-		Timeout:           GetEnvDuration("TIMEOUT", 60*time.Second),
-		SeedMode:          GetEnv("SEED_MODE", "random"),
-		StaticSeed:        GetEnvInt("STATIC_SEED", 12345),
-		RequestsPerMinute: GetEnvInt("REQUESTS_PER_MINUTE", 15),
-		RetryAttempts:     GetEnvInt("RETRY_ATTEMPTS", 3),
-		InitialRetryDelay: GetEnvDuration("INITIAL_RETRY_DELAY", 2*time.Second),
-		BackoffMultiplier: GetEnvFloat("BACKOFF_MULTIPLIER", 2.0),
-		MaxRetryDelay:     GetEnvDuration("MAX_RETRY_DELAY", 30*time.Second),
-		Tool:              GetEnv("TOOL", "whisk"),
-	}
-}
-
-// LoadConfigFromFile loads configuration from a JSON file
-func LoadConfigFromFile(filename string) (HttpConfig, error) {
-	var config HttpConfig
-
-	file, err := os.Open(filename)
-	if err != nil {
-		return config, err
-	}
-	defer file.Close()
-
-	decoder := json.NewDecoder(file)
-	err = decoder.Decode(&config)
-	return config, err
-}
-
-// SaveConfigToFile saves configuration to a JSON file
-func SaveConfigToFile(config HttpConfig, filename string) error {
-	file, err := os.Create(filename)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	return encoder.Encode(config)
-}
 func CreateImageFXPayload(options map[string]interface{}) ImageFXPayload {
 	payload := ImageFXPayload{
 		UserInput: ImageFXUserInput{
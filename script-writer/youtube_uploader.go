@@ -0,0 +1,532 @@
+// File: youtube_uploader.go
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"youtube_automation/tracing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	youtubeUploadURL    = "https://www.googleapis.com/upload/youtube/v3/videos"
+	youtubeThumbnailURL = "https://www.googleapis.com/upload/youtube/v3/thumbnails/set"
+	googleAuthURL       = "https://accounts.google.com/o/oauth2/v2/auth"
+	googleTokenURL      = "https://oauth2.googleapis.com/token"
+	youtubeUploadScope  = "https://www.googleapis.com/auth/youtube.upload"
+)
+
+// YouTubeUploader publishes videos through the YouTube Data API resumable upload protocol,
+// using the OAuth token stored for each channel
+type YouTubeUploader struct {
+	client       *http.Client
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	chunkSize    int64
+}
+
+func NewYouTubeUploader() *YouTubeUploader {
+	return &YouTubeUploader{
+		client:       &http.Client{Timeout: 10 * time.Minute, Transport: tracing.Transport{Name: ProviderYouTube, Next: providerTransport{provider: ProviderYouTube}}},
+		clientID:     appConfig().Uploads.YouTubeClientID,
+		clientSecret: appConfig().Uploads.YouTubeClientSecret,
+		redirectURL:  appConfig().Uploads.YouTubeRedirectURL,
+		chunkSize:    uploadChunkSize,
+	}
+}
+
+func (u *YouTubeUploader) Name() string {
+	return "youtube"
+}
+
+func (u *YouTubeUploader) Upload(ctx context.Context, channel *Channel, req UploadRequest, progress func(sent, total int64)) (*UploadResult, error) {
+	token, err := u.getToken(ctx, channel.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(req.VideoPath)
+	if err != nil {
+		return nil, fmt.Errorf("opening video: %w", err)
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("reading video size: %w", err)
+	}
+	total := info.Size()
+
+	sessionURL, err := u.startSession(ctx, token, req, total)
+	if err != nil {
+		return nil, err
+	}
+	slog.InfoContext(ctx, "Uploading to YouTube", "video", req.VideoPath, "bytes", total)
+
+	videoID, err := u.uploadChunks(ctx, token, sessionURL, file, total, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &UploadResult{
+		VideoID:  videoID,
+		VideoURL: "https://www.youtube.com/watch?v=" + videoID,
+	}
+	if req.ThumbnailPath != "" {
+		// Custom thumbnails need a verified account; the video is already up, so only warn
+		if err := u.setThumbnail(ctx, token, videoID, req.ThumbnailPath); err != nil {
+			slog.WarnContext(ctx, "Failed to set thumbnail", "video_id", videoID, "error", err)
+		} else {
+			result.ThumbnailSet = true
+		}
+	}
+	return result, nil
+}
+
+// startSession opens a resumable upload session and returns its URL
+func (u *YouTubeUploader) startSession(ctx context.Context, token *YouTubeToken, req UploadRequest, total int64) (string, error) {
+	categoryID := req.CategoryID
+	if categoryID == "" {
+		categoryID = "27" // Education
+	}
+	status := map[string]interface{}{
+		"privacyStatus":           req.PrivacyStatus,
+		"selfDeclaredMadeForKids": false,
+	}
+	if req.PublishAt != nil {
+		status["publishAt"] = req.PublishAt.UTC().Format(time.RFC3339)
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"snippet": map[string]interface{}{
+			"title":       req.Title,
+			"description": req.Description,
+			"tags":        req.Tags,
+			"categoryId":  categoryID,
+		},
+		"status": status,
+	})
+	if err != nil {
+		return "", fmt.Errorf("encoding video metadata: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", youtubeUploadURL+"?uploadType=resumable&part=snippet,status", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	httpReq.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	httpReq.Header.Set("X-Upload-Content-Length", strconv.FormatInt(total, 10))
+	httpReq.Header.Set("X-Upload-Content-Type", "video/*")
+
+	resp, err := u.client.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("starting upload session: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("starting upload session: status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	location := resp.Header.Get("Location")
+	if location == "" {
+		return "", fmt.Errorf("upload session response has no Location header")
+	}
+	return location, nil
+}
+
+// uploadChunks sends the file in chunkSize pieces, resuming from the server's last
+// acknowledged byte after transient failures
+func (u *YouTubeUploader) uploadChunks(ctx context.Context, token *YouTubeToken, sessionURL string, file *os.File, total int64, progress func(sent, total int64)) (string, error) {
+	var offset int64
+	retries := 0
+
+	for {
+		end := offset + u.chunkSize
+		if end > total {
+			end = total
+		}
+
+		chunk := io.NewSectionReader(file, offset, end-offset)
+		httpReq, err := http.NewRequestWithContext(ctx, "PUT", sessionURL, chunk)
+		if err != nil {
+			return "", err
+		}
+		httpReq.ContentLength = end - offset
+		httpReq.Header.Set("Authorization", "Bearer "+token.AccessToken)
+		if total > 0 {
+			httpReq.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, end-1, total))
+		}
+
+		resp, err := u.client.Do(httpReq)
+		if err == nil {
+			switch {
+			case resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusCreated:
+				var video struct {
+					ID string `json:"id"`
+				}
+				err := json.NewDecoder(resp.Body).Decode(&video)
+				resp.Body.Close()
+				if err != nil || video.ID == "" {
+					return "", fmt.Errorf("reading upload response: %v", err)
+				}
+				progress(total, total)
+				return video.ID, nil
+			case resp.StatusCode == http.StatusPermanentRedirect:
+				// 308 Resume Incomplete: Range says how much the server has stored
+				offset = parseUploadedRange(resp.Header.Get("Range"))
+				resp.Body.Close()
+				retries = 0
+				progress(offset, total)
+				continue
+			case resp.StatusCode < 500:
+				respBody, _ := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				return "", fmt.Errorf("upload failed: status %d: %s", resp.StatusCode, string(respBody))
+			}
+			resp.Body.Close()
+			err = fmt.Errorf("status %d", resp.StatusCode)
+		}
+
+		retries++
+		if retries > uploadMaxRetries {
+			return "", fmt.Errorf("upload failed after %d retries: %w", uploadMaxRetries, err)
+		}
+		backoff := time.Duration(1<<retries) * time.Second
+		slog.WarnContext(ctx, "Upload chunk failed, retrying", "attempt", retries, "backoff", backoff, "error", err)
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		if resumed, err := u.queryUploadOffset(ctx, token, sessionURL, total); err == nil {
+			offset = resumed
+		}
+	}
+}
+
+// queryUploadOffset asks the server how many bytes of an interrupted upload it stored
+func (u *YouTubeUploader) queryUploadOffset(ctx context.Context, token *YouTubeToken, sessionURL string, total int64) (int64, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "PUT", sessionURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	httpReq.Header.Set("Content-Range", fmt.Sprintf("bytes */%d", total))
+
+	resp, err := u.client.Do(httpReq)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusPermanentRedirect {
+		return 0, fmt.Errorf("status query returned %d", resp.StatusCode)
+	}
+	return parseUploadedRange(resp.Header.Get("Range")), nil
+}
+
+// parseUploadedRange turns a "bytes=0-12345" Range header into the next offset to send
+func parseUploadedRange(header string) int64 {
+	idx := strings.LastIndex(header, "-")
+	if idx < 0 {
+		return 0
+	}
+	last, err := strconv.ParseInt(header[idx+1:], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return last + 1
+}
+
+func (u *YouTubeUploader) setThumbnail(ctx context.Context, token *YouTubeToken, videoID, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading thumbnail: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", youtubeThumbnailURL+"?uploadType=media&videoId="+url.QueryEscape(videoID), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	httpReq.Header.Set("Content-Type", "image/jpeg")
+
+	resp, err := u.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("status %d: %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// getToken loads the channel's token, refreshing it when it is about to expire
+func (u *YouTubeUploader) getToken(ctx context.Context, channelID primitive.ObjectID) (*YouTubeToken, error) {
+	var token YouTubeToken
+	err := youtubeTokensCollection.FindOne(ctx, bson.M{"channel_id": channelID}).Decode(&token)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("channel has not authorized YouTube uploads")
+		}
+		return nil, fmt.Errorf("loading YouTube token: %w", err)
+	}
+
+	if time.Until(token.Expiry) > time.Minute {
+		return &token, nil
+	}
+	if token.RefreshToken == "" {
+		return nil, fmt.Errorf("YouTube token expired and has no refresh token, authorize the channel again")
+	}
+
+	refreshed, err := u.requestToken(ctx, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {token.RefreshToken},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("refreshing YouTube token: %w", err)
+	}
+	// Google only returns a refresh token on the first exchange
+	if refreshed.RefreshToken == "" {
+		refreshed.RefreshToken = token.RefreshToken
+	}
+	refreshed.ChannelID = channelID
+	if err := saveYouTubeToken(ctx, refreshed); err != nil {
+		return nil, err
+	}
+	return refreshed, nil
+}
+
+func (u *YouTubeUploader) requestToken(ctx context.Context, form url.Values) (*YouTubeToken, error) {
+	form.Set("client_id", u.clientID)
+	form.Set("client_secret", u.clientSecret)
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", googleTokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := u.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	var tokenResp struct {
+		AccessToken  string `json:"access_token"`
+		RefreshToken string `json:"refresh_token"`
+		TokenType    string `json:"token_type"`
+		Scope        string `json:"scope"`
+		ExpiresIn    int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return nil, fmt.Errorf("decoding token response: %w", err)
+	}
+
+	return &YouTubeToken{
+		AccessToken:  tokenResp.AccessToken,
+		RefreshToken: tokenResp.RefreshToken,
+		TokenType:    tokenResp.TokenType,
+		Scope:        tokenResp.Scope,
+		Expiry:       time.Now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second),
+	}, nil
+}
+
+func saveYouTubeToken(ctx context.Context, token *YouTubeToken) error {
+	token.UpdatedAt = time.Now()
+	_, err := youtubeTokensCollection.UpdateOne(
+		ctx,
+		bson.M{"channel_id": token.ChannelID},
+		bson.M{"$set": bson.M{
+			"channel_id":    token.ChannelID,
+			"access_token":  token.AccessToken,
+			"refresh_token": token.RefreshToken,
+			"token_type":    token.TokenType,
+			"scope":         token.Scope,
+			"expiry":        token.Expiry,
+			"updated_at":    token.UpdatedAt,
+		}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("saving YouTube token: %w", err)
+	}
+	return nil
+}
+
+type YouTubeAuthResponse struct {
+	AuthURL string `json:"auth_url"`
+}
+
+type YouTubeAuthorizedResponse struct {
+	Message string                `json:"message"`
+	Data    YouTubeAuthorizedData `json:"data"`
+}
+
+type YouTubeAuthorizedData struct {
+	Channel string    `json:"channel"`
+	Scope   string    `json:"scope"`
+	Expiry  time.Time `json:"expiry"`
+}
+
+// youtubeAuthHandler returns the consent URL a channel owner opens to authorize uploads
+// (/channels/{name}/youtube/auth)
+func (yt *YtAutomation) youtubeAuthHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	youtubeUploader, ok := yt.uploader.(*YouTubeUploader)
+	if !ok {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Uploader %q does not use OAuth", yt.uploader.Name()))
+		return
+	}
+	if youtubeUploader.clientID == "" || youtubeUploader.redirectURL == "" {
+		respondWithError(w, http.StatusInternalServerError, "YOUTUBE_CLIENT_ID and YOUTUBE_REDIRECT_URL must be set")
+		return
+	}
+
+	channelName := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/channels/"), "/youtube/auth")
+	channel, err := yt.getChannelByName(channelName)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			respondWithError(w, http.StatusNotFound, "Channel not found")
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Database error: %v", err))
+		return
+	}
+
+	state, err := newOAuthState(r.Context(), channel.ID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	params := url.Values{
+		"client_id":     {youtubeUploader.clientID},
+		"redirect_uri":  {youtubeUploader.redirectURL},
+		"response_type": {"code"},
+		"scope":         {youtubeUploadScope},
+		"access_type":   {"offline"},
+		"prompt":        {"consent"},
+		"state":         {state},
+	}
+	respondWithJSON(w, http.StatusOK, YouTubeAuthResponse{
+		AuthURL: googleAuthURL + "?" + params.Encode(),
+	})
+}
+
+// youtubeOAuthCallbackHandler exchanges the authorization code and stores the channel's token
+func (yt *YtAutomation) youtubeOAuthCallbackHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	youtubeUploader, ok := yt.uploader.(*YouTubeUploader)
+	if !ok {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Uploader %q does not use OAuth", yt.uploader.Name()))
+		return
+	}
+
+	query := r.URL.Query()
+	if errMsg := query.Get("error"); errMsg != "" {
+		respondWithError(w, http.StatusBadRequest, fmt.Sprintf("Authorization denied: %s", errMsg))
+		return
+	}
+	code := query.Get("code")
+	if code == "" {
+		respondWithError(w, http.StatusBadRequest, "code is required")
+		return
+	}
+	channelID, err := consumeOAuthState(r.Context(), query.Get("state"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid or expired state")
+		return
+	}
+	channel, err := yt.getChannelByID(channelID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Channel not found")
+		return
+	}
+
+	token, err := youtubeUploader.requestToken(r.Context(), url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {youtubeUploader.redirectURL},
+	})
+	if err != nil {
+		respondWithError(w, http.StatusBadGateway, fmt.Sprintf("Token exchange failed: %v", err))
+		return
+	}
+	token.ChannelID = channel.ID
+	if err := saveYouTubeToken(r.Context(), token); err != nil {
+		respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	slog.Info("Stored YouTube token", "channel", channel.ChannelName)
+	respondWithJSON(w, http.StatusOK, YouTubeAuthorizedResponse{
+		Message: "YouTube uploads authorized",
+		Data: YouTubeAuthorizedData{
+			Channel: channel.ChannelName,
+			Scope:   token.Scope,
+			Expiry:  token.Expiry,
+		},
+	})
+}
+
+// newOAuthState stores a random state for a channel's consent URL
+func newOAuthState(ctx context.Context, channelID primitive.ObjectID) (string, error) {
+	nonce := make([]byte, 32)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("generating OAuth state: %w", err)
+	}
+	state := OAuthState{
+		State:     base64.RawURLEncoding.EncodeToString(nonce),
+		ChannelID: channelID,
+		ExpiresAt: time.Now().Add(youtubeOAuthStateTTL),
+	}
+	if _, err := oauthStatesCollection.InsertOne(ctx, state); err != nil {
+		return "", fmt.Errorf("storing OAuth state: %w", err)
+	}
+	return state.State, nil
+}
+
+// consumeOAuthState deletes an unexpired state and returns the channel it was issued for.
+// The TTL index only sweeps periodically, so expiry is checked here too.
+func consumeOAuthState(ctx context.Context, value string) (primitive.ObjectID, error) {
+	if value == "" {
+		return primitive.NilObjectID, errors.New("state is required")
+	}
+	var state OAuthState
+	err := oauthStatesCollection.FindOneAndDelete(ctx, bson.M{
+		"_id":        value,
+		"expires_at": bson.M{"$gt": time.Now()},
+	}).Decode(&state)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	return state.ChannelID, nil
+}
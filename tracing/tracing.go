@@ -0,0 +1,270 @@
+// Package tracing records spans shared by the services on the OpenTelemetry SDK and
+// exports them to a collector over OTLP/HTTP. Trace context travels between the
+// services in the W3C traceparent header, so one video's calls to script-writer,
+// whisper and json_to_video end up in the same trace.
+//
+// The collector and sampling are configured with the standard variables:
+//
+//	OTEL_EXPORTER_OTLP_TRACES_ENDPOINT  full URL, e.g. http://collector:4318/v1/traces
+//	OTEL_EXPORTER_OTLP_ENDPOINT         base URL; /v1/traces is appended
+//	OTEL_EXPORTER_OTLP_HEADERS          extra headers, e.g. "authorization=Bearer x,team=video"
+//	OTEL_SERVICE_NAME                   overrides the service name passed to Init
+//	OTEL_TRACES_SAMPLER                 e.g. parentbased_traceidratio; parentbased_always_on by default
+//	OTEL_TRACES_SAMPLER_ARG             the ratio for the traceidratio samplers, e.g. 0.1
+//
+// With no endpoint set nothing is exported, but trace context is still propagated.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "youtube_automation/tracing"
+
+type SpanKind int
+
+// Values match the OpenTelemetry span kinds
+const (
+	KindInternal SpanKind = SpanKind(trace.SpanKindInternal)
+	KindServer   SpanKind = SpanKind(trace.SpanKindServer)
+	KindClient   SpanKind = SpanKind(trace.SpanKindClient)
+)
+
+type TraceID = trace.TraceID
+type SpanID = trace.SpanID
+
+// SpanContext identifies a span, local or received from another service
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// propagator reads and writes traceparent and tracestate, plus W3C baggage
+var propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// provider is set by Init; until then spans come from the global no-op provider
+var provider *sdktrace.TracerProvider
+
+// Span is one timed operation. A nil *Span is valid and does nothing.
+type Span struct {
+	span trace.Span
+}
+
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	sc := s.span.SpanContext()
+	return SpanContext{TraceID: sc.TraceID(), SpanID: sc.SpanID(), Sampled: sc.IsSampled()}
+}
+
+// SetAttribute records a string, bool, integer or float value on the span
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.span.SetAttributes(keyValue(key, value))
+}
+
+func keyValue(key string, value interface{}) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return attribute.String(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int64:
+		return attribute.Int64(key, v)
+	case float64:
+		return attribute.Float64(key, v)
+	}
+	return attribute.String(key, fmt.Sprint(value))
+}
+
+// SetError marks the span failed; a nil error leaves it unchanged
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+// End finishes the span and queues it for export; later calls are ignored
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.span.End()
+}
+
+// SpanFromContext returns the current span, or nil. A span received from another
+// service counts, so logs carry the caller's trace ID before the first local span.
+func SpanFromContext(ctx context.Context) *Span {
+	span := trace.SpanFromContext(ctx)
+	if !span.SpanContext().IsValid() {
+		return nil
+	}
+	return &Span{span: span}
+}
+
+// Start opens a span as a child of the span in ctx, or a new trace when there is none
+func Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	ctx, span := otel.Tracer(instrumentationName).Start(ctx, name, trace.WithSpanKind(trace.SpanKind(kind)))
+	return ctx, &Span{span: span}
+}
+
+// Detach keeps the trace of ctx but drops its deadline and cancellation, for background
+// work that outlives the request that started it
+func Detach(ctx context.Context) context.Context {
+	return trace.ContextWithSpan(context.Background(), trace.SpanFromContext(ctx))
+}
+
+// Inject writes the traceparent header for the span in ctx
+func Inject(ctx context.Context, header http.Header) {
+	propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// Extract returns ctx carrying the caller's span context from a traceparent header, if valid
+func Extract(ctx context.Context, header http.Header) context.Context {
+	return propagator.Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Flush keeps streaming responses working through the recorder
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Middleware opens a server span for each request, continuing the caller's trace.
+// name returns the span name, typically the method and route template.
+func Middleware(name func(*http.Request) string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := Extract(r.Context(), r.Header)
+		ctx, span := Start(ctx, name(r), KindServer)
+		defer span.End()
+		span.SetAttribute("http.request.method", r.Method)
+		span.SetAttribute("url.path", r.URL.Path)
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		span.SetAttribute("http.response.status_code", rec.status)
+		if rec.status >= 500 {
+			span.SetError(fmt.Errorf("%d %s", rec.status, http.StatusText(rec.status)))
+		}
+	})
+}
+
+// Transport opens a client span for each outgoing request and injects its traceparent
+type Transport struct {
+	Name string // Span name, e.g. the provider; defaults to the method and host
+	Next http.RoundTripper
+}
+
+func (t Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	name := t.Name
+	if name == "" {
+		name = req.Method + " " + req.URL.Host
+	}
+	ctx, span := Start(req.Context(), name, KindClient)
+	defer span.End()
+	span.SetAttribute("http.request.method", req.Method)
+	span.SetAttribute("server.address", req.URL.Host)
+	span.SetAttribute("url.path", req.URL.Path)
+
+	// RoundTrippers must not modify the caller's request
+	req = req.Clone(ctx)
+	Inject(ctx, req.Header)
+
+	next := t.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		span.SetError(err)
+		return nil, err
+	}
+	span.SetAttribute("http.response.status_code", resp.StatusCode)
+	if resp.StatusCode >= 400 {
+		span.SetError(fmt.Errorf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode)))
+	}
+	return resp, nil
+}
+
+// Init sets up the tracer provider from the environment. Call Shutdown before exiting
+// to send the spans still queued.
+func Init(serviceName string) {
+	otel.SetTextMapPropagator(propagator)
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		slog.Warn("Tracing error", "error", err)
+	}))
+
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the name passed in
+	res, err := resource.New(context.Background(),
+		resource.WithAttributes(attribute.String("service.name", serviceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		slog.Warn("Incomplete tracing resource", "error", err)
+	}
+
+	// The sampler comes from OTEL_TRACES_SAMPLER; spans are created even without an
+	// exporter so their IDs reach logs and downstream services
+	options := []sdktrace.TracerProviderOption{sdktrace.WithResource(res)}
+	if os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != "" || os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" {
+		exporter, err := otlptracehttp.New(context.Background())
+		if err != nil {
+			slog.Warn("Traces will not be exported", "error", err)
+		} else {
+			options = append(options, sdktrace.WithBatcher(exporter))
+		}
+	}
+	if provider != nil {
+		provider.Shutdown(context.Background())
+	}
+	provider = sdktrace.NewTracerProvider(options...)
+	otel.SetTracerProvider(provider)
+}
+
+// Shutdown exports the queued spans, waiting until ctx is done at most
+func Shutdown(ctx context.Context) {
+	if provider == nil {
+		return
+	}
+	if err := provider.Shutdown(ctx); err != nil {
+		slog.Warn("Failed to flush traces", "error", err)
+	}
+}
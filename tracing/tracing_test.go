@@ -0,0 +1,325 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// collector is an OTLP/HTTP endpoint that keeps the spans it receives
+type collector struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests []*coltracepb.ExportTraceServiceRequest
+	headers  []http.Header
+}
+
+func newCollector(t *testing.T) *collector {
+	c := &collector{}
+	c.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("reading export: %v", err)
+		}
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/x-protobuf" {
+			t.Errorf("export to %s as %s, want /v1/traces as application/x-protobuf", r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		var req coltracepb.ExportTraceServiceRequest
+		if err := proto.Unmarshal(body, &req); err != nil {
+			t.Errorf("decoding export: %v", err)
+		}
+		c.mu.Lock()
+		c.requests = append(c.requests, &req)
+		c.headers = append(c.headers, r.Header.Clone())
+		c.mu.Unlock()
+		w.Header().Set("Content-Type", "application/x-protobuf")
+	}))
+	t.Cleanup(c.Close)
+	return c
+}
+
+func (c *collector) spans() (resourceAttrs []*commonpb.KeyValue, spans []*tracepb.Span) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, req := range c.requests {
+		for _, rs := range req.ResourceSpans {
+			resourceAttrs = rs.Resource.Attributes
+			for _, ss := range rs.ScopeSpans {
+				spans = append(spans, ss.Spans...)
+			}
+		}
+	}
+	return resourceAttrs, spans
+}
+
+func attrValue(attrs []*commonpb.KeyValue, key string) *commonpb.AnyValue {
+	for _, attr := range attrs {
+		if attr.Key == key {
+			return attr.Value
+		}
+	}
+	return nil
+}
+
+// initTracing points Init at endpoint and shuts the provider down after the test
+func initTracing(t *testing.T, service, endpoint string) {
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", endpoint)
+	Init(service)
+	t.Cleanup(func() {
+		Shutdown(context.Background())
+		provider = nil
+	})
+}
+
+func TestExportEncoding(t *testing.T) {
+	c := newCollector(t)
+	t.Setenv("OTEL_EXPORTER_OTLP_HEADERS", "authorization=Bearer x,team=video")
+	t.Setenv("OTEL_SERVICE_NAME", "")
+	initTracing(t, "script-writer", c.URL+"/v1/traces")
+
+	ctx, parent := Start(context.Background(), "generate", KindServer)
+	parent.SetAttribute("script.id", "abc")
+	parent.SetAttribute("chunks", 12)
+	parent.SetAttribute("bytes", int64(1<<40))
+	parent.SetAttribute("ratio", 0.5)
+	parent.SetAttribute("cached", true)
+	parent.SetAttribute("duration", 3*time.Second)
+	_, child := Start(ctx, "tts", KindClient)
+	child.SetError(errors.New("quota exceeded"))
+	child.End()
+	parent.End()
+	Shutdown(context.Background())
+
+	resourceAttrs, spans := c.spans()
+	if got := attrValue(resourceAttrs, "service.name").GetStringValue(); got != "script-writer" {
+		t.Errorf("service.name = %q, want script-writer", got)
+	}
+	if len(c.headers) == 0 || c.headers[0].Get("Authorization") != "Bearer x" || c.headers[0].Get("Team") != "video" {
+		t.Errorf("export headers = %v, want those of OTEL_EXPORTER_OTLP_HEADERS", c.headers)
+	}
+	if len(spans) != 2 {
+		t.Fatalf("exported %d spans, want 2", len(spans))
+	}
+
+	byName := map[string]*tracepb.Span{}
+	for _, span := range spans {
+		byName[span.Name] = span
+	}
+	gen, tts := byName["generate"], byName["tts"]
+	if gen == nil || tts == nil {
+		t.Fatalf("exported spans %v, want generate and tts", spans)
+	}
+
+	if len(gen.TraceId) != 16 || len(gen.SpanId) != 8 {
+		t.Errorf("IDs are %d and %d bytes, want 16 and 8", len(gen.TraceId), len(gen.SpanId))
+	}
+	if string(tts.TraceId) != string(gen.TraceId) || string(tts.ParentSpanId) != string(gen.SpanId) {
+		t.Error("tts is not a child of generate in the same trace")
+	}
+	if len(gen.ParentSpanId) != 0 {
+		t.Error("root span has a parent")
+	}
+	if gen.Kind != tracepb.Span_SPAN_KIND_SERVER || tts.Kind != tracepb.Span_SPAN_KIND_CLIENT {
+		t.Errorf("kinds = %v and %v, want server and client", gen.Kind, tts.Kind)
+	}
+	if gen.StartTimeUnixNano == 0 || gen.EndTimeUnixNano < gen.StartTimeUnixNano {
+		t.Errorf("times = %d to %d", gen.StartTimeUnixNano, gen.EndTimeUnixNano)
+	}
+
+	if got := attrValue(gen.Attributes, "script.id").GetStringValue(); got != "abc" {
+		t.Errorf("script.id = %q", got)
+	}
+	if got := attrValue(gen.Attributes, "chunks").GetIntValue(); got != 12 {
+		t.Errorf("chunks = %d", got)
+	}
+	if got := attrValue(gen.Attributes, "bytes").GetIntValue(); got != 1<<40 {
+		t.Errorf("bytes = %d", got)
+	}
+	if got := attrValue(gen.Attributes, "ratio").GetDoubleValue(); got != 0.5 {
+		t.Errorf("ratio = %v", got)
+	}
+	if got := attrValue(gen.Attributes, "cached").GetBoolValue(); !got {
+		t.Errorf("cached = %v", got)
+	}
+	if got := attrValue(gen.Attributes, "duration").GetStringValue(); got != "3s" {
+		t.Errorf("duration = %q, want other types as strings", got)
+	}
+
+	if gen.Status.GetCode() != tracepb.Status_STATUS_CODE_UNSET {
+		t.Errorf("generate status = %v, want unset", gen.Status.GetCode())
+	}
+	if tts.Status.GetCode() != tracepb.Status_STATUS_CODE_ERROR || tts.Status.GetMessage() != "quota exceeded" {
+		t.Errorf("tts status = %v %q, want an error", tts.Status.GetCode(), tts.Status.GetMessage())
+	}
+	if len(tts.Events) != 1 || tts.Events[0].Name != "exception" {
+		t.Errorf("tts events = %v, want the recorded exception", tts.Events)
+	}
+}
+
+func TestSamplerFromEnvironment(t *testing.T) {
+	c := newCollector(t)
+	t.Setenv("OTEL_TRACES_SAMPLER", "traceidratio")
+	t.Setenv("OTEL_TRACES_SAMPLER_ARG", "0")
+	initTracing(t, "whisper", c.URL+"/v1/traces")
+
+	_, span := Start(context.Background(), "transcription", KindInternal)
+	if span.SpanContext().Sampled {
+		t.Error("span is sampled with a 0 ratio")
+	}
+	if !span.SpanContext().IsValid() {
+		t.Error("unsampled span has no IDs; they are still needed for propagation")
+	}
+	span.End()
+	Shutdown(context.Background())
+	if _, spans := c.spans(); len(spans) != 0 {
+		t.Errorf("exported %d spans, want none", len(spans))
+	}
+}
+
+var traceparentRegex = regexp.MustCompile(`^00-[0-9a-f]{32}-[0-9a-f]{16}-0[01]$`)
+
+func TestPropagation(t *testing.T) {
+	initTracing(t, "script-writer", "")
+
+	ctx, span := Start(context.Background(), "request", KindClient)
+	defer span.End()
+	header := http.Header{}
+	Inject(ctx, header)
+	traceparent := header.Get("traceparent")
+	if !traceparentRegex.MatchString(traceparent) {
+		t.Fatalf("traceparent = %q, want the W3C format", traceparent)
+	}
+	sc := span.SpanContext()
+	if want := "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-01"; traceparent != want {
+		t.Errorf("traceparent = %q, want %q", traceparent, want)
+	}
+
+	// The receiving side continues the trace
+	remote := Extract(context.Background(), header)
+	_, child := Start(remote, "handler", KindServer)
+	defer child.End()
+	if child.SpanContext().TraceID != sc.TraceID {
+		t.Error("child of an extracted context started a new trace")
+	}
+	if child.SpanContext().SpanID == sc.SpanID {
+		t.Error("child reused the caller's span ID")
+	}
+}
+
+func TestExtract(t *testing.T) {
+	initTracing(t, "script-writer", "")
+
+	tests := []struct {
+		name        string
+		traceparent string
+		valid       bool
+		sampled     bool
+	}{
+		{"sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true, true},
+		{"not sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", true, false},
+		{"future version with extra fields", "cc-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-what", true, true},
+		{"uppercase", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00F067AA0BA902B7-01", false, false},
+		{"version ff", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false, false},
+		{"zero trace ID", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", false, false},
+		{"zero span ID", "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false, false},
+		{"short trace ID", "00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01", false, false},
+		{"missing flags", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", false, false},
+		{"empty", "", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.traceparent != "" {
+				header.Set("traceparent", tt.traceparent)
+			}
+			span := SpanFromContext(Extract(context.Background(), header))
+			sc := span.SpanContext()
+			if sc.IsValid() != tt.valid {
+				t.Fatalf("valid = %v, want %v", sc.IsValid(), tt.valid)
+			}
+			if !tt.valid {
+				return
+			}
+			if sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || sc.SpanID.String() != "00f067aa0ba902b7" {
+				t.Errorf("IDs = %s %s", sc.TraceID, sc.SpanID)
+			}
+			if sc.Sampled != tt.sampled {
+				t.Errorf("sampled = %v, want %v", sc.Sampled, tt.sampled)
+			}
+		})
+	}
+}
+
+func TestDetachKeepsTraceDropsCancel(t *testing.T) {
+	initTracing(t, "script-writer", "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx, span := Start(ctx, "request", KindServer)
+	defer span.End()
+	detached := Detach(ctx)
+	cancel()
+
+	if detached.Err() != nil {
+		t.Error("detached context was canceled with its parent")
+	}
+	if SpanFromContext(detached).SpanContext() != span.SpanContext() {
+		t.Error("detached context lost the span")
+	}
+}
+
+func TestMiddlewareAndTransport(t *testing.T) {
+	initTracing(t, "script-writer", "")
+
+	var received http.Header
+	server := httptest.NewServer(Middleware(
+		func(r *http.Request) string { return r.Method + " " + r.URL.Path },
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = r.Header.Clone()
+			if SpanFromContext(r.Context()) == nil {
+				t.Error("handler has no server span")
+			}
+		}),
+	))
+	defer server.Close()
+
+	ctx, span := Start(context.Background(), "caller", KindInternal)
+	defer span.End()
+	req, _ := http.NewRequestWithContext(ctx, "GET", server.URL+"/health", nil)
+	resp, err := (&http.Client{Transport: Transport{Name: "health"}}).Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if req.Header.Get("traceparent") != "" {
+		t.Error("Transport modified the caller's request")
+	}
+	traceparent := received.Get("traceparent")
+	if !traceparentRegex.MatchString(traceparent) || traceparent[3:35] != span.SpanContext().TraceID.String() {
+		t.Errorf("server got traceparent %q, want one in trace %s", traceparent, span.SpanContext().TraceID)
+	}
+}
+
+func TestNilSpan(t *testing.T) {
+	var span *Span
+	span.SetAttribute("key", "value")
+	span.SetError(errors.New("ignored"))
+	span.End()
+	if span.SpanContext().IsValid() {
+		t.Error("nil span has a valid context")
+	}
+	if SpanFromContext(context.Background()) != nil {
+		t.Error("SpanFromContext of an empty context is not nil")
+	}
+}
@@ -3,15 +3,20 @@ package main
 import (
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
+	"youtube_automation/logging"
+	"youtube_automation/metrics"
+	"youtube_automation/readiness"
+	"youtube_automation/tracing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -62,22 +67,39 @@ const (
 	DEFAULT_MAX_LINES  = 2         // Default max lines per subtitle segment
 )
 
+var (
+	httpRequests = metrics.NewCounterVec("whisper_http_requests_total",
+		"HTTP requests by route and status code.", "route", "code")
+	transcriptionDuration = metrics.NewHistogramVec("whisper_transcription_duration_seconds",
+		"Time whisper-cli took per transcription.", nil, "model", "outcome")
+	transcriptionsInFlight = metrics.NewGaugeVec("whisper_transcriptions_in_flight",
+		"Transcriptions currently running.")
+)
+
 func main() {
+	logging.Setup()
 	// Create upload directory
 	os.MkdirAll(UPLOAD_DIR, 0755)
+	tracing.Init("whisper")
 
-	// Initialize Gin router
-	r := gin.Default()
+	// Initialize Gin router; requests are logged as JSON by requestLogMiddleware
+	gin.DebugPrintFunc = func(format string, values ...interface{}) {
+		slog.Debug(strings.TrimSpace(fmt.Sprintf(format, values...)))
+	}
+	r := gin.New()
 
 	// Middleware
-	r.Use(gin.Logger())
+	r.Use(requestLogMiddleware())
 	r.Use(gin.Recovery())
 	r.Use(corsMiddleware())
+	r.Use(metricsMiddleware())
 
 	// Routes
 	r.GET("/health", healthCheck)
+	r.GET("/ready", gin.WrapH(readiness.Handler(readiness.DefaultTimeout, readinessChecks)))
 	r.POST("/transcribe", transcribeAudio)
 	r.GET("/models", listModels)
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
 
 	// Start server
 	port := os.Getenv("PORT")
@@ -85,8 +107,23 @@ func main() {
 		port = "8086"
 	}
 
-	log.Printf("🎙️  Whisper API Server starting on port %s", port)
-	log.Fatal(r.Run(":" + port))
+	slog.Info("Whisper API server starting", "port", port)
+	// Routes have no IDs in them, so the path names the request span
+	spanName := func(req *http.Request) string { return req.Method + " " + req.URL.Path }
+	err := http.ListenAndServe(":"+port, tracing.Middleware(spanName, r))
+	slog.Error("Server stopped", "error", err)
+	os.Exit(1)
+}
+
+// requestLogMiddleware logs each request in place of gin's text logger
+func requestLogMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		slog.InfoContext(c.Request.Context(), "Request served", "method", c.Request.Method,
+			"path", c.Request.URL.Path, "status", c.Writer.Status(), "duration", time.Since(start))
+	}
 }
 
 func corsMiddleware() gin.HandlerFunc {
@@ -104,6 +141,18 @@ func corsMiddleware() gin.HandlerFunc {
 	}
 }
 
+func metricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		httpRequests.Inc(route, strconv.Itoa(c.Writer.Status()))
+	}
+}
+
 func healthCheck(c *gin.Context) {
 	c.JSON(http.StatusOK, HealthResponse{
 		Status:    "healthy",
@@ -112,22 +161,41 @@ func healthCheck(c *gin.Context) {
 	})
 }
 
-func listModels(c *gin.Context) {
+// readinessChecks covers what a transcription needs: whisper-cli, the default model and
+// somewhere to store uploads
+func readinessChecks() []readiness.Check {
+	return []readiness.Check{
+		readiness.Binary("whisper-cli"),
+		readiness.File("model:"+DEFAULT_MODEL, filepath.Join(MODELS_DIR, DEFAULT_MODEL)),
+		readiness.Writable(UPLOAD_DIR),
+	}
+}
+
+// availableModels lists the model files in MODELS_DIR. Requests may only name these, which
+// also keeps the model label of the metrics to a known set.
+func availableModels() ([]string, error) {
 	files, err := os.ReadDir(MODELS_DIR)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error:   "failed_to_list_models",
-			Message: "Could not read models directory",
-		})
-		return
+		return nil, err
 	}
-
 	var models []string
 	for _, file := range files {
 		if strings.HasSuffix(file.Name(), ".bin") {
 			models = append(models, file.Name())
 		}
 	}
+	return models, nil
+}
+
+func listModels(c *gin.Context) {
+	models, err := availableModels()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "failed_to_list_models",
+			Message: "Could not read models directory",
+		})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"models":  models,
@@ -182,6 +250,21 @@ func transcribeAudio(c *gin.Context) {
 	} else {
 		req.Model = DEFAULT_MODEL
 	}
+	models, err := availableModels()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, ErrorResponse{
+			Error:   "failed_to_list_models",
+			Message: "Could not read models directory",
+		})
+		return
+	}
+	if !slices.Contains(models, req.Model) {
+		c.JSON(http.StatusBadRequest, ErrorResponse{
+			Error:   "unknown_model",
+			Message: fmt.Sprintf("Model %q is not installed; GET /models lists the available ones", req.Model),
+		})
+		return
+	}
 
 	req.OutputSRT = outputSRT == "true" || outputSRT == "1"
 
@@ -233,8 +316,20 @@ func transcribeAudio(c *gin.Context) {
 
 	// Perform transcription
 	startTime := time.Now()
+	transcriptionsInFlight.Add(1)
+	_, span := tracing.Start(c.Request.Context(), "transcription", tracing.KindInternal)
+	span.SetAttribute("whisper.model", req.Model)
 	transcription, srtContent, err := performTranscription(tempFilePath, req)
+	span.SetError(err)
+	span.End()
+	transcriptionsInFlight.Add(-1)
+	outcome := "ok"
+	if err != nil {
+		outcome = "error"
+	}
+	transcriptionDuration.ObserveSince(startTime, req.Model, outcome)
 	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "Transcription failed", "model", req.Model, "error", err)
 		c.JSON(http.StatusInternalServerError, ErrorResponse{
 			Error:   "transcription_failed",
 			Message: err.Error(),